@@ -3,11 +3,17 @@ package main
 import (
 	"context"
 	cartHandler "github.com/aaanger/ecommerce/internal/cart/handler"
+	couponHandler "github.com/aaanger/ecommerce/internal/coupon/handler"
+	deliveryHandler "github.com/aaanger/ecommerce/internal/delivery/handler"
 	orderHandler "github.com/aaanger/ecommerce/internal/order/handler"
 	grpcorder "github.com/aaanger/ecommerce/internal/order/handler/grpc/product"
 	"github.com/aaanger/ecommerce/internal/order/service"
 	payment "github.com/aaanger/ecommerce/internal/payment/client"
+	"github.com/aaanger/ecommerce/internal/payment/reconcile"
+	"github.com/aaanger/ecommerce/internal/payment/webhook"
 	productHandler "github.com/aaanger/ecommerce/internal/product/handler"
+	productRepository "github.com/aaanger/ecommerce/internal/product/repository"
+	productService "github.com/aaanger/ecommerce/internal/product/service"
 	"github.com/aaanger/ecommerce/internal/server/grpc"
 	userHandler "github.com/aaanger/ecommerce/internal/user/handler"
 	"github.com/aaanger/ecommerce/pkg/db"
@@ -27,6 +33,8 @@ import (
 	"time"
 )
 
+const defaultFakePaymentConfirmDelay = 5 * time.Second
+
 type Server struct {
 	httpServer *http.Server
 }
@@ -91,6 +99,10 @@ func main() {
 	})
 
 	producer := kafka.NewProducer(writer, logger)
+	expiredProducer := kafka.NewProducer(kafka.NewWriter(kafka.KafkaConfig{
+		Brokers: []string{"localhost:9092"},
+		Topic:   service.OrderExpiredTopic,
+	}), logger)
 	consumer := kafka.NewConsumer(reader, logger)
 
 	logger.Debug("Kafka producer and consumer loaded successfully")
@@ -108,24 +120,82 @@ func main() {
 	}
 	orderConsumer := service.NewOrderConsumer(emailService, logger)
 
+	reservationTTL := productService.DefaultReservationTTL
+	if ttl := os.Getenv("RESERVATION_TTL"); ttl != "" {
+		reservationTTL, err = time.ParseDuration(ttl)
+		if err != nil {
+			logrus.Fatalf("Error parsing RESERVATION_TTL: %s", err)
+		}
+	}
+
+	orderPaymentTTL := service.DefaultOrderPaymentTTL
+	if ttl := os.Getenv("ORDER_PAYMENT_TTL"); ttl != "" {
+		orderPaymentTTL, err = time.ParseDuration(ttl)
+		if err != nil {
+			logrus.Fatalf("Error parsing ORDER_PAYMENT_TTL: %s", err)
+		}
+	}
+
 	go func() {
-		productGrpcServer := grpc.NewServer(logger, db, 9090)
+		productGrpcServer := grpc.NewServer(logger, db, 9090, reservationTTL)
 		productGrpcServer.MustRun()
 	}()
 
+	reservationSweeper := productService.NewReservationSweeper(productRepository.NewStockRepository(db), time.Minute, logger)
+	go reservationSweeper.Run(context.Background())
+
 	grpcClient, err := grpcorder.NewClient(context.Background(), logger, "localhost:9090", 3, 5*time.Second)
 	if err != nil {
 		logger.Error("error starting grpc client", zap.Error(err))
 	}
 
-	paymentClient := payment.NewClient(os.Getenv("SHOP_ID"), os.Getenv("SHOP_SECRET_KEY"))
+	paymentConfirmDelay := defaultFakePaymentConfirmDelay
+	if delay := os.Getenv("FAKE_PAYMENT_CONFIRM_DELAY"); delay != "" {
+		paymentConfirmDelay, err = time.ParseDuration(delay)
+		if err != nil {
+			logrus.Fatalf("Error parsing FAKE_PAYMENT_CONFIRM_DELAY: %s", err)
+		}
+	}
+
+	paymentClient, err := payment.NewProvider(payment.ProviderConfig{
+		Provider:     os.Getenv("PAYMENT_PROVIDER"),
+		ShopID:       os.Getenv("SHOP_ID"),
+		SecretKey:    os.Getenv("SHOP_SECRET_KEY"),
+		WebhookURL:   os.Getenv("FAKE_PAYMENT_WEBHOOK_URL"),
+		ConfirmDelay: paymentConfirmDelay,
+		Decline:      os.Getenv("FAKE_PAYMENT_DECLINE") == "true",
+	}, logger)
+	if err != nil {
+		logrus.Fatalf("Error initializing payment provider: %s", err)
+	}
+
+	holdPayments := os.Getenv("PAYMENT_HOLD") == "true"
 
 	router := gin.Default()
 
-	userHandler.UserRoutes(router, db)
+	userHandler.UserRoutes(router, db, logger, redisClient)
 	productHandler.ProductRoutes(router, db)
-	cartHandler.CartRoutes(router, db, logger, redisClient)
-	orderHandler.OrderRoutes(router, db, producer, grpcClient, paymentClient, orderConsumer, logger)
+	couponService := couponHandler.CouponRoutes(router, db, logger)
+	deliveryService := deliveryHandler.DeliveryRoutes(router, db, logger)
+	orderService := orderHandler.OrderRoutes(router, db, grpcClient, paymentClient, holdPayments, orderConsumer, couponService, deliveryService, logger)
+	orderHandler.ReturnRoutes(router, db, grpcClient, paymentClient, logger)
+	webhook.WebhookRoute(router, db, orderService, paymentClient, logger)
+	reconciler := reconcile.ReconcileRoutes(router, db, orderService, paymentClient, logger)
+	cartHandler.CartRoutes(router, db, logger, redisClient, orderService, couponService, deliveryService)
+
+	outboxRelay := orderHandler.OutboxRoutes(router, db, map[string]service.EventPublisher{
+		service.CreateOrderTopic:  producer,
+		service.OrderExpiredTopic: expiredProducer,
+	}, logger)
+	go outboxRelay.Run(context.Background())
+
+	orderExpirer := orderHandler.OrderExpirer(db, orderService, orderPaymentTTL, logger)
+	go orderExpirer.Run(context.Background())
+
+	holdSweeper := orderHandler.HoldSweeper(db, orderService, logger)
+	go holdSweeper.Run(context.Background())
+
+	go reconciler.Run(context.Background())
 
 	srv := new(Server)
 
@@ -1,14 +1,16 @@
 package handler
 
 import (
+	"errors"
 	"github.com/aaanger/ecommerce/internal/cart/model"
+	"github.com/aaanger/ecommerce/internal/cart/repository"
 	"github.com/aaanger/ecommerce/internal/cart/service"
-	"github.com/aaanger/ecommerce/pkg/cookie"
 	"github.com/aaanger/ecommerce/pkg/middleware"
 	"github.com/aaanger/ecommerce/pkg/response"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"net/http"
+	"strconv"
 )
 
 type CartHandler struct {
@@ -24,9 +26,9 @@ func NewCartHandler(service service.ICartService, log *zap.Logger) *CartHandler
 }
 
 func (h *CartHandler) GetCart(c *gin.Context) {
-	userID, err := middleware.GetUserID(c)
-	session, err := cookie.ReadCookie(c.Request, cookie.CookieSession)
-	if err != nil {
+	userID, _ := middleware.GetUserID(c)
+	session := middleware.GetSessionID(c)
+	if userID == 0 && session == "" {
 		response.Error(c, http.StatusBadGateway, "Try to visit page later")
 		return
 	}
@@ -46,24 +48,27 @@ func (h *CartHandler) AddProduct(c *gin.Context) {
 		zap.String("layer", "handler"),
 		zap.String("method", "AddProduct"))
 
-	userID, err := middleware.GetUserID(c)
-	session, err := cookie.ReadCookie(c.Request, cookie.CookieSession)
-	if err != nil {
-		log.Error("failed to read cookie",
-			zap.Error(err))
+	userID, _ := middleware.GetUserID(c)
+	session := middleware.GetSessionID(c)
+	if userID == 0 && session == "" {
+		log.Error("session not found")
 		response.Error(c, http.StatusBadGateway, "Try to visit page later")
 		return
 	}
 
 	var input model.AddProductReq
 
-	err = c.ShouldBindJSON(&input)
+	err := c.ShouldBindJSON(&input)
 	if err != nil {
 		response.Error(c, http.StatusBadRequest, "Invalid input parameters")
 		return
 	}
 
-	cart, err := h.service.AddProduct(userID, input.ProductID, input.Quantity, session)
+	cart, err := h.service.AddProduct(userID, input.ProductID, input.VariantID, input.Quantity, session)
+	if errors.Is(err, service.ErrNotInStock) || errors.Is(err, service.ErrNotEnoughStock) {
+		response.Error(c, http.StatusConflict, err.Error())
+		return
+	}
 	if err != nil {
 		log.Error("500 error",
 			zap.Error(err))
@@ -74,23 +79,63 @@ func (h *CartHandler) AddProduct(c *gin.Context) {
 	response.JSON(c, http.StatusOK, cart)
 }
 
-func (h *CartHandler) DeleteProduct(c *gin.Context) {
-	userID, err := middleware.GetUserID(c)
-	session, err := cookie.ReadCookie(c.Request, cookie.CookieSession)
+func (h *CartHandler) UpdateQuantity(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	session := middleware.GetSessionID(c)
+	if userID == 0 && session == "" {
+		response.Error(c, http.StatusBadGateway, "Try to visit page later")
+		return
+	}
+
+	productID, err := strconv.Atoi(c.Param("productID"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product id")
+		return
+	}
+
+	var input model.UpdateQuantityReq
+
+	err = c.ShouldBindJSON(&input)
 	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid input parameters")
+		return
+	}
+
+	cart, err := h.service.UpdateQuantity(userID, productID, input.VariantID, *input.Quantity, session)
+	if errors.Is(err, repository.ErrLineNotFound) {
+		response.Error(c, http.StatusNotFound, "Product is not in the cart")
+		return
+	}
+	if errors.Is(err, service.ErrNotInStock) || errors.Is(err, service.ErrNotEnoughStock) {
+		response.Error(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		h.log.Error("update cart line error", zap.Error(err), zap.Int("productID", productID))
+		response.Error(c, http.StatusInternalServerError, "Failed to update quantity")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, cart)
+}
+
+func (h *CartHandler) DeleteProduct(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	session := middleware.GetSessionID(c)
+	if userID == 0 && session == "" {
 		response.Error(c, http.StatusBadGateway, "Try to visit page later")
 		return
 	}
 
 	var input model.DeleteProductReq
 
-	err = c.ShouldBindJSON(&input)
+	err := c.ShouldBindJSON(&input)
 	if err != nil {
 		response.Error(c, http.StatusBadRequest, "invalid input parameters")
 		return
 	}
 
-	cart, err := h.service.DeleteProduct(userID, input.ProductID, session)
+	cart, err := h.service.DeleteProduct(userID, input.ProductID, input.VariantID, session)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Failed to delete product from the cart")
 		return
@@ -5,10 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/aaanger/ecommerce/internal/cart/model"
+	"github.com/aaanger/ecommerce/internal/cart/repository"
+	"github.com/aaanger/ecommerce/internal/cart/service"
 	"github.com/aaanger/ecommerce/internal/cart/service/mocks"
 	"github.com/aaanger/ecommerce/pkg/lib"
+	"github.com/aaanger/ecommerce/pkg/money"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -22,7 +26,7 @@ type CartHandlerSuite struct {
 
 func (suite *CartHandlerSuite) SetupTest() {
 	suite.service = mocks.NewICartService(suite.T())
-	suite.handler = NewCartHandler(suite.service)
+	suite.handler = NewCartHandler(suite.service, zap.NewNop())
 }
 
 func TestOrderHandlerSuite(t *testing.T) {
@@ -32,7 +36,7 @@ func TestOrderHandlerSuite(t *testing.T) {
 // =====================================================================================================================
 
 func (suite *CartHandlerSuite) TestHandler_GetCartSuccess() {
-	suite.service.On("GetCartByUserID", 1).Return(
+	suite.service.On("GetCartByUserID", 1, "").Return(
 		&model.Cart{
 			ID:     1,
 			UserID: 1,
@@ -60,7 +64,7 @@ func (suite *CartHandlerSuite) TestHandler_GetCartSuccess() {
 	suite.Equal(1, cartRes.UserID)
 }
 
-func (suite *CartHandlerSuite) TestHandler_GetCartUnauthorized() {
+func (suite *CartHandlerSuite) TestHandler_GetCartNoSession() {
 	router := gin.New()
 	router.GET("/", suite.handler.GetCart)
 
@@ -69,12 +73,12 @@ func (suite *CartHandlerSuite) TestHandler_GetCartUnauthorized() {
 
 	router.ServeHTTP(w, r)
 
-	suite.Equal(http.StatusUnauthorized, w.Code)
-	suite.Equal(`"user id not found"`, w.Body.String())
+	suite.Equal(http.StatusBadGateway, w.Code)
+	suite.Equal(`"Try to visit page later"`, w.Body.String())
 }
 
 func (suite *CartHandlerSuite) TestHandler_GetCartServiceFailure() {
-	suite.service.On("GetCartByUserID", 1).Return(nil, errors.New("error"))
+	suite.service.On("GetCartByUserID", 1, "").Return(nil, errors.New("error"))
 
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
@@ -107,10 +111,10 @@ func (suite *CartHandlerSuite) TestHandler_AddProductSuccess() {
 				Quantity:  1,
 			},
 		},
-		TotalPrice: 123,
+		TotalPrice: money.FromMinor(12300),
 	}
 
-	suite.service.On("AddProduct", 1, req.ProductID, req.Quantity).Return(res, nil)
+	suite.service.On("AddProduct", 1, req.ProductID, 0, req.Quantity, "").Return(res, nil)
 
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
@@ -132,12 +136,12 @@ func (suite *CartHandlerSuite) TestHandler_AddProductSuccess() {
 	lib.Copy(&cartRes, &response)
 
 	suite.Equal(http.StatusOK, w.Code)
-	suite.Equal(float64(123), cartRes.TotalPrice)
+	suite.Equal(money.FromMinor(12300), cartRes.TotalPrice)
 	suite.Equal(1, len(cartRes.Lines))
 	suite.Equal(1, cartRes.ID)
 }
 
-func (suite *CartHandlerSuite) TestHandler_AddProductUnauthorized() {
+func (suite *CartHandlerSuite) TestHandler_AddProductNoSession() {
 	req := &model.AddProductReq{
 		ProductID: 1,
 		Quantity:  1,
@@ -153,8 +157,8 @@ func (suite *CartHandlerSuite) TestHandler_AddProductUnauthorized() {
 
 	router.ServeHTTP(w, r)
 
-	suite.Equal(http.StatusUnauthorized, w.Code)
-	suite.Equal(`"user id not found"`, w.Body.String())
+	suite.Equal(http.StatusBadGateway, w.Code)
+	suite.Equal(`"Try to visit page later"`, w.Body.String())
 }
 
 func (suite *CartHandlerSuite) TestHandler_AddProductEmptyFields() {
@@ -185,7 +189,7 @@ func (suite *CartHandlerSuite) TestHandler_AddProductServiceFailure() {
 		Quantity:  1,
 	}
 
-	suite.service.On("AddProduct", 1, req.ProductID, req.Quantity).Return(nil, errors.New("error"))
+	suite.service.On("AddProduct", 1, req.ProductID, 0, req.Quantity, "").Return(nil, errors.New("error"))
 
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
@@ -222,7 +226,7 @@ func (suite *CartHandlerSuite) TestHandler_DeleteProductSuccess() {
 		},
 	}
 
-	suite.service.On("DeleteProduct", 1, req.ProductID).Return(res, nil)
+	suite.service.On("DeleteProduct", 1, req.ProductID, 0, "").Return(res, nil)
 
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
@@ -247,7 +251,7 @@ func (suite *CartHandlerSuite) TestHandler_DeleteProductSuccess() {
 	suite.Equal(1, cartRes.ID)
 }
 
-func (suite *CartHandlerSuite) TestHandler_DeleteProductUnauthorized() {
+func (suite *CartHandlerSuite) TestHandler_DeleteProductNoSession() {
 	req := &model.DeleteProductReq{
 		ProductID: 1,
 	}
@@ -262,8 +266,8 @@ func (suite *CartHandlerSuite) TestHandler_DeleteProductUnauthorized() {
 
 	router.ServeHTTP(w, r)
 
-	suite.Equal(http.StatusUnauthorized, w.Code)
-	suite.Equal(`"user id not found"`, w.Body.String())
+	suite.Equal(http.StatusBadGateway, w.Code)
+	suite.Equal(`"Try to visit page later"`, w.Body.String())
 }
 
 func (suite *CartHandlerSuite) TestHandler_DeleteProductEmptyField() {
@@ -291,7 +295,7 @@ func (suite *CartHandlerSuite) TestHandler_DeleteProductServiceFailure() {
 		ProductID: 1,
 	}
 
-	suite.service.On("DeleteProduct", 1, req.ProductID).Return(nil, errors.New("error"))
+	suite.service.On("DeleteProduct", 1, req.ProductID, 0, "").Return(nil, errors.New("error"))
 
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
@@ -309,3 +313,150 @@ func (suite *CartHandlerSuite) TestHandler_DeleteProductServiceFailure() {
 	suite.Equal(http.StatusInternalServerError, w.Code)
 	suite.Equal(`"Failed to delete product from the cart"`, w.Body.String())
 }
+
+func (suite *CartHandlerSuite) TestHandler_AddProductGuest() {
+	req := &model.AddProductReq{
+		ProductID: 1,
+		Quantity:  2,
+	}
+
+	suite.service.On("AddProduct", 0, req.ProductID, 0, req.Quantity, "session").Return(&model.Cart{
+		Lines: []model.CartLine{{ProductID: 1, Quantity: 2}},
+	}, nil)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("session", "session")
+	})
+	router.POST("/add", suite.handler.AddProduct)
+
+	requestBody, _ := json.Marshal(req)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/add", bytes.NewBuffer(requestBody))
+
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *CartHandlerSuite) TestHandler_AddProductNotEnoughStock() {
+	req := &model.AddProductReq{
+		ProductID: 1,
+		Quantity:  10,
+	}
+
+	suite.service.On("AddProduct", 1, req.ProductID, 0, req.Quantity, "").Return(nil, service.ErrNotEnoughStock)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+	})
+	router.POST("/add", suite.handler.AddProduct)
+
+	requestBody, _ := json.Marshal(req)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/add", bytes.NewBuffer(requestBody))
+
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusConflict, w.Code)
+}
+
+// =====================================================================================================================
+
+func (suite *CartHandlerSuite) TestHandler_UpdateQuantitySuccess() {
+	res := &model.Cart{
+		ID:         1,
+		UserID:     1,
+		Lines:      []model.CartLine{{ProductID: 1, Quantity: 3}},
+		TotalPrice: money.FromMinor(1500),
+	}
+
+	suite.service.On("UpdateQuantity", 1, 1, 0, 3, "").Return(res, nil)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+	})
+	router.PATCH("/lines/:productID", suite.handler.UpdateQuantity)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("PATCH", "/lines/1", bytes.NewBufferString(`{"quantity": 3}`))
+
+	router.ServeHTTP(w, r)
+
+	var cartRes model.Cart
+	_ = json.Unmarshal(w.Body.Bytes(), &cartRes)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(money.FromMinor(1500), cartRes.TotalPrice)
+}
+
+func (suite *CartHandlerSuite) TestHandler_UpdateQuantityMissingQuantity() {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+	})
+	router.PATCH("/lines/:productID", suite.handler.UpdateQuantity)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("PATCH", "/lines/1", bytes.NewBufferString(`{}`))
+
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(`"Invalid input parameters"`, w.Body.String())
+}
+
+func (suite *CartHandlerSuite) TestHandler_UpdateQuantityInvalidProductID() {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+	})
+	router.PATCH("/lines/:productID", suite.handler.UpdateQuantity)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("PATCH", "/lines/abc", bytes.NewBufferString(`{"quantity": 1}`))
+
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(`"Invalid product id"`, w.Body.String())
+}
+
+func (suite *CartHandlerSuite) TestHandler_UpdateQuantityLineNotFound() {
+	suite.service.On("UpdateQuantity", 1, 1, 0, 2, "").Return(nil, repository.ErrLineNotFound)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+	})
+	router.PATCH("/lines/:productID", suite.handler.UpdateQuantity)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("PATCH", "/lines/1", bytes.NewBufferString(`{"quantity": 2}`))
+
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusNotFound, w.Code)
+	suite.Equal(`"Product is not in the cart"`, w.Body.String())
+}
+
+func (suite *CartHandlerSuite) TestHandler_UpdateQuantityNotEnoughStock() {
+	suite.service.On("UpdateQuantity", 1, 1, 0, 20, "").Return(nil, service.ErrNotEnoughStock)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+	})
+	router.PATCH("/lines/:productID", suite.handler.UpdateQuantity)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("PATCH", "/lines/1", bytes.NewBufferString(`{"quantity": 20}`))
+
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusConflict, w.Code)
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"errors"
+	"github.com/aaanger/ecommerce/internal/cart/model"
+	"github.com/aaanger/ecommerce/internal/cart/service"
+	couponService "github.com/aaanger/ecommerce/internal/coupon/service"
+	deliveryService "github.com/aaanger/ecommerce/internal/delivery/service"
+	"github.com/aaanger/ecommerce/pkg/middleware"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"github.com/aaanger/ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"net/http"
+)
+
+type CheckoutHandler struct {
+	service service.ICheckoutService
+	log     *zap.Logger
+}
+
+func NewCheckoutHandler(service service.ICheckoutService, log *zap.Logger) *CheckoutHandler {
+	return &CheckoutHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+func (h *CheckoutHandler) Checkout(c *gin.Context) {
+	log := h.log.With(
+		zap.String("service", "cart"),
+		zap.String("layer", "handler"),
+		zap.String("method", "Checkout"))
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	email, err := middleware.GetUserEmail(c)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "user email not found")
+		return
+	}
+
+	var req model.CheckoutReq
+
+	err = c.ShouldBindJSON(&req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		response.Error(c, http.StatusBadRequest, "Invalid input parameters")
+		return
+	}
+
+	res, err := h.service.Checkout(c.Request.Context(), userID, email, &req)
+	if errors.Is(err, service.ErrEmptyCart) {
+		response.Error(c, http.StatusBadRequest, "Cart is empty")
+		return
+	}
+	if errors.Is(err, money.ErrCurrencyMismatch) {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrPriceChanged) || errors.Is(err, service.ErrNotInStock) || errors.Is(err, service.ErrNotEnoughStock) {
+		response.Error(c, http.StatusConflict, err.Error())
+		return
+	}
+	if couponService.IsCouponError(err) || deliveryService.IsDeliveryError(err) {
+		response.Error(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if status.Code(err) == codes.FailedPrecondition {
+		response.Error(c, http.StatusConflict, status.Convert(err).Message())
+		return
+	}
+	if err != nil {
+		log.Error("checkout error", zap.Error(err), zap.Int("userID", userID))
+		response.Error(c, http.StatusInternalServerError, "Failed to checkout")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, res)
+}
+
+func (h *CheckoutHandler) PreviewCoupon(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	session := middleware.GetSessionID(c)
+	if userID == 0 && session == "" {
+		response.Error(c, http.StatusBadGateway, "Try to visit page later")
+		return
+	}
+
+	var req model.ApplyCouponReq
+
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid input parameters")
+		return
+	}
+
+	preview, err := h.service.PreviewCoupon(c.Request.Context(), userID, session, req.Code)
+	if errors.Is(err, service.ErrEmptyCart) {
+		response.Error(c, http.StatusBadRequest, "Cart is empty")
+		return
+	}
+	if couponService.IsCouponError(err) {
+		response.Error(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err != nil {
+		h.log.Error("preview coupon error", zap.Error(err), zap.Int("userID", userID))
+		response.Error(c, http.StatusInternalServerError, "Failed to apply coupon")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, preview)
+}
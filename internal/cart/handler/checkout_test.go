@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/aaanger/ecommerce/internal/cart/model"
+	"github.com/aaanger/ecommerce/internal/cart/service"
+	"github.com/aaanger/ecommerce/internal/cart/service/mocks"
+	couponService "github.com/aaanger/ecommerce/internal/coupon/service"
+	deliveryModel "github.com/aaanger/ecommerce/internal/delivery/model"
+	deliveryService "github.com/aaanger/ecommerce/internal/delivery/service"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type CheckoutHandlerSuite struct {
+	suite.Suite
+	service *mocks.ICheckoutService
+	handler *CheckoutHandler
+	router  *gin.Engine
+}
+
+func (suite *CheckoutHandlerSuite) SetupTest() {
+	suite.service = mocks.NewICheckoutService(suite.T())
+	suite.handler = NewCheckoutHandler(suite.service, zap.NewNop())
+
+	suite.router = gin.New()
+	suite.router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Set("email", "test@test.com")
+	})
+	suite.router.POST("/checkout", suite.handler.Checkout)
+}
+
+func TestCheckoutHandlerSuite(t *testing.T) {
+	suite.Run(t, new(CheckoutHandlerSuite))
+}
+
+// =====================================================================================================================
+
+func (suite *CheckoutHandlerSuite) TestHandler_CheckoutSuccess() {
+	suite.service.On("Checkout", mock.Anything, 1, "test@test.com", &model.CheckoutReq{}).Return(&model.CheckoutRes{
+		OrderID:         10,
+		TotalPrice:      money.FromMinor(1500),
+		ConfirmationURL: "https://pay.test/10",
+	}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/checkout", nil)
+
+	suite.router.ServeHTTP(w, r)
+
+	var res model.CheckoutRes
+	_ = json.Unmarshal(w.Body.Bytes(), &res)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(10, res.OrderID)
+	suite.Equal("https://pay.test/10", res.ConfirmationURL)
+}
+
+func (suite *CheckoutHandlerSuite) TestHandler_CheckoutExpectedTotal() {
+	expected := money.FromMinor(1500)
+	suite.service.On("Checkout", mock.Anything, 1, "test@test.com", &model.CheckoutReq{
+		ExpectedTotal: &expected,
+		Delivery:      deliveryModel.DeliveryReq{Method: deliveryModel.MethodPickup, AddressID: 2},
+	}).Return(nil, service.ErrPriceChanged)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/checkout", bytes.NewBufferString(`{"expected_total": "15.00", "delivery": {"method": "pickup", "address_id": 2}}`))
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusConflict, w.Code)
+	suite.Equal(`"cart total has changed"`, w.Body.String())
+}
+
+func (suite *CheckoutHandlerSuite) TestHandler_CheckoutExpectedTotalForeignCurrency() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/checkout", bytes.NewBufferString(`{"expected_total": {"value": "1", "currency": "USD"}}`))
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.service.AssertNotCalled(suite.T(), "Checkout", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *CheckoutHandlerSuite) TestHandler_CheckoutCurrencyMismatch() {
+	suite.service.On("Checkout", mock.Anything, 1, "test@test.com", &model.CheckoutReq{}).Return(nil, money.ErrCurrencyMismatch)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/checkout", nil)
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(`"money: currency mismatch"`, w.Body.String())
+}
+
+func (suite *CheckoutHandlerSuite) TestHandler_CheckoutGuest() {
+	router := gin.New()
+	router.POST("/checkout", suite.handler.Checkout)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/checkout", nil)
+
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusUnauthorized, w.Code)
+	suite.Equal(`"user id not found"`, w.Body.String())
+}
+
+func (suite *CheckoutHandlerSuite) TestHandler_CheckoutEmptyCart() {
+	suite.service.On("Checkout", mock.Anything, 1, "test@test.com", &model.CheckoutReq{}).Return(nil, service.ErrEmptyCart)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/checkout", nil)
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(`"Cart is empty"`, w.Body.String())
+}
+
+func (suite *CheckoutHandlerSuite) TestHandler_CheckoutReservationFailure() {
+	suite.service.On("Checkout", mock.Anything, 1, "test@test.com", &model.CheckoutReq{}).
+		Return(nil, status.Error(codes.FailedPrecondition, "not enough stock for product 1"))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/checkout", nil)
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusConflict, w.Code)
+	suite.Equal(`"not enough stock for product 1"`, w.Body.String())
+}
+
+func (suite *CheckoutHandlerSuite) TestHandler_CheckoutDeliveryRejected() {
+	suite.service.On("Checkout", mock.Anything, 1, "test@test.com", &model.CheckoutReq{}).Return(nil, deliveryService.ErrAddressRequired)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/checkout", nil)
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusUnprocessableEntity, w.Code)
+	suite.Equal(`"either address_id or address is required"`, w.Body.String())
+}
+
+func (suite *CheckoutHandlerSuite) TestHandler_CheckoutServiceFailure() {
+	suite.service.On("Checkout", mock.Anything, 1, "test@test.com", &model.CheckoutReq{}).Return(nil, errors.New("error"))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/checkout", nil)
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusInternalServerError, w.Code)
+	suite.Equal(`"Failed to checkout"`, w.Body.String())
+}
+
+// =====================================================================================================================
+
+func (suite *CheckoutHandlerSuite) TestHandler_PreviewCouponSuccess() {
+	suite.service.On("PreviewCoupon", mock.Anything, 1, "", "SALE10").Return(&model.CouponPreview{
+		Code:       "SALE10",
+		Discount:   money.FromMinor(100),
+		TotalPrice: money.FromMinor(900),
+	}, nil)
+	suite.router.POST("/coupon", suite.handler.PreviewCoupon)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/coupon", bytes.NewBufferString(`{"code": "SALE10"}`))
+
+	suite.router.ServeHTTP(w, r)
+
+	var preview model.CouponPreview
+	_ = json.Unmarshal(w.Body.Bytes(), &preview)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(money.FromMinor(900), preview.TotalPrice)
+}
+
+func (suite *CheckoutHandlerSuite) TestHandler_PreviewCouponRejected() {
+	suite.service.On("PreviewCoupon", mock.Anything, 1, "", "OLD").Return(nil, couponService.ErrCouponInvalid)
+	suite.router.POST("/coupon", suite.handler.PreviewCoupon)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/coupon", bytes.NewBufferString(`{"code": "OLD"}`))
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusUnprocessableEntity, w.Code)
+	suite.Equal(`"coupon is not valid at this time"`, w.Body.String())
+}
@@ -4,6 +4,9 @@ import (
 	"database/sql"
 	"github.com/aaanger/ecommerce/internal/cart/repository"
 	"github.com/aaanger/ecommerce/internal/cart/service"
+	couponService "github.com/aaanger/ecommerce/internal/coupon/service"
+	deliveryService "github.com/aaanger/ecommerce/internal/delivery/service"
+	orderService "github.com/aaanger/ecommerce/internal/order/service"
 	productRepository "github.com/aaanger/ecommerce/internal/product/repository"
 	"github.com/aaanger/ecommerce/pkg/middleware"
 	"github.com/gin-gonic/gin"
@@ -11,17 +14,21 @@ import (
 	"go.uber.org/zap"
 )
 
-func CartRoutes(r *gin.Engine, db *sql.DB, log *zap.Logger, redisClient *redis.Client) {
+func CartRoutes(r *gin.Engine, db *sql.DB, log *zap.Logger, redisClient *redis.Client, orderService orderService.IOrderService, couponService couponService.ICouponService, deliveryService deliveryService.IDeliveryService) {
 	repo := repository.NewCartRepository(db)
 	redisRepo := repository.NewRedisCartRepository(redisClient, repository.TTL, log)
 	productRepo := productRepository.NewProductRepository(db)
-	svc := service.NewCartService(repo, redisRepo, productRepo, log)
+	variantRepo := productRepository.NewVariantRepository(db)
+	svc := service.NewCartService(repo, redisRepo, productRepo, variantRepo, log)
 	h := NewCartHandler(svc, log)
+	checkoutHandler := NewCheckoutHandler(service.NewCheckoutService(svc, repo, orderService, couponService, deliveryService, log), log)
 
-	cart := r.Group("/cart", middleware.SessionMiddleware)
+	cart := r.Group("/cart", middleware.SessionMiddleware, middleware.OptionalUserIdentity)
 
 	cart.GET("/", h.GetCart)
 	cart.POST("/add", h.AddProduct)
+	cart.PATCH("/lines/:productID", h.UpdateQuantity)
 	cart.DELETE("/", h.DeleteProduct)
-
+	cart.POST("/coupon", checkoutHandler.PreviewCoupon)
+	cart.POST("/checkout", checkoutHandler.Checkout)
 }
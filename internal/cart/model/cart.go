@@ -1,30 +1,96 @@
 package model
 
 import (
+	delivery "github.com/aaanger/ecommerce/internal/delivery/model"
 	"github.com/aaanger/ecommerce/internal/product/model"
+	"github.com/aaanger/ecommerce/pkg/money"
 	"time"
 )
 
 type Cart struct {
-	ID         int        `json:"id"`
-	UserID     int        `json:"user_id"`
-	Lines      []CartLine `json:"lines"`
-	TotalPrice float64    `json:"total_price"`
-	CreatedAt  time.Time  `json:"created_at"`
-	UpdatedAt  time.Time  `json:"updated_at"`
+	ID         int         `json:"id"`
+	UserID     int         `json:"user_id"`
+	Lines      []CartLine  `json:"lines"`
+	TotalPrice money.Money `json:"total_price"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
 }
 
 type CartLine struct {
 	ProductID int            `json:"product_id"`
+	VariantID int            `json:"variant_id,omitempty"`
 	Product   *model.Product `json:"product"`
+	Variant   *model.Variant `json:"variant,omitempty"`
 	Quantity  int            `json:"quantity"`
 }
 
 type AddProductReq struct {
 	ProductID int `json:"product_id" binding:"required"`
-	Quantity  int `json:"quantity" binding:"required"`
+	VariantID int `json:"variant_id"`
+	Quantity  int `json:"quantity" binding:"required,gt=0"`
 }
 
 type DeleteProductReq struct {
 	ProductID int `json:"product_id" binding:"required"`
+	VariantID int `json:"variant_id"`
+}
+
+type UpdateQuantityReq struct {
+	VariantID int  `json:"variant_id"`
+	Quantity  *int `json:"quantity" binding:"required,gte=0"`
+}
+
+type CheckoutReq struct {
+	// ExpectedTotal is the total the customer was shown, after the coupon discount and with delivery. When set,
+	// checkout fails if the cart no longer adds up to it, so the customer never pays a price
+	// they have not seen.
+	ExpectedTotal *money.Money         `json:"expected_total"`
+	CouponCode    string               `json:"coupon_code"`
+	Delivery      delivery.DeliveryReq `json:"delivery"`
+}
+
+type CheckoutRes struct {
+	OrderID         int         `json:"order_id"`
+	TotalPrice      money.Money `json:"total_price"`
+	Discount        money.Money `json:"discount"`
+	ConfirmationURL string      `json:"confirmation_url"`
+}
+
+type ApplyCouponReq struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// CouponPreview shows what the cart would cost with a coupon. Nothing is stored: the code is
+// sent again with the checkout request.
+type CouponPreview struct {
+	Cart         *Cart       `json:"cart"`
+	Code         string      `json:"code"`
+	Discount     money.Money `json:"discount"`
+	FreeShipping bool        `json:"free_shipping"`
+	TotalPrice   money.Money `json:"total_price"`
+}
+
+// Total returns price × quantity for a line filled with its product, zero before that.
+func (l *CartLine) Total() money.Money {
+	if l.Product == nil {
+		return money.Money{}
+	}
+
+	price := l.Product.Price
+	if l.Variant != nil {
+		price = l.Variant.EffectivePrice(l.Product)
+	}
+
+	return price.Mul(l.Quantity)
+}
+
+// Quantity returns how many units of the product (or its variant) are already in the cart.
+func (c *Cart) Quantity(productID, variantID int) int {
+	var quantity int
+	for _, line := range c.Lines {
+		if line.ProductID == productID && line.VariantID == variantID {
+			quantity += line.Quantity
+		}
+	}
+	return quantity
 }
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"github.com/aaanger/ecommerce/internal/cart/model"
+	"github.com/aaanger/ecommerce/pkg/db"
 )
 
 //go:generate mockery --name=ICartRepository
@@ -11,10 +12,14 @@ import (
 type ICartRepository interface {
 	CreateCart(userID int) (int, error)
 	GetCartByUserID(userID int) (*model.Cart, error)
-	AddProduct(cartID, productID, quantity int) error
-	DeleteProduct(cartID, productID int) error
+	AddProduct(cartID, productID, variantID, quantity int) error
+	DeleteProduct(cartID, productID, variantID int) error
+	UpdateQuantity(cartID, productID, variantID, quantity int) error
+	ClearCart(cartID int) error
 }
 
+var ErrLineNotFound = errors.New("cart line not found")
+
 type CartRepository struct {
 	db *sql.DB
 }
@@ -48,7 +53,7 @@ func (r *CartRepository) GetCartByUserID(userID int) (*model.Cart, error) {
 
 	var lines []model.CartLine
 
-	rows, err := r.db.Query(`SELECT product_id, quantity FROM cartline l INNER JOIN carts c ON c.id=l.cart_id WHERE c.id=$1;`, cart.ID)
+	rows, err := r.db.Query(`SELECT product_id, variant_id, quantity FROM cartline l INNER JOIN carts c ON c.id=l.cart_id WHERE c.id=$1;`, cart.ID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return &cart, nil
@@ -58,12 +63,15 @@ func (r *CartRepository) GetCartByUserID(userID int) (*model.Cart, error) {
 
 	for rows.Next() {
 		var line model.CartLine
+		var variantID sql.NullInt64
 
-		err = rows.Scan(&line.ProductID, &line.Quantity)
+		err = rows.Scan(&line.ProductID, &variantID, &line.Quantity)
 		if err != nil {
 			return nil, err
 		}
 
+		line.VariantID = int(variantID.Int64)
+
 		lines = append(lines, line)
 	}
 
@@ -72,8 +80,11 @@ func (r *CartRepository) GetCartByUserID(userID int) (*model.Cart, error) {
 	return &cart, nil
 }
 
-func (r *CartRepository) AddProduct(cartID, productID, quantity int) error {
-	_, err := r.db.Exec(`INSERT INTO cartline (cart_id, product_id, quantity) VALUES($1, $2, $3);`, cartID, productID, quantity)
+// AddProduct adds quantity to the cart line, creating the line if the product is not in the cart yet.
+func (r *CartRepository) AddProduct(cartID, productID, variantID, quantity int) error {
+	_, err := r.db.Exec(`INSERT INTO cartline (cart_id, product_id, variant_id, quantity) VALUES($1, $2, $3, $4)
+		ON CONFLICT (cart_id, product_id, (COALESCE(variant_id, 0))) DO UPDATE SET quantity = cartline.quantity + EXCLUDED.quantity;`,
+		cartID, productID, db.NullInt(variantID), quantity)
 	if err != nil {
 		return err
 	}
@@ -81,8 +92,35 @@ func (r *CartRepository) AddProduct(cartID, productID, quantity int) error {
 	return nil
 }
 
-func (r *CartRepository) DeleteProduct(cartID, productID int) error {
-	_, err := r.db.Exec(`DELETE FROM cartline WHERE cart_id=$1 AND product_id=$2;`, cartID, productID)
+func (r *CartRepository) DeleteProduct(cartID, productID, variantID int) error {
+	_, err := r.db.Exec(`DELETE FROM cartline WHERE cart_id=$1 AND product_id=$2 AND COALESCE(variant_id, 0)=$3;`, cartID, productID, variantID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *CartRepository) UpdateQuantity(cartID, productID, variantID, quantity int) error {
+	res, err := r.db.Exec(`UPDATE cartline SET quantity=$1 WHERE cart_id=$2 AND product_id=$3 AND COALESCE(variant_id, 0)=$4;`,
+		quantity, cartID, productID, variantID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLineNotFound
+	}
+
+	return nil
+}
+
+func (r *CartRepository) ClearCart(cartID int) error {
+	_, err := r.db.Exec(`DELETE FROM cartline WHERE cart_id=$1;`, cartID)
 	if err != nil {
 		return err
 	}
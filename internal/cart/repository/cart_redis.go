@@ -13,10 +13,14 @@ const (
 	TTL = 24 * time.Hour
 )
 
+//go:generate mockery --name=IRedisCartRepository
+
 type IRedisCartRepository interface {
 	GetCart(sessionID string) (*model.Cart, error)
-	AddProduct(sessionID string, productID, quantity int) error
-	DeleteProduct(sessionID string, productID int) error
+	AddProduct(sessionID string, productID, variantID, quantity int) error
+	DeleteProduct(sessionID string, productID, variantID int) error
+	UpdateQuantity(sessionID string, productID, variantID, quantity int) error
+	DeleteCart(sessionID string) error
 }
 
 type RedisCartRepository struct {
@@ -51,50 +55,55 @@ func (r *RedisCartRepository) GetCart(sessionID string) (*model.Cart, error) {
 	return &cart, nil
 }
 
-func (r *RedisCartRepository) AddProduct(sessionID string, productID, quantity int) error {
+// AddProduct adds quantity to the cart line, creating the line if the product is not in the cart yet.
+func (r *RedisCartRepository) AddProduct(sessionID string, productID, variantID, quantity int) error {
 	log := r.log.With(
 		zap.String("storage", "redis"),
 		zap.String("method", "AddProduct"))
 
-	var cart model.Cart
-
-	data, err := r.db.Get("cart:" + sessionID).Result()
-	if errors.Is(err, redis.Nil) {
-		cart = model.Cart{
-			Lines: []model.CartLine{},
-		}
-	} else if err != nil {
+	cart, err := r.GetCart(sessionID)
+	if err != nil {
 		log.Error("failed to get cart", zap.Error(err))
 		return err
 	}
 
-	if data == "" {
-		cart = model.Cart{
-			Lines: []model.CartLine{},
-		}
-	} else {
-		err = json.Unmarshal([]byte(data), &cart)
-		if err != nil {
-			log.Error("json unmarshal error", zap.Error(err), zap.String("data", data))
-			return err
+	found := false
+	for i := range cart.Lines {
+		if cart.Lines[i].ProductID == productID && cart.Lines[i].VariantID == variantID {
+			cart.Lines[i].Quantity += quantity
+			found = true
+			break
 		}
 	}
 
-	cart.Lines = append(cart.Lines, model.CartLine{
-		ProductID: productID,
-		Quantity:  quantity,
-	})
+	if !found {
+		cart.Lines = append(cart.Lines, model.CartLine{
+			ProductID: productID,
+			VariantID: variantID,
+			Quantity:  quantity,
+		})
+	}
+
+	return r.save(sessionID, cart)
+}
 
-	encodedCart, err := json.Marshal(cart)
+func (r *RedisCartRepository) UpdateQuantity(sessionID string, productID, variantID, quantity int) error {
+	cart, err := r.GetCart(sessionID)
 	if err != nil {
-		log.Error("json marshal error", zap.Error(err))
 		return err
 	}
 
-	return r.db.Set("cart:"+sessionID, encodedCart, r.ttl).Err()
+	for i := range cart.Lines {
+		if cart.Lines[i].ProductID == productID && cart.Lines[i].VariantID == variantID {
+			cart.Lines[i].Quantity = quantity
+			return r.save(sessionID, cart)
+		}
+	}
+
+	return ErrLineNotFound
 }
 
-func (r *RedisCartRepository) DeleteProduct(sessionID string, productID int) error {
+func (r *RedisCartRepository) DeleteProduct(sessionID string, productID, variantID int) error {
 	var cart model.Cart
 
 	data, err := r.db.Get("cart:" + sessionID).Result()
@@ -109,12 +118,9 @@ func (r *RedisCartRepository) DeleteProduct(sessionID string, productID int) err
 
 	updatedLines := make([]model.CartLine, 0, len(cart.Lines))
 	for _, line := range cart.Lines {
-		if line.ProductID != productID {
+		if line.ProductID != productID || line.VariantID != variantID {
 			updatedLines = append(updatedLines, line)
 		}
-		if line.ProductID == productID {
-			cart.TotalPrice -= line.Product.Price
-		}
 	}
 
 	cart.Lines = updatedLines
@@ -126,3 +132,17 @@ func (r *RedisCartRepository) DeleteProduct(sessionID string, productID int) err
 
 	return r.db.Set("cart:"+sessionID, encoded, r.ttl).Err()
 }
+
+func (r *RedisCartRepository) DeleteCart(sessionID string) error {
+	return r.db.Del("cart:" + sessionID).Err()
+}
+
+func (r *RedisCartRepository) save(sessionID string, cart *model.Cart) error {
+	encoded, err := json.Marshal(model.Cart{Lines: cart.Lines})
+	if err != nil {
+		r.log.Error("json marshal error", zap.Error(err))
+		return err
+	}
+
+	return r.db.Set("cart:"+sessionID, encoded, r.ttl).Err()
+}
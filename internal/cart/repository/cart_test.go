@@ -56,14 +56,16 @@ func (suite *CartRepositorySuite) TestRepository_GetCartByUserIDSuccess() {
 	rows := sqlmock.NewRows([]string{"id", "user_id", "created_at", "updated_at"}).AddRow(1, 1, time.Now(), time.Now())
 	suite.mock.ExpectQuery("SELECT id, user_id, created_at, updated_at FROM carts WHERE user_id=\\$1").WithArgs(1).WillReturnRows(rows)
 
-	lineRows := sqlmock.NewRows([]string{"product_id", "quantity"}).AddRow(1, 1)
-	suite.mock.ExpectQuery("SELECT product_id, quantity FROM cartline l INNER JOIN carts c ON c.id=l.cart_id WHERE c.id=\\$1").
+	lineRows := sqlmock.NewRows([]string{"product_id", "variant_id", "quantity"}).AddRow(1, nil, 1).AddRow(2, 5, 1)
+	suite.mock.ExpectQuery("SELECT product_id, variant_id, quantity FROM cartline l INNER JOIN carts c ON c.id=l.cart_id WHERE c.id=\\$1").
 		WithArgs(1).WillReturnRows(lineRows)
 
 	cart, err := suite.repo.GetCartByUserID(1)
 
 	suite.NotNil(cart)
 	suite.Nil(err)
+	suite.Equal(0, cart.Lines[0].VariantID)
+	suite.Equal(5, cart.Lines[1].VariantID)
 }
 
 func (suite *CartRepositorySuite) TestRepository_GetCartByIDFailure() {
@@ -80,7 +82,7 @@ func (suite *CartRepositorySuite) TestRepository_GetCartByIDGetLinesFailure() {
 	rows := sqlmock.NewRows([]string{"id", "user_id", "created_at", "updated_at"}).AddRow(1, 1, time.Now(), time.Now())
 	suite.mock.ExpectQuery("SELECT id, user_id, created_at, updated_at FROM carts WHERE user_id=\\$1").WithArgs(1).WillReturnRows(rows)
 
-	suite.mock.ExpectQuery("SELECT product_id, quantity FROM cartline l INNER JOIN carts c ON c.id=l.cart_id WHERE c.id=\\$1").
+	suite.mock.ExpectQuery("SELECT product_id, variant_id, quantity FROM cartline l INNER JOIN carts c ON c.id=l.cart_id WHERE c.id=\\$1").
 		WithArgs(1).WillReturnError(errors.New("error"))
 
 	cart, err := suite.repo.GetCartByUserID(1)
@@ -92,17 +94,34 @@ func (suite *CartRepositorySuite) TestRepository_GetCartByIDGetLinesFailure() {
 // ====================================================================================================================
 
 func (suite *CartRepositorySuite) TestRepository_AddProductSuccess() {
-	suite.mock.ExpectExec("INSERT INTO cartline").WithArgs(1, 1, 1).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec("INSERT INTO cartline").WithArgs(1, 1, nil, 1).WillReturnResult(sqlmock.NewResult(1, 1))
 
-	err := suite.repo.AddProduct(1, 1, 1)
+	err := suite.repo.AddProduct(1, 1, 0, 1)
+
+	suite.Nil(err)
+}
+
+func (suite *CartRepositorySuite) TestRepository_AddProductVariantSuccess() {
+	suite.mock.ExpectExec("INSERT INTO cartline").WithArgs(1, 1, 3, 1).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := suite.repo.AddProduct(1, 1, 3, 1)
+
+	suite.Nil(err)
+}
+
+func (suite *CartRepositorySuite) TestRepository_AddProductMergesExistingLine() {
+	suite.mock.ExpectExec("INSERT INTO cartline .* ON CONFLICT \\(cart_id, product_id, \\(COALESCE\\(variant_id, 0\\)\\)\\) DO UPDATE SET quantity = cartline.quantity \\+ EXCLUDED.quantity").
+		WithArgs(1, 1, nil, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := suite.repo.AddProduct(1, 1, 0, 2)
 
 	suite.Nil(err)
 }
 
 func (suite *CartRepositorySuite) TestRepository_AddProductFailure() {
-	suite.mock.ExpectExec("INSERT INTO cartline").WithArgs(1, 1, 1).WillReturnError(errors.New("error"))
+	suite.mock.ExpectExec("INSERT INTO cartline").WithArgs(1, 1, nil, 1).WillReturnError(errors.New("error"))
 
-	err := suite.repo.AddProduct(1, 1, 1)
+	err := suite.repo.AddProduct(1, 1, 0, 1)
 
 	suite.NotNil(err)
 }
@@ -110,17 +129,62 @@ func (suite *CartRepositorySuite) TestRepository_AddProductFailure() {
 // ====================================================================================================================
 
 func (suite *CartRepositorySuite) TestRepository_DeleteProductSuccess() {
-	suite.mock.ExpectExec("DELETE FROM cartline WHERE cart_id=\\$1 AND product_id=\\$2").WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec("DELETE FROM cartline WHERE cart_id=\\$1 AND product_id=\\$2 AND COALESCE\\(variant_id, 0\\)=\\$3").WithArgs(1, 1, 0).WillReturnResult(sqlmock.NewResult(1, 1))
 
-	err := suite.repo.DeleteProduct(1, 1)
+	err := suite.repo.DeleteProduct(1, 1, 0)
 
 	suite.Nil(err)
 }
 
 func (suite *CartRepositorySuite) TestRepository_DeleteProductFailure() {
-	suite.mock.ExpectExec("DELETE FROM cartline WHERE cart_id=\\$1 AND product_id=\\$2").WithArgs(1, 1).WillReturnError(errors.New("error"))
+	suite.mock.ExpectExec("DELETE FROM cartline WHERE cart_id=\\$1 AND product_id=\\$2 AND COALESCE\\(variant_id, 0\\)=\\$3").WithArgs(1, 1, 0).WillReturnError(errors.New("error"))
+
+	err := suite.repo.DeleteProduct(1, 1, 0)
+
+	suite.NotNil(err)
+}
+
+// ====================================================================================================================
+
+func (suite *CartRepositorySuite) TestRepository_UpdateQuantitySuccess() {
+	suite.mock.ExpectExec("UPDATE cartline SET quantity=\\$1 WHERE cart_id=\\$2 AND product_id=\\$3 AND COALESCE\\(variant_id, 0\\)=\\$4").
+		WithArgs(3, 1, 2, 0).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := suite.repo.UpdateQuantity(1, 2, 0, 3)
+
+	suite.Nil(err)
+}
+
+func (suite *CartRepositorySuite) TestRepository_UpdateQuantityLineNotFound() {
+	suite.mock.ExpectExec("UPDATE cartline SET quantity=\\$1").WithArgs(3, 1, 2, 0).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := suite.repo.UpdateQuantity(1, 2, 0, 3)
+
+	suite.ErrorIs(err, ErrLineNotFound)
+}
+
+func (suite *CartRepositorySuite) TestRepository_UpdateQuantityFailure() {
+	suite.mock.ExpectExec("UPDATE cartline SET quantity=\\$1").WithArgs(3, 1, 2, 0).WillReturnError(errors.New("error"))
+
+	err := suite.repo.UpdateQuantity(1, 2, 0, 3)
+
+	suite.NotNil(err)
+}
+
+// ====================================================================================================================
+
+func (suite *CartRepositorySuite) TestRepository_ClearCartSuccess() {
+	suite.mock.ExpectExec("DELETE FROM cartline WHERE cart_id=\\$1;").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err := suite.repo.ClearCart(1)
+
+	suite.Nil(err)
+}
+
+func (suite *CartRepositorySuite) TestRepository_ClearCartFailure() {
+	suite.mock.ExpectExec("DELETE FROM cartline WHERE cart_id=\\$1;").WithArgs(1).WillReturnError(errors.New("error"))
 
-	err := suite.repo.DeleteProduct(1, 1)
+	err := suite.repo.ClearCart(1)
 
 	suite.NotNil(err)
 }
@@ -1,4 +1,4 @@
-// Code generated by mockery v2.50.4. DO NOT EDIT.
+// Code generated by mockery v2.53.5. DO NOT EDIT.
 
 package mocks
 
@@ -12,17 +12,35 @@ type ICartRepository struct {
 	mock.Mock
 }
 
-// AddProduct provides a mock function with given fields: cartID, productID, quantity
-func (_m *ICartRepository) AddProduct(cartID int, productID int, quantity int) error {
-	ret := _m.Called(cartID, productID, quantity)
+// AddProduct provides a mock function with given fields: cartID, productID, variantID, quantity
+func (_m *ICartRepository) AddProduct(cartID int, productID int, variantID int, quantity int) error {
+	ret := _m.Called(cartID, productID, variantID, quantity)
 
 	if len(ret) == 0 {
 		panic("no return value specified for AddProduct")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int, int, int) error); ok {
-		r0 = rf(cartID, productID, quantity)
+	if rf, ok := ret.Get(0).(func(int, int, int, int) error); ok {
+		r0 = rf(cartID, productID, variantID, quantity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ClearCart provides a mock function with given fields: cartID
+func (_m *ICartRepository) ClearCart(cartID int) error {
+	ret := _m.Called(cartID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClearCart")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(cartID)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -58,17 +76,17 @@ func (_m *ICartRepository) CreateCart(userID int) (int, error) {
 	return r0, r1
 }
 
-// DeleteProduct provides a mock function with given fields: cartID, productID
-func (_m *ICartRepository) DeleteProduct(cartID int, productID int) error {
-	ret := _m.Called(cartID, productID)
+// DeleteProduct provides a mock function with given fields: cartID, productID, variantID
+func (_m *ICartRepository) DeleteProduct(cartID int, productID int, variantID int) error {
+	ret := _m.Called(cartID, productID, variantID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteProduct")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int, int) error); ok {
-		r0 = rf(cartID, productID)
+	if rf, ok := ret.Get(0).(func(int, int, int) error); ok {
+		r0 = rf(cartID, productID, variantID)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -106,6 +124,24 @@ func (_m *ICartRepository) GetCartByUserID(userID int) (*model.Cart, error) {
 	return r0, r1
 }
 
+// UpdateQuantity provides a mock function with given fields: cartID, productID, variantID, quantity
+func (_m *ICartRepository) UpdateQuantity(cartID int, productID int, variantID int, quantity int) error {
+	ret := _m.Called(cartID, productID, variantID, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateQuantity")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, int, int, int) error); ok {
+		r0 = rf(cartID, productID, variantID, quantity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewICartRepository creates a new instance of ICartRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewICartRepository(t interface {
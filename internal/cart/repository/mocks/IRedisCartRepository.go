@@ -0,0 +1,129 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	model "github.com/aaanger/ecommerce/internal/cart/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IRedisCartRepository is an autogenerated mock type for the IRedisCartRepository type
+type IRedisCartRepository struct {
+	mock.Mock
+}
+
+// AddProduct provides a mock function with given fields: sessionID, productID, variantID, quantity
+func (_m *IRedisCartRepository) AddProduct(sessionID string, productID int, variantID int, quantity int) error {
+	ret := _m.Called(sessionID, productID, variantID, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddProduct")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int, int, int) error); ok {
+		r0 = rf(sessionID, productID, variantID, quantity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteCart provides a mock function with given fields: sessionID
+func (_m *IRedisCartRepository) DeleteCart(sessionID string) error {
+	ret := _m.Called(sessionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteCart")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(sessionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteProduct provides a mock function with given fields: sessionID, productID, variantID
+func (_m *IRedisCartRepository) DeleteProduct(sessionID string, productID int, variantID int) error {
+	ret := _m.Called(sessionID, productID, variantID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteProduct")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int, int) error); ok {
+		r0 = rf(sessionID, productID, variantID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetCart provides a mock function with given fields: sessionID
+func (_m *IRedisCartRepository) GetCart(sessionID string) (*model.Cart, error) {
+	ret := _m.Called(sessionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCart")
+	}
+
+	var r0 *model.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*model.Cart, error)); ok {
+		return rf(sessionID)
+	}
+	if rf, ok := ret.Get(0).(func(string) *model.Cart); ok {
+		r0 = rf(sessionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Cart)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(sessionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateQuantity provides a mock function with given fields: sessionID, productID, variantID, quantity
+func (_m *IRedisCartRepository) UpdateQuantity(sessionID string, productID int, variantID int, quantity int) error {
+	ret := _m.Called(sessionID, productID, variantID, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateQuantity")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int, int, int) error); ok {
+		r0 = rf(sessionID, productID, variantID, quantity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewIRedisCartRepository creates a new instance of IRedisCartRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIRedisCartRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IRedisCartRepository {
+	mock := &IRedisCartRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
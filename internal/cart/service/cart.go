@@ -1,10 +1,14 @@
 package service
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/aaanger/ecommerce/internal/cart/model"
 	"github.com/aaanger/ecommerce/internal/cart/repository"
+	productModel "github.com/aaanger/ecommerce/internal/product/model"
 	productRepository "github.com/aaanger/ecommerce/internal/product/repository"
+	"github.com/aaanger/ecommerce/pkg/money"
 	"go.uber.org/zap"
 )
 
@@ -12,54 +16,71 @@ import (
 
 type ICartService interface {
 	GetCartByUserID(userID int, sessionID string) (*model.Cart, error)
-	AddProduct(userID, productID, quantity int, sessionID string) (*model.Cart, error)
-	DeleteProduct(userID, productID int, sessionID string) (*model.Cart, error)
+	AddProduct(userID, productID, variantID, quantity int, sessionID string) (*model.Cart, error)
+	UpdateQuantity(userID, productID, variantID, quantity int, sessionID string) (*model.Cart, error)
+	DeleteProduct(userID, productID, variantID int, sessionID string) (*model.Cart, error)
+	MergeGuestCart(userID int, sessionID string) (*model.Cart, error)
+	ValidateCart(cart *model.Cart) error
 }
 
+var (
+	ErrNotInStock     = errors.New("product is not in stock")
+	ErrNotEnoughStock = errors.New("not enough stock")
+)
+
 type CartService struct {
 	repo        repository.ICartRepository
 	redisRepo   repository.IRedisCartRepository
 	productRepo productRepository.IProductRepository
+	variantRepo productRepository.IVariantRepository
 	log         *zap.Logger
 }
 
-func NewCartService(repo repository.ICartRepository, redisRepo repository.IRedisCartRepository, productRepo productRepository.IProductRepository, log *zap.Logger) *CartService {
+func NewCartService(repo repository.ICartRepository, redisRepo repository.IRedisCartRepository, productRepo productRepository.IProductRepository, variantRepo productRepository.IVariantRepository, log *zap.Logger) *CartService {
 	return &CartService{
 		repo:        repo,
 		redisRepo:   redisRepo,
 		productRepo: productRepo,
+		variantRepo: variantRepo,
 		log:         log,
 	}
 }
 
 func (s *CartService) GetCartByUserID(userID int, sessionID string) (*model.Cart, error) {
+	var cart *model.Cart
+	var err error
+
 	if userID == 0 {
-		cart, err := s.redisRepo.GetCart(sessionID)
-		if err != nil {
-			return nil, err
-		}
-		return cart, nil
+		cart, err = s.redisRepo.GetCart(sessionID)
+	} else {
+		cart, err = s.repo.GetCartByUserID(userID)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return s.repo.GetCartByUserID(userID)
+	err = s.fillCart(cart)
+	if err != nil {
+		return nil, err
+	}
+
+	return cart, nil
 }
 
-func (s *CartService) AddProduct(userID, productID, quantity int, sessionID string) (*model.Cart, error) {
+func (s *CartService) AddProduct(userID, productID, variantID, quantity int, sessionID string) (*model.Cart, error) {
 	log := s.log.With(
 		zap.String("service", "cart"),
 		zap.String("layer", "service"),
 		zap.String("method", "AddProduct"),
 		zap.Int("userID", userID))
 
-	var totalPrice float64
-
-	product, err := s.productRepo.GetProductByID(productID)
+	available, err := s.availableStock(productID, variantID)
 	if err != nil {
-		log.Error("Get product error", zap.Error(err))
+		log.Error("Get available stock error", zap.Error(err))
 		return nil, err
 	}
-	if product.InStock == false {
-		return nil, errors.New("product is not in stock")
+	if available == 0 {
+		return nil, ErrNotInStock
 	}
 
 	if userID == 0 {
@@ -68,96 +89,289 @@ func (s *CartService) AddProduct(userID, productID, quantity int, sessionID stri
 			log.Error("Redis get cart error", zap.Error(err))
 			return nil, err
 		}
-		err = s.redisRepo.AddProduct(sessionID, productID, quantity)
+		if cart.Quantity(productID, variantID)+quantity > available {
+			return nil, ErrNotEnoughStock
+		}
+
+		err = s.redisRepo.AddProduct(sessionID, productID, variantID, quantity)
 		if err != nil {
 			log.Error("Redis add product error", zap.Error(err))
 			return nil, err
 		}
 
-		cart.Lines = append(cart.Lines, model.CartLine{
-			ProductID: productID,
-			Product:   product,
-			Quantity:  quantity,
-		})
+		return s.GetCartByUserID(userID, sessionID)
+	}
 
-		for _, line := range cart.Lines {
-			totalPrice += line.Product.Price
+	cart, err := s.userCart(userID)
+	if err != nil {
+		log.Error("Get cart error", zap.Error(err))
+		return nil, err
+	}
+	if cart.Quantity(productID, variantID)+quantity > available {
+		return nil, ErrNotEnoughStock
+	}
+
+	err = s.repo.AddProduct(cart.ID, productID, variantID, quantity)
+	if err != nil {
+		log.Error("Add product error", zap.Error(err))
+		return nil, err
+	}
+
+	return s.GetCartByUserID(userID, sessionID)
+}
+
+// UpdateQuantity sets the quantity of a cart line. Zero removes the line.
+func (s *CartService) UpdateQuantity(userID, productID, variantID, quantity int, sessionID string) (*model.Cart, error) {
+	if quantity == 0 {
+		return s.DeleteProduct(userID, productID, variantID, sessionID)
+	}
+
+	available, err := s.availableStock(productID, variantID)
+	if err != nil {
+		return nil, err
+	}
+	if quantity > available {
+		return nil, ErrNotEnoughStock
+	}
+
+	if userID == 0 {
+		err = s.redisRepo.UpdateQuantity(sessionID, productID, variantID, quantity)
+		if err != nil {
+			return nil, err
 		}
 
-		cart.TotalPrice = totalPrice
-		return cart, nil
+		return s.GetCartByUserID(userID, sessionID)
 	}
 
 	cart, err := s.repo.GetCartByUserID(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrLineNotFound
+	}
 	if err != nil {
-		cartID, err := s.repo.CreateCart(userID)
+		return nil, err
+	}
+
+	err = s.repo.UpdateQuantity(cart.ID, productID, variantID, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCartByUserID(userID, sessionID)
+}
+
+func (s *CartService) DeleteProduct(userID, productID, variantID int, sessionID string) (*model.Cart, error) {
+	if userID == 0 {
+		err := s.redisRepo.DeleteProduct(sessionID, productID, variantID)
 		if err != nil {
 			return nil, err
 		}
-		cart = &model.Cart{
-			ID:     cartID,
-			UserID: userID,
-		}
+
+		return s.GetCartByUserID(userID, sessionID)
 	}
 
-	err = s.repo.AddProduct(cart.ID, productID, quantity)
+	cart, err := s.repo.GetCartByUserID(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	cart.Lines = append(cart.Lines, model.CartLine{
-		ProductID: productID,
-		Product:   product,
-		Quantity:  quantity,
-	})
+	err = s.repo.DeleteProduct(cart.ID, productID, variantID)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, line := range cart.Lines {
-		totalPrice += line.Product.Price
+	return s.GetCartByUserID(userID, sessionID)
+}
+
+// MergeGuestCart moves the anonymous cart stored under the session into the user's cart.
+// Quantities of lines present in both carts are summed and capped at the available stock;
+// lines that are no longer in stock are dropped. The guest cart is deleted afterwards.
+func (s *CartService) MergeGuestCart(userID int, sessionID string) (*model.Cart, error) {
+	log := s.log.With(
+		zap.String("service", "cart"),
+		zap.String("layer", "service"),
+		zap.String("method", "MergeGuestCart"),
+		zap.Int("userID", userID))
+
+	guestCart, err := s.redisRepo.GetCart(sessionID)
+	if err != nil {
+		log.Error("Redis get cart error", zap.Error(err))
+		return nil, err
 	}
 
-	cart.TotalPrice = totalPrice
+	if len(guestCart.Lines) == 0 {
+		return nil, nil
+	}
 
-	return cart, nil
-}
+	cart, err := s.userCart(userID)
+	if err != nil {
+		log.Error("Get cart error", zap.Error(err))
+		return nil, err
+	}
 
-func (s *CartService) DeleteProduct(userID, productID int, sessionID string) (*model.Cart, error) {
-	if userID == 0 {
-		cart, err := s.redisRepo.GetCart(sessionID)
-		if err != nil {
+	type lineKey struct{ productID, variantID int }
+
+	existing := make(map[lineKey]int)
+	for _, line := range cart.Lines {
+		existing[lineKey{line.ProductID, line.VariantID}] += line.Quantity
+	}
 
+	guest := make(map[lineKey]int)
+	var order []lineKey
+	for _, line := range guestCart.Lines {
+		key := lineKey{line.ProductID, line.VariantID}
+		if _, ok := guest[key]; !ok {
+			order = append(order, key)
+		}
+		guest[key] += line.Quantity
+	}
+
+	for _, key := range order {
+		available, err := s.availableStock(key.productID, key.variantID)
+		if err != nil {
+			log.Error("Get available stock error", zap.Error(err), zap.Int("productID", key.productID))
 			return nil, err
 		}
-		err = s.redisRepo.DeleteProduct(sessionID, productID)
+
+		current, inCart := existing[key]
+		quantity := min(current+guest[key], available)
+		if quantity <= current {
+			continue
+		}
+
+		if inCart {
+			err = s.repo.UpdateQuantity(cart.ID, key.productID, key.variantID, quantity)
+		} else {
+			err = s.repo.AddProduct(cart.ID, key.productID, key.variantID, quantity)
+		}
 		if err != nil {
+			log.Error("Merge cart line error", zap.Error(err), zap.Int("productID", key.productID))
 			return nil, err
 		}
+	}
 
-		for i, line := range cart.Lines {
-			if line.ProductID == productID {
-				cart.Lines = append(cart.Lines[:i], cart.Lines[i+1:]...)
-				break
-			}
+	if err = s.redisRepo.DeleteCart(sessionID); err != nil {
+		log.Error("Redis delete cart error", zap.Error(err))
+	}
+
+	log.Info("Guest cart merged", zap.Int("cartID", cart.ID), zap.Int("lines", len(order)))
+
+	return s.GetCartByUserID(userID, "")
+}
+
+// ValidateCart checks that every line of the cart can still be bought in the requested quantity.
+func (s *CartService) ValidateCart(cart *model.Cart) error {
+	for _, line := range cart.Lines {
+		available, err := s.availableStock(line.ProductID, line.VariantID)
+		if err != nil {
+			return err
 		}
+		if available == 0 {
+			return fmt.Errorf("%w: product %d", ErrNotInStock, line.ProductID)
+		}
+		if line.Quantity > available {
+			return fmt.Errorf("%w: product %d", ErrNotEnoughStock, line.ProductID)
+		}
+	}
 
+	return nil
+}
+
+// userCart returns the user's cart, creating an empty one on first use.
+func (s *CartService) userCart(userID int) (*model.Cart, error) {
+	cart, err := s.repo.GetCartByUserID(userID)
+	if err == nil {
 		return cart, nil
 	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
 
-	cart, err := s.repo.GetCartByUserID(userID)
+	cartID, err := s.repo.CreateCart(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	err = s.repo.DeleteProduct(cart.ID, productID)
+	return &model.Cart{
+		ID:     cartID,
+		UserID: userID,
+	}, nil
+}
+
+// fillCart attaches products and variants to the cart lines and computes the total
+// as the sum of price × quantity. Lines whose product has been deleted are dropped.
+func (s *CartService) fillCart(cart *model.Cart) error {
+	var totalPrice money.Money
+	products := make(map[int]*productModel.Product)
+	lines := make([]model.CartLine, 0, len(cart.Lines))
+
+	for _, line := range cart.Lines {
+		product, ok := products[line.ProductID]
+		if !ok {
+			var err error
+			product, err = s.productRepo.GetProductByID(line.ProductID)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			products[line.ProductID] = product
+		}
+		line.Product = product
+
+		if line.VariantID != 0 {
+			variant, err := s.variantRepo.GetVariantByID(line.VariantID)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			line.Variant = variant
+		}
+
+		totalPrice = totalPrice.Add(line.Total())
+		lines = append(lines, line)
+	}
+
+	if totalPrice.Currency == "" {
+		totalPrice = money.FromMinor(0)
+	}
+
+	cart.Lines = lines
+	cart.TotalPrice = totalPrice
+
+	return nil
+}
+
+// availableStock returns how many units of the product (or its variant) can be put in a cart.
+// Products that were deleted or are out of stock have nothing available.
+func (s *CartService) availableStock(productID, variantID int) (int, error) {
+	product, err := s.productRepo.GetProductByID(productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	for i, line := range cart.Lines {
-		if line.ProductID == productID {
-			cart.Lines = append(cart.Lines[:i], cart.Lines[i+1:]...)
-			break
+	if variantID == 0 {
+		if !product.InStock {
+			return 0, nil
 		}
+		return product.Amount, nil
 	}
 
-	return cart, nil
+	variant, err := s.variantRepo.GetVariantByID(variantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if variant.ProductID != productID || !variant.InStock {
+		return 0, nil
+	}
+
+	return variant.Amount, nil
 }
@@ -1,26 +1,34 @@
 package service
 
 import (
+	"database/sql"
 	"errors"
 	"github.com/aaanger/ecommerce/internal/cart/model"
+	"github.com/aaanger/ecommerce/internal/cart/repository"
 	"github.com/aaanger/ecommerce/internal/cart/repository/mocks"
 	productModel "github.com/aaanger/ecommerce/internal/product/model"
 	productMocks "github.com/aaanger/ecommerce/internal/product/repository/mocks"
+	"github.com/aaanger/ecommerce/pkg/money"
 	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
 	"testing"
 )
 
 type CartServiceSuite struct {
 	suite.Suite
 	repo        *mocks.ICartRepository
+	redisRepo   *mocks.IRedisCartRepository
 	productRepo *productMocks.IProductRepository
+	variantRepo *productMocks.IVariantRepository
 	service     *CartService
 }
 
 func (suite *CartServiceSuite) SetupTest() {
 	suite.repo = mocks.NewICartRepository(suite.T())
+	suite.redisRepo = mocks.NewIRedisCartRepository(suite.T())
 	suite.productRepo = productMocks.NewIProductRepository(suite.T())
-	suite.service = NewCartService(suite.repo, suite.productRepo)
+	suite.variantRepo = productMocks.NewIVariantRepository(suite.T())
+	suite.service = NewCartService(suite.repo, suite.redisRepo, suite.productRepo, suite.variantRepo, zap.NewNop())
 }
 
 func TestCartServiceSuite(t *testing.T) {
@@ -30,21 +38,42 @@ func TestCartServiceSuite(t *testing.T) {
 // ====================================================================================================================
 
 func (suite *CartServiceSuite) TestService_GetCartByIDSuccess() {
+	variantPrice := money.FromMinor(700)
+
 	suite.repo.On("GetCartByUserID", 1).Return(&model.Cart{
 		ID:     1,
 		UserID: 1,
+		Lines: []model.CartLine{
+			{ProductID: 1, Quantity: 3},
+			{ProductID: 1, VariantID: 2, Quantity: 2},
+			{ProductID: 4, Quantity: 1},
+		},
 	}, nil)
+	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{ID: 1, Price: money.FromMinor(500)}, nil).Once()
+	suite.variantRepo.On("GetVariantByID", 2).Return(&productModel.Variant{ID: 2, ProductID: 1, Price: &variantPrice}, nil)
+	suite.productRepo.On("GetProductByID", 4).Return(nil, sql.ErrNoRows)
 
-	cart, err := suite.service.GetCartByUserID(1)
+	cart, err := suite.service.GetCartByUserID(1, "")
 
-	suite.NotNil(cart)
 	suite.Nil(err)
+	suite.Len(cart.Lines, 2)
+	// 3 × 5.00 + 2 × 7.00
+	suite.Equal(money.FromMinor(2900), cart.TotalPrice)
+}
+
+func (suite *CartServiceSuite) TestService_GetCartByIDGuest() {
+	suite.redisRepo.On("GetCart", "session").Return(&model.Cart{}, nil)
+
+	cart, err := suite.service.GetCartByUserID(0, "session")
+
+	suite.Nil(err)
+	suite.Equal(money.FromMinor(0), cart.TotalPrice)
 }
 
 func (suite *CartServiceSuite) TestService_GetCartByIDFailure() {
 	suite.repo.On("GetCartByUserID", 1).Return(nil, errors.New("error"))
 
-	cart, err := suite.service.GetCartByUserID(1)
+	cart, err := suite.service.GetCartByUserID(1, "")
 
 	suite.Nil(cart)
 	suite.NotNil(err)
@@ -57,7 +86,7 @@ func (suite *CartServiceSuite) TestService_AddProductSuccess() {
 		ID:          1,
 		Name:        "test",
 		Description: "test",
-		Price:       5,
+		Price:       money.FromMinor(500),
 		Amount:      5,
 		InStock:     true,
 	}, nil)
@@ -65,53 +94,82 @@ func (suite *CartServiceSuite) TestService_AddProductSuccess() {
 	suite.repo.On("GetCartByUserID", 1).Return(&model.Cart{
 		ID:     1,
 		UserID: 1,
-	}, nil)
+		Lines:  []model.CartLine{{ProductID: 1, Quantity: 1}},
+	}, nil).Once()
+
+	suite.repo.On("AddProduct", 1, 1, 0, 2).Return(nil)
 
-	suite.repo.On("AddProduct", 1, 1, 1).Return(nil)
+	suite.repo.On("GetCartByUserID", 1).Return(&model.Cart{
+		ID:     1,
+		UserID: 1,
+		Lines:  []model.CartLine{{ProductID: 1, Quantity: 3}},
+	}, nil).Once()
 
-	cart, err := suite.service.AddProduct(1, 1, 1)
+	cart, err := suite.service.AddProduct(1, 1, 0, 2, "")
 
-	suite.NotNil(cart)
 	suite.Nil(err)
+	suite.Len(cart.Lines, 1)
+	suite.Equal(money.FromMinor(1500), cart.TotalPrice)
 }
 
-func (suite *CartServiceSuite) TestService_AddProductGetCartFailure() {
-	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{
-		ID:          1,
-		Name:        "test",
-		Description: "test",
-		Price:       5,
-		Amount:      5,
-		InStock:     true,
+func (suite *CartServiceSuite) TestService_AddProductGuestSuccess() {
+	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{ID: 1, Price: money.FromMinor(500), Amount: 5, InStock: true}, nil)
+
+	suite.redisRepo.On("GetCart", "session").Return(&model.Cart{}, nil).Once()
+	suite.redisRepo.On("AddProduct", "session", 1, 0, 1).Return(nil)
+	suite.redisRepo.On("GetCart", "session").Return(&model.Cart{Lines: []model.CartLine{{ProductID: 1, Quantity: 1}}}, nil).Once()
+
+	cart, err := suite.service.AddProduct(0, 1, 0, 1, "session")
+
+	suite.Nil(err)
+	suite.Equal(money.FromMinor(500), cart.TotalPrice)
+}
+
+func (suite *CartServiceSuite) TestService_AddProductNotEnoughStock() {
+	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{ID: 1, Amount: 5, InStock: true}, nil)
+
+	suite.repo.On("GetCartByUserID", 1).Return(&model.Cart{
+		ID:     1,
+		UserID: 1,
+		Lines:  []model.CartLine{{ProductID: 1, Quantity: 4}},
 	}, nil)
 
-	suite.repo.On("GetCartByUserID", 1).Return(nil, errors.New("error"))
+	cart, err := suite.service.AddProduct(1, 1, 0, 2, "")
 
-	suite.repo.On("CreateCart", 1).Return(1, nil)
+	suite.Nil(cart)
+	suite.ErrorIs(err, ErrNotEnoughStock)
+}
+
+func (suite *CartServiceSuite) TestService_AddProductNotInStock() {
+	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{ID: 1, Amount: 5, InStock: false}, nil)
+
+	cart, err := suite.service.AddProduct(1, 1, 0, 1, "")
 
-	suite.repo.On("AddProduct", 1, 1, 1).Return(nil)
+	suite.Nil(cart)
+	suite.ErrorIs(err, ErrNotInStock)
+}
+
+func (suite *CartServiceSuite) TestService_AddProductCreatesCart() {
+	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{ID: 1, Price: money.FromMinor(500), Amount: 5, InStock: true}, nil)
+
+	suite.repo.On("GetCartByUserID", 1).Return(nil, sql.ErrNoRows).Once()
+	suite.repo.On("CreateCart", 1).Return(1, nil)
+	suite.repo.On("AddProduct", 1, 1, 0, 1).Return(nil)
+	suite.repo.On("GetCartByUserID", 1).Return(&model.Cart{ID: 1, UserID: 1, Lines: []model.CartLine{{ProductID: 1, Quantity: 1}}}, nil).Once()
 
-	cart, err := suite.service.AddProduct(1, 1, 1)
+	cart, err := suite.service.AddProduct(1, 1, 0, 1, "")
 
 	suite.NotNil(cart)
 	suite.Nil(err)
 }
 
 func (suite *CartServiceSuite) TestService_AddProductCreateCartFailure() {
-	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{
-		ID:          1,
-		Name:        "test",
-		Description: "test",
-		Price:       5,
-		Amount:      5,
-		InStock:     true,
-	}, nil)
-
-	suite.repo.On("GetCartByUserID", 1).Return(nil, errors.New("error"))
+	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{ID: 1, Amount: 5, InStock: true}, nil)
 
+	suite.repo.On("GetCartByUserID", 1).Return(nil, sql.ErrNoRows)
 	suite.repo.On("CreateCart", 1).Return(0, errors.New("error"))
 
-	cart, err := suite.service.AddProduct(1, 1, 1)
+	cart, err := suite.service.AddProduct(1, 1, 0, 1, "")
 
 	suite.Nil(cart)
 	suite.NotNil(err)
@@ -120,30 +178,23 @@ func (suite *CartServiceSuite) TestService_AddProductCreateCartFailure() {
 func (suite *CartServiceSuite) TestService_AddProductGetProductFailure() {
 	suite.productRepo.On("GetProductByID", 1).Return(nil, errors.New("error"))
 
-	cart, err := suite.service.AddProduct(1, 1, 1)
+	cart, err := suite.service.AddProduct(1, 1, 0, 1, "")
 
 	suite.Nil(cart)
 	suite.NotNil(err)
 }
 
 func (suite *CartServiceSuite) TestService_AddProductFailure() {
-	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{
-		ID:          1,
-		Name:        "test",
-		Description: "test",
-		Price:       5,
-		Amount:      5,
-		InStock:     true,
-	}, nil)
+	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{ID: 1, Amount: 5, InStock: true}, nil)
 
 	suite.repo.On("GetCartByUserID", 1).Return(&model.Cart{
 		ID:     1,
 		UserID: 1,
 	}, nil)
 
-	suite.repo.On("AddProduct", 1, 1, 1).Return(errors.New("error"))
+	suite.repo.On("AddProduct", 1, 1, 0, 1).Return(errors.New("error"))
 
-	cart, err := suite.service.AddProduct(1, 1, 1)
+	cart, err := suite.service.AddProduct(1, 1, 0, 1, "")
 
 	suite.Nil(cart)
 	suite.NotNil(err)
@@ -151,15 +202,73 @@ func (suite *CartServiceSuite) TestService_AddProductFailure() {
 
 // ====================================================================================================================
 
+func (suite *CartServiceSuite) TestService_UpdateQuantitySuccess() {
+	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{ID: 1, Price: money.FromMinor(500), Amount: 5, InStock: true}, nil)
+
+	suite.repo.On("GetCartByUserID", 1).Return(&model.Cart{ID: 1, UserID: 1, Lines: []model.CartLine{{ProductID: 1, Quantity: 1}}}, nil).Once()
+	suite.repo.On("UpdateQuantity", 1, 1, 0, 5).Return(nil)
+	suite.repo.On("GetCartByUserID", 1).Return(&model.Cart{ID: 1, UserID: 1, Lines: []model.CartLine{{ProductID: 1, Quantity: 5}}}, nil).Once()
+
+	cart, err := suite.service.UpdateQuantity(1, 1, 0, 5, "")
+
+	suite.Nil(err)
+	suite.Equal(money.FromMinor(2500), cart.TotalPrice)
+}
+
+func (suite *CartServiceSuite) TestService_UpdateQuantityGuest() {
+	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{ID: 1, Price: money.FromMinor(500), Amount: 5, InStock: true}, nil)
+
+	suite.redisRepo.On("UpdateQuantity", "session", 1, 0, 2).Return(nil)
+	suite.redisRepo.On("GetCart", "session").Return(&model.Cart{Lines: []model.CartLine{{ProductID: 1, Quantity: 2}}}, nil)
+
+	cart, err := suite.service.UpdateQuantity(0, 1, 0, 2, "session")
+
+	suite.Nil(err)
+	suite.Equal(money.FromMinor(1000), cart.TotalPrice)
+}
+
+func (suite *CartServiceSuite) TestService_UpdateQuantityNotEnoughStock() {
+	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{ID: 1, Amount: 5, InStock: true}, nil)
+
+	cart, err := suite.service.UpdateQuantity(1, 1, 0, 6, "")
+
+	suite.Nil(cart)
+	suite.ErrorIs(err, ErrNotEnoughStock)
+}
+
+func (suite *CartServiceSuite) TestService_UpdateQuantityZeroDeletesLine() {
+	suite.repo.On("GetCartByUserID", 1).Return(&model.Cart{ID: 1, UserID: 1}, nil)
+	suite.repo.On("DeleteProduct", 1, 1, 0).Return(nil)
+
+	cart, err := suite.service.UpdateQuantity(1, 1, 0, 0, "")
+
+	suite.NotNil(cart)
+	suite.Nil(err)
+}
+
+func (suite *CartServiceSuite) TestService_UpdateQuantityLineNotFound() {
+	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{ID: 1, Amount: 5, InStock: true}, nil)
+
+	suite.repo.On("GetCartByUserID", 1).Return(&model.Cart{ID: 1, UserID: 1}, nil)
+	suite.repo.On("UpdateQuantity", 1, 1, 0, 2).Return(repository.ErrLineNotFound)
+
+	cart, err := suite.service.UpdateQuantity(1, 1, 0, 2, "")
+
+	suite.Nil(cart)
+	suite.ErrorIs(err, repository.ErrLineNotFound)
+}
+
+// ====================================================================================================================
+
 func (suite *CartServiceSuite) TestService_DeleteProductSuccess() {
 	suite.repo.On("GetCartByUserID", 1).Return(&model.Cart{
 		ID:     1,
 		UserID: 1,
 	}, nil)
 
-	suite.repo.On("DeleteProduct", 1, 1).Return(nil)
+	suite.repo.On("DeleteProduct", 1, 1, 0).Return(nil)
 
-	cart, err := suite.service.DeleteProduct(1, 1)
+	cart, err := suite.service.DeleteProduct(1, 1, 0, "")
 
 	suite.NotNil(cart)
 	suite.Nil(err)
@@ -168,7 +277,7 @@ func (suite *CartServiceSuite) TestService_DeleteProductSuccess() {
 func (suite *CartServiceSuite) TestService_DeleteProductGetCartFailure() {
 	suite.repo.On("GetCartByUserID", 1).Return(nil, errors.New("error"))
 
-	cart, err := suite.service.DeleteProduct(1, 1)
+	cart, err := suite.service.DeleteProduct(1, 1, 0, "")
 
 	suite.Nil(cart)
 	suite.NotNil(err)
@@ -180,10 +289,115 @@ func (suite *CartServiceSuite) TestService_DeleteProductFailure() {
 		UserID: 1,
 	}, nil)
 
-	suite.repo.On("DeleteProduct", 1, 1).Return(errors.New("error"))
+	suite.repo.On("DeleteProduct", 1, 1, 0).Return(errors.New("error"))
 
-	cart, err := suite.service.DeleteProduct(1, 1)
+	cart, err := suite.service.DeleteProduct(1, 1, 0, "")
 
 	suite.Nil(cart)
 	suite.NotNil(err)
 }
+
+// ====================================================================================================================
+
+func (suite *CartServiceSuite) TestService_MergeGuestCartSuccess() {
+	suite.redisRepo.On("GetCart", "session").Return(&model.Cart{
+		Lines: []model.CartLine{
+			{ProductID: 1, Quantity: 2},
+			{ProductID: 2, VariantID: 5, Quantity: 1},
+			{ProductID: 1, Quantity: 1},
+			{ProductID: 3, Quantity: 1},
+		},
+	}, nil)
+
+	suite.repo.On("GetCartByUserID", 1).Return(&model.Cart{
+		ID:     1,
+		UserID: 1,
+		Lines:  []model.CartLine{{ProductID: 1, Quantity: 2}},
+	}, nil).Once()
+
+	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{ID: 1, Amount: 4, InStock: true}, nil)
+	suite.productRepo.On("GetProductByID", 2).Return(&productModel.Product{ID: 2, InStock: false}, nil)
+	suite.variantRepo.On("GetVariantByID", 5).Return(&productModel.Variant{ID: 5, ProductID: 2, Amount: 3, InStock: true}, nil)
+	suite.productRepo.On("GetProductByID", 3).Return(nil, sql.ErrNoRows)
+
+	// 2 in the user cart + 3 from the guest cart, capped at the 4 in stock.
+	suite.repo.On("UpdateQuantity", 1, 1, 0, 4).Return(nil)
+	suite.repo.On("AddProduct", 1, 2, 5, 1).Return(nil)
+	suite.redisRepo.On("DeleteCart", "session").Return(nil)
+
+	suite.repo.On("GetCartByUserID", 1).Return(&model.Cart{ID: 1, UserID: 1}, nil).Once()
+
+	cart, err := suite.service.MergeGuestCart(1, "session")
+
+	suite.NotNil(cart)
+	suite.Nil(err)
+}
+
+func (suite *CartServiceSuite) TestService_MergeGuestCartCreatesUserCart() {
+	suite.redisRepo.On("GetCart", "session").Return(&model.Cart{
+		Lines: []model.CartLine{{ProductID: 1, Quantity: 2}},
+	}, nil)
+
+	suite.repo.On("GetCartByUserID", 1).Return(nil, sql.ErrNoRows).Once()
+	suite.repo.On("CreateCart", 1).Return(3, nil)
+	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{ID: 1, Amount: 10, InStock: true}, nil)
+	suite.repo.On("AddProduct", 3, 1, 0, 2).Return(nil)
+	suite.redisRepo.On("DeleteCart", "session").Return(nil)
+	suite.repo.On("GetCartByUserID", 1).Return(&model.Cart{ID: 3, UserID: 1}, nil).Once()
+
+	cart, err := suite.service.MergeGuestCart(1, "session")
+
+	suite.Equal(3, cart.ID)
+	suite.Nil(err)
+}
+
+func (suite *CartServiceSuite) TestService_MergeGuestCartEmpty() {
+	suite.redisRepo.On("GetCart", "session").Return(&model.Cart{}, nil)
+
+	cart, err := suite.service.MergeGuestCart(1, "session")
+
+	suite.Nil(cart)
+	suite.Nil(err)
+}
+
+func (suite *CartServiceSuite) TestService_MergeGuestCartKeepsGuestCartOnFailure() {
+	suite.redisRepo.On("GetCart", "session").Return(&model.Cart{
+		Lines: []model.CartLine{{ProductID: 1, Quantity: 2}},
+	}, nil)
+
+	suite.repo.On("GetCartByUserID", 1).Return(&model.Cart{ID: 1, UserID: 1}, nil)
+	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{ID: 1, Amount: 10, InStock: true}, nil)
+	suite.repo.On("AddProduct", 1, 1, 0, 2).Return(errors.New("error"))
+
+	cart, err := suite.service.MergeGuestCart(1, "session")
+
+	suite.Nil(cart)
+	suite.NotNil(err)
+	suite.redisRepo.AssertNotCalled(suite.T(), "DeleteCart", "session")
+}
+
+// ====================================================================================================================
+
+func (suite *CartServiceSuite) TestService_ValidateCartSuccess() {
+	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{ID: 1, Amount: 5, InStock: true}, nil)
+
+	err := suite.service.ValidateCart(&model.Cart{Lines: []model.CartLine{{ProductID: 1, Quantity: 5}}})
+
+	suite.Nil(err)
+}
+
+func (suite *CartServiceSuite) TestService_ValidateCartNotEnoughStock() {
+	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{ID: 1, Amount: 5, InStock: true}, nil)
+
+	err := suite.service.ValidateCart(&model.Cart{Lines: []model.CartLine{{ProductID: 1, Quantity: 6}}})
+
+	suite.ErrorIs(err, ErrNotEnoughStock)
+}
+
+func (suite *CartServiceSuite) TestService_ValidateCartNotInStock() {
+	suite.productRepo.On("GetProductByID", 1).Return(nil, sql.ErrNoRows)
+
+	err := suite.service.ValidateCart(&model.Cart{Lines: []model.CartLine{{ProductID: 1, Quantity: 1}}})
+
+	suite.ErrorIs(err, ErrNotInStock)
+}
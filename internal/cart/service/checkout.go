@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/aaanger/ecommerce/internal/cart/model"
+	"github.com/aaanger/ecommerce/internal/cart/repository"
+	couponModel "github.com/aaanger/ecommerce/internal/coupon/model"
+	couponService "github.com/aaanger/ecommerce/internal/coupon/service"
+	deliveryService "github.com/aaanger/ecommerce/internal/delivery/service"
+	orderModel "github.com/aaanger/ecommerce/internal/order/model"
+	orderService "github.com/aaanger/ecommerce/internal/order/service"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"go.uber.org/zap"
+)
+
+//go:generate mockery --name=ICheckoutService
+
+type ICheckoutService interface {
+	Checkout(ctx context.Context, userID int, userEmail string, req *model.CheckoutReq) (*model.CheckoutRes, error)
+	PreviewCoupon(ctx context.Context, userID int, sessionID, code string) (*model.CouponPreview, error)
+}
+
+var (
+	ErrEmptyCart    = errors.New("cart is empty")
+	ErrPriceChanged = errors.New("cart total has changed")
+)
+
+type CheckoutService struct {
+	cartService     ICartService
+	repo            repository.ICartRepository
+	orderService    orderService.IOrderService
+	couponService   couponService.ICouponService
+	deliveryService deliveryService.IDeliveryService
+	log             *zap.Logger
+}
+
+func NewCheckoutService(cartService ICartService, repo repository.ICartRepository, orderService orderService.IOrderService, couponService couponService.ICouponService, deliveryService deliveryService.IDeliveryService, log *zap.Logger) *CheckoutService {
+	return &CheckoutService{
+		cartService:     cartService,
+		repo:            repo,
+		orderService:    orderService,
+		couponService:   couponService,
+		deliveryService: deliveryService,
+		log:             log,
+	}
+}
+
+// Checkout turns the user's cart into an order. The cart is re-read with current prices and
+// checked against stock first, and it is emptied only once the order has been created.
+func (s *CheckoutService) Checkout(ctx context.Context, userID int, userEmail string, req *model.CheckoutReq) (*model.CheckoutRes, error) {
+	log := s.log.With(
+		zap.String("service", "cart"),
+		zap.String("layer", "service"),
+		zap.String("method", "Checkout"),
+		zap.Int("userID", userID))
+
+	cart, err := s.cartService.GetCartByUserID(userID, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Lines) == 0 {
+		return nil, ErrEmptyCart
+	}
+
+	method, _, err := s.deliveryService.Resolve(ctx, userID, &req.Delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	total := cart.TotalPrice
+	shipping := method.Price
+	if req.CouponCode != "" {
+		discount, err := s.applyCoupon(ctx, cart, userID, req.CouponCode)
+		if err != nil {
+			return nil, err
+		}
+		total = total.Sub(discount.Amount)
+		if discount.FreeShipping {
+			shipping = money.FromMinor(0)
+		}
+	}
+	total = total.Add(shipping)
+
+	if req.ExpectedTotal != nil {
+		if err = req.ExpectedTotal.CheckCurrency(total); err != nil {
+			return nil, err
+		}
+		if req.ExpectedTotal.Cmp(total) != 0 {
+			log.Info("cart total changed before checkout",
+				zap.Stringer("expected", req.ExpectedTotal),
+				zap.Stringer("actual", total))
+			return nil, ErrPriceChanged
+		}
+	}
+
+	err = s.cartService.ValidateCart(cart)
+	if err != nil {
+		return nil, err
+	}
+
+	orderReq := &orderModel.CreateOrderReq{CouponCode: req.CouponCode, Delivery: req.Delivery}
+	for _, line := range cart.Lines {
+		orderReq.Lines = append(orderReq.Lines, orderModel.OrderLineReq{
+			ProductID: line.ProductID,
+			VariantID: line.VariantID,
+			Quantity:  line.Quantity,
+		})
+	}
+
+	order, err := s.orderService.CreateOrder(ctx, userID, userEmail, orderReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// The order is already persisted, so a cart that fails to clear is only logged: failing the
+	// request here would make the customer check out the same cart twice.
+	if err = s.repo.ClearCart(cart.ID); err != nil {
+		log.Error("failed to clear cart after checkout", zap.Error(err), zap.Int("orderID", order.Order.ID))
+	}
+
+	res := &model.CheckoutRes{
+		OrderID:    order.Order.ID,
+		TotalPrice: order.Order.TotalPrice,
+		Discount:   money.FromMinor(0),
+	}
+	if order.Order.Coupon != nil {
+		res.Discount = order.Order.Coupon.Discount
+	}
+	if order.Payment != nil {
+		res.ConfirmationURL = order.Payment.Confirmation.ConfirmationURL
+	}
+
+	return res, nil
+}
+
+// PreviewCoupon shows the cart with the coupon applied without redeeming it.
+func (s *CheckoutService) PreviewCoupon(ctx context.Context, userID int, sessionID, code string) (*model.CouponPreview, error) {
+	cart, err := s.cartService.GetCartByUserID(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Lines) == 0 {
+		return nil, ErrEmptyCart
+	}
+
+	discount, err := s.applyCoupon(ctx, cart, userID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.CouponPreview{
+		Cart:         cart,
+		Code:         discount.Code,
+		Discount:     discount.Amount,
+		FreeShipping: discount.FreeShipping,
+		TotalPrice:   cart.TotalPrice.Sub(discount.Amount),
+	}, nil
+}
+
+func (s *CheckoutService) applyCoupon(ctx context.Context, cart *model.Cart, userID int, code string) (*couponModel.Discount, error) {
+	lines := make([]couponModel.Line, len(cart.Lines))
+	for i := range cart.Lines {
+		lines[i] = couponModel.Line{
+			ProductID: cart.Lines[i].ProductID,
+			Price:     cart.Lines[i].Total(),
+		}
+	}
+
+	return s.couponService.Apply(ctx, code, userID, lines)
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/aaanger/ecommerce/internal/cart/model"
+	"github.com/aaanger/ecommerce/internal/cart/repository/mocks"
+	serviceMocks "github.com/aaanger/ecommerce/internal/cart/service/mocks"
+	couponModel "github.com/aaanger/ecommerce/internal/coupon/model"
+	couponService "github.com/aaanger/ecommerce/internal/coupon/service"
+	couponMocks "github.com/aaanger/ecommerce/internal/coupon/service/mocks"
+	deliveryModel "github.com/aaanger/ecommerce/internal/delivery/model"
+	deliveryService "github.com/aaanger/ecommerce/internal/delivery/service"
+	deliveryMocks "github.com/aaanger/ecommerce/internal/delivery/service/mocks"
+	orderModel "github.com/aaanger/ecommerce/internal/order/model"
+	orderMocks "github.com/aaanger/ecommerce/internal/order/service/mocks"
+	paymentModel "github.com/aaanger/ecommerce/internal/payment/model"
+	productModel "github.com/aaanger/ecommerce/internal/product/model"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"testing"
+)
+
+type CheckoutServiceSuite struct {
+	suite.Suite
+	cartService     *serviceMocks.ICartService
+	repo            *mocks.ICartRepository
+	orderService    *orderMocks.IOrderService
+	couponService   *couponMocks.ICouponService
+	deliveryService *deliveryMocks.IDeliveryService
+	service         *CheckoutService
+}
+
+func (suite *CheckoutServiceSuite) SetupTest() {
+	suite.cartService = serviceMocks.NewICartService(suite.T())
+	suite.repo = mocks.NewICartRepository(suite.T())
+	suite.orderService = orderMocks.NewIOrderService(suite.T())
+	suite.couponService = couponMocks.NewICouponService(suite.T())
+	suite.deliveryService = deliveryMocks.NewIDeliveryService(suite.T())
+	suite.deliveryService.On("Resolve", mock.Anything, 1, mock.Anything).
+		Return(&deliveryModel.Method{Code: deliveryModel.MethodCourier, Price: money.FromMinor(300), Active: true}, &deliveryModel.Destination{City: "Moscow"}, nil).Maybe()
+	suite.service = NewCheckoutService(suite.cartService, suite.repo, suite.orderService, suite.couponService, suite.deliveryService, zap.NewNop())
+}
+
+func TestCheckoutServiceSuite(t *testing.T) {
+	suite.Run(t, new(CheckoutServiceSuite))
+}
+
+func (suite *CheckoutServiceSuite) cart() *model.Cart {
+	return &model.Cart{
+		ID:     7,
+		UserID: 1,
+		Lines: []model.CartLine{
+			{ProductID: 1, Quantity: 2},
+			{ProductID: 2, VariantID: 3, Quantity: 1},
+		},
+		TotalPrice: money.FromMinor(1500),
+	}
+}
+
+// ====================================================================================================================
+
+func (suite *CheckoutServiceSuite) TestService_CheckoutSuccess() {
+	ctx := context.Background()
+	cart := suite.cart()
+	courier := deliveryModel.DeliveryReq{Method: deliveryModel.MethodCourier, AddressID: 2}
+
+	suite.cartService.On("GetCartByUserID", 1, "").Return(cart, nil)
+	suite.cartService.On("ValidateCart", cart).Return(nil)
+	suite.orderService.On("CreateOrder", ctx, 1, "test@test.com", &orderModel.CreateOrderReq{
+		Lines: []orderModel.OrderLineReq{
+			{ProductID: 1, Quantity: 2},
+			{ProductID: 2, VariantID: 3, Quantity: 1},
+		},
+		Delivery: courier,
+	}).Return(&orderModel.CreateOrderRes{
+		Order: &orderModel.Order{ID: 10, TotalPrice: money.FromMinor(1800)},
+		Payment: &paymentModel.CreatePaymentRes{
+			Confirmation: paymentModel.ConfirmationRes{ConfirmationURL: "https://pay.test/10"},
+		},
+	}, nil)
+	suite.repo.On("ClearCart", 7).Return(nil)
+
+	// The customer is shown the cart total with delivery.
+	expected := money.FromMinor(1800)
+	res, err := suite.service.Checkout(ctx, 1, "test@test.com", &model.CheckoutReq{ExpectedTotal: &expected, Delivery: courier})
+
+	suite.Nil(err)
+	suite.Equal(10, res.OrderID)
+	suite.Equal("https://pay.test/10", res.ConfirmationURL)
+}
+
+func (suite *CheckoutServiceSuite) TestService_CheckoutEmptyCart() {
+	suite.cartService.On("GetCartByUserID", 1, "").Return(&model.Cart{ID: 7, UserID: 1}, nil)
+
+	res, err := suite.service.Checkout(context.Background(), 1, "test@test.com", &model.CheckoutReq{})
+
+	suite.Nil(res)
+	suite.ErrorIs(err, ErrEmptyCart)
+}
+
+func (suite *CheckoutServiceSuite) TestService_CheckoutPriceChanged() {
+	suite.cartService.On("GetCartByUserID", 1, "").Return(suite.cart(), nil)
+
+	expected := money.FromMinor(1200)
+	res, err := suite.service.Checkout(context.Background(), 1, "test@test.com", &model.CheckoutReq{ExpectedTotal: &expected})
+
+	suite.Nil(res)
+	suite.ErrorIs(err, ErrPriceChanged)
+}
+
+func (suite *CheckoutServiceSuite) TestService_CheckoutExpectedTotalCurrencyMismatch() {
+	suite.cartService.On("GetCartByUserID", 1, "").Return(suite.cart(), nil)
+
+	expected := money.New(1500, "USD")
+	res, err := suite.service.Checkout(context.Background(), 1, "test@test.com", &model.CheckoutReq{ExpectedTotal: &expected})
+
+	suite.Nil(res)
+	suite.ErrorIs(err, money.ErrCurrencyMismatch)
+}
+
+func (suite *CheckoutServiceSuite) TestService_CheckoutNotEnoughStock() {
+	cart := suite.cart()
+
+	suite.cartService.On("GetCartByUserID", 1, "").Return(cart, nil)
+	suite.cartService.On("ValidateCart", cart).Return(ErrNotEnoughStock)
+
+	res, err := suite.service.Checkout(context.Background(), 1, "test@test.com", &model.CheckoutReq{})
+
+	suite.Nil(res)
+	suite.ErrorIs(err, ErrNotEnoughStock)
+}
+
+func (suite *CheckoutServiceSuite) TestService_CheckoutCreateOrderFailureKeepsCart() {
+	ctx := context.Background()
+	cart := suite.cart()
+
+	suite.cartService.On("GetCartByUserID", 1, "").Return(cart, nil)
+	suite.cartService.On("ValidateCart", cart).Return(nil)
+	suite.orderService.On("CreateOrder", ctx, 1, "test@test.com", &orderModel.CreateOrderReq{
+		Lines: []orderModel.OrderLineReq{
+			{ProductID: 1, Quantity: 2},
+			{ProductID: 2, VariantID: 3, Quantity: 1},
+		},
+	}).Return(nil, errors.New("error"))
+
+	res, err := suite.service.Checkout(ctx, 1, "test@test.com", &model.CheckoutReq{})
+
+	suite.Nil(res)
+	suite.NotNil(err)
+	suite.repo.AssertNotCalled(suite.T(), "ClearCart", 7)
+}
+
+func (suite *CheckoutServiceSuite) TestService_CheckoutClearCartFailure() {
+	ctx := context.Background()
+	cart := suite.cart()
+
+	suite.cartService.On("GetCartByUserID", 1, "").Return(cart, nil)
+	suite.cartService.On("ValidateCart", cart).Return(nil)
+	suite.orderService.On("CreateOrder", ctx, 1, "test@test.com", &orderModel.CreateOrderReq{
+		Lines: []orderModel.OrderLineReq{
+			{ProductID: 1, Quantity: 2},
+			{ProductID: 2, VariantID: 3, Quantity: 1},
+		},
+	}).Return(&orderModel.CreateOrderRes{Order: &orderModel.Order{ID: 10}}, nil)
+	suite.repo.On("ClearCart", 7).Return(errors.New("error"))
+
+	res, err := suite.service.Checkout(ctx, 1, "test@test.com", &model.CheckoutReq{})
+
+	suite.Nil(err)
+	suite.Equal(10, res.OrderID)
+}
+
+func (suite *CheckoutServiceSuite) TestService_CheckoutWithCoupon() {
+	ctx := context.Background()
+	cart := &model.Cart{
+		ID:     7,
+		UserID: 1,
+		Lines: []model.CartLine{
+			{ProductID: 1, Quantity: 2, Product: &productModel.Product{ID: 1, Price: money.FromMinor(500)}},
+		},
+		TotalPrice: money.FromMinor(1000),
+	}
+
+	suite.cartService.On("GetCartByUserID", 1, "").Return(cart, nil)
+	suite.couponService.On("Apply", mock.Anything, "SALE10", 1, []couponModel.Line{{ProductID: 1, Price: money.FromMinor(1000)}}).
+		Return(&couponModel.Discount{Code: "SALE10", Amount: money.FromMinor(100)}, nil)
+	suite.cartService.On("ValidateCart", cart).Return(nil)
+	suite.orderService.On("CreateOrder", ctx, 1, "test@test.com", &orderModel.CreateOrderReq{
+		Lines:      []orderModel.OrderLineReq{{ProductID: 1, Quantity: 2}},
+		CouponCode: "SALE10",
+	}).Return(&orderModel.CreateOrderRes{
+		Order: &orderModel.Order{
+			ID:         10,
+			TotalPrice: money.FromMinor(1200),
+			Coupon:     &orderModel.AppliedCoupon{Code: "SALE10", Discount: money.FromMinor(100)},
+		},
+	}, nil)
+	suite.repo.On("ClearCart", 7).Return(nil)
+
+	expected := money.FromMinor(1200)
+	res, err := suite.service.Checkout(ctx, 1, "test@test.com", &model.CheckoutReq{ExpectedTotal: &expected, CouponCode: "SALE10"})
+
+	suite.Nil(err)
+	suite.Equal(money.FromMinor(1200), res.TotalPrice)
+	suite.Equal(money.FromMinor(100), res.Discount)
+}
+
+func (suite *CheckoutServiceSuite) TestService_CheckoutCouponRejected() {
+	cart := suite.cart()
+
+	suite.cartService.On("GetCartByUserID", 1, "").Return(cart, nil)
+	suite.couponService.On("Apply", mock.Anything, "OLD", 1, []couponModel.Line{
+		{ProductID: 1, Price: money.Money{}},
+		{ProductID: 2, Price: money.Money{}},
+	}).Return(nil, couponService.ErrCouponInvalid)
+
+	res, err := suite.service.Checkout(context.Background(), 1, "test@test.com", &model.CheckoutReq{CouponCode: "OLD"})
+
+	suite.Nil(res)
+	suite.ErrorIs(err, couponService.ErrCouponInvalid)
+}
+
+func (suite *CheckoutServiceSuite) TestService_CheckoutFreeShipping() {
+	ctx := context.Background()
+	cart := &model.Cart{
+		ID:     7,
+		UserID: 1,
+		Lines: []model.CartLine{
+			{ProductID: 1, Quantity: 2, Product: &productModel.Product{ID: 1, Price: money.FromMinor(500)}},
+		},
+		TotalPrice: money.FromMinor(1000),
+	}
+
+	suite.cartService.On("GetCartByUserID", 1, "").Return(cart, nil)
+	suite.couponService.On("Apply", mock.Anything, "SHIPFREE", 1, mock.Anything).
+		Return(&couponModel.Discount{Code: "SHIPFREE", FreeShipping: true}, nil)
+	suite.cartService.On("ValidateCart", cart).Return(nil)
+	suite.orderService.On("CreateOrder", ctx, 1, "test@test.com", mock.Anything).
+		Return(&orderModel.CreateOrderRes{Order: &orderModel.Order{ID: 10, TotalPrice: money.FromMinor(1000)}}, nil)
+	suite.repo.On("ClearCart", 7).Return(nil)
+
+	expected := money.FromMinor(1000)
+	res, err := suite.service.Checkout(ctx, 1, "test@test.com", &model.CheckoutReq{ExpectedTotal: &expected, CouponCode: "SHIPFREE"})
+
+	suite.Nil(err)
+	suite.Equal(money.FromMinor(1000), res.TotalPrice)
+}
+
+func (suite *CheckoutServiceSuite) TestService_CheckoutDeliveryRejected() {
+	req := &model.CheckoutReq{Delivery: deliveryModel.DeliveryReq{Method: deliveryModel.MethodPost}}
+
+	suite.cartService.On("GetCartByUserID", 2, "").Return(suite.cart(), nil)
+	suite.deliveryService.On("Resolve", mock.Anything, 2, &req.Delivery).Return(nil, nil, deliveryService.ErrAddressRequired)
+
+	res, err := suite.service.Checkout(context.Background(), 2, "test@test.com", req)
+
+	suite.Nil(res)
+	suite.ErrorIs(err, deliveryService.ErrAddressRequired)
+}
+
+// ====================================================================================================================
+
+func (suite *CheckoutServiceSuite) TestService_PreviewCouponSuccess() {
+	cart := &model.Cart{
+		Lines: []model.CartLine{
+			{ProductID: 1, Quantity: 1, Product: &productModel.Product{ID: 1, Price: money.FromMinor(2000)}},
+		},
+		TotalPrice: money.FromMinor(2000),
+	}
+
+	suite.cartService.On("GetCartByUserID", 0, "session").Return(cart, nil)
+	suite.couponService.On("Apply", mock.Anything, "FIXED5", 0, []couponModel.Line{{ProductID: 1, Price: money.FromMinor(2000)}}).
+		Return(&couponModel.Discount{Code: "FIXED5", Amount: money.FromMinor(500)}, nil)
+
+	preview, err := suite.service.PreviewCoupon(context.Background(), 0, "session", "FIXED5")
+
+	suite.Nil(err)
+	suite.Equal(money.FromMinor(500), preview.Discount)
+	suite.Equal(money.FromMinor(1500), preview.TotalPrice)
+}
+
+func (suite *CheckoutServiceSuite) TestService_PreviewCouponEmptyCart() {
+	suite.cartService.On("GetCartByUserID", 0, "session").Return(&model.Cart{}, nil)
+
+	preview, err := suite.service.PreviewCoupon(context.Background(), 0, "session", "FIXED5")
+
+	suite.Nil(preview)
+	suite.ErrorIs(err, ErrEmptyCart)
+}
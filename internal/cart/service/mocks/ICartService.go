@@ -1,4 +1,4 @@
-// Code generated by mockery v2.50.4. DO NOT EDIT.
+// Code generated by mockery v2.53.5. DO NOT EDIT.
 
 package mocks
 
@@ -12,9 +12,9 @@ type ICartService struct {
 	mock.Mock
 }
 
-// AddProduct provides a mock function with given fields: userID, productID, quantity
-func (_m *ICartService) AddProduct(userID int, productID int, quantity int) (*model.Cart, error) {
-	ret := _m.Called(userID, productID, quantity)
+// AddProduct provides a mock function with given fields: userID, productID, variantID, quantity, sessionID
+func (_m *ICartService) AddProduct(userID int, productID int, variantID int, quantity int, sessionID string) (*model.Cart, error) {
+	ret := _m.Called(userID, productID, variantID, quantity, sessionID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for AddProduct")
@@ -22,19 +22,19 @@ func (_m *ICartService) AddProduct(userID int, productID int, quantity int) (*mo
 
 	var r0 *model.Cart
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int, int, int) (*model.Cart, error)); ok {
-		return rf(userID, productID, quantity)
+	if rf, ok := ret.Get(0).(func(int, int, int, int, string) (*model.Cart, error)); ok {
+		return rf(userID, productID, variantID, quantity, sessionID)
 	}
-	if rf, ok := ret.Get(0).(func(int, int, int) *model.Cart); ok {
-		r0 = rf(userID, productID, quantity)
+	if rf, ok := ret.Get(0).(func(int, int, int, int, string) *model.Cart); ok {
+		r0 = rf(userID, productID, variantID, quantity, sessionID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Cart)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int, int, int) error); ok {
-		r1 = rf(userID, productID, quantity)
+	if rf, ok := ret.Get(1).(func(int, int, int, int, string) error); ok {
+		r1 = rf(userID, productID, variantID, quantity, sessionID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -42,9 +42,9 @@ func (_m *ICartService) AddProduct(userID int, productID int, quantity int) (*mo
 	return r0, r1
 }
 
-// DeleteProduct provides a mock function with given fields: userID, productID
-func (_m *ICartService) DeleteProduct(userID int, productID int) (*model.Cart, error) {
-	ret := _m.Called(userID, productID)
+// DeleteProduct provides a mock function with given fields: userID, productID, variantID, sessionID
+func (_m *ICartService) DeleteProduct(userID int, productID int, variantID int, sessionID string) (*model.Cart, error) {
+	ret := _m.Called(userID, productID, variantID, sessionID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteProduct")
@@ -52,19 +52,19 @@ func (_m *ICartService) DeleteProduct(userID int, productID int) (*model.Cart, e
 
 	var r0 *model.Cart
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int, int) (*model.Cart, error)); ok {
-		return rf(userID, productID)
+	if rf, ok := ret.Get(0).(func(int, int, int, string) (*model.Cart, error)); ok {
+		return rf(userID, productID, variantID, sessionID)
 	}
-	if rf, ok := ret.Get(0).(func(int, int) *model.Cart); ok {
-		r0 = rf(userID, productID)
+	if rf, ok := ret.Get(0).(func(int, int, int, string) *model.Cart); ok {
+		r0 = rf(userID, productID, variantID, sessionID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Cart)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int, int) error); ok {
-		r1 = rf(userID, productID)
+	if rf, ok := ret.Get(1).(func(int, int, int, string) error); ok {
+		r1 = rf(userID, productID, variantID, sessionID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -72,9 +72,9 @@ func (_m *ICartService) DeleteProduct(userID int, productID int) (*model.Cart, e
 	return r0, r1
 }
 
-// GetCartByUserID provides a mock function with given fields: userID
-func (_m *ICartService) GetCartByUserID(userID int) (*model.Cart, error) {
-	ret := _m.Called(userID)
+// GetCartByUserID provides a mock function with given fields: userID, sessionID
+func (_m *ICartService) GetCartByUserID(userID int, sessionID string) (*model.Cart, error) {
+	ret := _m.Called(userID, sessionID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetCartByUserID")
@@ -82,19 +82,19 @@ func (_m *ICartService) GetCartByUserID(userID int) (*model.Cart, error) {
 
 	var r0 *model.Cart
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int) (*model.Cart, error)); ok {
-		return rf(userID)
+	if rf, ok := ret.Get(0).(func(int, string) (*model.Cart, error)); ok {
+		return rf(userID, sessionID)
 	}
-	if rf, ok := ret.Get(0).(func(int) *model.Cart); ok {
-		r0 = rf(userID)
+	if rf, ok := ret.Get(0).(func(int, string) *model.Cart); ok {
+		r0 = rf(userID, sessionID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Cart)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int) error); ok {
-		r1 = rf(userID)
+	if rf, ok := ret.Get(1).(func(int, string) error); ok {
+		r1 = rf(userID, sessionID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -102,6 +102,84 @@ func (_m *ICartService) GetCartByUserID(userID int) (*model.Cart, error) {
 	return r0, r1
 }
 
+// MergeGuestCart provides a mock function with given fields: userID, sessionID
+func (_m *ICartService) MergeGuestCart(userID int, sessionID string) (*model.Cart, error) {
+	ret := _m.Called(userID, sessionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MergeGuestCart")
+	}
+
+	var r0 *model.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, string) (*model.Cart, error)); ok {
+		return rf(userID, sessionID)
+	}
+	if rf, ok := ret.Get(0).(func(int, string) *model.Cart); ok {
+		r0 = rf(userID, sessionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Cart)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, string) error); ok {
+		r1 = rf(userID, sessionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateQuantity provides a mock function with given fields: userID, productID, variantID, quantity, sessionID
+func (_m *ICartService) UpdateQuantity(userID int, productID int, variantID int, quantity int, sessionID string) (*model.Cart, error) {
+	ret := _m.Called(userID, productID, variantID, quantity, sessionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateQuantity")
+	}
+
+	var r0 *model.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, int, int, int, string) (*model.Cart, error)); ok {
+		return rf(userID, productID, variantID, quantity, sessionID)
+	}
+	if rf, ok := ret.Get(0).(func(int, int, int, int, string) *model.Cart); ok {
+		r0 = rf(userID, productID, variantID, quantity, sessionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Cart)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, int, int, int, string) error); ok {
+		r1 = rf(userID, productID, variantID, quantity, sessionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ValidateCart provides a mock function with given fields: cart
+func (_m *ICartService) ValidateCart(cart *model.Cart) error {
+	ret := _m.Called(cart)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ValidateCart")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.Cart) error); ok {
+		r0 = rf(cart)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewICartService creates a new instance of ICartService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewICartService(t interface {
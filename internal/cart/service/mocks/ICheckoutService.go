@@ -0,0 +1,89 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/aaanger/ecommerce/internal/cart/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ICheckoutService is an autogenerated mock type for the ICheckoutService type
+type ICheckoutService struct {
+	mock.Mock
+}
+
+// Checkout provides a mock function with given fields: ctx, userID, userEmail, req
+func (_m *ICheckoutService) Checkout(ctx context.Context, userID int, userEmail string, req *model.CheckoutReq) (*model.CheckoutRes, error) {
+	ret := _m.Called(ctx, userID, userEmail, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Checkout")
+	}
+
+	var r0 *model.CheckoutRes
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, *model.CheckoutReq) (*model.CheckoutRes, error)); ok {
+		return rf(ctx, userID, userEmail, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, *model.CheckoutReq) *model.CheckoutRes); ok {
+		r0 = rf(ctx, userID, userEmail, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.CheckoutRes)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string, *model.CheckoutReq) error); ok {
+		r1 = rf(ctx, userID, userEmail, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PreviewCoupon provides a mock function with given fields: ctx, userID, sessionID, code
+func (_m *ICheckoutService) PreviewCoupon(ctx context.Context, userID int, sessionID string, code string) (*model.CouponPreview, error) {
+	ret := _m.Called(ctx, userID, sessionID, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PreviewCoupon")
+	}
+
+	var r0 *model.CouponPreview
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, string) (*model.CouponPreview, error)); ok {
+		return rf(ctx, userID, sessionID, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, string) *model.CouponPreview); ok {
+		r0 = rf(ctx, userID, sessionID, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.CouponPreview)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string, string) error); ok {
+		r1 = rf(ctx, userID, sessionID, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewICheckoutService creates a new instance of ICheckoutService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewICheckoutService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ICheckoutService {
+	mock := &ICheckoutService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
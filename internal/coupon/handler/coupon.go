@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+	"github.com/aaanger/ecommerce/internal/coupon/model"
+	"github.com/aaanger/ecommerce/internal/coupon/repository"
+	"github.com/aaanger/ecommerce/internal/coupon/service"
+	"github.com/aaanger/ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type CouponHandler struct {
+	service service.ICouponService
+	log     *zap.Logger
+}
+
+func NewCouponHandler(service service.ICouponService, log *zap.Logger) *CouponHandler {
+	return &CouponHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+func (h *CouponHandler) CreateCoupon(c *gin.Context) {
+	var req model.CouponReq
+
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid input parameters")
+		return
+	}
+
+	coupon, err := h.service.CreateCoupon(c.Request.Context(), &req)
+	if errors.Is(err, service.ErrInvalidDiscount) {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, repository.ErrCodeTaken) {
+		response.Error(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		h.log.Error("create coupon error", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "Failed to create coupon")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, coupon)
+}
+
+func (h *CouponHandler) GetAllCoupons(c *gin.Context) {
+	coupons, err := h.service.GetAllCoupons(c.Request.Context())
+	if err != nil {
+		h.log.Error("get coupons error", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "Failed to get coupons")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, coupons)
+}
+
+func (h *CouponHandler) GetCouponByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid coupon id")
+		return
+	}
+
+	coupon, err := h.service.GetCouponByID(c.Request.Context(), id)
+	if errors.Is(err, service.ErrCouponNotFound) {
+		response.Error(c, http.StatusNotFound, "Coupon not found")
+		return
+	}
+	if err != nil {
+		h.log.Error("get coupon error", zap.Error(err), zap.Int("couponID", id))
+		response.Error(c, http.StatusInternalServerError, "Failed to get coupon")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, coupon)
+}
+
+func (h *CouponHandler) UpdateCoupon(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid coupon id")
+		return
+	}
+
+	var input model.UpdateCoupon
+
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid input parameters")
+		return
+	}
+
+	coupon, err := h.service.UpdateCoupon(c.Request.Context(), id, input)
+	if errors.Is(err, service.ErrCouponNotFound) {
+		response.Error(c, http.StatusNotFound, "Coupon not found")
+		return
+	}
+	if err != nil {
+		h.log.Error("update coupon error", zap.Error(err), zap.Int("couponID", id))
+		response.Error(c, http.StatusInternalServerError, "Failed to update coupon")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, coupon)
+}
+
+func (h *CouponHandler) DeleteCoupon(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid coupon id")
+		return
+	}
+
+	err = h.service.DeleteCoupon(c.Request.Context(), id)
+	if err != nil {
+		h.log.Error("delete coupon error", zap.Error(err), zap.Int("couponID", id))
+		response.Error(c, http.StatusInternalServerError, "Failed to delete coupon")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, "coupon deleted")
+}
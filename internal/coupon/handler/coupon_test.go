@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/aaanger/ecommerce/internal/coupon/model"
+	"github.com/aaanger/ecommerce/internal/coupon/repository"
+	"github.com/aaanger/ecommerce/internal/coupon/service"
+	"github.com/aaanger/ecommerce/internal/coupon/service/mocks"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type CouponHandlerSuite struct {
+	suite.Suite
+	service *mocks.ICouponService
+	handler *CouponHandler
+	router  *gin.Engine
+}
+
+func (suite *CouponHandlerSuite) SetupTest() {
+	suite.service = mocks.NewICouponService(suite.T())
+	suite.handler = NewCouponHandler(suite.service, zap.NewNop())
+
+	suite.router = gin.New()
+	suite.router.POST("/create", suite.handler.CreateCoupon)
+	suite.router.GET("/:id", suite.handler.GetCouponByID)
+	suite.router.PUT("/:id", suite.handler.UpdateCoupon)
+	suite.router.DELETE("/:id", suite.handler.DeleteCoupon)
+}
+
+func TestCouponHandlerSuite(t *testing.T) {
+	suite.Run(t, new(CouponHandlerSuite))
+}
+
+// =====================================================================================================================
+
+func (suite *CouponHandlerSuite) TestHandler_CreateCouponSuccess() {
+	req := &model.CouponReq{
+		Code:      "FIXED5",
+		Type:      model.TypeFixed,
+		AmountOff: money.FromMinor(500),
+		MinBasket: money.FromMinor(0),
+	}
+
+	suite.service.On("CreateCoupon", mock.Anything, req).Return(&model.Coupon{ID: 1, Code: "FIXED5", Type: model.TypeFixed, AmountOff: money.FromMinor(500)}, nil)
+
+	requestBody, _ := json.Marshal(req)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/create", bytes.NewBuffer(requestBody))
+
+	suite.router.ServeHTTP(w, r)
+
+	var coupon model.Coupon
+	_ = json.Unmarshal(w.Body.Bytes(), &coupon)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(1, coupon.ID)
+	suite.Equal(money.FromMinor(500), coupon.AmountOff)
+}
+
+func (suite *CouponHandlerSuite) TestHandler_CreateCouponInvalidType() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/create", bytes.NewBufferString(`{"code": "X", "type": "bogus"}`))
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(`"Invalid input parameters"`, w.Body.String())
+}
+
+func (suite *CouponHandlerSuite) TestHandler_CreateCouponCodeTaken() {
+	req := &model.CouponReq{Code: "SHIP", Type: model.TypeFreeShipping, AmountOff: money.FromMinor(0), MinBasket: money.FromMinor(0)}
+
+	suite.service.On("CreateCoupon", mock.Anything, req).Return(nil, repository.ErrCodeTaken)
+
+	requestBody, _ := json.Marshal(req)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/create", bytes.NewBuffer(requestBody))
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusConflict, w.Code)
+	suite.Equal(`"coupon code already exists"`, w.Body.String())
+}
+
+// =====================================================================================================================
+
+func (suite *CouponHandlerSuite) TestHandler_GetCouponByIDNotFound() {
+	suite.service.On("GetCouponByID", mock.Anything, 1).Return(nil, service.ErrCouponNotFound)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/1", nil)
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusNotFound, w.Code)
+	suite.Equal(`"Coupon not found"`, w.Body.String())
+}
+
+func (suite *CouponHandlerSuite) TestHandler_GetCouponByIDInvalidID() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/abc", nil)
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(`"Invalid coupon id"`, w.Body.String())
+}
+
+// =====================================================================================================================
+
+func (suite *CouponHandlerSuite) TestHandler_UpdateCouponSuccess() {
+	active := false
+
+	suite.service.On("UpdateCoupon", mock.Anything, 1, model.UpdateCoupon{Active: &active}).Return(&model.Coupon{ID: 1, Active: false}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("PUT", "/1", bytes.NewBufferString(`{"active": false}`))
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *CouponHandlerSuite) TestHandler_DeleteCouponFailure() {
+	suite.service.On("DeleteCoupon", mock.Anything, 1).Return(errors.New("error"))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("DELETE", "/1", nil)
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusInternalServerError, w.Code)
+	suite.Equal(`"Failed to delete coupon"`, w.Body.String())
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"database/sql"
+	"github.com/aaanger/ecommerce/internal/coupon/repository"
+	"github.com/aaanger/ecommerce/internal/coupon/service"
+	"github.com/aaanger/ecommerce/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func CouponRoutes(r *gin.Engine, db *sql.DB, logger *zap.Logger) service.ICouponService {
+	repo := repository.NewCouponRepository(db)
+	svc := service.NewCouponService(repo)
+	h := NewCouponHandler(svc, logger)
+
+	coupons := r.Group("/coupons", middleware.UserIdentity, middleware.ModeratorIdentity)
+
+	coupons.POST("/create", h.CreateCoupon)
+	coupons.GET("/", h.GetAllCoupons)
+	coupons.GET("/:id", h.GetCouponByID)
+	coupons.PUT("/:id", h.UpdateCoupon)
+	coupons.DELETE("/:id", h.DeleteCoupon)
+
+	return svc
+}
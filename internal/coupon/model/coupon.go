@@ -0,0 +1,95 @@
+package model
+
+import (
+	"github.com/aaanger/ecommerce/pkg/money"
+	"time"
+)
+
+const (
+	TypePercent      = "percent"
+	TypeFixed        = "fixed"
+	TypeFreeShipping = "free_shipping"
+)
+
+type Coupon struct {
+	ID   int    `json:"id"`
+	Code string `json:"code"`
+	Type string `json:"type"`
+	// PercentOff is the discount of a TypePercent coupon in basis points, 1500 is 15%.
+	PercentOff int64       `json:"percent_off_bp,omitempty"`
+	AmountOff  money.Money `json:"amount_off"`
+	MinBasket  money.Money `json:"min_basket"`
+	StartsAt   *time.Time  `json:"starts_at,omitempty"`
+	ExpiresAt  *time.Time  `json:"expires_at,omitempty"`
+	// UsageLimit and PerUserLimit cap redemptions overall and per user, 0 means unlimited.
+	UsageLimit   int  `json:"usage_limit"`
+	PerUserLimit int  `json:"per_user_limit"`
+	UsedCount    int  `json:"used_count"`
+	Active       bool `json:"active"`
+	// ProductIDs and CategoryIDs restrict the coupon to those products and to products in those
+	// categories or their subcategories. A coupon with neither applies to every product.
+	ProductIDs  []int     `json:"product_ids,omitempty"`
+	CategoryIDs []int     `json:"category_ids,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Restricted reports whether the coupon applies only to some products.
+func (c *Coupon) Restricted() bool {
+	return len(c.ProductIDs) > 0 || len(c.CategoryIDs) > 0
+}
+
+// ValidAt reports whether the coupon can be used at the given time.
+func (c *Coupon) ValidAt(t time.Time) bool {
+	if !c.Active {
+		return false
+	}
+	if c.StartsAt != nil && t.Before(*c.StartsAt) {
+		return false
+	}
+	if c.ExpiresAt != nil && !t.Before(*c.ExpiresAt) {
+		return false
+	}
+	return true
+}
+
+type CouponReq struct {
+	Code         string      `json:"code" binding:"required"`
+	Type         string      `json:"type" binding:"required,oneof=percent fixed free_shipping"`
+	PercentOff   int64       `json:"percent_off_bp" binding:"gte=0,lte=10000"`
+	AmountOff    money.Money `json:"amount_off"`
+	MinBasket    money.Money `json:"min_basket"`
+	StartsAt     *time.Time  `json:"starts_at"`
+	ExpiresAt    *time.Time  `json:"expires_at"`
+	UsageLimit   int         `json:"usage_limit" binding:"gte=0"`
+	PerUserLimit int         `json:"per_user_limit" binding:"gte=0"`
+	ProductIDs   []int       `json:"product_ids"`
+	CategoryIDs  []int       `json:"category_ids"`
+}
+
+type UpdateCoupon struct {
+	Active       *bool        `json:"active"`
+	MinBasket    *money.Money `json:"min_basket"`
+	StartsAt     *time.Time   `json:"starts_at"`
+	ExpiresAt    *time.Time   `json:"expires_at"`
+	UsageLimit   *int         `json:"usage_limit" binding:"omitempty,gte=0"`
+	PerUserLimit *int         `json:"per_user_limit" binding:"omitempty,gte=0"`
+	// ProductIDs and CategoryIDs replace the current restrictions when present.
+	ProductIDs  *[]int `json:"product_ids"`
+	CategoryIDs *[]int `json:"category_ids"`
+}
+
+// Line is a basket line the discount is computed for. Price is the line total, price × quantity.
+type Line struct {
+	ProductID int
+	Price     money.Money
+}
+
+// Discount is the effect of a coupon on a basket.
+type Discount struct {
+	CouponID int         `json:"-"`
+	Code     string      `json:"code"`
+	Amount   money.Money `json:"amount"`
+	// Lines holds the share of Amount taken off each basket line, in the order the lines were given.
+	Lines        []money.Money `json:"-"`
+	FreeShipping bool          `json:"free_shipping"`
+}
@@ -0,0 +1,358 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/aaanger/ecommerce/internal/coupon/model"
+	"github.com/aaanger/ecommerce/pkg/db"
+	"github.com/jackc/pgx/v5/pgconn"
+	"strings"
+)
+
+//go:generate mockery --name=ICouponRepository
+
+type ICouponRepository interface {
+	CreateCoupon(ctx context.Context, req *model.CouponReq) (*model.Coupon, error)
+	GetAllCoupons(ctx context.Context) ([]model.Coupon, error)
+	GetCouponByID(ctx context.Context, id int) (*model.Coupon, error)
+	GetCouponByCode(ctx context.Context, code string) (*model.Coupon, error)
+	UpdateCoupon(ctx context.Context, id int, input model.UpdateCoupon) error
+	DeleteCoupon(ctx context.Context, id int) error
+	IsProductEligible(ctx context.Context, couponID, productID int) (bool, error)
+	CountRedemptions(ctx context.Context, couponID, userID int) (int, error)
+	Redeem(ctx context.Context, couponID, userID, orderID int) error
+	Release(ctx context.Context, orderID int) error
+}
+
+var (
+	ErrCodeTaken           = errors.New("coupon code already exists")
+	ErrUsageLimitReached   = errors.New("coupon usage limit reached")
+	ErrPerUserLimitReached = errors.New("coupon already used the maximum number of times")
+)
+
+// uniqueViolation is the PostgreSQL SQLSTATE for a unique constraint violation.
+const uniqueViolation = "23505"
+
+const couponColumns = `id, code, type, percent_off, amount_off, min_basket, starts_at, expires_at, usage_limit, per_user_limit, used_count, active, created_at`
+
+type CouponRepository struct {
+	db *sql.DB
+}
+
+func NewCouponRepository(db *sql.DB) *CouponRepository {
+	return &CouponRepository{
+		db: db,
+	}
+}
+
+func (r *CouponRepository) CreateCoupon(ctx context.Context, req *model.CouponReq) (*model.Coupon, error) {
+	var coupon *model.Coupon
+
+	err := db.RunInTx(ctx, r.db, func(ctx context.Context) error {
+		row := db.Conn(ctx, r.db).QueryRowContext(ctx, `INSERT INTO coupons (code, type, percent_off, amount_off, min_basket, starts_at, expires_at, usage_limit, per_user_limit)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING `+couponColumns+`;`,
+			strings.ToUpper(req.Code), req.Type, req.PercentOff, req.AmountOff, req.MinBasket, req.StartsAt, req.ExpiresAt, req.UsageLimit, req.PerUserLimit)
+
+		var err error
+		coupon, err = scanCoupon(row)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return ErrCodeTaken
+		}
+		if err != nil {
+			return err
+		}
+
+		return r.setRestrictions(ctx, coupon.ID, &req.ProductIDs, &req.CategoryIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	coupon.ProductIDs = req.ProductIDs
+	coupon.CategoryIDs = req.CategoryIDs
+
+	return coupon, nil
+}
+
+func (r *CouponRepository) GetAllCoupons(ctx context.Context) ([]model.Coupon, error) {
+	var coupons []model.Coupon
+
+	rows, err := db.Conn(ctx, r.db).QueryContext(ctx, `SELECT `+couponColumns+` FROM coupons ORDER BY id;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, *coupon)
+	}
+
+	return coupons, rows.Err()
+}
+
+func (r *CouponRepository) GetCouponByID(ctx context.Context, id int) (*model.Coupon, error) {
+	coupon, err := scanCoupon(db.Conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+couponColumns+` FROM coupons WHERE id=$1;`, id))
+	if err != nil {
+		return nil, err
+	}
+
+	return coupon, r.getRestrictions(ctx, coupon)
+}
+
+// GetCouponByCode looks the coupon up case-insensitively, codes are stored upper-case.
+func (r *CouponRepository) GetCouponByCode(ctx context.Context, code string) (*model.Coupon, error) {
+	coupon, err := scanCoupon(db.Conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+couponColumns+` FROM coupons WHERE code=$1;`, strings.ToUpper(code)))
+	if err != nil {
+		return nil, err
+	}
+
+	return coupon, r.getRestrictions(ctx, coupon)
+}
+
+func (r *CouponRepository) UpdateCoupon(ctx context.Context, id int, input model.UpdateCoupon) error {
+	keys := make([]string, 0)
+	values := make([]interface{}, 0)
+	arg := 1
+
+	if input.Active != nil {
+		keys = append(keys, fmt.Sprintf("active=$%d", arg))
+		values = append(values, *input.Active)
+		arg++
+	}
+	if input.MinBasket != nil {
+		keys = append(keys, fmt.Sprintf("min_basket=$%d", arg))
+		values = append(values, *input.MinBasket)
+		arg++
+	}
+	if input.StartsAt != nil {
+		keys = append(keys, fmt.Sprintf("starts_at=$%d", arg))
+		values = append(values, *input.StartsAt)
+		arg++
+	}
+	if input.ExpiresAt != nil {
+		keys = append(keys, fmt.Sprintf("expires_at=$%d", arg))
+		values = append(values, *input.ExpiresAt)
+		arg++
+	}
+	if input.UsageLimit != nil {
+		keys = append(keys, fmt.Sprintf("usage_limit=$%d", arg))
+		values = append(values, *input.UsageLimit)
+		arg++
+	}
+	if input.PerUserLimit != nil {
+		keys = append(keys, fmt.Sprintf("per_user_limit=$%d", arg))
+		values = append(values, *input.PerUserLimit)
+		arg++
+	}
+
+	return db.RunInTx(ctx, r.db, func(ctx context.Context) error {
+		if len(keys) > 0 {
+			query := fmt.Sprintf(`UPDATE coupons SET %s WHERE id=$%d;`, strings.Join(keys, ", "), arg)
+			values = append(values, id)
+
+			_, err := db.Conn(ctx, r.db).ExecContext(ctx, query, values...)
+			if err != nil {
+				return err
+			}
+		}
+
+		return r.setRestrictions(ctx, id, input.ProductIDs, input.CategoryIDs)
+	})
+}
+
+func (r *CouponRepository) DeleteCoupon(ctx context.Context, id int) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, `DELETE FROM coupons WHERE id=$1;`, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// IsProductEligible reports whether a restricted coupon applies to the product, either directly
+// or through one of its categories or their ancestors.
+func (r *CouponRepository) IsProductEligible(ctx context.Context, couponID, productID int) (bool, error) {
+	var eligible bool
+
+	row := db.Conn(ctx, r.db).QueryRowContext(ctx, `WITH RECURSIVE eligible_categories AS (
+			SELECT category_id AS id FROM coupon_categories WHERE coupon_id=$1
+			UNION
+			SELECT c.id FROM categories c INNER JOIN eligible_categories e ON c.parent_id=e.id
+		)
+		SELECT EXISTS (SELECT 1 FROM coupon_products WHERE coupon_id=$1 AND product_id=$2)
+			OR EXISTS (SELECT 1 FROM product_categories WHERE product_id=$2 AND category_id IN (SELECT id FROM eligible_categories));`,
+		couponID, productID)
+	err := row.Scan(&eligible)
+	if err != nil {
+		return false, err
+	}
+
+	return eligible, nil
+}
+
+func (r *CouponRepository) CountRedemptions(ctx context.Context, couponID, userID int) (int, error) {
+	var count int
+
+	row := db.Conn(ctx, r.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id=$1 AND user_id=$2;`, couponID, userID)
+	err := row.Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Redeem records the use of the coupon by an order. The coupon row is locked so concurrent
+// checkouts cannot push it past its usage limits.
+func (r *CouponRepository) Redeem(ctx context.Context, couponID, userID, orderID int) error {
+	return db.RunInTx(ctx, r.db, func(ctx context.Context) error {
+		conn := db.Conn(ctx, r.db)
+
+		var usageLimit, perUserLimit, usedCount int
+
+		row := conn.QueryRowContext(ctx, `SELECT usage_limit, per_user_limit, used_count FROM coupons WHERE id=$1 FOR UPDATE;`, couponID)
+		err := row.Scan(&usageLimit, &perUserLimit, &usedCount)
+		if err != nil {
+			return err
+		}
+
+		if usageLimit > 0 && usedCount >= usageLimit {
+			return ErrUsageLimitReached
+		}
+
+		if perUserLimit > 0 {
+			var userCount int
+
+			row = conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id=$1 AND user_id=$2;`, couponID, userID)
+			err = row.Scan(&userCount)
+			if err != nil {
+				return err
+			}
+			if userCount >= perUserLimit {
+				return ErrPerUserLimitReached
+			}
+		}
+
+		_, err = conn.ExecContext(ctx, `INSERT INTO coupon_redemptions (coupon_id, user_id, order_id) VALUES($1, $2, $3);`, couponID, userID, orderID)
+		if err != nil {
+			return err
+		}
+
+		_, err = conn.ExecContext(ctx, `UPDATE coupons SET used_count = used_count + 1 WHERE id=$1;`, couponID)
+		return err
+	})
+}
+
+// Release gives back the coupon redeemed by an order, if any, so it can be used again.
+func (r *CouponRepository) Release(ctx context.Context, orderID int) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, `WITH released AS (
+			DELETE FROM coupon_redemptions WHERE order_id=$1 RETURNING coupon_id
+		)
+		UPDATE coupons SET used_count = used_count - 1 WHERE id IN (SELECT coupon_id FROM released);`, orderID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *CouponRepository) getRestrictions(ctx context.Context, coupon *model.Coupon) error {
+	var err error
+
+	coupon.ProductIDs, err = r.getIDs(ctx, `SELECT product_id FROM coupon_products WHERE coupon_id=$1 ORDER BY product_id;`, coupon.ID)
+	if err != nil {
+		return err
+	}
+
+	coupon.CategoryIDs, err = r.getIDs(ctx, `SELECT category_id FROM coupon_categories WHERE coupon_id=$1 ORDER BY category_id;`, coupon.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *CouponRepository) getIDs(ctx context.Context, query string, couponID int) ([]int, error) {
+	var ids []int
+
+	rows, err := db.Conn(ctx, r.db).QueryContext(ctx, query, couponID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// setRestrictions replaces the coupon's product and category restrictions. A nil slice pointer
+// leaves that restriction unchanged.
+func (r *CouponRepository) setRestrictions(ctx context.Context, couponID int, productIDs, categoryIDs *[]int) error {
+	conn := db.Conn(ctx, r.db)
+
+	if productIDs != nil {
+		_, err := conn.ExecContext(ctx, `DELETE FROM coupon_products WHERE coupon_id=$1;`, couponID)
+		if err != nil {
+			return err
+		}
+		for _, productID := range *productIDs {
+			_, err = conn.ExecContext(ctx, `INSERT INTO coupon_products (coupon_id, product_id) VALUES($1, $2) ON CONFLICT DO NOTHING;`, couponID, productID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if categoryIDs != nil {
+		_, err := conn.ExecContext(ctx, `DELETE FROM coupon_categories WHERE coupon_id=$1;`, couponID)
+		if err != nil {
+			return err
+		}
+		for _, categoryID := range *categoryIDs {
+			_, err = conn.ExecContext(ctx, `INSERT INTO coupon_categories (coupon_id, category_id) VALUES($1, $2) ON CONFLICT DO NOTHING;`, couponID, categoryID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCoupon(row scanner) (*model.Coupon, error) {
+	var coupon model.Coupon
+	var startsAt, expiresAt sql.NullTime
+
+	err := row.Scan(&coupon.ID, &coupon.Code, &coupon.Type, &coupon.PercentOff, &coupon.AmountOff, &coupon.MinBasket,
+		&startsAt, &expiresAt, &coupon.UsageLimit, &coupon.PerUserLimit, &coupon.UsedCount, &coupon.Active, &coupon.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if startsAt.Valid {
+		coupon.StartsAt = &startsAt.Time
+	}
+	if expiresAt.Valid {
+		coupon.ExpiresAt = &expiresAt.Time
+	}
+
+	return &coupon, nil
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/aaanger/ecommerce/internal/coupon/model"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type CouponRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo *CouponRepository
+}
+
+func (suite *CouponRepositorySuite) SetupTest() {
+	var err error
+	suite.db, suite.mock, err = sqlmock.New()
+	assert.NoError(suite.T(), err)
+	suite.repo = NewCouponRepository(suite.db)
+}
+
+func TestCouponRepositorySuite(t *testing.T) {
+	suite.Run(t, new(CouponRepositorySuite))
+}
+
+var couponRowColumns = []string{"id", "code", "type", "percent_off", "amount_off", "min_basket", "starts_at", "expires_at",
+	"usage_limit", "per_user_limit", "used_count", "active", "created_at"}
+
+// ====================================================================================================================
+
+func (suite *CouponRepositorySuite) TestRepository_CreateCouponSuccess() {
+	req := &model.CouponReq{
+		Code:       "sale10",
+		Type:       model.TypePercent,
+		PercentOff: 1000,
+		ProductIDs: []int{3},
+	}
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("INSERT INTO coupons").
+		WithArgs("SALE10", model.TypePercent, int64(1000), sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil, 0, 0).
+		WillReturnRows(sqlmock.NewRows(couponRowColumns).
+			AddRow(1, "SALE10", model.TypePercent, 1000, 0, 0, nil, nil, 0, 0, 0, true, time.Now()))
+	suite.mock.ExpectExec("DELETE FROM coupon_products").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectExec("INSERT INTO coupon_products").WithArgs(1, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("DELETE FROM coupon_categories").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectCommit()
+
+	coupon, err := suite.repo.CreateCoupon(context.Background(), req)
+
+	suite.Nil(err)
+	suite.Equal("SALE10", coupon.Code)
+	suite.Equal([]int{3}, coupon.ProductIDs)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *CouponRepositorySuite) TestRepository_CreateCouponCodeTaken() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("INSERT INTO coupons").WillReturnError(&pgconn.PgError{Code: uniqueViolation})
+	suite.mock.ExpectRollback()
+
+	coupon, err := suite.repo.CreateCoupon(context.Background(), &model.CouponReq{Code: "SALE10", Type: model.TypeFreeShipping})
+
+	suite.Nil(coupon)
+	suite.ErrorIs(err, ErrCodeTaken)
+}
+
+// ====================================================================================================================
+
+func (suite *CouponRepositorySuite) TestRepository_GetCouponByCodeSuccess() {
+	expires := time.Now().Add(time.Hour)
+
+	suite.mock.ExpectQuery("SELECT .* FROM coupons WHERE code=\\$1").WithArgs("SALE10").
+		WillReturnRows(sqlmock.NewRows(couponRowColumns).
+			AddRow(1, "SALE10", model.TypeFixed, 0, 50000, 100000, nil, expires, 100, 1, 5, true, time.Now()))
+	suite.mock.ExpectQuery("SELECT product_id FROM coupon_products").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id"}))
+	suite.mock.ExpectQuery("SELECT category_id FROM coupon_categories").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"category_id"}).AddRow(4))
+
+	coupon, err := suite.repo.GetCouponByCode(context.Background(), "sale10")
+
+	suite.Nil(err)
+	suite.Equal(money.FromMinor(50000), coupon.AmountOff)
+	suite.Equal(money.FromMinor(100000), coupon.MinBasket)
+	suite.Nil(coupon.StartsAt)
+	suite.NotNil(coupon.ExpiresAt)
+	suite.Equal([]int{4}, coupon.CategoryIDs)
+	suite.True(coupon.Restricted())
+}
+
+func (suite *CouponRepositorySuite) TestRepository_GetCouponByCodeNotFound() {
+	suite.mock.ExpectQuery("SELECT .* FROM coupons WHERE code=\\$1").WithArgs("NOPE").WillReturnError(sql.ErrNoRows)
+
+	coupon, err := suite.repo.GetCouponByCode(context.Background(), "nope")
+
+	suite.Nil(coupon)
+	suite.ErrorIs(err, sql.ErrNoRows)
+}
+
+// ====================================================================================================================
+
+func (suite *CouponRepositorySuite) TestRepository_UpdateCouponSuccess() {
+	active := false
+	limit := 10
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("UPDATE coupons SET active=\\$1, usage_limit=\\$2 WHERE id=\\$3").WithArgs(false, 10, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	err := suite.repo.UpdateCoupon(context.Background(), 1, model.UpdateCoupon{Active: &active, UsageLimit: &limit})
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+// ====================================================================================================================
+
+func (suite *CouponRepositorySuite) TestRepository_IsProductEligible() {
+	suite.mock.ExpectQuery("WITH RECURSIVE eligible_categories").WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	eligible, err := suite.repo.IsProductEligible(context.Background(), 1, 2)
+
+	suite.Nil(err)
+	suite.True(eligible)
+}
+
+// ====================================================================================================================
+
+func (suite *CouponRepositorySuite) TestRepository_RedeemSuccess() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT usage_limit, per_user_limit, used_count FROM coupons WHERE id=\\$1 FOR UPDATE").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"usage_limit", "per_user_limit", "used_count"}).AddRow(10, 1, 3))
+	suite.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM coupon_redemptions").WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	suite.mock.ExpectExec("INSERT INTO coupon_redemptions").WithArgs(1, 2, 3).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec("UPDATE coupons SET used_count = used_count \\+ 1").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	err := suite.repo.Redeem(context.Background(), 1, 2, 3)
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *CouponRepositorySuite) TestRepository_RedeemUsageLimitReached() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT usage_limit, per_user_limit, used_count FROM coupons").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"usage_limit", "per_user_limit", "used_count"}).AddRow(10, 0, 10))
+	suite.mock.ExpectRollback()
+
+	err := suite.repo.Redeem(context.Background(), 1, 2, 3)
+
+	suite.ErrorIs(err, ErrUsageLimitReached)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *CouponRepositorySuite) TestRepository_RedeemPerUserLimitReached() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT usage_limit, per_user_limit, used_count FROM coupons").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"usage_limit", "per_user_limit", "used_count"}).AddRow(0, 1, 4))
+	suite.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM coupon_redemptions").WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	suite.mock.ExpectRollback()
+
+	err := suite.repo.Redeem(context.Background(), 1, 2, 3)
+
+	suite.ErrorIs(err, ErrPerUserLimitReached)
+}
+
+func (suite *CouponRepositorySuite) TestRepository_ReleaseFailure() {
+	suite.mock.ExpectExec("DELETE FROM coupon_redemptions WHERE order_id=\\$1").WithArgs(3).WillReturnError(errors.New("error"))
+
+	err := suite.repo.Release(context.Background(), 3)
+
+	suite.NotNil(err)
+}
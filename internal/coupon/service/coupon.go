@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/aaanger/ecommerce/internal/coupon/model"
+	"github.com/aaanger/ecommerce/internal/coupon/repository"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"time"
+)
+
+//go:generate mockery --name=ICouponService
+
+type ICouponService interface {
+	CreateCoupon(ctx context.Context, req *model.CouponReq) (*model.Coupon, error)
+	GetAllCoupons(ctx context.Context) ([]model.Coupon, error)
+	GetCouponByID(ctx context.Context, id int) (*model.Coupon, error)
+	UpdateCoupon(ctx context.Context, id int, input model.UpdateCoupon) (*model.Coupon, error)
+	DeleteCoupon(ctx context.Context, id int) error
+	Apply(ctx context.Context, code string, userID int, lines []model.Line) (*model.Discount, error)
+	Redeem(ctx context.Context, couponID, userID, orderID int) error
+	Release(ctx context.Context, orderID int) error
+}
+
+var (
+	ErrCouponNotFound  = errors.New("coupon not found")
+	ErrCouponInvalid   = errors.New("coupon is not valid at this time")
+	ErrMinBasket       = errors.New("basket is below the coupon minimum")
+	ErrNotApplicable   = errors.New("coupon does not apply to any product in the basket")
+	ErrInvalidDiscount = errors.New("invalid coupon discount")
+)
+
+// IsCouponError reports whether err means the coupon cannot be applied to the basket, as opposed
+// to a failure looking it up.
+func IsCouponError(err error) bool {
+	return errors.Is(err, ErrCouponNotFound) ||
+		errors.Is(err, ErrCouponInvalid) ||
+		errors.Is(err, ErrMinBasket) ||
+		errors.Is(err, ErrNotApplicable) ||
+		errors.Is(err, repository.ErrUsageLimitReached) ||
+		errors.Is(err, repository.ErrPerUserLimitReached)
+}
+
+type CouponService struct {
+	repo repository.ICouponRepository
+	now  func() time.Time
+}
+
+func NewCouponService(repo repository.ICouponRepository) *CouponService {
+	return &CouponService{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+func (s *CouponService) CreateCoupon(ctx context.Context, req *model.CouponReq) (*model.Coupon, error) {
+	switch req.Type {
+	case model.TypePercent:
+		if req.PercentOff <= 0 {
+			return nil, ErrInvalidDiscount
+		}
+	case model.TypeFixed:
+		if req.AmountOff.Amount <= 0 {
+			return nil, ErrInvalidDiscount
+		}
+	}
+
+	return s.repo.CreateCoupon(ctx, req)
+}
+
+func (s *CouponService) GetAllCoupons(ctx context.Context) ([]model.Coupon, error) {
+	return s.repo.GetAllCoupons(ctx)
+}
+
+func (s *CouponService) GetCouponByID(ctx context.Context, id int) (*model.Coupon, error) {
+	coupon, err := s.repo.GetCouponByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCouponNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+func (s *CouponService) UpdateCoupon(ctx context.Context, id int, input model.UpdateCoupon) (*model.Coupon, error) {
+	_, err := s.GetCouponByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.UpdateCoupon(ctx, id, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCouponByID(ctx, id)
+}
+
+func (s *CouponService) DeleteCoupon(ctx context.Context, id int) error {
+	return s.repo.DeleteCoupon(ctx, id)
+}
+
+// Apply validates the coupon for the user's basket and computes the discount. Nothing is recorded:
+// the coupon is only used up by Redeem once an order exists. Guests pass userID 0, which skips the
+// per-user limit until they sign in.
+func (s *CouponService) Apply(ctx context.Context, code string, userID int, lines []model.Line) (*model.Discount, error) {
+	coupon, err := s.repo.GetCouponByCode(ctx, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCouponNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !coupon.ValidAt(s.now()) {
+		return nil, ErrCouponInvalid
+	}
+
+	if coupon.UsageLimit > 0 && coupon.UsedCount >= coupon.UsageLimit {
+		return nil, repository.ErrUsageLimitReached
+	}
+
+	if coupon.PerUserLimit > 0 && userID != 0 {
+		count, err := s.repo.CountRedemptions(ctx, coupon.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if count >= coupon.PerUserLimit {
+			return nil, repository.ErrPerUserLimitReached
+		}
+	}
+
+	var subtotal money.Money
+	for _, line := range lines {
+		subtotal = subtotal.Add(line.Price)
+	}
+	if subtotal.Cmp(coupon.MinBasket) < 0 {
+		return nil, ErrMinBasket
+	}
+
+	// Only eligible lines carry weight, so the discount is spread over them alone.
+	weights := make([]money.Money, len(lines))
+	var eligibleTotal money.Money
+	for i, line := range lines {
+		eligible := true
+		if coupon.Restricted() {
+			eligible, err = s.repo.IsProductEligible(ctx, coupon.ID, line.ProductID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if eligible {
+			weights[i] = line.Price
+			eligibleTotal = eligibleTotal.Add(line.Price)
+		}
+	}
+	if coupon.Restricted() && eligibleTotal.IsZero() {
+		return nil, ErrNotApplicable
+	}
+
+	discount := &model.Discount{
+		CouponID: coupon.ID,
+		Code:     coupon.Code,
+		Amount:   money.FromMinor(0),
+	}
+
+	switch coupon.Type {
+	case model.TypePercent:
+		discount.Amount = eligibleTotal.Percent(coupon.PercentOff)
+	case model.TypeFixed:
+		discount.Amount = money.Min(coupon.AmountOff, eligibleTotal)
+	case model.TypeFreeShipping:
+		discount.FreeShipping = true
+	}
+
+	discount.Lines = discount.Amount.Allocate(weights)
+
+	return discount, nil
+}
+
+func (s *CouponService) Redeem(ctx context.Context, couponID, userID, orderID int) error {
+	return s.repo.Redeem(ctx, couponID, userID, orderID)
+}
+
+func (s *CouponService) Release(ctx context.Context, orderID int) error {
+	return s.repo.Release(ctx, orderID)
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/aaanger/ecommerce/internal/coupon/model"
+	"github.com/aaanger/ecommerce/internal/coupon/repository"
+	"github.com/aaanger/ecommerce/internal/coupon/repository/mocks"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type CouponServiceSuite struct {
+	suite.Suite
+	repo    *mocks.ICouponRepository
+	service *CouponService
+	now     time.Time
+}
+
+func (suite *CouponServiceSuite) SetupTest() {
+	suite.repo = mocks.NewICouponRepository(suite.T())
+	suite.service = NewCouponService(suite.repo)
+	suite.now = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	suite.service.now = func() time.Time { return suite.now }
+}
+
+func TestCouponServiceSuite(t *testing.T) {
+	suite.Run(t, new(CouponServiceSuite))
+}
+
+func lines(prices ...int64) []model.Line {
+	res := make([]model.Line, len(prices))
+	for i, price := range prices {
+		res[i] = model.Line{ProductID: i + 1, Price: money.FromMinor(price)}
+	}
+	return res
+}
+
+// ====================================================================================================================
+
+func (suite *CouponServiceSuite) TestService_ApplyPercent() {
+	suite.repo.On("GetCouponByCode", mock.Anything, "SALE15").Return(&model.Coupon{
+		ID: 1, Code: "SALE15", Type: model.TypePercent, PercentOff: 1500, Active: true,
+	}, nil)
+
+	discount, err := suite.service.Apply(context.Background(), "SALE15", 1, lines(1000, 333))
+
+	suite.Nil(err)
+	// 15% of 13.33 is 1.9995, rounded to 2.00 and split 150/50 across the lines.
+	suite.Equal(money.FromMinor(200), discount.Amount)
+	suite.Equal([]money.Money{money.FromMinor(150), money.FromMinor(50)}, discount.Lines)
+}
+
+func (suite *CouponServiceSuite) TestService_ApplyFixedCappedAtBasket() {
+	suite.repo.On("GetCouponByCode", mock.Anything, "FIXED").Return(&model.Coupon{
+		ID: 1, Code: "FIXED", Type: model.TypeFixed, AmountOff: money.FromMinor(5000), Active: true,
+	}, nil)
+
+	discount, err := suite.service.Apply(context.Background(), "FIXED", 1, lines(1000, 2000))
+
+	suite.Nil(err)
+	suite.Equal(money.FromMinor(3000), discount.Amount)
+	suite.Equal([]money.Money{money.FromMinor(1000), money.FromMinor(2000)}, discount.Lines)
+}
+
+func (suite *CouponServiceSuite) TestService_ApplyFreeShipping() {
+	suite.repo.On("GetCouponByCode", mock.Anything, "SHIP").Return(&model.Coupon{
+		ID: 1, Code: "SHIP", Type: model.TypeFreeShipping, Active: true,
+	}, nil)
+
+	discount, err := suite.service.Apply(context.Background(), "SHIP", 1, lines(1000))
+
+	suite.Nil(err)
+	suite.True(discount.FreeShipping)
+	suite.True(discount.Amount.IsZero())
+}
+
+func (suite *CouponServiceSuite) TestService_ApplyRestrictedToProducts() {
+	suite.repo.On("GetCouponByCode", mock.Anything, "SHOES").Return(&model.Coupon{
+		ID: 1, Code: "SHOES", Type: model.TypePercent, PercentOff: 5000, Active: true, CategoryIDs: []int{7},
+	}, nil)
+	suite.repo.On("IsProductEligible", mock.Anything, 1, 1).Return(false, nil)
+	suite.repo.On("IsProductEligible", mock.Anything, 1, 2).Return(true, nil)
+
+	discount, err := suite.service.Apply(context.Background(), "SHOES", 1, lines(1000, 400))
+
+	suite.Nil(err)
+	suite.Equal(money.FromMinor(200), discount.Amount)
+	suite.Equal([]money.Money{money.FromMinor(0), money.FromMinor(200)}, discount.Lines)
+}
+
+func (suite *CouponServiceSuite) TestService_ApplyNotApplicable() {
+	suite.repo.On("GetCouponByCode", mock.Anything, "SHOES").Return(&model.Coupon{
+		ID: 1, Code: "SHOES", Type: model.TypePercent, PercentOff: 5000, Active: true, ProductIDs: []int{9},
+	}, nil)
+	suite.repo.On("IsProductEligible", mock.Anything, 1, 1).Return(false, nil)
+
+	discount, err := suite.service.Apply(context.Background(), "SHOES", 1, lines(1000))
+
+	suite.Nil(discount)
+	suite.ErrorIs(err, ErrNotApplicable)
+}
+
+func (suite *CouponServiceSuite) TestService_ApplyMinBasket() {
+	suite.repo.On("GetCouponByCode", mock.Anything, "BIG").Return(&model.Coupon{
+		ID: 1, Code: "BIG", Type: model.TypeFixed, AmountOff: money.FromMinor(500), MinBasket: money.FromMinor(5000), Active: true,
+	}, nil)
+
+	discount, err := suite.service.Apply(context.Background(), "BIG", 1, lines(1000, 2000))
+
+	suite.Nil(discount)
+	suite.ErrorIs(err, ErrMinBasket)
+}
+
+func (suite *CouponServiceSuite) TestService_ApplyExpired() {
+	expired := suite.now.Add(-time.Hour)
+	suite.repo.On("GetCouponByCode", mock.Anything, "OLD").Return(&model.Coupon{
+		ID: 1, Code: "OLD", Type: model.TypeFreeShipping, Active: true, ExpiresAt: &expired,
+	}, nil)
+
+	discount, err := suite.service.Apply(context.Background(), "OLD", 1, lines(1000))
+
+	suite.Nil(discount)
+	suite.ErrorIs(err, ErrCouponInvalid)
+}
+
+func (suite *CouponServiceSuite) TestService_ApplyNotStarted() {
+	starts := suite.now.Add(time.Hour)
+	suite.repo.On("GetCouponByCode", mock.Anything, "SOON").Return(&model.Coupon{
+		ID: 1, Code: "SOON", Type: model.TypeFreeShipping, Active: true, StartsAt: &starts,
+	}, nil)
+
+	discount, err := suite.service.Apply(context.Background(), "SOON", 1, lines(1000))
+
+	suite.Nil(discount)
+	suite.ErrorIs(err, ErrCouponInvalid)
+}
+
+func (suite *CouponServiceSuite) TestService_ApplyUsageLimitReached() {
+	suite.repo.On("GetCouponByCode", mock.Anything, "LIMITED").Return(&model.Coupon{
+		ID: 1, Code: "LIMITED", Type: model.TypeFreeShipping, Active: true, UsageLimit: 5, UsedCount: 5,
+	}, nil)
+
+	discount, err := suite.service.Apply(context.Background(), "LIMITED", 1, lines(1000))
+
+	suite.Nil(discount)
+	suite.ErrorIs(err, repository.ErrUsageLimitReached)
+}
+
+func (suite *CouponServiceSuite) TestService_ApplyPerUserLimitReached() {
+	suite.repo.On("GetCouponByCode", mock.Anything, "ONCE").Return(&model.Coupon{
+		ID: 1, Code: "ONCE", Type: model.TypeFreeShipping, Active: true, PerUserLimit: 1,
+	}, nil)
+	suite.repo.On("CountRedemptions", mock.Anything, 1, 2).Return(1, nil)
+
+	discount, err := suite.service.Apply(context.Background(), "ONCE", 2, lines(1000))
+
+	suite.Nil(discount)
+	suite.ErrorIs(err, repository.ErrPerUserLimitReached)
+}
+
+func (suite *CouponServiceSuite) TestService_ApplyPerUserLimitSkippedForGuest() {
+	suite.repo.On("GetCouponByCode", mock.Anything, "ONCE").Return(&model.Coupon{
+		ID: 1, Code: "ONCE", Type: model.TypeFreeShipping, Active: true, PerUserLimit: 1,
+	}, nil)
+
+	discount, err := suite.service.Apply(context.Background(), "ONCE", 0, lines(1000))
+
+	suite.Nil(err)
+	suite.True(discount.FreeShipping)
+}
+
+func (suite *CouponServiceSuite) TestService_ApplyNotFound() {
+	suite.repo.On("GetCouponByCode", mock.Anything, "NOPE").Return(nil, sql.ErrNoRows)
+
+	discount, err := suite.service.Apply(context.Background(), "NOPE", 1, lines(1000))
+
+	suite.Nil(discount)
+	suite.ErrorIs(err, ErrCouponNotFound)
+	suite.True(IsCouponError(err))
+}
+
+// ====================================================================================================================
+
+func (suite *CouponServiceSuite) TestService_CreateCouponInvalidDiscount() {
+	coupon, err := suite.service.CreateCoupon(context.Background(), &model.CouponReq{Code: "ZERO", Type: model.TypePercent})
+
+	suite.Nil(coupon)
+	suite.ErrorIs(err, ErrInvalidDiscount)
+}
+
+func (suite *CouponServiceSuite) TestService_UpdateCouponNotFound() {
+	suite.repo.On("GetCouponByID", mock.Anything, 1).Return(nil, sql.ErrNoRows)
+
+	coupon, err := suite.service.UpdateCoupon(context.Background(), 1, model.UpdateCoupon{})
+
+	suite.Nil(coupon)
+	suite.ErrorIs(err, ErrCouponNotFound)
+}
@@ -0,0 +1,233 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/aaanger/ecommerce/internal/coupon/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ICouponService is an autogenerated mock type for the ICouponService type
+type ICouponService struct {
+	mock.Mock
+}
+
+// Apply provides a mock function with given fields: ctx, code, userID, lines
+func (_m *ICouponService) Apply(ctx context.Context, code string, userID int, lines []model.Line) (*model.Discount, error) {
+	ret := _m.Called(ctx, code, userID, lines)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Apply")
+	}
+
+	var r0 *model.Discount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, []model.Line) (*model.Discount, error)); ok {
+		return rf(ctx, code, userID, lines)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, []model.Line) *model.Discount); ok {
+		r0 = rf(ctx, code, userID, lines)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Discount)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, []model.Line) error); ok {
+		r1 = rf(ctx, code, userID, lines)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateCoupon provides a mock function with given fields: ctx, req
+func (_m *ICouponService) CreateCoupon(ctx context.Context, req *model.CouponReq) (*model.Coupon, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateCoupon")
+	}
+
+	var r0 *model.Coupon
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.CouponReq) (*model.Coupon, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *model.CouponReq) *model.Coupon); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Coupon)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *model.CouponReq) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteCoupon provides a mock function with given fields: ctx, id
+func (_m *ICouponService) DeleteCoupon(ctx context.Context, id int) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteCoupon")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAllCoupons provides a mock function with given fields: ctx
+func (_m *ICouponService) GetAllCoupons(ctx context.Context) ([]model.Coupon, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAllCoupons")
+	}
+
+	var r0 []model.Coupon
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]model.Coupon, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []model.Coupon); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Coupon)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCouponByID provides a mock function with given fields: ctx, id
+func (_m *ICouponService) GetCouponByID(ctx context.Context, id int) (*model.Coupon, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCouponByID")
+	}
+
+	var r0 *model.Coupon
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*model.Coupon, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *model.Coupon); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Coupon)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Redeem provides a mock function with given fields: ctx, couponID, userID, orderID
+func (_m *ICouponService) Redeem(ctx context.Context, couponID int, userID int, orderID int) error {
+	ret := _m.Called(ctx, couponID, userID, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Redeem")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) error); ok {
+		r0 = rf(ctx, couponID, userID, orderID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Release provides a mock function with given fields: ctx, orderID
+func (_m *ICouponService) Release(ctx context.Context, orderID int) error {
+	ret := _m.Called(ctx, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Release")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateCoupon provides a mock function with given fields: ctx, id, input
+func (_m *ICouponService) UpdateCoupon(ctx context.Context, id int, input model.UpdateCoupon) (*model.Coupon, error) {
+	ret := _m.Called(ctx, id, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateCoupon")
+	}
+
+	var r0 *model.Coupon
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.UpdateCoupon) (*model.Coupon, error)); ok {
+		return rf(ctx, id, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.UpdateCoupon) *model.Coupon); ok {
+		r0 = rf(ctx, id, input)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Coupon)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, model.UpdateCoupon) error); ok {
+		r1 = rf(ctx, id, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewICouponService creates a new instance of ICouponService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewICouponService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ICouponService {
+	mock := &ICouponService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
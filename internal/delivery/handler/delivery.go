@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"errors"
+	"github.com/aaanger/ecommerce/internal/delivery/model"
+	"github.com/aaanger/ecommerce/internal/delivery/service"
+	"github.com/aaanger/ecommerce/pkg/middleware"
+	"github.com/aaanger/ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type DeliveryHandler struct {
+	service service.IDeliveryService
+	log     *zap.Logger
+}
+
+func NewDeliveryHandler(service service.IDeliveryService, log *zap.Logger) *DeliveryHandler {
+	return &DeliveryHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+func (h *DeliveryHandler) CreateAddress(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	var req model.Destination
+
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid input parameters")
+		return
+	}
+
+	address, err := h.service.CreateAddress(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.log.Error("create address error", zap.Error(err), zap.Int("userID", userID))
+		response.Error(c, http.StatusInternalServerError, "Failed to create address")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, address)
+}
+
+func (h *DeliveryHandler) GetAddresses(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	addresses, err := h.service.GetAddresses(c.Request.Context(), userID)
+	if err != nil {
+		h.log.Error("get addresses error", zap.Error(err), zap.Int("userID", userID))
+		response.Error(c, http.StatusInternalServerError, "Failed to get addresses")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, addresses)
+}
+
+func (h *DeliveryHandler) GetAddress(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid address id")
+		return
+	}
+
+	address, err := h.service.GetAddress(c.Request.Context(), userID, id)
+	if errors.Is(err, service.ErrAddressNotFound) {
+		response.Error(c, http.StatusNotFound, "Address not found")
+		return
+	}
+	if err != nil {
+		h.log.Error("get address error", zap.Error(err), zap.Int("addressID", id))
+		response.Error(c, http.StatusInternalServerError, "Failed to get address")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, address)
+}
+
+func (h *DeliveryHandler) UpdateAddress(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid address id")
+		return
+	}
+
+	var req model.Destination
+
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid input parameters")
+		return
+	}
+
+	address, err := h.service.UpdateAddress(c.Request.Context(), userID, id, &req)
+	if errors.Is(err, service.ErrAddressNotFound) {
+		response.Error(c, http.StatusNotFound, "Address not found")
+		return
+	}
+	if err != nil {
+		h.log.Error("update address error", zap.Error(err), zap.Int("addressID", id))
+		response.Error(c, http.StatusInternalServerError, "Failed to update address")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, address)
+}
+
+func (h *DeliveryHandler) DeleteAddress(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid address id")
+		return
+	}
+
+	err = h.service.DeleteAddress(c.Request.Context(), userID, id)
+	if errors.Is(err, service.ErrAddressNotFound) {
+		response.Error(c, http.StatusNotFound, "Address not found")
+		return
+	}
+	if err != nil {
+		h.log.Error("delete address error", zap.Error(err), zap.Int("addressID", id))
+		response.Error(c, http.StatusInternalServerError, "Failed to delete address")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, "address deleted")
+}
+
+// GetMethods lists the delivery methods customers can choose from.
+func (h *DeliveryHandler) GetMethods(c *gin.Context) {
+	h.getMethods(c, true)
+}
+
+// GetAllMethods lists every delivery method, including disabled ones.
+func (h *DeliveryHandler) GetAllMethods(c *gin.Context) {
+	h.getMethods(c, false)
+}
+
+func (h *DeliveryHandler) getMethods(c *gin.Context, activeOnly bool) {
+	methods, err := h.service.GetMethods(c.Request.Context(), activeOnly)
+	if err != nil {
+		h.log.Error("get delivery methods error", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "Failed to get delivery methods")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, methods)
+}
+
+func (h *DeliveryHandler) UpdateMethod(c *gin.Context) {
+	var input model.UpdateMethod
+
+	err := c.ShouldBindJSON(&input)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid input parameters")
+		return
+	}
+
+	method, err := h.service.UpdateMethod(c.Request.Context(), c.Param("code"), input)
+	if errors.Is(err, service.ErrInvalidPrice) {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrMethodNotFound) {
+		response.Error(c, http.StatusNotFound, "Delivery method not found")
+		return
+	}
+	if err != nil {
+		h.log.Error("update delivery method error", zap.Error(err), zap.String("code", c.Param("code")))
+		response.Error(c, http.StatusInternalServerError, "Failed to update delivery method")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, method)
+}
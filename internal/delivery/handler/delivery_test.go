@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/aaanger/ecommerce/internal/delivery/model"
+	"github.com/aaanger/ecommerce/internal/delivery/service"
+	"github.com/aaanger/ecommerce/internal/delivery/service/mocks"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type DeliveryHandlerSuite struct {
+	suite.Suite
+	service *mocks.IDeliveryService
+	handler *DeliveryHandler
+	router  *gin.Engine
+}
+
+func (suite *DeliveryHandlerSuite) SetupTest() {
+	suite.service = mocks.NewIDeliveryService(suite.T())
+	suite.handler = NewDeliveryHandler(suite.service, zap.NewNop())
+
+	suite.router = gin.New()
+	suite.router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	})
+	suite.router.POST("/addresses", suite.handler.CreateAddress)
+	suite.router.GET("/addresses/:id", suite.handler.GetAddress)
+	suite.router.DELETE("/addresses/:id", suite.handler.DeleteAddress)
+	suite.router.GET("/delivery-methods", suite.handler.GetMethods)
+	suite.router.PUT("/delivery-methods/:code", suite.handler.UpdateMethod)
+}
+
+func TestDeliveryHandlerSuite(t *testing.T) {
+	suite.Run(t, new(DeliveryHandlerSuite))
+}
+
+// =====================================================================================================================
+
+func (suite *DeliveryHandlerSuite) TestHandler_CreateAddressSuccess() {
+	req := &model.Destination{Recipient: "Ivan", Phone: "+79990000000", Country: "RU", City: "Moscow", Street: "Tverskaya 1", PostalCode: "125009"}
+
+	suite.service.On("CreateAddress", mock.Anything, 1, req).Return(&model.Address{ID: 5, UserID: 1, Destination: *req}, nil)
+
+	requestBody, _ := json.Marshal(req)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/addresses", bytes.NewBuffer(requestBody))
+
+	suite.router.ServeHTTP(w, r)
+
+	var address model.Address
+	_ = json.Unmarshal(w.Body.Bytes(), &address)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(5, address.ID)
+	suite.Equal("Moscow", address.City)
+}
+
+func (suite *DeliveryHandlerSuite) TestHandler_CreateAddressMissingFields() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/addresses", bytes.NewBufferString(`{"recipient": "Ivan"}`))
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(`"Invalid input parameters"`, w.Body.String())
+}
+
+func (suite *DeliveryHandlerSuite) TestHandler_GetAddressNotFound() {
+	suite.service.On("GetAddress", mock.Anything, 1, 9).Return(nil, service.ErrAddressNotFound)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/addresses/9", nil)
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusNotFound, w.Code)
+	suite.Equal(`"Address not found"`, w.Body.String())
+}
+
+func (suite *DeliveryHandlerSuite) TestHandler_DeleteAddressSuccess() {
+	suite.service.On("DeleteAddress", mock.Anything, 1, 5).Return(nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("DELETE", "/addresses/5", nil)
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+// =====================================================================================================================
+
+func (suite *DeliveryHandlerSuite) TestHandler_GetMethodsSuccess() {
+	suite.service.On("GetMethods", mock.Anything, true).Return([]model.Method{
+		{Code: model.MethodPickup, Name: "Pickup point", Price: money.FromMinor(20000), Active: true},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/delivery-methods", nil)
+
+	suite.router.ServeHTTP(w, r)
+
+	var methods []model.Method
+	_ = json.Unmarshal(w.Body.Bytes(), &methods)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Len(methods, 1)
+	suite.Equal(money.FromMinor(20000), methods[0].Price)
+}
+
+func (suite *DeliveryHandlerSuite) TestHandler_UpdateMethodNegativePrice() {
+	suite.service.On("UpdateMethod", mock.Anything, model.MethodCourier, mock.Anything).Return(nil, service.ErrInvalidPrice)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("PUT", "/delivery-methods/courier", bytes.NewBufferString(`{"price": "-1.00"}`))
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(`"delivery price must not be negative"`, w.Body.String())
+}
+
+func (suite *DeliveryHandlerSuite) TestHandler_UpdateMethodNotFound() {
+	suite.service.On("UpdateMethod", mock.Anything, "drone", mock.Anything).Return(nil, service.ErrMethodNotFound)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("PUT", "/delivery-methods/drone", bytes.NewBufferString(`{"active": false}`))
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusNotFound, w.Code)
+	suite.Equal(`"Delivery method not found"`, w.Body.String())
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"database/sql"
+	"github.com/aaanger/ecommerce/internal/delivery/repository"
+	"github.com/aaanger/ecommerce/internal/delivery/service"
+	"github.com/aaanger/ecommerce/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func DeliveryRoutes(r *gin.Engine, db *sql.DB, logger *zap.Logger) service.IDeliveryService {
+	repo := repository.NewDeliveryRepository(db)
+	svc := service.NewDeliveryService(repo)
+	h := NewDeliveryHandler(svc, logger)
+
+	addresses := r.Group("/addresses", middleware.UserIdentity)
+
+	addresses.POST("/", h.CreateAddress)
+	addresses.GET("/", h.GetAddresses)
+	addresses.GET("/:id", h.GetAddress)
+	addresses.PUT("/:id", h.UpdateAddress)
+	addresses.DELETE("/:id", h.DeleteAddress)
+
+	r.GET("/delivery-methods", h.GetMethods)
+
+	methods := r.Group("/delivery-methods", middleware.UserIdentity, middleware.ModeratorIdentity)
+
+	methods.GET("/all", h.GetAllMethods)
+	methods.PUT("/:code", h.UpdateMethod)
+
+	return svc
+}
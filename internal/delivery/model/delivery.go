@@ -0,0 +1,57 @@
+package model
+
+import (
+	"fmt"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"time"
+)
+
+const (
+	MethodCourier = "courier"
+	MethodPickup  = "pickup"
+	MethodPost    = "post"
+)
+
+// Destination is where a parcel goes. It is what an order keeps of the address it was shipped to.
+type Destination struct {
+	Recipient  string `json:"recipient" binding:"required"`
+	Phone      string `json:"phone" binding:"required"`
+	Country    string `json:"country" binding:"required"`
+	City       string `json:"city" binding:"required"`
+	Street     string `json:"street" binding:"required"`
+	PostalCode string `json:"postal_code" binding:"required"`
+}
+
+// String formats the destination for a shipping label or an email.
+func (d Destination) String() string {
+	return fmt.Sprintf("%s, %s, %s, %s, %s, %s", d.Recipient, d.Phone, d.Street, d.City, d.PostalCode, d.Country)
+}
+
+// Address is an entry of a user's address book.
+type Address struct {
+	ID     int `json:"id"`
+	UserID int `json:"user_id"`
+	Destination
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Method is a way of delivering an order. Inactive methods are listed for moderators but cannot be ordered with.
+type Method struct {
+	Code   string      `json:"code"`
+	Name   string      `json:"name"`
+	Price  money.Money `json:"price"`
+	Active bool        `json:"active"`
+}
+
+type UpdateMethod struct {
+	Name   *string      `json:"name"`
+	Price  *money.Money `json:"price"`
+	Active *bool        `json:"active"`
+}
+
+// DeliveryReq picks how and where an order is delivered: a saved address by AddressID or a one-off Address.
+type DeliveryReq struct {
+	Method    string       `json:"method" binding:"required"`
+	AddressID int          `json:"address_id"`
+	Address   *Destination `json:"address"`
+}
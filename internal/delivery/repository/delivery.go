@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/aaanger/ecommerce/internal/delivery/model"
+	"github.com/aaanger/ecommerce/pkg/db"
+	"strings"
+)
+
+//go:generate mockery --name=IDeliveryRepository
+
+type IDeliveryRepository interface {
+	CreateAddress(ctx context.Context, userID int, req *model.Destination) (*model.Address, error)
+	GetAddresses(ctx context.Context, userID int) ([]model.Address, error)
+	GetAddress(ctx context.Context, userID, id int) (*model.Address, error)
+	UpdateAddress(ctx context.Context, userID, id int, req *model.Destination) (*model.Address, error)
+	DeleteAddress(ctx context.Context, userID, id int) (bool, error)
+	GetMethods(ctx context.Context) ([]model.Method, error)
+	GetMethod(ctx context.Context, code string) (*model.Method, error)
+	UpdateMethod(ctx context.Context, code string, input model.UpdateMethod) error
+}
+
+const (
+	addressColumns = `id, user_id, recipient, phone, country, city, street, postal_code, created_at`
+	methodColumns  = `code, name, price, active`
+)
+
+// DeliveryRepository stores address books and delivery methods. Addresses are always looked up by
+// owner and id together, so one user never reads or changes another's address.
+type DeliveryRepository struct {
+	db *sql.DB
+}
+
+func NewDeliveryRepository(db *sql.DB) *DeliveryRepository {
+	return &DeliveryRepository{
+		db: db,
+	}
+}
+
+func (r *DeliveryRepository) CreateAddress(ctx context.Context, userID int, req *model.Destination) (*model.Address, error) {
+	row := db.Conn(ctx, r.db).QueryRowContext(ctx, `INSERT INTO addresses (user_id, recipient, phone, country, city, street, postal_code)
+		VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING `+addressColumns+`;`,
+		userID, req.Recipient, req.Phone, req.Country, req.City, req.Street, req.PostalCode)
+
+	return scanAddress(row)
+}
+
+func (r *DeliveryRepository) GetAddresses(ctx context.Context, userID int) ([]model.Address, error) {
+	var addresses []model.Address
+
+	rows, err := db.Conn(ctx, r.db).QueryContext(ctx, `SELECT `+addressColumns+` FROM addresses WHERE user_id=$1 ORDER BY id;`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		address, err := scanAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, *address)
+	}
+
+	return addresses, rows.Err()
+}
+
+func (r *DeliveryRepository) GetAddress(ctx context.Context, userID, id int) (*model.Address, error) {
+	return scanAddress(db.Conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+addressColumns+` FROM addresses WHERE id=$1 AND user_id=$2;`, id, userID))
+}
+
+func (r *DeliveryRepository) UpdateAddress(ctx context.Context, userID, id int, req *model.Destination) (*model.Address, error) {
+	row := db.Conn(ctx, r.db).QueryRowContext(ctx, `UPDATE addresses SET recipient=$1, phone=$2, country=$3, city=$4, street=$5, postal_code=$6
+		WHERE id=$7 AND user_id=$8 RETURNING `+addressColumns+`;`,
+		req.Recipient, req.Phone, req.Country, req.City, req.Street, req.PostalCode, id, userID)
+
+	return scanAddress(row)
+}
+
+// DeleteAddress reports whether the user had an address with the id.
+func (r *DeliveryRepository) DeleteAddress(ctx context.Context, userID, id int) (bool, error) {
+	res, err := db.Conn(ctx, r.db).ExecContext(ctx, `DELETE FROM addresses WHERE id=$1 AND user_id=$2;`, id, userID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (r *DeliveryRepository) GetMethods(ctx context.Context) ([]model.Method, error) {
+	var methods []model.Method
+
+	rows, err := db.Conn(ctx, r.db).QueryContext(ctx, `SELECT `+methodColumns+` FROM delivery_methods ORDER BY price, code;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var method model.Method
+
+		err = rows.Scan(&method.Code, &method.Name, &method.Price, &method.Active)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, method)
+	}
+
+	return methods, rows.Err()
+}
+
+func (r *DeliveryRepository) GetMethod(ctx context.Context, code string) (*model.Method, error) {
+	var method model.Method
+
+	row := db.Conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+methodColumns+` FROM delivery_methods WHERE code=$1;`, code)
+	err := row.Scan(&method.Code, &method.Name, &method.Price, &method.Active)
+	if err != nil {
+		return nil, err
+	}
+
+	return &method, nil
+}
+
+func (r *DeliveryRepository) UpdateMethod(ctx context.Context, code string, input model.UpdateMethod) error {
+	keys := make([]string, 0)
+	values := make([]interface{}, 0)
+	arg := 1
+
+	if input.Name != nil {
+		keys = append(keys, fmt.Sprintf("name=$%d", arg))
+		values = append(values, *input.Name)
+		arg++
+	}
+	if input.Price != nil {
+		keys = append(keys, fmt.Sprintf("price=$%d", arg))
+		values = append(values, *input.Price)
+		arg++
+	}
+	if input.Active != nil {
+		keys = append(keys, fmt.Sprintf("active=$%d", arg))
+		values = append(values, *input.Active)
+		arg++
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(`UPDATE delivery_methods SET %s WHERE code=$%d;`, strings.Join(keys, ", "), arg)
+	values = append(values, code)
+
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, query, values...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAddress(row scanner) (*model.Address, error) {
+	var address model.Address
+
+	err := row.Scan(&address.ID, &address.UserID, &address.Recipient, &address.Phone, &address.Country, &address.City,
+		&address.Street, &address.PostalCode, &address.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &address, nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/aaanger/ecommerce/internal/delivery/model"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type DeliveryRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo *DeliveryRepository
+}
+
+func (suite *DeliveryRepositorySuite) SetupTest() {
+	var err error
+	suite.db, suite.mock, err = sqlmock.New()
+	assert.NoError(suite.T(), err)
+	suite.repo = NewDeliveryRepository(suite.db)
+}
+
+func TestDeliveryRepositorySuite(t *testing.T) {
+	suite.Run(t, new(DeliveryRepositorySuite))
+}
+
+var addressRowColumns = []string{"id", "user_id", "recipient", "phone", "country", "city", "street", "postal_code", "created_at"}
+
+// ====================================================================================================================
+
+func (suite *DeliveryRepositorySuite) TestRepository_CreateAddressSuccess() {
+	req := &model.Destination{Recipient: "Ivan", Phone: "+79990000000", Country: "RU", City: "Moscow", Street: "Tverskaya 1", PostalCode: "125009"}
+
+	suite.mock.ExpectQuery("INSERT INTO addresses").
+		WithArgs(1, "Ivan", "+79990000000", "RU", "Moscow", "Tverskaya 1", "125009").
+		WillReturnRows(sqlmock.NewRows(addressRowColumns).
+			AddRow(5, 1, "Ivan", "+79990000000", "RU", "Moscow", "Tverskaya 1", "125009", time.Now()))
+
+	address, err := suite.repo.CreateAddress(context.Background(), 1, req)
+
+	suite.Nil(err)
+	suite.Equal(5, address.ID)
+	suite.Equal(*req, address.Destination)
+}
+
+func (suite *DeliveryRepositorySuite) TestRepository_GetAddressOtherUser() {
+	suite.mock.ExpectQuery("SELECT (.+) FROM addresses WHERE id=\\$1 AND user_id=\\$2").
+		WithArgs(5, 2).WillReturnRows(sqlmock.NewRows(addressRowColumns))
+
+	address, err := suite.repo.GetAddress(context.Background(), 2, 5)
+
+	suite.Nil(address)
+	suite.ErrorIs(err, sql.ErrNoRows)
+}
+
+func (suite *DeliveryRepositorySuite) TestRepository_DeleteAddressMissing() {
+	suite.mock.ExpectExec("DELETE FROM addresses WHERE id=\\$1 AND user_id=\\$2").
+		WithArgs(5, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	deleted, err := suite.repo.DeleteAddress(context.Background(), 1, 5)
+
+	suite.Nil(err)
+	suite.False(deleted)
+}
+
+// ====================================================================================================================
+
+func (suite *DeliveryRepositorySuite) TestRepository_GetMethodsSuccess() {
+	suite.mock.ExpectQuery("SELECT code, name, price, active FROM delivery_methods").
+		WillReturnRows(sqlmock.NewRows([]string{"code", "name", "price", "active"}).
+			AddRow(model.MethodPickup, "Pickup point", 20000, true).
+			AddRow(model.MethodCourier, "Courier", 50000, false))
+
+	methods, err := suite.repo.GetMethods(context.Background())
+
+	suite.Nil(err)
+	suite.Len(methods, 2)
+	suite.Equal(money.FromMinor(20000), methods[0].Price)
+	suite.False(methods[1].Active)
+}
+
+func (suite *DeliveryRepositorySuite) TestRepository_UpdateMethodSuccess() {
+	price := money.FromMinor(40000)
+	active := false
+
+	suite.mock.ExpectExec("UPDATE delivery_methods SET price=\\$1, active=\\$2 WHERE code=\\$3").
+		WithArgs(price, false, model.MethodCourier).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := suite.repo.UpdateMethod(context.Background(), model.MethodCourier, model.UpdateMethod{Price: &price, Active: &active})
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
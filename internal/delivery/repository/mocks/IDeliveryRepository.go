@@ -0,0 +1,255 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/aaanger/ecommerce/internal/delivery/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IDeliveryRepository is an autogenerated mock type for the IDeliveryRepository type
+type IDeliveryRepository struct {
+	mock.Mock
+}
+
+// CreateAddress provides a mock function with given fields: ctx, userID, req
+func (_m *IDeliveryRepository) CreateAddress(ctx context.Context, userID int, req *model.Destination) (*model.Address, error) {
+	ret := _m.Called(ctx, userID, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAddress")
+	}
+
+	var r0 *model.Address
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, *model.Destination) (*model.Address, error)); ok {
+		return rf(ctx, userID, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, *model.Destination) *model.Address); ok {
+		r0 = rf(ctx, userID, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Address)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, *model.Destination) error); ok {
+		r1 = rf(ctx, userID, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteAddress provides a mock function with given fields: ctx, userID, id
+func (_m *IDeliveryRepository) DeleteAddress(ctx context.Context, userID int, id int) (bool, error) {
+	ret := _m.Called(ctx, userID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAddress")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (bool, error)); ok {
+		return rf(ctx, userID, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) bool); ok {
+		r0 = rf(ctx, userID, id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, userID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAddress provides a mock function with given fields: ctx, userID, id
+func (_m *IDeliveryRepository) GetAddress(ctx context.Context, userID int, id int) (*model.Address, error) {
+	ret := _m.Called(ctx, userID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAddress")
+	}
+
+	var r0 *model.Address
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (*model.Address, error)); ok {
+		return rf(ctx, userID, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) *model.Address); ok {
+		r0 = rf(ctx, userID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Address)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, userID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAddresses provides a mock function with given fields: ctx, userID
+func (_m *IDeliveryRepository) GetAddresses(ctx context.Context, userID int) ([]model.Address, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAddresses")
+	}
+
+	var r0 []model.Address
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]model.Address, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []model.Address); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Address)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMethod provides a mock function with given fields: ctx, code
+func (_m *IDeliveryRepository) GetMethod(ctx context.Context, code string) (*model.Method, error) {
+	ret := _m.Called(ctx, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMethod")
+	}
+
+	var r0 *model.Method
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.Method, error)); ok {
+		return rf(ctx, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Method); ok {
+		r0 = rf(ctx, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Method)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMethods provides a mock function with given fields: ctx
+func (_m *IDeliveryRepository) GetMethods(ctx context.Context) ([]model.Method, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMethods")
+	}
+
+	var r0 []model.Method
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]model.Method, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []model.Method); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Method)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateAddress provides a mock function with given fields: ctx, userID, id, req
+func (_m *IDeliveryRepository) UpdateAddress(ctx context.Context, userID int, id int, req *model.Destination) (*model.Address, error) {
+	ret := _m.Called(ctx, userID, id, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAddress")
+	}
+
+	var r0 *model.Address
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, *model.Destination) (*model.Address, error)); ok {
+		return rf(ctx, userID, id, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, *model.Destination) *model.Address); ok {
+		r0 = rf(ctx, userID, id, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Address)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, *model.Destination) error); ok {
+		r1 = rf(ctx, userID, id, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateMethod provides a mock function with given fields: ctx, code, input
+func (_m *IDeliveryRepository) UpdateMethod(ctx context.Context, code string, input model.UpdateMethod) error {
+	ret := _m.Called(ctx, code, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateMethod")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.UpdateMethod) error); ok {
+		r0 = rf(ctx, code, input)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewIDeliveryRepository creates a new instance of IDeliveryRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIDeliveryRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IDeliveryRepository {
+	mock := &IDeliveryRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
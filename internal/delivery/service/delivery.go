@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/aaanger/ecommerce/internal/delivery/model"
+	"github.com/aaanger/ecommerce/internal/delivery/repository"
+)
+
+//go:generate mockery --name=IDeliveryService
+
+type IDeliveryService interface {
+	CreateAddress(ctx context.Context, userID int, req *model.Destination) (*model.Address, error)
+	GetAddresses(ctx context.Context, userID int) ([]model.Address, error)
+	GetAddress(ctx context.Context, userID, id int) (*model.Address, error)
+	UpdateAddress(ctx context.Context, userID, id int, req *model.Destination) (*model.Address, error)
+	DeleteAddress(ctx context.Context, userID, id int) error
+	GetMethods(ctx context.Context, activeOnly bool) ([]model.Method, error)
+	UpdateMethod(ctx context.Context, code string, input model.UpdateMethod) (*model.Method, error)
+	Resolve(ctx context.Context, userID int, req *model.DeliveryReq) (*model.Method, *model.Destination, error)
+}
+
+var (
+	ErrAddressNotFound   = errors.New("address not found")
+	ErrMethodNotFound    = errors.New("delivery method not found")
+	ErrMethodUnavailable = errors.New("delivery method is not available")
+	ErrAddressRequired   = errors.New("either address_id or address is required")
+	ErrInvalidPrice      = errors.New("delivery price must not be negative")
+)
+
+// IsDeliveryError reports whether err means the requested delivery cannot be used, as opposed to
+// a failure looking it up.
+func IsDeliveryError(err error) bool {
+	return errors.Is(err, ErrAddressNotFound) ||
+		errors.Is(err, ErrMethodNotFound) ||
+		errors.Is(err, ErrMethodUnavailable) ||
+		errors.Is(err, ErrAddressRequired)
+}
+
+type DeliveryService struct {
+	repo repository.IDeliveryRepository
+}
+
+func NewDeliveryService(repo repository.IDeliveryRepository) *DeliveryService {
+	return &DeliveryService{
+		repo: repo,
+	}
+}
+
+func (s *DeliveryService) CreateAddress(ctx context.Context, userID int, req *model.Destination) (*model.Address, error) {
+	return s.repo.CreateAddress(ctx, userID, req)
+}
+
+func (s *DeliveryService) GetAddresses(ctx context.Context, userID int) ([]model.Address, error) {
+	return s.repo.GetAddresses(ctx, userID)
+}
+
+func (s *DeliveryService) GetAddress(ctx context.Context, userID, id int) (*model.Address, error) {
+	address, err := s.repo.GetAddress(ctx, userID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAddressNotFound
+	}
+
+	return address, err
+}
+
+func (s *DeliveryService) UpdateAddress(ctx context.Context, userID, id int, req *model.Destination) (*model.Address, error) {
+	address, err := s.repo.UpdateAddress(ctx, userID, id, req)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAddressNotFound
+	}
+
+	return address, err
+}
+
+func (s *DeliveryService) DeleteAddress(ctx context.Context, userID, id int) error {
+	deleted, err := s.repo.DeleteAddress(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrAddressNotFound
+	}
+
+	return nil
+}
+
+func (s *DeliveryService) GetMethods(ctx context.Context, activeOnly bool) ([]model.Method, error) {
+	methods, err := s.repo.GetMethods(ctx)
+	if err != nil || !activeOnly {
+		return methods, err
+	}
+
+	active := make([]model.Method, 0, len(methods))
+	for _, method := range methods {
+		if method.Active {
+			active = append(active, method)
+		}
+	}
+
+	return active, nil
+}
+
+func (s *DeliveryService) UpdateMethod(ctx context.Context, code string, input model.UpdateMethod) (*model.Method, error) {
+	if input.Price != nil && input.Price.IsNegative() {
+		return nil, ErrInvalidPrice
+	}
+
+	if _, err := s.getMethod(ctx, code); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateMethod(ctx, code, input); err != nil {
+		return nil, err
+	}
+
+	return s.getMethod(ctx, code)
+}
+
+// Resolve checks that the delivery method can be ordered with and returns it with the destination to ship to.
+// A saved address must belong to the user, a one-off address is used without being saved.
+func (s *DeliveryService) Resolve(ctx context.Context, userID int, req *model.DeliveryReq) (*model.Method, *model.Destination, error) {
+	if (req.AddressID == 0) == (req.Address == nil) {
+		return nil, nil, ErrAddressRequired
+	}
+
+	method, err := s.getMethod(ctx, req.Method)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !method.Active {
+		return nil, nil, ErrMethodUnavailable
+	}
+
+	if req.Address != nil {
+		return method, req.Address, nil
+	}
+
+	address, err := s.GetAddress(ctx, userID, req.AddressID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return method, &address.Destination, nil
+}
+
+func (s *DeliveryService) getMethod(ctx context.Context, code string) (*model.Method, error) {
+	method, err := s.repo.GetMethod(ctx, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMethodNotFound
+	}
+
+	return method, err
+}
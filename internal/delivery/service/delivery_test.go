@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/aaanger/ecommerce/internal/delivery/model"
+	"github.com/aaanger/ecommerce/internal/delivery/repository/mocks"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type DeliveryServiceSuite struct {
+	suite.Suite
+	repo    *mocks.IDeliveryRepository
+	service *DeliveryService
+}
+
+func (suite *DeliveryServiceSuite) SetupTest() {
+	suite.repo = mocks.NewIDeliveryRepository(suite.T())
+	suite.service = NewDeliveryService(suite.repo)
+}
+
+func TestDeliveryServiceSuite(t *testing.T) {
+	suite.Run(t, new(DeliveryServiceSuite))
+}
+
+func destination() *model.Destination {
+	return &model.Destination{Recipient: "Ivan", Phone: "+79990000000", Country: "RU", City: "Moscow", Street: "Tverskaya 1", PostalCode: "125009"}
+}
+
+// ====================================================================================================================
+
+func (suite *DeliveryServiceSuite) TestService_GetAddressNotFound() {
+	suite.repo.On("GetAddress", mock.Anything, 1, 5).Return(nil, sql.ErrNoRows)
+
+	address, err := suite.service.GetAddress(context.Background(), 1, 5)
+
+	suite.Nil(address)
+	suite.ErrorIs(err, ErrAddressNotFound)
+}
+
+func (suite *DeliveryServiceSuite) TestService_UpdateAddressNotFound() {
+	suite.repo.On("UpdateAddress", mock.Anything, 1, 5, destination()).Return(nil, sql.ErrNoRows)
+
+	address, err := suite.service.UpdateAddress(context.Background(), 1, 5, destination())
+
+	suite.Nil(address)
+	suite.ErrorIs(err, ErrAddressNotFound)
+}
+
+func (suite *DeliveryServiceSuite) TestService_DeleteAddressNotFound() {
+	suite.repo.On("DeleteAddress", mock.Anything, 1, 5).Return(false, nil)
+
+	err := suite.service.DeleteAddress(context.Background(), 1, 5)
+
+	suite.ErrorIs(err, ErrAddressNotFound)
+}
+
+// ====================================================================================================================
+
+func (suite *DeliveryServiceSuite) TestService_GetMethodsActiveOnly() {
+	suite.repo.On("GetMethods", mock.Anything).Return([]model.Method{
+		{Code: model.MethodPickup, Active: true},
+		{Code: model.MethodPost, Active: false},
+	}, nil)
+
+	methods, err := suite.service.GetMethods(context.Background(), true)
+
+	suite.Nil(err)
+	suite.Equal([]model.Method{{Code: model.MethodPickup, Active: true}}, methods)
+}
+
+func (suite *DeliveryServiceSuite) TestService_UpdateMethodSuccess() {
+	price := money.FromMinor(40000)
+	input := model.UpdateMethod{Price: &price}
+
+	suite.repo.On("GetMethod", mock.Anything, model.MethodCourier).Return(&model.Method{Code: model.MethodCourier, Price: money.FromMinor(50000)}, nil).Once()
+	suite.repo.On("UpdateMethod", mock.Anything, model.MethodCourier, input).Return(nil)
+	suite.repo.On("GetMethod", mock.Anything, model.MethodCourier).Return(&model.Method{Code: model.MethodCourier, Price: price}, nil).Once()
+
+	method, err := suite.service.UpdateMethod(context.Background(), model.MethodCourier, input)
+
+	suite.Nil(err)
+	suite.Equal(price, method.Price)
+}
+
+func (suite *DeliveryServiceSuite) TestService_UpdateMethodNegativePrice() {
+	price := money.FromMinor(-1)
+
+	method, err := suite.service.UpdateMethod(context.Background(), model.MethodCourier, model.UpdateMethod{Price: &price})
+
+	suite.Nil(method)
+	suite.ErrorIs(err, ErrInvalidPrice)
+}
+
+func (suite *DeliveryServiceSuite) TestService_UpdateMethodNotFound() {
+	suite.repo.On("GetMethod", mock.Anything, "drone").Return(nil, sql.ErrNoRows)
+
+	method, err := suite.service.UpdateMethod(context.Background(), "drone", model.UpdateMethod{})
+
+	suite.Nil(method)
+	suite.ErrorIs(err, ErrMethodNotFound)
+}
+
+// ====================================================================================================================
+
+func (suite *DeliveryServiceSuite) TestService_ResolveSavedAddress() {
+	suite.repo.On("GetMethod", mock.Anything, model.MethodCourier).
+		Return(&model.Method{Code: model.MethodCourier, Price: money.FromMinor(50000), Active: true}, nil)
+	suite.repo.On("GetAddress", mock.Anything, 1, 5).Return(&model.Address{ID: 5, UserID: 1, Destination: *destination()}, nil)
+
+	method, dest, err := suite.service.Resolve(context.Background(), 1, &model.DeliveryReq{Method: model.MethodCourier, AddressID: 5})
+
+	suite.Nil(err)
+	suite.Equal(money.FromMinor(50000), method.Price)
+	suite.Equal(destination(), dest)
+}
+
+func (suite *DeliveryServiceSuite) TestService_ResolveInlineAddress() {
+	suite.repo.On("GetMethod", mock.Anything, model.MethodPost).
+		Return(&model.Method{Code: model.MethodPost, Price: money.FromMinor(30000), Active: true}, nil)
+
+	method, dest, err := suite.service.Resolve(context.Background(), 1, &model.DeliveryReq{Method: model.MethodPost, Address: destination()})
+
+	suite.Nil(err)
+	suite.Equal(model.MethodPost, method.Code)
+	suite.Equal(destination(), dest)
+	suite.repo.AssertNotCalled(suite.T(), "GetAddress", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *DeliveryServiceSuite) TestService_ResolveAddressRequired() {
+	_, _, err := suite.service.Resolve(context.Background(), 1, &model.DeliveryReq{Method: model.MethodPost})
+	suite.ErrorIs(err, ErrAddressRequired)
+
+	_, _, err = suite.service.Resolve(context.Background(), 1, &model.DeliveryReq{Method: model.MethodPost, AddressID: 5, Address: destination()})
+	suite.ErrorIs(err, ErrAddressRequired)
+}
+
+func (suite *DeliveryServiceSuite) TestService_ResolveMethodUnavailable() {
+	suite.repo.On("GetMethod", mock.Anything, model.MethodPost).Return(&model.Method{Code: model.MethodPost, Active: false}, nil)
+
+	_, _, err := suite.service.Resolve(context.Background(), 1, &model.DeliveryReq{Method: model.MethodPost, AddressID: 5})
+
+	suite.ErrorIs(err, ErrMethodUnavailable)
+}
+
+func (suite *DeliveryServiceSuite) TestService_ResolveForeignAddress() {
+	suite.repo.On("GetMethod", mock.Anything, model.MethodCourier).Return(&model.Method{Code: model.MethodCourier, Active: true}, nil)
+	suite.repo.On("GetAddress", mock.Anything, 1, 9).Return(nil, sql.ErrNoRows)
+
+	_, _, err := suite.service.Resolve(context.Background(), 1, &model.DeliveryReq{Method: model.MethodCourier, AddressID: 9})
+
+	suite.ErrorIs(err, ErrAddressNotFound)
+	suite.True(IsDeliveryError(err))
+}
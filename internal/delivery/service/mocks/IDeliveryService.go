@@ -0,0 +1,266 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/aaanger/ecommerce/internal/delivery/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IDeliveryService is an autogenerated mock type for the IDeliveryService type
+type IDeliveryService struct {
+	mock.Mock
+}
+
+// CreateAddress provides a mock function with given fields: ctx, userID, req
+func (_m *IDeliveryService) CreateAddress(ctx context.Context, userID int, req *model.Destination) (*model.Address, error) {
+	ret := _m.Called(ctx, userID, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAddress")
+	}
+
+	var r0 *model.Address
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, *model.Destination) (*model.Address, error)); ok {
+		return rf(ctx, userID, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, *model.Destination) *model.Address); ok {
+		r0 = rf(ctx, userID, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Address)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, *model.Destination) error); ok {
+		r1 = rf(ctx, userID, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteAddress provides a mock function with given fields: ctx, userID, id
+func (_m *IDeliveryService) DeleteAddress(ctx context.Context, userID int, id int) error {
+	ret := _m.Called(ctx, userID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAddress")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = rf(ctx, userID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAddress provides a mock function with given fields: ctx, userID, id
+func (_m *IDeliveryService) GetAddress(ctx context.Context, userID int, id int) (*model.Address, error) {
+	ret := _m.Called(ctx, userID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAddress")
+	}
+
+	var r0 *model.Address
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (*model.Address, error)); ok {
+		return rf(ctx, userID, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) *model.Address); ok {
+		r0 = rf(ctx, userID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Address)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, userID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAddresses provides a mock function with given fields: ctx, userID
+func (_m *IDeliveryService) GetAddresses(ctx context.Context, userID int) ([]model.Address, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAddresses")
+	}
+
+	var r0 []model.Address
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]model.Address, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []model.Address); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Address)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMethods provides a mock function with given fields: ctx, activeOnly
+func (_m *IDeliveryService) GetMethods(ctx context.Context, activeOnly bool) ([]model.Method, error) {
+	ret := _m.Called(ctx, activeOnly)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMethods")
+	}
+
+	var r0 []model.Method
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, bool) ([]model.Method, error)); ok {
+		return rf(ctx, activeOnly)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, bool) []model.Method); ok {
+		r0 = rf(ctx, activeOnly)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Method)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, bool) error); ok {
+		r1 = rf(ctx, activeOnly)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Resolve provides a mock function with given fields: ctx, userID, req
+func (_m *IDeliveryService) Resolve(ctx context.Context, userID int, req *model.DeliveryReq) (*model.Method, *model.Destination, error) {
+	ret := _m.Called(ctx, userID, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Resolve")
+	}
+
+	var r0 *model.Method
+	var r1 *model.Destination
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, *model.DeliveryReq) (*model.Method, *model.Destination, error)); ok {
+		return rf(ctx, userID, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, *model.DeliveryReq) *model.Method); ok {
+		r0 = rf(ctx, userID, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Method)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, *model.DeliveryReq) *model.Destination); ok {
+		r1 = rf(ctx, userID, req)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*model.Destination)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int, *model.DeliveryReq) error); ok {
+		r2 = rf(ctx, userID, req)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// UpdateAddress provides a mock function with given fields: ctx, userID, id, req
+func (_m *IDeliveryService) UpdateAddress(ctx context.Context, userID int, id int, req *model.Destination) (*model.Address, error) {
+	ret := _m.Called(ctx, userID, id, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAddress")
+	}
+
+	var r0 *model.Address
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, *model.Destination) (*model.Address, error)); ok {
+		return rf(ctx, userID, id, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, *model.Destination) *model.Address); ok {
+		r0 = rf(ctx, userID, id, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Address)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, *model.Destination) error); ok {
+		r1 = rf(ctx, userID, id, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateMethod provides a mock function with given fields: ctx, code, input
+func (_m *IDeliveryService) UpdateMethod(ctx context.Context, code string, input model.UpdateMethod) (*model.Method, error) {
+	ret := _m.Called(ctx, code, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateMethod")
+	}
+
+	var r0 *model.Method
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.UpdateMethod) (*model.Method, error)); ok {
+		return rf(ctx, code, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.UpdateMethod) *model.Method); ok {
+		r0 = rf(ctx, code, input)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Method)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, model.UpdateMethod) error); ok {
+		r1 = rf(ctx, code, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewIDeliveryService creates a new instance of IDeliveryService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIDeliveryService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IDeliveryService {
+	mock := &IDeliveryService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -1,16 +1,26 @@
 package handler
 
 import (
+	"errors"
+	couponService "github.com/aaanger/ecommerce/internal/coupon/service"
+	deliveryService "github.com/aaanger/ecommerce/internal/delivery/service"
 	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/internal/order/repository"
 	"github.com/aaanger/ecommerce/internal/order/service"
 	"github.com/aaanger/ecommerce/pkg/middleware"
 	"github.com/aaanger/ecommerce/pkg/response"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
 	"net/http"
 	"strconv"
 )
 
+// maxIdempotencyKeyLength bounds the Idempotency-Key header, clients are expected to send a UUID.
+const maxIdempotencyKeyLength = 255
+
 type OrderHandler struct {
 	service  service.IOrderService
 	consumer *service.OrderConsumer
@@ -57,9 +67,29 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
+	req.IdempotencyKey = c.GetHeader("Idempotency-Key")
+	if len(req.IdempotencyKey) > maxIdempotencyKeyLength {
+		response.Error(c, http.StatusBadRequest, "Idempotency-Key is too long")
+		return
+	}
+
 	log.Info("Creating order", zap.Int("userID", userID), zap.Any("request data", req))
 
-	order, err := h.service.CreateOrder(c, userID, email, &req)
+	order, err := h.service.CreateOrder(c.Request.Context(), userID, email, &req)
+	if errors.Is(err, service.ErrIdempotencyKeyReused) || errors.Is(err, service.ErrIdempotencyInProgress) {
+		log.Warn("Create order: idempotency key conflict", zap.Error(err), zap.String("key", req.IdempotencyKey))
+		response.Error(c, http.StatusConflict, err.Error())
+		return
+	}
+	if couponService.IsCouponError(err) || deliveryService.IsDeliveryError(err) {
+		response.Error(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if status.Code(err) == codes.FailedPrecondition {
+		log.Warn("Create order: not enough stock", zap.Error(err), zap.Any("request data", req))
+		response.Error(c, http.StatusConflict, status.Convert(err).Message())
+		return
+	}
 	if err != nil {
 		log.Error("Failed to create order", zap.Error(err), zap.Any("request data", req))
 		response.Error(c, http.StatusInternalServerError, "Failed to create order")
@@ -71,7 +101,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 }
 
 func (h *OrderHandler) GetOrderByID(c *gin.Context) {
-	userID, err := middleware.GetUserID(c)
+	actor, err := getActor(c)
 	if err != nil {
 		response.Error(c, http.StatusUnauthorized, "user id not found")
 		return
@@ -83,46 +113,56 @@ func (h *OrderHandler) GetOrderByID(c *gin.Context) {
 		return
 	}
 
-	order, err := h.service.GetOrderByID(orderID)
+	order, err := h.service.GetOrderByID(c.Request.Context(), orderID, actor)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "Order not found")
-		return
-	}
-
-	if order.UserID != userID {
-		response.Error(c, http.StatusForbidden, "not your order")
+		transitionError(c, err, "Failed to get order")
 		return
 	}
 
 	response.JSON(c, http.StatusOK, order)
 }
 
+// GetAllOrders lists the caller's own orders a page at a time, moderators included.
 func (h *OrderHandler) GetAllOrders(c *gin.Context) {
-	userID, err := middleware.GetUserID(c)
+	h.listOrders(c, true)
+}
+
+// GetOrders lists the orders of all customers for moderators, optionally narrowed down with ?user_id=.
+func (h *OrderHandler) GetOrders(c *gin.Context) {
+	h.listOrders(c, false)
+}
+
+func (h *OrderHandler) listOrders(c *gin.Context, own bool) {
+	actor, err := getActor(c)
 	if err != nil {
 		response.Error(c, http.StatusUnauthorized, "user id not found")
 		return
 	}
 
-	orders, err := h.service.GetAllOrders(userID)
+	var req model.OrderListReq
+
+	err = c.ShouldBindQuery(&req)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "Orders not found")
+		response.Error(c, http.StatusBadRequest, "invalid query parameters")
 		return
 	}
 
-	var res []model.GetAllOrdersRes
+	if own {
+		req.UserID = actor.ID
+	}
 
-	for i := range orders {
-		res = append(res, model.GetAllOrdersRes{
-			ID:         orders[i].ID,
-			CreatedAt:  orders[i].CreatedAt,
-			UpdatedAt:  orders[i].UpdatedAt,
-			Status:     orders[i].Status,
-			TotalPrice: orders[i].TotalPrice,
-		})
+	orders, err := h.service.GetOrders(c.Request.Context(), actor, &req)
+	if errors.Is(err, service.ErrInvalidOrderFilter) || errors.Is(err, repository.ErrInvalidCursor) {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		h.log.Error("List orders: failed to get orders", zap.Error(err), zap.Int("userID", actor.ID))
+		response.Error(c, http.StatusInternalServerError, "Orders not found")
+		return
 	}
 
-	response.JSON(c, http.StatusOK, res)
+	response.JSON(c, http.StatusOK, orders)
 }
 
 func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
@@ -134,13 +174,13 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	role, ok := c.Get("role")
-	if !ok {
+	actor, err := getActor(c)
+	if err != nil {
 		response.Error(c, http.StatusUnauthorized, "role not found")
 		return
 	}
 
-	if role != "moderator" {
+	if actor.Role != model.RoleModerator {
 		response.Error(c, http.StatusForbidden, "moderator role required")
 		return
 	}
@@ -151,9 +191,9 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	order, err := h.service.UpdateOrderStatus(orderID, req.Status)
+	order, err := h.service.UpdateOrderStatus(c.Request.Context(), orderID, req.Status, actor, req.Reason)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "Failed to update order status")
+		transitionError(c, err, "Failed to update order status")
 		return
 	}
 
@@ -167,11 +207,97 @@ func (h *OrderHandler) CancelOrder(c *gin.Context) {
 		return
 	}
 
-	err = h.service.CancelOrder(c, orderID)
+	var req model.CancelOrderReq
+
+	err = c.ShouldBindJSON(&req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		response.Error(c, http.StatusBadRequest, "invalid input parameters")
+		return
+	}
+
+	actor, err := getActor(c)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	err = h.service.CancelOrder(c.Request.Context(), orderID, actor, req.Reason)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "Failed to cancel order")
+		transitionError(c, err, "Failed to cancel order")
 		return
 	}
 
 	response.JSON(c, http.StatusOK, "order canceled")
 }
+
+func (h *OrderHandler) GetStatusHistory(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid order id")
+		return
+	}
+
+	history, err := h.service.GetStatusHistory(c.Request.Context(), orderID, actor)
+	if err != nil {
+		transitionError(c, err, "Failed to get order history")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, history)
+}
+
+func getActor(c *gin.Context) (model.Actor, error) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return model.Actor{}, err
+	}
+
+	role, err := middleware.GetUserRole(c)
+	if err != nil {
+		return model.Actor{}, err
+	}
+
+	return model.Actor{ID: userID, Role: role}, nil
+}
+
+func (h *OrderHandler) GetOrderPayments(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid order id")
+		return
+	}
+
+	payments, err := h.service.GetOrderPayments(c.Request.Context(), orderID, actor)
+	if err != nil {
+		transitionError(c, err, "Failed to get order payments")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, payments)
+}
+
+// transitionError maps state machine and authorization errors to responses and falls back to a 500 with msg.
+func transitionError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound):
+		response.Error(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, model.ErrTransitionForbidden), errors.Is(err, service.ErrNotOrderOwner), errors.Is(err, service.ErrModeratorRequired):
+		response.Error(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, model.ErrInvalidTransition), errors.Is(err, repository.ErrStatusConflict), errors.Is(err, service.ErrPaymentNotHeld):
+		response.Error(c, http.StatusConflict, err.Error())
+	default:
+		response.Error(c, http.StatusInternalServerError, msg)
+	}
+}
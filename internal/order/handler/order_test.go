@@ -4,15 +4,25 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	deliveryModel "github.com/aaanger/ecommerce/internal/delivery/model"
+	deliveryService "github.com/aaanger/ecommerce/internal/delivery/service"
 	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/internal/order/repository"
+	"github.com/aaanger/ecommerce/internal/order/service"
 	"github.com/aaanger/ecommerce/internal/order/service/mocks"
+	paymentModel "github.com/aaanger/ecommerce/internal/payment/model"
 	"github.com/aaanger/ecommerce/pkg/lib"
+	"github.com/aaanger/ecommerce/pkg/money"
 	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 )
 
 type OrderHandlerSuite struct {
@@ -23,7 +33,7 @@ type OrderHandlerSuite struct {
 
 func (suite *OrderHandlerSuite) SetupTest() {
 	suite.service = mocks.NewIOrderService(suite.T())
-	suite.handler = NewOrderHandler(suite.service)
+	suite.handler = NewOrderHandler(suite.service, nil, zap.NewNop())
 }
 
 func TestOrderHandlerSuite(t *testing.T) {
@@ -44,6 +54,7 @@ func (suite *OrderHandlerSuite) TestHandler_CreateOrderOK() {
 				Quantity:  2,
 			},
 		},
+		Delivery: deliveryModel.DeliveryReq{Method: deliveryModel.MethodCourier, AddressID: 1},
 	}
 
 	res := &model.Order{
@@ -59,17 +70,18 @@ func (suite *OrderHandlerSuite) TestHandler_CreateOrderOK() {
 				Quantity:  2,
 			},
 		},
-		Status:     model.StatusOrderCreated,
-		TotalPrice: 123,
+		Status:     model.StatusCreated,
+		TotalPrice: money.FromMinor(12300),
 	}
 
-	suite.service.On("CreateOrder", 1, req).Return(res, nil).Times(1)
+	suite.service.On("CreateOrder", mock.Anything, 1, "test@test.com", req).Return(&model.CreateOrderRes{Order: res}, nil).Times(1)
 
 	requestBody, _ := json.Marshal(req)
 
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set("userID", 1)
+		c.Set("email", "test@test.com")
 		c.Next()
 	})
 	router.POST("/create", suite.handler.CreateOrder)
@@ -77,17 +89,48 @@ func (suite *OrderHandlerSuite) TestHandler_CreateOrderOK() {
 	r := httptest.NewRequest("POST", "/create", bytes.NewBuffer(requestBody))
 	router.ServeHTTP(w, r)
 
-	var response interface{}
-	var orderRes model.Order
+	var response model.CreateOrderRes
 	_ = json.Unmarshal(w.Body.Bytes(), &response)
-	lib.Copy(&orderRes, &response)
+	orderRes := response.Order
 
 	suite.Equal(http.StatusOK, w.Code)
-	suite.Equal(model.StatusOrderCreated, orderRes.Status)
-	suite.Equal(float64(123), orderRes.TotalPrice)
+	suite.Equal(model.StatusCreated, orderRes.Status)
+	suite.Equal(money.FromMinor(12300), orderRes.TotalPrice)
 	suite.Equal(2, len(orderRes.Lines))
 }
 
+func (suite *OrderHandlerSuite) TestHandler_CreateOrderIdempotencyKeyReused() {
+	req := &model.CreateOrderReq{
+		Lines: []model.OrderLineReq{
+			{
+				ProductID: 1,
+				Quantity:  1,
+			},
+		},
+		Delivery: deliveryModel.DeliveryReq{Method: deliveryModel.MethodCourier, AddressID: 1},
+	}
+
+	requestBody, _ := json.Marshal(req)
+	req.IdempotencyKey = "key-1"
+
+	suite.service.On("CreateOrder", mock.Anything, 1, "test@test.com", req).Return(nil, service.ErrIdempotencyKeyReused)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Set("email", "test@test.com")
+		c.Next()
+	})
+	router.POST("/create", suite.handler.CreateOrder)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/create", bytes.NewBuffer(requestBody))
+	r.Header.Set("Idempotency-Key", "key-1")
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusConflict, w.Code)
+	suite.Equal(`"idempotency key was already used with a different request"`, w.Body.String())
+}
+
 func (suite *OrderHandlerSuite) TestHandler_CreateOrderEmptyFields() {
 	req := &model.CreateOrderReq{
 		Lines: []model.OrderLineReq{
@@ -111,6 +154,25 @@ func (suite *OrderHandlerSuite) TestHandler_CreateOrderEmptyFields() {
 	suite.Equal(`"Failed to parse request body"`, w.Body.String())
 }
 
+func (suite *OrderHandlerSuite) TestHandler_CreateOrderNonPositiveQuantity() {
+	for _, quantity := range []int{0, -9} {
+		requestBody, _ := json.Marshal(&model.CreateOrderReq{
+			Lines: []model.OrderLineReq{{ProductID: 1, Quantity: 10}, {ProductID: 2, Quantity: quantity}},
+		})
+
+		router := gin.New()
+
+		router.POST("/create", suite.handler.CreateOrder)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/create", bytes.NewBuffer(requestBody))
+		router.ServeHTTP(w, r)
+
+		suite.Equal(http.StatusBadRequest, w.Code)
+		suite.Equal(`"Failed to parse request body"`, w.Body.String())
+	}
+}
+
 func (suite *OrderHandlerSuite) TestHandler_CreateOrderUnauthorized() {
 	req := &model.CreateOrderReq{
 		Lines: []model.OrderLineReq{
@@ -123,6 +185,7 @@ func (suite *OrderHandlerSuite) TestHandler_CreateOrderUnauthorized() {
 				Quantity:  2,
 			},
 		},
+		Delivery: deliveryModel.DeliveryReq{Method: deliveryModel.MethodCourier, AddressID: 1},
 	}
 
 	requestBody, _ := json.Marshal(req)
@@ -138,6 +201,36 @@ func (suite *OrderHandlerSuite) TestHandler_CreateOrderUnauthorized() {
 	suite.Equal(`"user id not found"`, w.Body.String())
 }
 
+func (suite *OrderHandlerSuite) TestHandler_CreateOrderDeliveryUnavailable() {
+	req := &model.CreateOrderReq{
+		Lines: []model.OrderLineReq{
+			{
+				ProductID: 1,
+				Quantity:  1,
+			},
+		},
+		Delivery: deliveryModel.DeliveryReq{Method: deliveryModel.MethodPost, AddressID: 1},
+	}
+
+	suite.service.On("CreateOrder", mock.Anything, 1, "test@test.com", req).Return(nil, deliveryService.ErrMethodUnavailable)
+
+	requestBody, _ := json.Marshal(req)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Set("email", "test@test.com")
+		c.Next()
+	})
+	router.POST("/create", suite.handler.CreateOrder)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/create", bytes.NewBuffer(requestBody))
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusUnprocessableEntity, w.Code)
+	suite.Equal(`"delivery method is not available"`, w.Body.String())
+}
+
 func (suite *OrderHandlerSuite) TestHandler_CreateOrderServiceFailure() {
 	req := &model.CreateOrderReq{
 		Lines: []model.OrderLineReq{
@@ -150,15 +243,17 @@ func (suite *OrderHandlerSuite) TestHandler_CreateOrderServiceFailure() {
 				Quantity:  2,
 			},
 		},
+		Delivery: deliveryModel.DeliveryReq{Method: deliveryModel.MethodCourier, AddressID: 1},
 	}
 
-	suite.service.On("CreateOrder", 1, req).Return(nil, errors.New("error"))
+	suite.service.On("CreateOrder", mock.Anything, 1, "test@test.com", req).Return(nil, errors.New("error"))
 
 	requestBody, _ := json.Marshal(req)
 
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set("userID", 1)
+		c.Set("email", "test@test.com")
 		c.Next()
 	})
 	router.POST("/create", suite.handler.CreateOrder)
@@ -173,7 +268,7 @@ func (suite *OrderHandlerSuite) TestHandler_CreateOrderServiceFailure() {
 // =====================================================================================================================
 
 func (suite *OrderHandlerSuite) TestHandler_GetOrderByIDSuccess() {
-	suite.service.On("GetOrderByID", 1, 1).Return(&model.Order{
+	suite.service.On("GetOrderByID", mock.Anything, 1, model.Actor{ID: 1, Role: model.RoleUser}).Return(&model.Order{
 		ID:     1,
 		UserID: 1,
 		Lines: []model.OrderLine{
@@ -186,13 +281,14 @@ func (suite *OrderHandlerSuite) TestHandler_GetOrderByIDSuccess() {
 				Quantity:  2,
 			},
 		},
-		Status:     model.StatusOrderCreated,
-		TotalPrice: 123,
+		Status:     model.StatusCreated,
+		TotalPrice: money.FromMinor(12300),
 	}, nil)
 
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set("userID", 1)
+		c.Set("role", "user")
 		c.AddParam("id", strconv.Itoa(1))
 		c.Next()
 	})
@@ -208,8 +304,8 @@ func (suite *OrderHandlerSuite) TestHandler_GetOrderByIDSuccess() {
 	lib.Copy(&orderRes, &response)
 
 	suite.Equal(http.StatusOK, w.Code)
-	suite.Equal(model.StatusOrderCreated, orderRes.Status)
-	suite.Equal(float64(123), orderRes.TotalPrice)
+	suite.Equal(model.StatusCreated, orderRes.Status)
+	suite.Equal(money.FromMinor(12300), orderRes.TotalPrice)
 	suite.Equal(2, len(orderRes.Lines))
 }
 
@@ -229,12 +325,53 @@ func (suite *OrderHandlerSuite) TestHandler_GetOrderByIDUnauthorized() {
 	suite.Equal(`"user id not found"`, w.Body.String())
 }
 
+func (suite *OrderHandlerSuite) TestHandler_GetOrderByIDNotOwner() {
+	suite.service.On("GetOrderByID", mock.Anything, 1, model.Actor{ID: 2, Role: model.RoleUser}).Return(nil, service.ErrNotOrderOwner)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 2)
+		c.Set("role", "user")
+		c.AddParam("id", strconv.Itoa(1))
+		c.Next()
+	})
+	router.GET("/:id", suite.handler.GetOrderByID)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/1", nil)
+
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusForbidden, w.Code)
+	suite.Equal(`"not your order"`, w.Body.String())
+}
+
+func (suite *OrderHandlerSuite) TestHandler_GetOrderByIDNotFound() {
+	suite.service.On("GetOrderByID", mock.Anything, 1, model.Actor{ID: 1, Role: model.RoleUser}).Return(nil, service.ErrOrderNotFound)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Set("role", "user")
+		c.AddParam("id", strconv.Itoa(1))
+		c.Next()
+	})
+	router.GET("/:id", suite.handler.GetOrderByID)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/1", nil)
+
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusNotFound, w.Code)
+	suite.Equal(`"order not found"`, w.Body.String())
+}
+
 func (suite *OrderHandlerSuite) TestHandler_GetOrderByIDServiceFailure() {
-	suite.service.On("GetOrderByID", 1, 1).Return(nil, errors.New("error"))
+	suite.service.On("GetOrderByID", mock.Anything, 1, model.Actor{ID: 1, Role: model.RoleUser}).Return(nil, errors.New("error"))
 
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set("userID", 1)
+		c.Set("role", "user")
 		c.AddParam("id", strconv.Itoa(1))
 		c.Next()
 	})
@@ -245,50 +382,96 @@ func (suite *OrderHandlerSuite) TestHandler_GetOrderByIDServiceFailure() {
 	router.ServeHTTP(w, r)
 
 	suite.Equal(http.StatusInternalServerError, w.Code)
-	suite.Equal(`"Order not found"`, w.Body.String())
+	suite.Equal(`"Failed to get order"`, w.Body.String())
 }
 
 // =====================================================================================================================
 
-func (suite *OrderHandlerSuite) TestHandler_GetAllOrdersSuccess() {
-	suite.service.On("GetAllOrders", 1).Return([]model.Order{
-		{
-			ID:     1,
-			UserID: 1,
-			Lines: []model.OrderLine{
-				{
-					ProductID: 1,
-					Quantity:  1,
-				},
-				{
-					ProductID: 2,
-					Quantity:  2,
-				},
-			},
-			Status:     model.StatusOrderCreated,
-			TotalPrice: 123,
-		},
-	}, nil)
-
+func (suite *OrderHandlerSuite) listRouter(userID int, role string) *gin.Engine {
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
-		c.Set("userID", 1)
+		c.Set("userID", userID)
+		c.Set("role", role)
 		c.Next()
 	})
 	router.GET("/all", suite.handler.GetAllOrders)
+	router.GET("/admin/orders", suite.handler.GetOrders)
+	return router
+}
+
+func (suite *OrderHandlerSuite) TestHandler_GetAllOrdersSuccess() {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	expected := &model.OrderListReq{UserID: 1, Status: model.StatusCreated, From: from, MinTotal: money.FromMinor(10000), Cursor: "abc", Limit: 10}
+	suite.service.On("GetOrders", mock.Anything, model.Actor{ID: 1, Role: model.RoleUser}, expected).Return(&model.OrderListRes{
+		Orders: []model.GetAllOrdersRes{
+			{ID: 1, UserID: 1, Status: model.StatusCreated, TotalPrice: money.FromMinor(12300)},
+		},
+		NextCursor: "def",
+		Limit:      10,
+	}, nil)
+
 	w := httptest.NewRecorder()
-	r := httptest.NewRequest("GET", "/all", nil)
+	r := httptest.NewRequest("GET", "/all?status=Created&from=2024-05-01T00:00:00Z&min_total=100&cursor=abc&limit=10", nil)
 
-	router.ServeHTTP(w, r)
+	suite.listRouter(1, "user").ServeHTTP(w, r)
 
-	var response interface{}
-	var orderRes []model.Order
-	_ = json.Unmarshal(w.Body.Bytes(), &response)
-	lib.Copy(&orderRes, &response)
+	var res model.OrderListRes
+	_ = json.Unmarshal(w.Body.Bytes(), &res)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(model.StatusCreated, res.Orders[0].Status)
+	suite.Equal(money.FromMinor(12300), res.Orders[0].TotalPrice)
+	suite.Equal("def", res.NextCursor)
+}
+
+func (suite *OrderHandlerSuite) TestHandler_GetAllOrdersOwnForModerator() {
+	suite.service.On("GetOrders", mock.Anything, model.Actor{ID: 5, Role: model.RoleModerator}, &model.OrderListReq{UserID: 5}).
+		Return(&model.OrderListRes{Orders: []model.GetAllOrdersRes{}}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/all?user_id=1", nil)
+
+	suite.listRouter(5, "moderator").ServeHTTP(w, r)
+
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *OrderHandlerSuite) TestHandler_GetOrdersModerator() {
+	suite.service.On("GetOrders", mock.Anything, model.Actor{ID: 5, Role: model.RoleModerator}, &model.OrderListReq{UserID: 1, Email: "test@test.com"}).
+		Return(&model.OrderListRes{Orders: []model.GetAllOrdersRes{{ID: 1, UserID: 1, UserEmail: "test@test.com"}}}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/admin/orders?user_id=1&email=test@test.com", nil)
+
+	suite.listRouter(5, "moderator").ServeHTTP(w, r)
+
+	var res model.OrderListRes
+	_ = json.Unmarshal(w.Body.Bytes(), &res)
 
 	suite.Equal(http.StatusOK, w.Code)
-	suite.Equal(model.StatusOrderCreated, orderRes[0].Status)
-	suite.Equal(float64(123), orderRes[0].TotalPrice)
+	suite.Equal("test@test.com", res.Orders[0].UserEmail)
+}
+
+func (suite *OrderHandlerSuite) TestHandler_GetAllOrdersInvalidDate() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/all?from=yesterday", nil)
+
+	suite.listRouter(1, "user").ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(`"invalid query parameters"`, w.Body.String())
+}
+
+func (suite *OrderHandlerSuite) TestHandler_GetAllOrdersInvalidCursor() {
+	suite.service.On("GetOrders", mock.Anything, mock.Anything, mock.Anything).Return(nil, repository.ErrInvalidCursor)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/all?cursor=garbage", nil)
+
+	suite.listRouter(1, "user").ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(`"invalid cursor"`, w.Body.String())
 }
 
 func (suite *OrderHandlerSuite) TestHandler_GetAllOrdersUnauthorized() {
@@ -305,19 +488,12 @@ func (suite *OrderHandlerSuite) TestHandler_GetAllOrdersUnauthorized() {
 }
 
 func (suite *OrderHandlerSuite) TestHandler_GetAllOrdersServiceFailure() {
-	suite.service.On("GetAllOrders", 1).Return(nil, errors.New("error"))
-
-	router := gin.New()
-	router.Use(func(c *gin.Context) {
-		c.Set("userID", 1)
-		c.Next()
-	})
+	suite.service.On("GetOrders", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("error"))
 
-	router.GET("/all", suite.handler.GetAllOrders)
 	w := httptest.NewRecorder()
 	r := httptest.NewRequest("GET", "/all", nil)
 
-	router.ServeHTTP(w, r)
+	suite.listRouter(1, "user").ServeHTTP(w, r)
 
 	suite.Equal(http.StatusInternalServerError, w.Code)
 	suite.Equal(`"Orders not found"`, w.Body.String())
@@ -328,10 +504,10 @@ func (suite *OrderHandlerSuite) TestHandler_GetAllOrdersServiceFailure() {
 func (suite *OrderHandlerSuite) TestHandler_UpdateOrderStatusSuccess() {
 	req := &model.UpdateOrderStatusReq{
 		UserID: 1,
-		Status: model.StatusOrderDelivering,
+		Status: model.StatusDelivering,
 	}
 
-	suite.service.On("UpdateOrderStatus", 1, 1, model.StatusOrderDelivering).Return(&model.Order{
+	suite.service.On("UpdateOrderStatus", mock.Anything, 1, model.StatusDelivering, model.Actor{ID: 2, Role: model.RoleModerator}, "").Return(&model.Order{
 		ID:     1,
 		UserID: 1,
 		Lines: []model.OrderLine{
@@ -344,8 +520,8 @@ func (suite *OrderHandlerSuite) TestHandler_UpdateOrderStatusSuccess() {
 				Quantity:  2,
 			},
 		},
-		Status:     model.StatusOrderDelivering,
-		TotalPrice: 123,
+		Status:     model.StatusDelivering,
+		TotalPrice: money.FromMinor(12300),
 	}, nil)
 
 	router := gin.New()
@@ -370,13 +546,13 @@ func (suite *OrderHandlerSuite) TestHandler_UpdateOrderStatusSuccess() {
 	lib.Copy(&orderRes, &res)
 
 	suite.Equal(http.StatusOK, w.Code)
-	suite.Equal(model.StatusOrderDelivering, orderRes.Status)
+	suite.Equal(model.StatusDelivering, orderRes.Status)
 }
 
 func (suite *OrderHandlerSuite) TestHandler_UpdateOrderStatusForbidden() {
 	req := &model.UpdateOrderStatusReq{
 		UserID: 1,
-		Status: model.StatusOrderDelivering,
+		Status: model.StatusDelivering,
 	}
 
 	router := gin.New()
@@ -399,6 +575,35 @@ func (suite *OrderHandlerSuite) TestHandler_UpdateOrderStatusForbidden() {
 	suite.Equal(`"moderator role required"`, w.Body.String())
 }
 
+func (suite *OrderHandlerSuite) TestHandler_UpdateOrderStatusPaymentNotHeld() {
+	req := &model.UpdateOrderStatusReq{
+		UserID: 1,
+		Status: model.StatusDelivering,
+	}
+
+	suite.service.On("UpdateOrderStatus", mock.Anything, 1, model.StatusDelivering, model.Actor{ID: 2, Role: model.RoleModerator}, "").
+		Return(nil, service.ErrPaymentNotHeld)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 2)
+		c.Set("role", "moderator")
+		c.AddParam("id", strconv.Itoa(1))
+		c.Next()
+	})
+	router.PUT("/update-status/:id", suite.handler.UpdateOrderStatus)
+
+	requestBody, _ := json.Marshal(req)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("PUT", "/update-status/1", bytes.NewBuffer(requestBody))
+
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusConflict, w.Code)
+	suite.Equal(`"order payment is neither held nor captured"`, w.Body.String())
+}
+
 func (suite *OrderHandlerSuite) TestHandler_UpdateOrderStatusEmptyFields() {
 	req := &model.UpdateOrderStatusReq{
 		UserID: 1,
@@ -430,7 +635,8 @@ func (suite *OrderHandlerSuite) TestHandler_UpdateOrderStatusInvalidStatus() {
 		Status: "invalid",
 	}
 
-	suite.service.On("UpdateOrderStatus", req.UserID, 1, req.Status).Return(nil, errors.New("invalid order status"))
+	suite.service.On("UpdateOrderStatus", mock.Anything, 1, req.Status, model.Actor{ID: 2, Role: model.RoleModerator}, "").
+		Return(nil, fmt.Errorf("%w: %s to %s", model.ErrInvalidTransition, model.StatusCreated, req.Status))
 
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
@@ -448,17 +654,17 @@ func (suite *OrderHandlerSuite) TestHandler_UpdateOrderStatusInvalidStatus() {
 
 	router.ServeHTTP(w, r)
 
-	suite.Equal(http.StatusInternalServerError, w.Code)
-	suite.Equal(`"Failed to update order status"`, w.Body.String())
+	suite.Equal(http.StatusConflict, w.Code)
+	suite.Equal(`"order status transition is not allowed: Created to invalid"`, w.Body.String())
 }
 
 func (suite *OrderHandlerSuite) TestHandler_UpdateOrderStatusServiceFailure() {
 	req := &model.UpdateOrderStatusReq{
 		UserID: 1,
-		Status: model.StatusOrderDelivering,
+		Status: model.StatusDelivering,
 	}
 
-	suite.service.On("UpdateOrderStatus", req.UserID, 1, req.Status).Return(nil, errors.New("error"))
+	suite.service.On("UpdateOrderStatus", mock.Anything, 1, req.Status, model.Actor{ID: 2, Role: model.RoleModerator}, "").Return(nil, errors.New("error"))
 
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
@@ -483,44 +689,23 @@ func (suite *OrderHandlerSuite) TestHandler_UpdateOrderStatusServiceFailure() {
 // ====================================================================================================================
 
 func (suite *OrderHandlerSuite) TestHandler_CancelOrderSuccess() {
-	suite.service.On("CancelOrder", 1, 1).Return(&model.Order{
-		ID:     1,
-		UserID: 1,
-		Lines: []model.OrderLine{
-			{
-				ProductID: 1,
-				Quantity:  1,
-			},
-			{
-				ProductID: 2,
-				Quantity:  2,
-			},
-		},
-		Status:     model.StatusOrderCreated,
-		TotalPrice: 123,
-	}, nil)
+	suite.service.On("CancelOrder", mock.Anything, 1, model.Actor{ID: 1, Role: model.RoleUser}, "changed my mind").Return(nil)
 
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set("userID", 1)
+		c.Set("role", "user")
 		c.AddParam("id", strconv.Itoa(1))
 		c.Next()
 	})
 	router.POST("/cancel/:id", suite.handler.CancelOrder)
 	w := httptest.NewRecorder()
-	r := httptest.NewRequest("POST", "/cancel/1", nil)
+	r := httptest.NewRequest("POST", "/cancel/1", bytes.NewBufferString(`{"reason": "changed my mind"}`))
 
 	router.ServeHTTP(w, r)
 
-	var res interface{}
-	var orderRes model.Order
-	_ = json.Unmarshal(w.Body.Bytes(), &res)
-	lib.Copy(&orderRes, &res)
-
 	suite.Equal(http.StatusOK, w.Code)
-	suite.Equal(model.StatusOrderCreated, orderRes.Status)
-	suite.Equal(float64(123), orderRes.TotalPrice)
-	suite.Equal(2, len(orderRes.Lines))
+	suite.Equal(`"order canceled"`, w.Body.String())
 }
 
 func (suite *OrderHandlerSuite) TestHandler_CancelOrderUnauthorized() {
@@ -539,12 +724,33 @@ func (suite *OrderHandlerSuite) TestHandler_CancelOrderUnauthorized() {
 	suite.Equal(`"user id not found"`, w.Body.String())
 }
 
+func (suite *OrderHandlerSuite) TestHandler_CancelOrderForbidden() {
+	suite.service.On("CancelOrder", mock.Anything, 1, model.Actor{ID: 1, Role: model.RoleUser}, "").
+		Return(fmt.Errorf("%w: %s to %s as user", model.ErrTransitionForbidden, model.StatusDelivering, model.StatusCanceled))
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Set("role", "user")
+		c.AddParam("id", strconv.Itoa(1))
+		c.Next()
+	})
+	router.POST("/cancel/:id", suite.handler.CancelOrder)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/cancel/1", nil)
+
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusForbidden, w.Code)
+}
+
 func (suite *OrderHandlerSuite) TestHandler_CancelOrderServiceFailure() {
-	suite.service.On("CancelOrder", 1, 1).Return(nil, errors.New("error"))
+	suite.service.On("CancelOrder", mock.Anything, 1, model.Actor{ID: 1, Role: model.RoleUser}, "").Return(errors.New("error"))
 
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set("userID", 1)
+		c.Set("role", "user")
 		c.AddParam("id", strconv.Itoa(1))
 		c.Next()
 	})
@@ -557,3 +763,112 @@ func (suite *OrderHandlerSuite) TestHandler_CancelOrderServiceFailure() {
 	suite.Equal(http.StatusInternalServerError, w.Code)
 	suite.Equal(`"Failed to cancel order"`, w.Body.String())
 }
+
+// ====================================================================================================================
+
+func (suite *OrderHandlerSuite) historyRouter(userID int, role string) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", userID)
+		c.Set("role", role)
+		c.Next()
+	})
+	router.GET("/:id/history", suite.handler.GetStatusHistory)
+	return router
+}
+
+func (suite *OrderHandlerSuite) TestHandler_GetStatusHistorySuccess() {
+	suite.service.On("GetStatusHistory", mock.Anything, 1, model.Actor{ID: 1, Role: model.RoleUser}).Return([]model.StatusChange{
+		{ID: 1, OrderID: 1, ToStatus: model.StatusPending, ActorID: 1, ActorRole: model.RoleUser},
+		{ID: 2, OrderID: 1, FromStatus: model.StatusPending, ToStatus: model.StatusCreated, ActorRole: model.RoleSystem, Reason: "payment succeeded"},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/1/history", nil)
+
+	suite.historyRouter(1, "user").ServeHTTP(w, r)
+
+	var history []model.StatusChange
+	_ = json.Unmarshal(w.Body.Bytes(), &history)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Len(history, 2)
+	suite.Equal("payment succeeded", history[1].Reason)
+}
+
+func (suite *OrderHandlerSuite) TestHandler_GetStatusHistoryModerator() {
+	suite.service.On("GetStatusHistory", mock.Anything, 1, model.Actor{ID: 2, Role: model.RoleModerator}).Return([]model.StatusChange{}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/1/history", nil)
+
+	suite.historyRouter(2, "moderator").ServeHTTP(w, r)
+
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *OrderHandlerSuite) TestHandler_GetStatusHistoryForbidden() {
+	suite.service.On("GetStatusHistory", mock.Anything, 1, model.Actor{ID: 2, Role: model.RoleUser}).Return(nil, service.ErrNotOrderOwner)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/1/history", nil)
+
+	suite.historyRouter(2, "user").ServeHTTP(w, r)
+
+	suite.Equal(http.StatusForbidden, w.Code)
+	suite.Equal(`"not your order"`, w.Body.String())
+}
+
+// ====================================================================================================================
+
+func (suite *OrderHandlerSuite) paymentsRouter(userID int, role string) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", userID)
+		c.Set("role", role)
+		c.Next()
+	})
+	router.GET("/:id/payments", suite.handler.GetOrderPayments)
+	return router
+}
+
+func (suite *OrderHandlerSuite) TestHandler_GetOrderPaymentsSuccess() {
+	suite.service.On("GetOrderPayments", mock.Anything, 1, model.Actor{ID: 1, Role: model.RoleUser}).Return([]paymentModel.Payment{
+		{ID: 1, ProviderPaymentID: "pay-1", OrderID: 1, Amount: money.FromMinor(12300), Status: "canceled"},
+		{ID: 2, ProviderPaymentID: "pay-2", OrderID: 1, Amount: money.FromMinor(12300), Status: "succeeded"},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/1/payments", nil)
+
+	suite.paymentsRouter(1, "user").ServeHTTP(w, r)
+
+	var payments []paymentModel.Payment
+	_ = json.Unmarshal(w.Body.Bytes(), &payments)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Len(payments, 2)
+	suite.Equal("succeeded", payments[1].Status)
+}
+
+func (suite *OrderHandlerSuite) TestHandler_GetOrderPaymentsForbidden() {
+	suite.service.On("GetOrderPayments", mock.Anything, 1, model.Actor{ID: 2, Role: model.RoleUser}).Return(nil, service.ErrNotOrderOwner)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/1/payments", nil)
+
+	suite.paymentsRouter(2, "user").ServeHTTP(w, r)
+
+	suite.Equal(http.StatusForbidden, w.Code)
+}
+
+func (suite *OrderHandlerSuite) TestHandler_GetOrderPaymentsNotFound() {
+	suite.service.On("GetOrderPayments", mock.Anything, 1, model.Actor{ID: 1, Role: model.RoleUser}).Return(nil, service.ErrOrderNotFound)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/1/payments", nil)
+
+	suite.paymentsRouter(1, "user").ServeHTTP(w, r)
+
+	suite.Equal(http.StatusNotFound, w.Code)
+}
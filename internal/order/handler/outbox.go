@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/internal/order/repository"
+	"github.com/aaanger/ecommerce/internal/order/service"
+	"github.com/aaanger/ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultOutboxLimit = 50
+	maxOutboxLimit     = 500
+)
+
+type OutboxHandler struct {
+	service service.IOutboxService
+	log     *zap.Logger
+}
+
+func NewOutboxHandler(service service.IOutboxService, log *zap.Logger) *OutboxHandler {
+	return &OutboxHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// GetMessages lists outbox messages, failed ones unless ?status= asks for another status or "all".
+func (h *OutboxHandler) GetMessages(c *gin.Context) {
+	status := c.DefaultQuery("status", model.OutboxFailed)
+	switch status {
+	case model.OutboxPending, model.OutboxSent, model.OutboxFailed:
+	case "all":
+		status = ""
+	default:
+		response.Error(c, http.StatusBadRequest, "invalid status")
+		return
+	}
+
+	limit := defaultOutboxLimit
+	if param := c.Query("limit"); param != "" {
+		var err error
+		limit, err = strconv.Atoi(param)
+		if err != nil || limit < 1 || limit > maxOutboxLimit {
+			response.Error(c, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	messages, err := h.service.GetMessages(c.Request.Context(), status, limit)
+	if err != nil {
+		h.log.Error("Get outbox messages: failed to get messages", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "Failed to get outbox messages")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, messages)
+}
+
+func (h *OutboxHandler) RetryMessage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid message id")
+		return
+	}
+
+	err = h.service.RetryMessage(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrOutboxMessageNotFound) {
+		response.Error(c, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		h.log.Error("Retry outbox message: failed to requeue message", zap.Error(err), zap.Int("messageID", id))
+		response.Error(c, http.StatusInternalServerError, "Failed to retry outbox message")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, "message queued for retry")
+}
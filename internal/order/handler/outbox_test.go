@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/internal/order/repository"
+	"github.com/aaanger/ecommerce/internal/order/service/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type OutboxHandlerSuite struct {
+	suite.Suite
+	service *mocks.IOutboxService
+	handler *OutboxHandler
+	router  *gin.Engine
+}
+
+func (suite *OutboxHandlerSuite) SetupTest() {
+	suite.service = mocks.NewIOutboxService(suite.T())
+	suite.handler = NewOutboxHandler(suite.service, zap.NewNop())
+
+	suite.router = gin.New()
+	suite.router.GET("/admin/outbox", suite.handler.GetMessages)
+	suite.router.POST("/admin/outbox/:id/retry", suite.handler.RetryMessage)
+}
+
+func TestOutboxHandlerSuite(t *testing.T) {
+	suite.Run(t, new(OutboxHandlerSuite))
+}
+
+// =====================================================================================================================
+
+func (suite *OutboxHandlerSuite) TestHandler_GetMessagesFailedByDefault() {
+	suite.service.On("GetMessages", mock.Anything, model.OutboxFailed, defaultOutboxLimit).Return([]model.OutboxMessage{
+		{ID: 1, Topic: "order_created", Key: "1", Payload: json.RawMessage(`{"id":1}`), Status: model.OutboxFailed, Attempts: 10, LastError: "broker unavailable"},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/admin/outbox", nil)
+	suite.router.ServeHTTP(w, r)
+
+	var messages []model.OutboxMessage
+	_ = json.Unmarshal(w.Body.Bytes(), &messages)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Len(messages, 1)
+	suite.Equal("broker unavailable", messages[0].LastError)
+}
+
+func (suite *OutboxHandlerSuite) TestHandler_GetMessagesAll() {
+	suite.service.On("GetMessages", mock.Anything, "", 10).Return(nil, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/admin/outbox?status=all&limit=10", nil)
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *OutboxHandlerSuite) TestHandler_GetMessagesInvalidStatus() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/admin/outbox?status=lost", nil)
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *OutboxHandlerSuite) TestHandler_GetMessagesInvalidLimit() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/admin/outbox?limit=1000", nil)
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+// =====================================================================================================================
+
+func (suite *OutboxHandlerSuite) TestHandler_RetryMessageOK() {
+	suite.service.On("RetryMessage", mock.Anything, 1).Return(nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/admin/outbox/1/retry", nil)
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *OutboxHandlerSuite) TestHandler_RetryMessageNotFailed() {
+	suite.service.On("RetryMessage", mock.Anything, 1).Return(repository.ErrOutboxMessageNotFound)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/admin/outbox/1/retry", nil)
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusNotFound, w.Code)
+}
+
+func (suite *OutboxHandlerSuite) TestHandler_RetryMessageFailure() {
+	suite.service.On("RetryMessage", mock.Anything, 1).Return(errors.New("db error"))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/admin/outbox/1/retry", nil)
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusInternalServerError, w.Code)
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"errors"
+	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/internal/order/repository"
+	"github.com/aaanger/ecommerce/internal/order/service"
+	"github.com/aaanger/ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultReturnLimit = 50
+	maxReturnLimit     = 500
+)
+
+type ReturnHandler struct {
+	service service.IReturnService
+	log     *zap.Logger
+}
+
+func NewReturnHandler(service service.IReturnService, log *zap.Logger) *ReturnHandler {
+	return &ReturnHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+func (h *ReturnHandler) RequestReturn(c *gin.Context) {
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid order id")
+		return
+	}
+
+	var req model.ReturnReq
+
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid input parameters")
+		return
+	}
+
+	actor, err := getActor(c)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	ret, err := h.service.RequestReturn(c.Request.Context(), actor, orderID, &req)
+	if err != nil {
+		h.returnError(c, err, "Failed to request return")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, ret)
+}
+
+func (h *ReturnHandler) GetOrderReturns(c *gin.Context) {
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid order id")
+		return
+	}
+
+	actor, err := getActor(c)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	returns, err := h.service.GetOrderReturns(c.Request.Context(), actor, orderID)
+	if err != nil {
+		h.returnError(c, err, "Failed to get returns")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, returns)
+}
+
+// GetReturns lists returns waiting for review unless ?status= asks for another status or "all".
+func (h *ReturnHandler) GetReturns(c *gin.Context) {
+	status := c.DefaultQuery("status", model.ReturnRequested)
+	switch status {
+	case model.ReturnRequested, model.ReturnApproved, model.ReturnRejected, model.ReturnRefunded:
+	case "all":
+		status = ""
+	default:
+		response.Error(c, http.StatusBadRequest, "invalid status")
+		return
+	}
+
+	limit := defaultReturnLimit
+	if param := c.Query("limit"); param != "" {
+		var err error
+		limit, err = strconv.Atoi(param)
+		if err != nil || limit < 1 || limit > maxReturnLimit {
+			response.Error(c, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	returns, err := h.service.GetReturns(c.Request.Context(), status, limit)
+	if err != nil {
+		h.log.Error("Get returns: failed to get returns", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "Failed to get returns")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, returns)
+}
+
+func (h *ReturnHandler) ApproveReturn(c *gin.Context) {
+	id, req, ok := h.bindReview(c)
+	if !ok {
+		return
+	}
+
+	ret, err := h.service.ApproveReturn(c.Request.Context(), id, req.Comment)
+	if err != nil {
+		h.returnError(c, err, "Failed to approve return")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, ret)
+}
+
+func (h *ReturnHandler) RejectReturn(c *gin.Context) {
+	id, req, ok := h.bindReview(c)
+	if !ok {
+		return
+	}
+
+	ret, err := h.service.RejectReturn(c.Request.Context(), id, req.Comment)
+	if err != nil {
+		h.returnError(c, err, "Failed to reject return")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, ret)
+}
+
+// bindReview reads the return id and the optional review body, answering with 400 if either is invalid.
+func (h *ReturnHandler) bindReview(c *gin.Context) (int, model.ReviewReturnReq, bool) {
+	var req model.ReviewReturnReq
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "invalid return id")
+		return 0, req, false
+	}
+
+	err = c.ShouldBindJSON(&req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		response.Error(c, http.StatusBadRequest, "invalid input parameters")
+		return 0, req, false
+	}
+
+	return id, req, true
+}
+
+// returnError maps return errors to responses and falls back to a 500 with msg.
+func (h *ReturnHandler) returnError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound), errors.Is(err, service.ErrReturnNotFound):
+		response.Error(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrNotOrderOwner):
+		response.Error(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrOrderNotReturnable), errors.Is(err, service.ErrReturnRefunded), errors.Is(err, repository.ErrReturnStatusConflict):
+		response.Error(c, http.StatusConflict, err.Error())
+	case errors.Is(err, repository.ErrOrderLineNotFound), errors.Is(err, repository.ErrReturnQuantityExceeded):
+		response.Error(c, http.StatusUnprocessableEntity, err.Error())
+	default:
+		h.log.Error(msg, zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, msg)
+	}
+}
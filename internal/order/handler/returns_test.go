@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/internal/order/repository"
+	"github.com/aaanger/ecommerce/internal/order/service"
+	"github.com/aaanger/ecommerce/internal/order/service/mocks"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type ReturnHandlerSuite struct {
+	suite.Suite
+	service *mocks.IReturnService
+	handler *ReturnHandler
+	router  *gin.Engine
+}
+
+func (suite *ReturnHandlerSuite) SetupTest() {
+	suite.service = mocks.NewIReturnService(suite.T())
+	suite.handler = NewReturnHandler(suite.service, zap.NewNop())
+
+	suite.router = gin.New()
+	suite.router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Set("role", model.RoleUser)
+		c.Next()
+	})
+	suite.router.POST("/orders/:id/returns", suite.handler.RequestReturn)
+	suite.router.GET("/orders/:id/returns", suite.handler.GetOrderReturns)
+	suite.router.GET("/admin/returns", suite.handler.GetReturns)
+	suite.router.POST("/admin/returns/:id/approve", suite.handler.ApproveReturn)
+	suite.router.POST("/admin/returns/:id/reject", suite.handler.RejectReturn)
+}
+
+func TestReturnHandlerSuite(t *testing.T) {
+	suite.Run(t, new(ReturnHandlerSuite))
+}
+
+// =====================================================================================================================
+
+func (suite *ReturnHandlerSuite) TestHandler_RequestReturnSuccess() {
+	req := &model.ReturnReq{OrderLineID: 10, Quantity: 1, Reason: "broken"}
+
+	suite.service.On("RequestReturn", mock.Anything, model.Actor{ID: 1, Role: model.RoleUser}, 1, req).
+		Return(&model.Return{ID: 7, OrderID: 1, OrderLineID: 10, Quantity: 1, Reason: "broken", Status: model.ReturnRequested}, nil)
+
+	requestBody, _ := json.Marshal(req)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/orders/1/returns", bytes.NewBuffer(requestBody))
+	suite.router.ServeHTTP(w, r)
+
+	var ret model.Return
+	_ = json.Unmarshal(w.Body.Bytes(), &ret)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(7, ret.ID)
+	suite.Equal(model.ReturnRequested, ret.Status)
+}
+
+func (suite *ReturnHandlerSuite) TestHandler_RequestReturnZeroQuantity() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/orders/1/returns", bytes.NewBufferString(`{"order_line_id": 10, "quantity": 0, "reason": "broken"}`))
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *ReturnHandlerSuite) TestHandler_RequestReturnQuantityExceeded() {
+	suite.service.On("RequestReturn", mock.Anything, mock.Anything, 1, mock.Anything).Return(nil, repository.ErrReturnQuantityExceeded)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/orders/1/returns", bytes.NewBufferString(`{"order_line_id": 10, "quantity": 5, "reason": "broken"}`))
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusUnprocessableEntity, w.Code)
+}
+
+func (suite *ReturnHandlerSuite) TestHandler_GetOrderReturnsNotOwner() {
+	suite.service.On("GetOrderReturns", mock.Anything, model.Actor{ID: 1, Role: model.RoleUser}, 2).Return(nil, service.ErrNotOrderOwner)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/orders/2/returns", nil)
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusForbidden, w.Code)
+	suite.Equal(`"not your order"`, w.Body.String())
+}
+
+// =====================================================================================================================
+
+func (suite *ReturnHandlerSuite) TestHandler_GetReturnsRequestedByDefault() {
+	suite.service.On("GetReturns", mock.Anything, model.ReturnRequested, defaultReturnLimit).Return([]model.Return{
+		{ID: 7, OrderID: 1, OrderLineID: 10, Quantity: 1, Status: model.ReturnRequested},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/admin/returns", nil)
+	suite.router.ServeHTTP(w, r)
+
+	var returns []model.Return
+	_ = json.Unmarshal(w.Body.Bytes(), &returns)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Len(returns, 1)
+}
+
+func (suite *ReturnHandlerSuite) TestHandler_GetReturnsInvalidStatus() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/admin/returns?status=lost", nil)
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *ReturnHandlerSuite) TestHandler_ApproveReturnSuccess() {
+	suite.service.On("ApproveReturn", mock.Anything, 7, "ok").
+		Return(&model.Return{ID: 7, Status: model.ReturnApproved, Comment: "ok", RefundAmount: money.FromMinor(30000), RefundID: "refund-1"}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/admin/returns/7/approve", bytes.NewBufferString(`{"comment": "ok"}`))
+	suite.router.ServeHTTP(w, r)
+
+	var ret model.Return
+	_ = json.Unmarshal(w.Body.Bytes(), &ret)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(money.FromMinor(30000), ret.RefundAmount)
+}
+
+func (suite *ReturnHandlerSuite) TestHandler_ApproveReturnConflict() {
+	suite.service.On("ApproveReturn", mock.Anything, 7, "").Return(nil, repository.ErrReturnStatusConflict)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/admin/returns/7/approve", nil)
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusConflict, w.Code)
+}
+
+func (suite *ReturnHandlerSuite) TestHandler_RejectReturnNotFound() {
+	suite.service.On("RejectReturn", mock.Anything, 9, "used").Return(nil, service.ErrReturnNotFound)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/admin/returns/9/reject", bytes.NewBufferString(`{"comment": "used"}`))
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusNotFound, w.Code)
+	suite.Equal(`"return not found"`, w.Body.String())
+}
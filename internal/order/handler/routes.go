@@ -2,37 +2,90 @@ package handler
 
 import (
 	"database/sql"
+	couponService "github.com/aaanger/ecommerce/internal/coupon/service"
+	deliveryService "github.com/aaanger/ecommerce/internal/delivery/service"
 	grpcorder "github.com/aaanger/ecommerce/internal/order/handler/grpc/product"
 	"github.com/aaanger/ecommerce/internal/order/repository"
 	"github.com/aaanger/ecommerce/internal/order/service"
 	payment "github.com/aaanger/ecommerce/internal/payment/client"
-	"github.com/aaanger/ecommerce/internal/payment/webhook"
+	paymentRepository "github.com/aaanger/ecommerce/internal/payment/repository"
 	repository2 "github.com/aaanger/ecommerce/internal/product/repository"
-	"github.com/aaanger/ecommerce/pkg/kafka"
+	database "github.com/aaanger/ecommerce/pkg/db"
 	"github.com/aaanger/ecommerce/pkg/middleware"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"time"
 )
 
-func OrderRoutes(r *gin.Engine, db *sql.DB, producer *kafka.Producer, grpcClient *grpcorder.OrderGRPCClient, paymentClient *payment.Client, consumer *service.OrderConsumer, logger *zap.Logger) {
+func OrderRoutes(r *gin.Engine, db *sql.DB, grpcClient *grpcorder.OrderGRPCClient, paymentClient payment.PaymentProvider, holdPayments bool, consumer *service.OrderConsumer, couponService couponService.ICouponService, deliveryService deliveryService.IDeliveryService, logger *zap.Logger) service.IOrderService {
 	repo := repository.NewOrderRepository(db, logger)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
 	productRepo := repository2.NewProductRepository(db)
-	svc := service.NewOrderService(repo, productRepo, grpcClient, paymentClient, producer, logger)
+	variantRepo := repository2.NewVariantRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	paymentRepo := paymentRepository.NewPaymentRepository(db)
+	svc := service.NewOrderService(repo, idempotencyRepo, database.NewTxManager(db), productRepo, variantRepo, couponService, deliveryService, grpcClient, paymentClient, paymentRepo, holdPayments, outboxRepo, logger)
 	h := NewOrderHandler(svc, consumer, logger)
 
-	webhookHandler := webhook.NewWebhookHandler(svc, logger)
-
-	r.POST("/payment/webhook", webhookHandler.Handle)
-
 	order := r.Group("/orders", middleware.UserIdentity)
 
 	order.POST("/create", h.CreateOrder)
 	order.GET("/:id", h.GetOrderByID)
+	order.GET("/:id/history", h.GetStatusHistory)
+	order.GET("/:id/payments", h.GetOrderPayments)
 	order.GET("/all", h.GetAllOrders)
 	order.PUT("/cancel/:id", h.CancelOrder)
 
-	updateStatus := order.Group("/update-status")
+	updateStatus := order.Group("/update-status", middleware.ModeratorIdentity)
 	{
 		updateStatus.PUT("/:id", h.UpdateOrderStatus)
 	}
+
+	admin := r.Group("/admin/orders", middleware.UserIdentity, middleware.ModeratorIdentity)
+
+	admin.GET("/", h.GetOrders)
+
+	return svc
+}
+
+// ReturnRoutes registers the customer return endpoints under /orders and the review endpoints under /admin/returns.
+func ReturnRoutes(r *gin.Engine, db *sql.DB, grpcClient *grpcorder.OrderGRPCClient, paymentClient payment.PaymentProvider, logger *zap.Logger) service.IReturnService {
+	svc := service.NewReturnService(repository.NewReturnRepository(db), repository.NewOrderRepository(db, logger), grpcClient, paymentClient, logger)
+	h := NewReturnHandler(svc, logger)
+
+	order := r.Group("/orders", middleware.UserIdentity)
+
+	order.POST("/:id/returns", h.RequestReturn)
+	order.GET("/:id/returns", h.GetOrderReturns)
+
+	returns := r.Group("/admin/returns", middleware.UserIdentity, middleware.ModeratorIdentity)
+
+	returns.GET("/", h.GetReturns)
+	returns.POST("/:id/approve", h.ApproveReturn)
+	returns.POST("/:id/reject", h.RejectReturn)
+
+	return svc
+}
+
+// OutboxRoutes registers the outbox admin endpoints and returns the relay, which the caller starts with Run.
+func OutboxRoutes(r *gin.Engine, db *sql.DB, publishers map[string]service.EventPublisher, logger *zap.Logger) *service.OutboxService {
+	svc := service.NewOutboxService(repository.NewOutboxRepository(db), database.NewTxManager(db), publishers, service.DefaultOutboxInterval, logger)
+	h := NewOutboxHandler(svc, logger)
+
+	outbox := r.Group("/admin/outbox", middleware.UserIdentity, middleware.ModeratorIdentity)
+
+	outbox.GET("/", h.GetMessages)
+	outbox.POST("/:id/retry", h.RetryMessage)
+
+	return svc
+}
+
+// OrderExpirer returns the worker canceling unpaid orders, the caller starts it with Run.
+func OrderExpirer(db *sql.DB, orders service.IOrderService, ttl time.Duration, logger *zap.Logger) *service.OrderExpirer {
+	return service.NewOrderExpirer(repository.NewOrderRepository(db, logger), orders, database.NewAdvisoryLocker(db), ttl, time.Minute, logger)
+}
+
+// HoldSweeper returns the worker settling payment holds about to expire, the caller starts it with Run.
+func HoldSweeper(db *sql.DB, orders service.IOrderService, logger *zap.Logger) *service.HoldSweeper {
+	return service.NewHoldSweeper(paymentRepository.NewPaymentRepository(db), orders, database.NewAdvisoryLocker(db), service.DefaultHoldSettleMargin, time.Hour, logger)
 }
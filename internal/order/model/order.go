@@ -1,8 +1,10 @@
 package model
 
 import (
+	delivery "github.com/aaanger/ecommerce/internal/delivery/model"
 	payment "github.com/aaanger/ecommerce/internal/payment/model"
 	"github.com/aaanger/ecommerce/internal/product/model"
+	"github.com/aaanger/ecommerce/pkg/money"
 	"time"
 )
 
@@ -22,24 +24,56 @@ type Order struct {
 	UpdatedAt  time.Time   `json:"updated_at"`
 	Lines      []OrderLine `json:"lines"`
 	Status     string      `json:"status"`
-	TotalPrice float64     `json:"total_price"`
+	TotalPrice money.Money `json:"total_price"`
+	// ReservationID points at the stock hold in the product service, 0 if none was made.
+	ReservationID int `json:"reservation_id,omitempty"`
+	// Coupon is the promo code the order was placed with. TotalPrice is already net of its discount.
+	Coupon *AppliedCoupon `json:"coupon,omitempty"`
+	// Delivery is nil only for orders placed before delivery was introduced.
+	Delivery *Delivery `json:"delivery,omitempty"`
+	// PaymentID is the payment that paid for the order, empty until the payment succeeds.
+	PaymentID string `json:"payment_id,omitempty"`
+}
+
+// Delivery is how and where the order ships. Price is included in the order's TotalPrice, it is
+// zero when a free shipping coupon was applied.
+type Delivery struct {
+	Method  string               `json:"method"`
+	Price   money.Money          `json:"price"`
+	Address delivery.Destination `json:"address"`
+}
+
+type AppliedCoupon struct {
+	Code         string      `json:"code"`
+	Discount     money.Money `json:"discount"`
+	FreeShipping bool        `json:"free_shipping"`
 }
 
 type OrderLine struct {
 	ID        int            `json:"id"`
 	ProductID int            `json:"product_id"`
+	VariantID int            `json:"variant_id,omitempty"`
 	Product   *model.Product `json:"product"`
+	Variant   *model.Variant `json:"variant,omitempty"`
 	Quantity  int            `json:"quantity"`
-	Price     float64        `json:"price"`
+	Price     money.Money    `json:"price"`
+	// Discount is the line's share of the coupon discount, Price is before it.
+	Discount money.Money `json:"discount"`
 }
 
 type OrderLineReq struct {
 	ProductID int `json:"product_id" binding:"required"`
-	Quantity  int `json:"quantity" binding:"required"`
+	VariantID int `json:"variant_id"`
+	Quantity  int `json:"quantity" binding:"required,gt=0"`
 }
 
 type CreateOrderReq struct {
-	Lines []OrderLineReq `json:"lines" binding:"required,dive,required"`
+	Lines      []OrderLineReq       `json:"lines" binding:"required,dive,required"`
+	CouponCode string               `json:"coupon_code"`
+	Delivery   delivery.DeliveryReq `json:"delivery"`
+	// IdempotencyKey comes from the Idempotency-Key header. Retries carrying the same key get
+	// the order created by the first request instead of a new one.
+	IdempotencyKey string `json:"-"`
 }
 
 type CreateOrderRes struct {
@@ -47,15 +81,69 @@ type CreateOrderRes struct {
 	Payment *payment.CreatePaymentRes
 }
 
+// IdempotencyKey remembers the order a keyed create request produced. RequestHash fingerprints the
+// request body, Response is nil until the payment has been created.
+type IdempotencyKey struct {
+	UserID      int
+	Key         string
+	RequestHash string
+	OrderID     int
+	Response    *CreateOrderRes
+	CreatedAt   time.Time
+}
+
 type UpdateOrderStatusReq struct {
 	UserID int    `json:"user_id" binding:"required"`
 	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+type CancelOrderReq struct {
+	Reason string `json:"reason"`
 }
 
 type GetAllOrdersRes struct {
-	ID         int       `json:"id"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-	Status     string    `json:"status"`
-	TotalPrice float64   `json:"total_price"`
+	ID         int         `json:"id"`
+	UserID     int         `json:"user_id"`
+	UserEmail  string      `json:"user_email"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+	Status     string      `json:"status"`
+	TotalPrice money.Money `json:"total_price"`
+}
+
+const (
+	DefaultOrderPageLimit = 20
+	MaxOrderPageLimit     = 100
+)
+
+// Order lists sort by creation time or total, newest or largest first unless Dir is SortAsc.
+const (
+	SortCreatedAt = "created_at"
+	SortTotal     = "total"
+	SortAsc       = "asc"
+	SortDesc      = "desc"
+)
+
+// OrderListReq filters and pages order lists. From is inclusive and To exclusive, both in RFC 3339.
+// Cursor is the next_cursor of the previous page. UserID is ignored for customers, who only list their own orders.
+type OrderListReq struct {
+	UserID   int         `form:"user_id" binding:"gte=0"`
+	Status   string      `form:"status"`
+	Email    string      `form:"email"`
+	From     time.Time   `form:"from"`
+	To       time.Time   `form:"to"`
+	MinTotal money.Money `form:"min_total"`
+	MaxTotal money.Money `form:"max_total"`
+	Sort     string      `form:"sort"`
+	Dir      string      `form:"dir"`
+	Cursor   string      `form:"cursor"`
+	Limit    int         `form:"limit" binding:"gte=0"`
+}
+
+// OrderListRes is a page of orders. NextCursor is empty on the last page.
+type OrderListRes struct {
+	Orders     []GetAllOrdersRes `json:"orders"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	Limit      int               `json:"limit"`
 }
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxFailed  = "failed"
+)
+
+// OutboxMessage is an event written in the same transaction as the change it describes.
+// The relay publishes it to Topic and marks it sent, or failed once it runs out of attempts.
+type OutboxMessage struct {
+	ID            int             `json:"id"`
+	Topic         string          `json:"topic"`
+	Key           string          `json:"key"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error,omitempty"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	SentAt        *time.Time      `json:"sent_at,omitempty"`
+}
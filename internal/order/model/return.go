@@ -0,0 +1,42 @@
+package model
+
+import (
+	"github.com/aaanger/ecommerce/pkg/money"
+	"time"
+)
+
+// A return is requested, then rejected or approved. An approved return has been restocked and moves on
+// to refunded, which is final, once the refund has gone out.
+const (
+	ReturnRequested = "requested"
+	ReturnApproved  = "approved"
+	ReturnRejected  = "rejected"
+	ReturnRefunded  = "refunded"
+)
+
+// Return asks to send back Quantity items of one order line. RefundAmount is what the customer
+// paid for them, RefundID is set once the approved return has been refunded.
+type Return struct {
+	ID           int         `json:"id"`
+	OrderID      int         `json:"order_id"`
+	OrderLineID  int         `json:"order_line_id"`
+	Quantity     int         `json:"quantity"`
+	Reason       string      `json:"reason"`
+	Status       string      `json:"status"`
+	Comment      string      `json:"comment,omitempty"`
+	RefundAmount money.Money `json:"refund_amount"`
+	RefundID     string      `json:"refund_id,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+type ReturnReq struct {
+	OrderLineID int    `json:"order_line_id" binding:"required"`
+	Quantity    int    `json:"quantity" binding:"required,min=1"`
+	Reason      string `json:"reason" binding:"required"`
+}
+
+// ReviewReturnReq is the moderator's decision on a return, Comment is shown to the customer.
+type ReviewReturnReq struct {
+	Comment string `json:"comment"`
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Roles an actor can take when moving an order between statuses. RoleUser and RoleModerator
+// match the roles issued in access tokens, RoleSystem covers payment callbacks and internal jobs.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleSystem    = "system"
+)
+
+var (
+	ErrInvalidTransition   = errors.New("order status transition is not allowed")
+	ErrTransitionForbidden = errors.New("not allowed to change order status")
+)
+
+// Actor is whoever triggers a status change. ID is 0 for the system.
+type Actor struct {
+	ID   int
+	Role string
+}
+
+var SystemActor = Actor{Role: RoleSystem}
+
+// transitions lists every allowed status change and the roles that may make it.
+// Delivered and Canceled are final.
+var transitions = map[string]map[string][]string{
+	StatusPending: {
+		StatusCreated:  {RoleSystem},
+		StatusCanceled: {RoleUser, RoleModerator, RoleSystem},
+	},
+	StatusCreated: {
+		StatusDelivering: {RoleModerator},
+		StatusCanceled:   {RoleUser, RoleModerator, RoleSystem},
+	},
+	StatusDelivering: {
+		StatusDelivered: {RoleModerator},
+		StatusCanceled:  {RoleModerator},
+	},
+}
+
+// CheckTransition reports whether actor may move an order from one status to another.
+func CheckTransition(from, to string, actor Actor) error {
+	roles, ok := transitions[from][to]
+	if !ok {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, from, to)
+	}
+
+	for _, role := range roles {
+		if role == actor.Role {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s to %s as %s", ErrTransitionForbidden, from, to, actor.Role)
+}
+
+// StatusChange is one entry of an order's status history. FromStatus is empty for the
+// entry written when the order is created.
+type StatusChange struct {
+	ID         int       `json:"id"`
+	OrderID    int       `json:"order_id"`
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status"`
+	ActorID    int       `json:"actor_id,omitempty"`
+	ActorRole  string    `json:"actor_role"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
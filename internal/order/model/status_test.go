@@ -0,0 +1,51 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCheckTransition(t *testing.T) {
+	user := Actor{ID: 1, Role: RoleUser}
+	moderator := Actor{ID: 2, Role: RoleModerator}
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		actor   Actor
+		wantErr error
+	}{
+		{name: "payment confirms order", from: StatusPending, to: StatusCreated, actor: SystemActor},
+		{name: "user cannot confirm payment", from: StatusPending, to: StatusCreated, actor: user, wantErr: ErrTransitionForbidden},
+		{name: "moderator cannot confirm payment", from: StatusPending, to: StatusCreated, actor: moderator, wantErr: ErrTransitionForbidden},
+		{name: "user cancels unpaid order", from: StatusPending, to: StatusCanceled, actor: user},
+		{name: "system cancels unpaid order", from: StatusPending, to: StatusCanceled, actor: SystemActor},
+		{name: "moderator ships order", from: StatusCreated, to: StatusDelivering, actor: moderator},
+		{name: "user cannot ship order", from: StatusCreated, to: StatusDelivering, actor: user, wantErr: ErrTransitionForbidden},
+		{name: "user cancels paid order", from: StatusCreated, to: StatusCanceled, actor: user},
+		{name: "moderator delivers order", from: StatusDelivering, to: StatusDelivered, actor: moderator},
+		{name: "moderator cancels order in delivery", from: StatusDelivering, to: StatusCanceled, actor: moderator},
+		{name: "user cannot cancel order in delivery", from: StatusDelivering, to: StatusCanceled, actor: user, wantErr: ErrTransitionForbidden},
+		{name: "system cannot cancel order in delivery", from: StatusDelivering, to: StatusCanceled, actor: SystemActor, wantErr: ErrTransitionForbidden},
+		{name: "unpaid order cannot ship", from: StatusPending, to: StatusDelivering, actor: moderator, wantErr: ErrInvalidTransition},
+		{name: "no going back", from: StatusDelivering, to: StatusCreated, actor: moderator, wantErr: ErrInvalidTransition},
+		{name: "same status", from: StatusCreated, to: StatusCreated, actor: moderator, wantErr: ErrInvalidTransition},
+		{name: "delivered is final", from: StatusDelivered, to: StatusCanceled, actor: moderator, wantErr: ErrInvalidTransition},
+		{name: "canceled is final", from: StatusCanceled, to: StatusPending, actor: SystemActor, wantErr: ErrInvalidTransition},
+		{name: "unknown status", from: "lost", to: StatusCanceled, actor: moderator, wantErr: ErrInvalidTransition},
+		{name: "unknown role", from: StatusCreated, to: StatusCanceled, actor: Actor{ID: 3, Role: "guest"}, wantErr: ErrTransitionForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckTransition(tt.from, tt.to, tt.actor)
+
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/pkg/db"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+//go:generate mockery --name=IIdempotencyRepository
+
+type IIdempotencyRepository interface {
+	GetKey(ctx context.Context, userID int, key string) (*model.IdempotencyKey, error)
+	SaveKey(ctx context.Context, userID int, key, requestHash string, orderID int) error
+	SaveResponse(ctx context.Context, userID int, key string, res *model.CreateOrderRes) error
+	DeleteKey(ctx context.Context, userID int, key string) error
+}
+
+// ErrKeyExists is returned by SaveKey when another request has already claimed the key.
+var ErrKeyExists = errors.New("idempotency key already exists")
+
+// uniqueViolation is the PostgreSQL SQLSTATE for a unique constraint violation.
+const uniqueViolation = "23505"
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		db: db,
+	}
+}
+
+func (r *IdempotencyRepository) GetKey(ctx context.Context, userID int, key string) (*model.IdempotencyKey, error) {
+	record := model.IdempotencyKey{
+		UserID: userID,
+		Key:    key,
+	}
+	var response []byte
+
+	row := db.Conn(ctx, r.db).QueryRowContext(ctx, `SELECT request_hash, order_id, response, created_at FROM idempotency_keys WHERE user_id=$1 AND key=$2;`,
+		userID, key)
+	err := row.Scan(&record.RequestHash, &record.OrderID, &response, &record.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if response != nil {
+		err = json.Unmarshal(response, &record.Response)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &record, nil
+}
+
+// SaveKey claims the key for the order. It is meant to run in the transaction creating the order,
+// so a concurrent request with the same key waits for it and then gets ErrKeyExists.
+func (r *IdempotencyRepository) SaveKey(ctx context.Context, userID int, key, requestHash string, orderID int) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, `INSERT INTO idempotency_keys (user_id, key, request_hash, order_id) VALUES($1, $2, $3, $4);`,
+		userID, key, requestHash, orderID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrKeyExists
+	}
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *IdempotencyRepository) SaveResponse(ctx context.Context, userID int, key string, res *model.CreateOrderRes) error {
+	response, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Conn(ctx, r.db).ExecContext(ctx, `UPDATE idempotency_keys SET response=$1 WHERE user_id=$2 AND key=$3;`, response, userID, key)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *IdempotencyRepository) DeleteKey(ctx context.Context, userID int, key string) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, `DELETE FROM idempotency_keys WHERE user_id=$1 AND key=$2;`, userID, key)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
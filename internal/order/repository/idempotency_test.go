@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type IdempotencyRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo *IdempotencyRepository
+}
+
+func (suite *IdempotencyRepositorySuite) SetupTest() {
+	var err error
+	suite.db, suite.mock, err = sqlmock.New()
+	assert.NoError(suite.T(), err)
+	suite.repo = NewIdempotencyRepository(suite.db)
+}
+
+func TestIdempotencyRepositorySuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyRepositorySuite))
+}
+
+// ====================================================================================================================
+
+func (suite *IdempotencyRepositorySuite) TestRepository_GetKeyWithResponse() {
+	rows := sqlmock.NewRows([]string{"request_hash", "order_id", "response", "created_at"}).
+		AddRow("hash", 1, []byte(`{"Order": {"id": 1, "status": "Pending"}, "Payment": {"id": "pay-1"}}`), time.Now())
+	suite.mock.ExpectQuery("SELECT request_hash, order_id, response, created_at FROM idempotency_keys WHERE user_id=\\$1 AND key=\\$2").
+		WithArgs(1, "key-1").WillReturnRows(rows)
+
+	record, err := suite.repo.GetKey(context.Background(), 1, "key-1")
+
+	suite.Nil(err)
+	suite.Equal("hash", record.RequestHash)
+	suite.Equal(1, record.Response.Order.ID)
+	suite.Equal("pay-1", record.Response.Payment.ID)
+}
+
+func (suite *IdempotencyRepositorySuite) TestRepository_GetKeyWithoutResponse() {
+	rows := sqlmock.NewRows([]string{"request_hash", "order_id", "response", "created_at"}).AddRow("hash", 1, nil, time.Now())
+	suite.mock.ExpectQuery("SELECT .* FROM idempotency_keys").WithArgs(1, "key-1").WillReturnRows(rows)
+
+	record, err := suite.repo.GetKey(context.Background(), 1, "key-1")
+
+	suite.Nil(err)
+	suite.Equal(1, record.OrderID)
+	suite.Nil(record.Response)
+}
+
+func (suite *IdempotencyRepositorySuite) TestRepository_GetKeyNotFound() {
+	suite.mock.ExpectQuery("SELECT .* FROM idempotency_keys").WithArgs(1, "key-1").WillReturnError(sql.ErrNoRows)
+
+	record, err := suite.repo.GetKey(context.Background(), 1, "key-1")
+
+	suite.Nil(record)
+	suite.ErrorIs(err, sql.ErrNoRows)
+}
+
+// ====================================================================================================================
+
+func (suite *IdempotencyRepositorySuite) TestRepository_SaveKeyExists() {
+	suite.mock.ExpectExec("INSERT INTO idempotency_keys").WithArgs(1, "key-1", "hash", 2).
+		WillReturnError(&pgconn.PgError{Code: uniqueViolation})
+
+	err := suite.repo.SaveKey(context.Background(), 1, "key-1", "hash", 2)
+
+	suite.ErrorIs(err, ErrKeyExists)
+}
+
+func (suite *IdempotencyRepositorySuite) TestRepository_SaveResponseSuccess() {
+	suite.mock.ExpectExec("UPDATE idempotency_keys SET response=\\$1 WHERE user_id=\\$2 AND key=\\$3").
+		WithArgs(sqlmock.AnyArg(), 1, "key-1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := suite.repo.SaveResponse(context.Background(), 1, "key-1", &model.CreateOrderRes{Order: &model.Order{ID: 1}})
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *IdempotencyRepositorySuite) TestRepository_DeleteKey() {
+	suite.mock.ExpectExec("DELETE FROM idempotency_keys WHERE user_id=\\$1 AND key=\\$2").
+		WithArgs(1, "key-1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := suite.repo.DeleteKey(context.Background(), 1, "key-1")
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,113 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/aaanger/ecommerce/internal/order/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IIdempotencyRepository is an autogenerated mock type for the IIdempotencyRepository type
+type IIdempotencyRepository struct {
+	mock.Mock
+}
+
+// DeleteKey provides a mock function with given fields: ctx, userID, key
+func (_m *IIdempotencyRepository) DeleteKey(ctx context.Context, userID int, key string) error {
+	ret := _m.Called(ctx, userID, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, userID, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetKey provides a mock function with given fields: ctx, userID, key
+func (_m *IIdempotencyRepository) GetKey(ctx context.Context, userID int, key string) (*model.IdempotencyKey, error) {
+	ret := _m.Called(ctx, userID, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetKey")
+	}
+
+	var r0 *model.IdempotencyKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) (*model.IdempotencyKey, error)); ok {
+		return rf(ctx, userID, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) *model.IdempotencyKey); ok {
+		r0 = rf(ctx, userID, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.IdempotencyKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, userID, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveKey provides a mock function with given fields: ctx, userID, key, requestHash, orderID
+func (_m *IIdempotencyRepository) SaveKey(ctx context.Context, userID int, key string, requestHash string, orderID int) error {
+	ret := _m.Called(ctx, userID, key, requestHash, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, string, int) error); ok {
+		r0 = rf(ctx, userID, key, requestHash, orderID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveResponse provides a mock function with given fields: ctx, userID, key, res
+func (_m *IIdempotencyRepository) SaveResponse(ctx context.Context, userID int, key string, res *model.CreateOrderRes) error {
+	ret := _m.Called(ctx, userID, key, res)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveResponse")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, *model.CreateOrderRes) error); ok {
+		r0 = rf(ctx, userID, key, res)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewIIdempotencyRepository creates a new instance of IIdempotencyRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIIdempotencyRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IIdempotencyRepository {
+	mock := &IIdempotencyRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -1,10 +1,14 @@
-// Code generated by mockery v2.50.4. DO NOT EDIT.
+// Code generated by mockery v2.53.5. DO NOT EDIT.
 
 package mocks
 
 import (
+	context "context"
+
 	model "github.com/aaanger/ecommerce/internal/order/model"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // IOrderRepository is an autogenerated mock type for the IOrderRepository type
@@ -12,9 +16,9 @@ type IOrderRepository struct {
 	mock.Mock
 }
 
-// CreateOrder provides a mock function with given fields: userID, lines
-func (_m *IOrderRepository) CreateOrder(userID int, lines []model.OrderLine) (*model.Order, error) {
-	ret := _m.Called(userID, lines)
+// CreateOrder provides a mock function with given fields: ctx, userID, userEmail, lines, coupon, delivery
+func (_m *IOrderRepository) CreateOrder(ctx context.Context, userID int, userEmail string, lines []model.OrderLine, coupon *model.AppliedCoupon, delivery *model.Delivery) (*model.Order, error) {
+	ret := _m.Called(ctx, userID, userEmail, lines, coupon, delivery)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateOrder")
@@ -22,19 +26,19 @@ func (_m *IOrderRepository) CreateOrder(userID int, lines []model.OrderLine) (*m
 
 	var r0 *model.Order
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int, []model.OrderLine) (*model.Order, error)); ok {
-		return rf(userID, lines)
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, []model.OrderLine, *model.AppliedCoupon, *model.Delivery) (*model.Order, error)); ok {
+		return rf(ctx, userID, userEmail, lines, coupon, delivery)
 	}
-	if rf, ok := ret.Get(0).(func(int, []model.OrderLine) *model.Order); ok {
-		r0 = rf(userID, lines)
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, []model.OrderLine, *model.AppliedCoupon, *model.Delivery) *model.Order); ok {
+		r0 = rf(ctx, userID, userEmail, lines, coupon, delivery)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Order)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int, []model.OrderLine) error); ok {
-		r1 = rf(userID, lines)
+	if rf, ok := ret.Get(1).(func(context.Context, int, string, []model.OrderLine, *model.AppliedCoupon, *model.Delivery) error); ok {
+		r1 = rf(ctx, userID, userEmail, lines, coupon, delivery)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -42,29 +46,29 @@ func (_m *IOrderRepository) CreateOrder(userID int, lines []model.OrderLine) (*m
 	return r0, r1
 }
 
-// GetAllOrders provides a mock function with given fields: userID
-func (_m *IOrderRepository) GetAllOrders(userID int) ([]model.Order, error) {
-	ret := _m.Called(userID)
+// GetExpiredOrders provides a mock function with given fields: ctx, createdBefore, limit
+func (_m *IOrderRepository) GetExpiredOrders(ctx context.Context, createdBefore time.Time, limit int) ([]int, error) {
+	ret := _m.Called(ctx, createdBefore, limit)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetAllOrders")
+		panic("no return value specified for GetExpiredOrders")
 	}
 
-	var r0 []model.Order
+	var r0 []int
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int) ([]model.Order, error)); ok {
-		return rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) ([]int, error)); ok {
+		return rf(ctx, createdBefore, limit)
 	}
-	if rf, ok := ret.Get(0).(func(int) []model.Order); ok {
-		r0 = rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) []int); ok {
+		r0 = rf(ctx, createdBefore, limit)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]model.Order)
+			r0 = ret.Get(0).([]int)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int) error); ok {
-		r1 = rf(userID)
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, createdBefore, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -72,9 +76,9 @@ func (_m *IOrderRepository) GetAllOrders(userID int) ([]model.Order, error) {
 	return r0, r1
 }
 
-// GetOrderByID provides a mock function with given fields: userID, orderID
-func (_m *IOrderRepository) GetOrderByID(userID int, orderID int) (*model.Order, error) {
-	ret := _m.Called(userID, orderID)
+// GetOrderByID provides a mock function with given fields: ctx, orderID
+func (_m *IOrderRepository) GetOrderByID(ctx context.Context, orderID int) (*model.Order, error) {
+	ret := _m.Called(ctx, orderID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetOrderByID")
@@ -82,19 +86,86 @@ func (_m *IOrderRepository) GetOrderByID(userID int, orderID int) (*model.Order,
 
 	var r0 *model.Order
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int, int) (*model.Order, error)); ok {
-		return rf(userID, orderID)
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*model.Order, error)); ok {
+		return rf(ctx, orderID)
 	}
-	if rf, ok := ret.Get(0).(func(int, int) *model.Order); ok {
-		r0 = rf(userID, orderID)
+	if rf, ok := ret.Get(0).(func(context.Context, int) *model.Order); ok {
+		r0 = rf(ctx, orderID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Order)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int, int) error); ok {
-		r1 = rf(userID, orderID)
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrders provides a mock function with given fields: ctx, req
+func (_m *IOrderRepository) GetOrders(ctx context.Context, req *model.OrderListReq) ([]model.Order, string, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrders")
+	}
+
+	var r0 []model.Order
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.OrderListReq) ([]model.Order, string, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *model.OrderListReq) []model.Order); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *model.OrderListReq) string); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, *model.OrderListReq) error); ok {
+		r2 = rf(ctx, req)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetStatusHistory provides a mock function with given fields: ctx, orderID
+func (_m *IOrderRepository) GetStatusHistory(ctx context.Context, orderID int) ([]model.StatusChange, error) {
+	ret := _m.Called(ctx, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStatusHistory")
+	}
+
+	var r0 []model.StatusChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]model.StatusChange, error)); ok {
+		return rf(ctx, orderID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []model.StatusChange); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.StatusChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, orderID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -102,17 +173,53 @@ func (_m *IOrderRepository) GetOrderByID(userID int, orderID int) (*model.Order,
 	return r0, r1
 }
 
-// UpdateOrder provides a mock function with given fields: userID, orderID, status
-func (_m *IOrderRepository) UpdateOrder(userID int, orderID int, status string) error {
-	ret := _m.Called(userID, orderID, status)
+// SetPaymentID provides a mock function with given fields: ctx, orderID, paymentID
+func (_m *IOrderRepository) SetPaymentID(ctx context.Context, orderID int, paymentID string) error {
+	ret := _m.Called(ctx, orderID, paymentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetPaymentID")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, orderID, paymentID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateOrderReservation provides a mock function with given fields: ctx, orderID, reservationID
+func (_m *IOrderRepository) UpdateOrderReservation(ctx context.Context, orderID int, reservationID int) error {
+	ret := _m.Called(ctx, orderID, reservationID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateOrderReservation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = rf(ctx, orderID, reservationID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateOrderStatus provides a mock function with given fields: ctx, orderID, from, to, actor, reason
+func (_m *IOrderRepository) UpdateOrderStatus(ctx context.Context, orderID int, from string, to string, actor model.Actor, reason string) error {
+	ret := _m.Called(ctx, orderID, from, to, actor, reason)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateOrder")
+		panic("no return value specified for UpdateOrderStatus")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int, int, string) error); ok {
-		r0 = rf(userID, orderID, status)
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, string, model.Actor, string) error); ok {
+		r0 = rf(ctx, orderID, from, to, actor, reason)
 	} else {
 		r0 = ret.Error(0)
 	}
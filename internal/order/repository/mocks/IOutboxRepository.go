@@ -0,0 +1,181 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/aaanger/ecommerce/internal/order/model"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// IOutboxRepository is an autogenerated mock type for the IOutboxRepository type
+type IOutboxRepository struct {
+	mock.Mock
+}
+
+// Add provides a mock function with given fields: ctx, topic, key, payload
+func (_m *IOutboxRepository) Add(ctx context.Context, topic string, key string, payload interface{}) error {
+	ret := _m.Called(ctx, topic, key, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Add")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, interface{}) error); ok {
+		r0 = rf(ctx, topic, key, payload)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FetchDue provides a mock function with given fields: ctx, limit
+func (_m *IOutboxRepository) FetchDue(ctx context.Context, limit int) ([]model.OutboxMessage, error) {
+	ret := _m.Called(ctx, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FetchDue")
+	}
+
+	var r0 []model.OutboxMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]model.OutboxMessage, error)); ok {
+		return rf(ctx, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []model.OutboxMessage); ok {
+		r0 = rf(ctx, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.OutboxMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMessages provides a mock function with given fields: ctx, status, limit
+func (_m *IOutboxRepository) GetMessages(ctx context.Context, status string, limit int) ([]model.OutboxMessage, error) {
+	ret := _m.Called(ctx, status, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMessages")
+	}
+
+	var r0 []model.OutboxMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]model.OutboxMessage, error)); ok {
+		return rf(ctx, status, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []model.OutboxMessage); ok {
+		r0 = rf(ctx, status, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.OutboxMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, status, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkFailed provides a mock function with given fields: ctx, id, lastErr
+func (_m *IOutboxRepository) MarkFailed(ctx context.Context, id int, lastErr string) error {
+	ret := _m.Called(ctx, id, lastErr)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkFailed")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, id, lastErr)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MarkRetry provides a mock function with given fields: ctx, id, lastErr, nextAttemptAt
+func (_m *IOutboxRepository) MarkRetry(ctx context.Context, id int, lastErr string, nextAttemptAt time.Time) error {
+	ret := _m.Called(ctx, id, lastErr, nextAttemptAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkRetry")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, time.Time) error); ok {
+		r0 = rf(ctx, id, lastErr, nextAttemptAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MarkSent provides a mock function with given fields: ctx, id
+func (_m *IOutboxRepository) MarkSent(ctx context.Context, id int) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkSent")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Requeue provides a mock function with given fields: ctx, id
+func (_m *IOutboxRepository) Requeue(ctx context.Context, id int) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Requeue")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewIOutboxRepository creates a new instance of IOutboxRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIOutboxRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IOutboxRepository {
+	mock := &IOutboxRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,214 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/aaanger/ecommerce/internal/order/model"
+	money "github.com/aaanger/ecommerce/pkg/money"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IReturnRepository is an autogenerated mock type for the IReturnRepository type
+type IReturnRepository struct {
+	mock.Mock
+}
+
+// ApprovedQuantity provides a mock function with given fields: ctx, orderLineID, excludeID
+func (_m *IReturnRepository) ApprovedQuantity(ctx context.Context, orderLineID int, excludeID int) (int, error) {
+	ret := _m.Called(ctx, orderLineID, excludeID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ApprovedQuantity")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (int, error)); ok {
+		return rf(ctx, orderLineID, excludeID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) int); ok {
+		r0 = rf(ctx, orderLineID, excludeID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, orderLineID, excludeID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateReturn provides a mock function with given fields: ctx, orderID, req
+func (_m *IReturnRepository) CreateReturn(ctx context.Context, orderID int, req *model.ReturnReq) (*model.Return, error) {
+	ret := _m.Called(ctx, orderID, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateReturn")
+	}
+
+	var r0 *model.Return
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, *model.ReturnReq) (*model.Return, error)); ok {
+		return rf(ctx, orderID, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, *model.ReturnReq) *model.Return); ok {
+		r0 = rf(ctx, orderID, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Return)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, *model.ReturnReq) error); ok {
+		r1 = rf(ctx, orderID, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReturn provides a mock function with given fields: ctx, id
+func (_m *IReturnRepository) GetReturn(ctx context.Context, id int) (*model.Return, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReturn")
+	}
+
+	var r0 *model.Return
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*model.Return, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *model.Return); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Return)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReturns provides a mock function with given fields: ctx, status, limit
+func (_m *IReturnRepository) GetReturns(ctx context.Context, status string, limit int) ([]model.Return, error) {
+	ret := _m.Called(ctx, status, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReturns")
+	}
+
+	var r0 []model.Return
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]model.Return, error)); ok {
+		return rf(ctx, status, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []model.Return); ok {
+		r0 = rf(ctx, status, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Return)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, status, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReturnsByOrder provides a mock function with given fields: ctx, orderID
+func (_m *IReturnRepository) GetReturnsByOrder(ctx context.Context, orderID int) ([]model.Return, error) {
+	ret := _m.Called(ctx, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReturnsByOrder")
+	}
+
+	var r0 []model.Return
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]model.Return, error)); ok {
+		return rf(ctx, orderID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []model.Return); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Return)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetRefund provides a mock function with given fields: ctx, id, refundID, amount
+func (_m *IReturnRepository) SetRefund(ctx context.Context, id int, refundID string, amount money.Money) error {
+	ret := _m.Called(ctx, id, refundID, amount)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetRefund")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, money.Money) error); ok {
+		r0 = rf(ctx, id, refundID, amount)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateReturnStatus provides a mock function with given fields: ctx, id, from, to, comment
+func (_m *IReturnRepository) UpdateReturnStatus(ctx context.Context, id int, from string, to string, comment string) error {
+	ret := _m.Called(ctx, id, from, to, comment)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateReturnStatus")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, string, string) error); ok {
+		r0 = rf(ctx, id, from, to, comment)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewIReturnRepository creates a new instance of IReturnRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIReturnRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IReturnRepository {
+	mock := &IReturnRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -1,19 +1,45 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/pkg/db"
+	"github.com/aaanger/ecommerce/pkg/money"
 	"go.uber.org/zap"
+	"strconv"
+	"strings"
 	"time"
 )
 
 //go:generate mockery --name=IOrderRepository
 
+var (
+	// ErrStatusConflict is returned when the order left the expected status before the update landed.
+	ErrStatusConflict = errors.New("order status was changed concurrently")
+	ErrInvalidCursor  = errors.New("invalid cursor")
+)
+
+// orderSortColumns maps the sorts of order lists to columns. Pages are cut on (column, id), which the
+// orders listing indexes cover.
+var orderSortColumns = map[string]string{
+	model.SortCreatedAt: "created_at",
+	model.SortTotal:     "total_price",
+}
+
 type IOrderRepository interface {
-	CreateOrder(userID int, userEmail string, lines []model.OrderLine) (*model.Order, error)
-	GetOrderByID(orderID int) (*model.Order, error)
-	GetAllOrders(userID int) ([]model.Order, error)
-	UpdateOrder(orderID int, status string) error
+	CreateOrder(ctx context.Context, userID int, userEmail string, lines []model.OrderLine, coupon *model.AppliedCoupon, delivery *model.Delivery) (*model.Order, error)
+	GetOrderByID(ctx context.Context, orderID int) (*model.Order, error)
+	GetOrders(ctx context.Context, req *model.OrderListReq) ([]model.Order, string, error)
+	UpdateOrderStatus(ctx context.Context, orderID int, from, to string, actor model.Actor, reason string) error
+	GetStatusHistory(ctx context.Context, orderID int) ([]model.StatusChange, error)
+	UpdateOrderReservation(ctx context.Context, orderID, reservationID int) error
+	SetPaymentID(ctx context.Context, orderID int, paymentID string) error
+	GetExpiredOrders(ctx context.Context, createdBefore time.Time, limit int) ([]int, error)
 }
 
 type OrderRepository struct {
@@ -28,17 +54,42 @@ func NewOrderRepository(db *sql.DB, log *zap.Logger) *OrderRepository {
 	}
 }
 
-func (r *OrderRepository) CreateOrder(userID int, userEmail string, lines []model.OrderLine) (*model.Order, error) {
+// CreateOrder stores the order with its lines and first history entry in one transaction,
+// joining the caller's if ctx carries one. The delivery price is added to the lines' total.
+func (r *OrderRepository) CreateOrder(ctx context.Context, userID int, userEmail string, lines []model.OrderLine, coupon *model.AppliedCoupon, delivery *model.Delivery) (*model.Order, error) {
 	log := r.log.With(
 		zap.String("service", "order"),
 		zap.String("layer", "repository"),
 		zap.String("method", "CreateOrder"),
 		zap.Int("userID", userID))
 
-	var totalPrice float64
+	var totalPrice money.Money
 
 	for _, line := range lines {
-		totalPrice += line.Price
+		totalPrice = totalPrice.Add(line.Price).Sub(line.Discount)
+	}
+
+	var couponCode sql.NullString
+	var discount money.Money
+	var freeShipping bool
+	if coupon != nil {
+		couponCode = sql.NullString{String: coupon.Code, Valid: true}
+		discount = coupon.Discount
+		freeShipping = coupon.FreeShipping
+	}
+
+	var deliveryMethod sql.NullString
+	var deliveryPrice money.Money
+	var shippingAddress []byte
+	if delivery != nil {
+		var err error
+		shippingAddress, err = json.Marshal(delivery.Address)
+		if err != nil {
+			return nil, err
+		}
+		deliveryMethod = sql.NullString{String: delivery.Method, Valid: true}
+		deliveryPrice = delivery.Price
+		totalPrice = totalPrice.Add(delivery.Price)
 	}
 
 	order := model.Order{
@@ -49,56 +100,106 @@ func (r *OrderRepository) CreateOrder(userID int, userEmail string, lines []mode
 		Lines:      lines,
 		Status:     model.StatusPending,
 		TotalPrice: totalPrice,
+		Coupon:     coupon,
+		Delivery:   delivery,
 	}
 
-	log.Debug("Executing INSERT query on orders")
-	row := r.db.QueryRow(`INSERT INTO orders (user_id, user_email, created_at, updated_at, status, total_price) VALUES($1, $2, $3, $4, $5, $6) RETURNING id;`,
-		order.UserID, order.UserEmail, order.CreatedAt, order.UpdatedAt, order.Status, order.TotalPrice)
+	err := db.RunInTx(ctx, r.db, func(ctx context.Context) error {
+		conn := db.Conn(ctx, r.db)
 
-	err := row.Scan(&order.ID)
-	if err != nil {
-		log.Error("Failed to create order", zap.Error(err))
-		return nil, err
-	}
+		log.Debug("Executing INSERT query on orders")
+		row := conn.QueryRowContext(ctx, `INSERT INTO orders (user_id, user_email, created_at, updated_at, status, total_price, coupon_code, discount, free_shipping,
+			delivery_method, delivery_price, shipping_address)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id;`,
+			order.UserID, order.UserEmail, order.CreatedAt, order.UpdatedAt, order.Status, order.TotalPrice, couponCode, discount, freeShipping,
+			deliveryMethod, deliveryPrice, shippingAddress)
 
-	log.Debug("Executing INSERT query on orderline")
-	for _, line := range lines {
-		_, err = r.db.Exec(`INSERT INTO orderline (order_id, product_id, quantity, price) VALUES($1, $2, $3, $4);`,
-			order.ID, line.ProductID, line.Quantity, line.Price)
+		err := row.Scan(&order.ID)
 		if err != nil {
-			log.Error("Failed to create orderline", zap.Error(err))
-			return nil, err
+			log.Error("Failed to create order", zap.Error(err))
+			return err
+		}
+
+		log.Debug("Executing INSERT query on orderline")
+		for _, line := range lines {
+			_, err = conn.ExecContext(ctx, `INSERT INTO orderline (order_id, product_id, variant_id, quantity, price, discount) VALUES($1, $2, $3, $4, $5, $6);`,
+				order.ID, line.ProductID, db.NullInt(line.VariantID), line.Quantity, line.Price, line.Discount)
+			if err != nil {
+				log.Error("Failed to create orderline", zap.Error(err))
+				return err
+			}
+		}
+
+		_, err = conn.ExecContext(ctx, `INSERT INTO order_status_history (order_id, to_status, actor_id, actor_role, created_at) VALUES($1, $2, $3, $4, $5);`,
+			order.ID, order.Status, db.NullInt(userID), model.RoleUser, order.CreatedAt)
+		if err != nil {
+			log.Error("Failed to record order status", zap.Error(err))
+			return err
 		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	log.Info("Order successfully created", zap.Int("orderID", order.ID))
 	return &order, nil
 }
 
-func (r *OrderRepository) GetOrderByID(orderID int) (*model.Order, error) {
+func (r *OrderRepository) GetOrderByID(ctx context.Context, orderID int) (*model.Order, error) {
 	var order model.Order
+	var reservationID sql.NullInt64
+	var couponCode sql.NullString
+	var coupon model.AppliedCoupon
+	var deliveryMethod sql.NullString
+	var delivery model.Delivery
+	var shippingAddress []byte
+	var paymentID sql.NullString
+
+	conn := db.Conn(ctx, r.db)
 
-	row := r.db.QueryRow(`SELECT id, user_email, created_at, updated_at, status, total_price FROM orders WHERE id=$1;`, orderID)
-	err := row.Scan(&order.ID, &order.UserEmail, &order.CreatedAt, &order.UpdatedAt, &order.Status, &order.TotalPrice)
+	row := conn.QueryRowContext(ctx, `SELECT id, user_id, user_email, created_at, updated_at, status, total_price, reservation_id, coupon_code, discount, free_shipping,
+		delivery_method, delivery_price, shipping_address, payment_id FROM orders WHERE id=$1;`, orderID)
+	err := row.Scan(&order.ID, &order.UserID, &order.UserEmail, &order.CreatedAt, &order.UpdatedAt, &order.Status, &order.TotalPrice, &reservationID,
+		&couponCode, &coupon.Discount, &coupon.FreeShipping, &deliveryMethod, &delivery.Price, &shippingAddress, &paymentID)
 	if err != nil {
 		return nil, err
 	}
 
+	order.ReservationID = int(reservationID.Int64)
+	if couponCode.Valid {
+		coupon.Code = couponCode.String
+		order.Coupon = &coupon
+	}
+	order.PaymentID = paymentID.String
+	if deliveryMethod.Valid {
+		delivery.Method = deliveryMethod.String
+		if err = json.Unmarshal(shippingAddress, &delivery.Address); err != nil {
+			return nil, err
+		}
+		order.Delivery = &delivery
+	}
+
 	var lines []model.OrderLine
 
-	rows, err := r.db.Query(`SELECT product_id, quantity, price FROM orderline ol INNER JOIN orders o ON ol.order_id=o.id WHERE o.id=$1;`, orderID)
+	rows, err := conn.QueryContext(ctx, `SELECT ol.id, product_id, variant_id, quantity, price, ol.discount FROM orderline ol INNER JOIN orders o ON ol.order_id=o.id WHERE o.id=$1 ORDER BY ol.id;`, orderID)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var line model.OrderLine
+		var variantID sql.NullInt64
 
-		err = rows.Scan(&line.ProductID, &line.Quantity, &line.Price)
+		err = rows.Scan(&line.ID, &line.ProductID, &variantID, &line.Quantity, &line.Price, &line.Discount)
 		if err != nil {
 			return nil, err
 		}
 
+		line.VariantID = int(variantID.Int64)
+
 		lines = append(lines, line)
 	}
 
@@ -107,32 +208,238 @@ func (r *OrderRepository) GetOrderByID(orderID int) (*model.Order, error) {
 	return &order, nil
 }
 
-func (r *OrderRepository) GetAllOrders(userID int) ([]model.Order, error) {
-	var orders []model.Order
+// GetOrders returns a page of the orders matching req and the cursor of the next page, empty on the last one.
+// req must be normalized: Sort and Dir set and Limit positive.
+func (r *OrderRepository) GetOrders(ctx context.Context, req *model.OrderListReq) ([]model.Order, string, error) {
+	column, ok := orderSortColumns[req.Sort]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown sort %q", req.Sort)
+	}
+
+	var conditions []string
+	var values []any
+	arg := 1
 
-	rows, err := r.db.Query(`SELECT id, created_at, updated_at, status, total_price FROM orders WHERE user_id=$1;`, userID)
+	if req.UserID != 0 {
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", arg))
+		values = append(values, req.UserID)
+		arg++
+	}
+	if req.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", arg))
+		values = append(values, req.Status)
+		arg++
+	}
+	if req.Email != "" {
+		conditions = append(conditions, fmt.Sprintf("lower(user_email) = lower($%d)", arg))
+		values = append(values, req.Email)
+		arg++
+	}
+	if !req.From.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", arg))
+		values = append(values, req.From)
+		arg++
+	}
+	if !req.To.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", arg))
+		values = append(values, req.To)
+		arg++
+	}
+	if !req.MinTotal.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("total_price >= $%d", arg))
+		values = append(values, req.MinTotal)
+		arg++
+	}
+	if !req.MaxTotal.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("total_price <= $%d", arg))
+		values = append(values, req.MaxTotal)
+		arg++
+	}
+
+	cmp, dir := "<", "DESC"
+	if req.Dir == model.SortAsc {
+		cmp, dir = ">", "ASC"
+	}
+
+	if req.Cursor != "" {
+		after, id, err := decodeCursor(req.Cursor, req.Sort, req.Dir)
+		if err != nil {
+			return nil, "", err
+		}
+
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s ($%d, $%d)", column, cmp, arg, arg+1))
+		values = append(values, after, id)
+		arg += 2
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// One row past the page tells whether there is a next one.
+	query := fmt.Sprintf(`SELECT id, user_id, user_email, created_at, updated_at, status, total_price FROM orders%s ORDER BY %s %s, id %s LIMIT $%d;`,
+		where, column, dir, dir, arg)
+	values = append(values, req.Limit+1)
+
+	rows, err := db.Conn(ctx, r.db).QueryContext(ctx, query, values...)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
+	defer rows.Close()
+
+	orders := make([]model.Order, 0)
 
 	for rows.Next() {
 		var order model.Order
 
-		err = rows.Scan(&order.ID, &order.UpdatedAt, &order.UpdatedAt, &order.Status, &order.TotalPrice)
+		err = rows.Scan(&order.ID, &order.UserID, &order.UserEmail, &order.CreatedAt, &order.UpdatedAt, &order.Status, &order.TotalPrice)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
 		orders = append(orders, order)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if len(orders) <= req.Limit {
+		return orders, "", nil
+	}
+
+	orders = orders[:req.Limit]
+	return orders, encodeCursor(&orders[req.Limit-1], req.Sort, req.Dir), nil
+}
+
+// UpdateOrderStatus moves the order from one status to another and appends the change to its history.
+// The update only applies while the order is still in the from status, so two racing transitions
+// cannot both succeed.
+func (r *OrderRepository) UpdateOrderStatus(ctx context.Context, orderID int, from, to string, actor model.Actor, reason string) error {
+	return db.RunInTx(ctx, r.db, func(ctx context.Context) error {
+		conn := db.Conn(ctx, r.db)
+
+		res, err := conn.ExecContext(ctx, `UPDATE orders SET updated_at = current_timestamp, status=$1 WHERE id=$2 AND status=$3;`, to, orderID, from)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrStatusConflict
+		}
+
+		_, err = conn.ExecContext(ctx, `INSERT INTO order_status_history (order_id, from_status, to_status, actor_id, actor_role, reason) VALUES($1, $2, $3, $4, $5, $6);`,
+			orderID, from, to, db.NullInt(actor.ID), actor.Role, reason)
+		return err
+	})
+}
+
+func (r *OrderRepository) GetStatusHistory(ctx context.Context, orderID int) ([]model.StatusChange, error) {
+	var history []model.StatusChange
+
+	rows, err := db.Conn(ctx, r.db).QueryContext(ctx, `SELECT id, order_id, from_status, to_status, actor_id, actor_role, reason, created_at FROM order_status_history
+		WHERE order_id=$1 ORDER BY created_at, id;`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var change model.StatusChange
+		var fromStatus sql.NullString
+		var actorID sql.NullInt64
+
+		err = rows.Scan(&change.ID, &change.OrderID, &fromStatus, &change.ToStatus, &actorID, &change.ActorRole, &change.Reason, &change.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		change.FromStatus = fromStatus.String
+		change.ActorID = int(actorID.Int64)
 
-	return orders, nil
+		history = append(history, change)
+	}
+
+	return history, rows.Err()
 }
 
-func (r *OrderRepository) UpdateOrder(orderID int, status string) error {
-	_, err := r.db.Exec(`UPDATE orders SET updated_at = current_timestamp, status=$1 WHERE id=$2;`, status, orderID)
+func (r *OrderRepository) UpdateOrderReservation(ctx context.Context, orderID, reservationID int) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, `UPDATE orders SET updated_at = current_timestamp, reservation_id=$1 WHERE id=$2;`, db.NullInt(reservationID), orderID)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+func (r *OrderRepository) SetPaymentID(ctx context.Context, orderID int, paymentID string) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, `UPDATE orders SET updated_at = current_timestamp, payment_id=$1 WHERE id=$2;`, paymentID, orderID)
+	return err
+}
+
+// GetExpiredOrders returns the IDs of orders still awaiting payment that were created before createdBefore, oldest first.
+func (r *OrderRepository) GetExpiredOrders(ctx context.Context, createdBefore time.Time, limit int) ([]int, error) {
+	var ids []int
+
+	rows, err := db.Conn(ctx, r.db).QueryContext(ctx, `SELECT id FROM orders WHERE status=$1 AND created_at<$2 ORDER BY created_at LIMIT $3;`,
+		model.StatusPending, createdBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// encodeCursor points past the order in a list with the given sort. The cursor carries the sort so that
+// it cannot be replayed against a list in another order.
+func encodeCursor(order *model.Order, sort, dir string) string {
+	after := order.CreatedAt.UTC().Format(time.RFC3339Nano)
+	if sort == model.SortTotal {
+		after = strconv.FormatInt(order.TotalPrice.Amount, 10)
+	}
+
+	return base64.RawURLEncoding.EncodeToString([]byte(strings.Join([]string{sort, dir, after, strconv.Itoa(order.ID)}, "|")))
+}
+
+func decodeCursor(cursor, sort, dir string) (any, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 4 || parts[0] != sort || parts[1] != dir {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	if sort == model.SortTotal {
+		amount, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return nil, 0, ErrInvalidCursor
+		}
+		return amount, id, nil
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[2])
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+	return createdAt, id, nil
+}
@@ -1,12 +1,16 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
+	deliveryModel "github.com/aaanger/ecommerce/internal/delivery/model"
 	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/pkg/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
 	"testing"
 	"time"
 )
@@ -22,13 +26,16 @@ func (suite *OrderRepositorySuite) SetupTest() {
 	var err error
 	suite.db, suite.mock, err = sqlmock.New()
 	assert.NoError(suite.T(), err)
-	suite.repo = NewOrderRepository(suite.db)
+	suite.repo = NewOrderRepository(suite.db, zap.NewNop())
 }
 
 func TestOrderRepositorySuite(t *testing.T) {
 	suite.Run(t, new(OrderRepositorySuite))
 }
 
+var orderRowColumns = []string{"id", "user_id", "user_email", "created_at", "updated_at", "status", "total_price", "reservation_id",
+	"coupon_code", "discount", "free_shipping", "delivery_method", "delivery_price", "shipping_address", "payment_id"}
+
 // ====================================================================================================================
 
 func (suite *OrderRepositorySuite) TestRepository_CreateOrderSuccess() {
@@ -36,24 +43,79 @@ func (suite *OrderRepositorySuite) TestRepository_CreateOrderSuccess() {
 		{
 			ProductID: 1,
 			Quantity:  1,
-			Price:     5,
+			Price:     money.FromMinor(500),
 		},
 	}
 
 	suite.mock.ExpectBegin()
 	orderRows := sqlmock.NewRows([]string{"id"}).AddRow(1)
 	suite.mock.ExpectQuery("INSERT INTO orders").
-		WithArgs(1, time.Now(), time.Now(), model.StatusOrderCreated, float64(5)).WillReturnRows(orderRows)
+		WithArgs(1, "test@test.com", sqlmock.AnyArg(), sqlmock.AnyArg(), model.StatusPending, money.FromMinor(500), sql.NullString{}, money.Money{}, false,
+			sql.NullString{}, money.Money{}, []byte(nil)).
+		WillReturnRows(orderRows)
 
-	suite.mock.ExpectExec("INSERT INTO orderline").WithArgs(1, reqLines[0].ProductID, reqLines[0].Quantity, reqLines[0].Price).
+	suite.mock.ExpectExec("INSERT INTO orderline").WithArgs(1, reqLines[0].ProductID, sql.NullInt64{}, reqLines[0].Quantity, reqLines[0].Price, money.Money{}).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec("INSERT INTO order_status_history").WithArgs(1, model.StatusPending, sql.NullInt64{Int64: 1, Valid: true}, model.RoleUser, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	suite.mock.ExpectCommit()
+
+	order, err := suite.repo.CreateOrder(context.Background(), 1, "test@test.com", reqLines, nil, nil)
+
+	suite.Nil(err)
+	suite.Equal(1, order.ID)
+	suite.Equal(money.FromMinor(500), order.TotalPrice)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *OrderRepositorySuite) TestRepository_CreateOrderWithCoupon() {
+	reqLines := []model.OrderLine{
+		{ProductID: 1, Quantity: 2, Price: money.FromMinor(1000), Discount: money.FromMinor(100)},
+	}
+	coupon := &model.AppliedCoupon{Code: "SALE10", Discount: money.FromMinor(100)}
 
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("INSERT INTO orders").
+		WithArgs(1, "test@test.com", sqlmock.AnyArg(), sqlmock.AnyArg(), model.StatusPending, money.FromMinor(900),
+			sql.NullString{String: "SALE10", Valid: true}, money.FromMinor(100), false, sql.NullString{}, money.Money{}, []byte(nil)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	suite.mock.ExpectExec("INSERT INTO orderline").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec("INSERT INTO order_status_history").WillReturnResult(sqlmock.NewResult(1, 1))
 	suite.mock.ExpectCommit()
 
-	order, err := suite.repo.CreateOrder(1, reqLines)
+	order, err := suite.repo.CreateOrder(context.Background(), 1, "test@test.com", reqLines, coupon, nil)
 
-	suite.NotNil(order)
 	suite.Nil(err)
+	suite.Equal(money.FromMinor(900), order.TotalPrice)
+	suite.Equal(coupon, order.Coupon)
+}
+
+func (suite *OrderRepositorySuite) TestRepository_CreateOrderWithDelivery() {
+	reqLines := []model.OrderLine{
+		{ProductID: 1, Quantity: 1, Price: money.FromMinor(1000)},
+	}
+	delivery := &model.Delivery{
+		Method:  deliveryModel.MethodCourier,
+		Price:   money.FromMinor(300),
+		Address: deliveryModel.Destination{Recipient: "Ivan", City: "Moscow", Street: "Tverskaya 1"},
+	}
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("INSERT INTO orders").
+		WithArgs(1, "test@test.com", sqlmock.AnyArg(), sqlmock.AnyArg(), model.StatusPending, money.FromMinor(1300),
+			sql.NullString{}, money.Money{}, false, sql.NullString{String: deliveryModel.MethodCourier, Valid: true}, money.FromMinor(300), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	suite.mock.ExpectExec("INSERT INTO orderline").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec("INSERT INTO order_status_history").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectCommit()
+
+	order, err := suite.repo.CreateOrder(context.Background(), 1, "test@test.com", reqLines, nil, delivery)
+
+	suite.Nil(err)
+	suite.Equal(money.FromMinor(1300), order.TotalPrice)
+	suite.Equal(delivery, order.Delivery)
+	suite.Nil(suite.mock.ExpectationsWereMet())
 }
 
 func (suite *OrderRepositorySuite) TestRepository_CreateOrderFailureOrder() {
@@ -61,19 +123,19 @@ func (suite *OrderRepositorySuite) TestRepository_CreateOrderFailureOrder() {
 		{
 			ProductID: 1,
 			Quantity:  1,
-			Price:     5,
+			Price:     money.FromMinor(500),
 		},
 	}
 
 	suite.mock.ExpectBegin()
-	suite.mock.ExpectQuery("INSERT INTO orders").WithArgs(1, time.Now(), time.Now(), model.StatusOrderCreated, float64(5)).
-		WillReturnError(errors.New("error"))
+	suite.mock.ExpectQuery("INSERT INTO orders").WillReturnError(errors.New("error"))
 	suite.mock.ExpectRollback()
 
-	order, err := suite.repo.CreateOrder(1, reqLines)
+	order, err := suite.repo.CreateOrder(context.Background(), 1, "test@test.com", reqLines, nil, nil)
 
 	suite.Nil(order)
 	suite.NotNil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
 }
 
 func (suite *OrderRepositorySuite) TestRepository_CreateOrderFailureOrderLines() {
@@ -81,70 +143,194 @@ func (suite *OrderRepositorySuite) TestRepository_CreateOrderFailureOrderLines()
 		{
 			ProductID: 1,
 			Quantity:  1,
-			Price:     5,
+			Price:     money.FromMinor(500),
+		},
+		{
+			ProductID: 2,
+			Quantity:  1,
+			Price:     money.FromMinor(700),
 		},
 	}
 
 	suite.mock.ExpectBegin()
 	orderRows := sqlmock.NewRows([]string{"id"}).AddRow(1)
-	suite.mock.ExpectQuery("INSERT INTO orders").WithArgs(1, reqLines[0].ProductID, reqLines[0].Quantity, reqLines[0].Price).
-		WillReturnRows(orderRows)
+	suite.mock.ExpectQuery("INSERT INTO orders").WillReturnRows(orderRows)
 
-	suite.mock.ExpectExec("INSERT INTO orderline").WithArgs(1, reqLines[0].ProductID, reqLines[0].Quantity, reqLines[0].Price).
+	suite.mock.ExpectExec("INSERT INTO orderline").WithArgs(1, 1, sql.NullInt64{}, 1, money.FromMinor(500), money.Money{}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec("INSERT INTO orderline").WithArgs(1, 2, sql.NullInt64{}, 1, money.FromMinor(700), money.Money{}).
 		WillReturnError(errors.New("error"))
 	suite.mock.ExpectRollback()
 
-	order, err := suite.repo.CreateOrder(1, reqLines)
+	order, err := suite.repo.CreateOrder(context.Background(), 1, "test@test.com", reqLines, nil, nil)
 
+	// The order and its first line are rolled back with the failed one.
 	suite.Nil(order)
 	suite.NotNil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *OrderRepositorySuite) TestRepository_CreateOrderFailureHistory() {
+	reqLines := []model.OrderLine{
+		{ProductID: 1, Quantity: 1, Price: money.FromMinor(500)},
+	}
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	suite.mock.ExpectExec("INSERT INTO orderline").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec("INSERT INTO order_status_history").WillReturnError(errors.New("error"))
+	suite.mock.ExpectRollback()
+
+	order, err := suite.repo.CreateOrder(context.Background(), 1, "test@test.com", reqLines, nil, nil)
+
+	suite.Nil(order)
+	suite.NotNil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
 }
 
 // ====================================================================================================================
 
 func (suite *OrderRepositorySuite) TestRepository_GetOrderByIDSuccess() {
-	orderRows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "status", "total_price"}).AddRow(1, time.Now(), time.Now(), model.StatusOrderCreated, float64(5))
-	suite.mock.ExpectQuery("SELECT id, created_at, updated_at, status, total_price FROM orders").WithArgs(1, 1).WillReturnRows(orderRows)
+	orderRows := sqlmock.NewRows(orderRowColumns).
+		AddRow(1, 2, "test@test.com", time.Now(), time.Now(), model.StatusCreated, 500, 3, nil, 0, false, nil, 0, nil, nil)
+	suite.mock.ExpectQuery("SELECT id, user_id, user_email, created_at, updated_at, status, total_price, reservation_id, coupon_code, discount, free_shipping,\\s+delivery_method, delivery_price, shipping_address, payment_id FROM orders").
+		WithArgs(1).WillReturnRows(orderRows)
 
-	lineRows := sqlmock.NewRows([]string{"product_id", "quantity", "price"}).AddRow(1, 1, float64(5))
-	suite.mock.ExpectQuery("SELECT product_id, quantity, price FROM orderline ol INNER JOIN orders o ON ol.order_id=o.id WHERE o.id=\\$1").WithArgs(1).WillReturnRows(lineRows)
+	lineRows := sqlmock.NewRows([]string{"id", "product_id", "variant_id", "quantity", "price", "discount"}).AddRow(11, 1, nil, 1, 500, 0)
+	suite.mock.ExpectQuery("SELECT ol.id, product_id, variant_id, quantity, price, ol.discount FROM orderline ol INNER JOIN orders o ON ol.order_id=o.id WHERE o.id=\\$1").
+		WithArgs(1).WillReturnRows(lineRows)
 
-	order, err := suite.repo.GetOrderByID(1, 1)
+	order, err := suite.repo.GetOrderByID(context.Background(), 1)
 
-	suite.NotNil(order)
 	suite.Nil(err)
+	suite.Equal(2, order.UserID)
+	suite.Equal(3, order.ReservationID)
+	suite.Nil(order.Coupon)
+	suite.Len(order.Lines, 1)
 }
 
-func (suite *OrderRepositorySuite) TestRepository_GetOrderByIDFailure() {
-	orderRows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "status", "total_price"})
-	suite.mock.ExpectQuery("SELECT id, created_at, updated_at, status, total_price FROM orders").WithArgs(1, 1).WillReturnRows(orderRows)
+func (suite *OrderRepositorySuite) TestRepository_GetOrderByIDWithDelivery() {
+	orderRows := sqlmock.NewRows(orderRowColumns).
+		AddRow(1, 2, "test@test.com", time.Now(), time.Now(), model.StatusCreated, 800, nil, nil, 0, false,
+			deliveryModel.MethodPickup, 300, []byte(`{"recipient":"Ivan","city":"Moscow","street":"Tverskaya 1"}`), "pay-1")
+	suite.mock.ExpectQuery("SELECT (.+) FROM orders WHERE id").WithArgs(1).WillReturnRows(orderRows)
+	suite.mock.ExpectQuery("SELECT ol.id, product_id, variant_id, quantity, price, ol.discount FROM orderline").
+		WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "variant_id", "quantity", "price", "discount"}).AddRow(11, 1, nil, 1, 500, 0))
+
+	order, err := suite.repo.GetOrderByID(context.Background(), 1)
+
+	suite.Nil(err)
+	suite.Require().NotNil(order.Delivery)
+	suite.Equal(deliveryModel.MethodPickup, order.Delivery.Method)
+	suite.Equal(money.FromMinor(300), order.Delivery.Price)
+	suite.Equal("Moscow", order.Delivery.Address.City)
+	suite.Equal("pay-1", order.PaymentID)
+}
 
-	lineRows := sqlmock.NewRows([]string{"product_id", "quantity", "price"}).AddRow(1, 1, float64(5))
-	suite.mock.ExpectQuery("SELECT product_id, quantity, price FROM orderline ol INNER JOIN orders o ON ol.order_id=o.id WHERE o.id=\\$1").WithArgs(1).WillReturnRows(lineRows)
+func (suite *OrderRepositorySuite) TestRepository_GetOrderByIDFailure() {
+	orderRows := sqlmock.NewRows(orderRowColumns)
+	suite.mock.ExpectQuery("SELECT id, user_id, user_email, created_at, updated_at, status, total_price, reservation_id, coupon_code, discount, free_shipping,\\s+delivery_method, delivery_price, shipping_address, payment_id FROM orders").
+		WithArgs(1).WillReturnRows(orderRows)
 
-	order, err := suite.repo.GetOrderByID(1, 1)
+	order, err := suite.repo.GetOrderByID(context.Background(), 1)
 
 	suite.Nil(order)
-	suite.NotNil(err)
+	suite.ErrorIs(err, sql.ErrNoRows)
 }
 
 // ====================================================================================================================
 
-func (suite *OrderRepositorySuite) TestRepository_GetAllOrdersSuccess() {
-	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "status", "total_price"}).AddRow(1, time.Now(), time.Now(), model.StatusOrderCreated, float64(5))
-	suite.mock.ExpectQuery("SELECT id, created_at, updated_at, status, total_price FROM orders").WithArgs(1).WillReturnRows(rows)
+func listRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "user_id", "user_email", "created_at", "updated_at", "status", "total_price"})
+}
+
+func listReq() *model.OrderListReq {
+	return &model.OrderListReq{UserID: 1, Sort: model.SortCreatedAt, Dir: model.SortDesc, Limit: 2}
+}
+
+func (suite *OrderRepositorySuite) TestRepository_GetOrdersLastPage() {
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	rows := listRows().AddRow(2, 1, "test@test.com", createdAt, createdAt, model.StatusCreated, 500)
+	suite.mock.ExpectQuery("SELECT id, user_id, user_email, created_at, updated_at, status, total_price FROM orders WHERE user_id = \\$1 ORDER BY created_at DESC, id DESC LIMIT \\$2").
+		WithArgs(1, 3).WillReturnRows(rows)
 
-	orders, err := suite.repo.GetAllOrders(1)
+	orders, next, err := suite.repo.GetOrders(context.Background(), listReq())
 
-	suite.NotNil(orders)
 	suite.Nil(err)
+	suite.Len(orders, 1)
+	suite.Equal(createdAt, orders[0].CreatedAt)
+	suite.Equal("test@test.com", orders[0].UserEmail)
+	suite.Equal("", next)
 }
 
-func (suite *OrderRepositorySuite) TestRepository_GetAllOrdersFailure() {
+func (suite *OrderRepositorySuite) TestRepository_GetOrdersNextPage() {
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	rows := listRows().
+		AddRow(5, 1, "test@test.com", createdAt.Add(2*time.Hour), createdAt, model.StatusCreated, 500).
+		AddRow(4, 1, "test@test.com", createdAt.Add(time.Hour), createdAt, model.StatusCreated, 700).
+		AddRow(3, 1, "test@test.com", createdAt, createdAt, model.StatusCreated, 900)
+	suite.mock.ExpectQuery("FROM orders WHERE user_id = \\$1 ORDER BY created_at DESC, id DESC LIMIT \\$2").WithArgs(1, 3).WillReturnRows(rows)
 
-	suite.mock.ExpectQuery("SELECT id, created_at, updated_at, status, total_price FROM orders").WithArgs(1).WillReturnError(errors.New("error"))
+	orders, next, err := suite.repo.GetOrders(context.Background(), listReq())
 
-	orders, err := suite.repo.GetAllOrders(1)
+	suite.Nil(err)
+	suite.Len(orders, 2)
+	suite.NotEmpty(next)
+
+	// The next page starts after the last order returned.
+	req := listReq()
+	req.Cursor = next
+	suite.mock.ExpectQuery("FROM orders WHERE user_id = \\$1 AND \\(created_at, id\\) < \\(\\$2, \\$3\\) ORDER BY created_at DESC, id DESC LIMIT \\$4").
+		WithArgs(1, createdAt.Add(time.Hour), 4, 3).WillReturnRows(listRows())
+
+	orders, next, err = suite.repo.GetOrders(context.Background(), req)
+
+	suite.Nil(err)
+	suite.Empty(orders)
+	suite.Equal("", next)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *OrderRepositorySuite) TestRepository_GetOrdersFilters() {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	req := &model.OrderListReq{
+		Status:   model.StatusCreated,
+		Email:    "Test@Test.com",
+		From:     from,
+		To:       to,
+		MinTotal: money.FromMinor(100),
+		MaxTotal: money.FromMinor(1000),
+		Sort:     model.SortTotal,
+		Dir:      model.SortAsc,
+		Limit:    20,
+	}
+
+	suite.mock.ExpectQuery("FROM orders WHERE status = \\$1 AND lower\\(user_email\\) = lower\\(\\$2\\) AND created_at >= \\$3 AND created_at < \\$4 "+
+		"AND total_price >= \\$5 AND total_price <= \\$6 ORDER BY total_price ASC, id ASC LIMIT \\$7").
+		WithArgs(model.StatusCreated, "Test@Test.com", from, to, int64(100), int64(1000), 21).WillReturnRows(listRows())
+
+	orders, _, err := suite.repo.GetOrders(context.Background(), req)
+
+	suite.Nil(err)
+	suite.Empty(orders)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *OrderRepositorySuite) TestRepository_GetOrdersCursorOfAnotherSort() {
+	req := listReq()
+	req.Cursor = encodeCursor(&model.Order{ID: 3, TotalPrice: money.FromMinor(900)}, model.SortTotal, model.SortDesc)
+
+	orders, _, err := suite.repo.GetOrders(context.Background(), req)
+
+	suite.Nil(orders)
+	suite.ErrorIs(err, ErrInvalidCursor)
+}
+
+func (suite *OrderRepositorySuite) TestRepository_GetOrdersFailure() {
+	suite.mock.ExpectQuery("FROM orders").WithArgs(1, 3).WillReturnError(errors.New("error"))
+
+	orders, _, err := suite.repo.GetOrders(context.Background(), listReq())
 
 	suite.Nil(orders)
 	suite.NotNil(err)
@@ -152,19 +338,116 @@ func (suite *OrderRepositorySuite) TestRepository_GetAllOrdersFailure() {
 
 // ====================================================================================================================
 
-func (suite *OrderRepositorySuite) TestRepository_UpdateOrderSuccess() {
-	suite.mock.ExpectExec("UPDATE orders SET updated_at = current_timestamp, status=\\$1 WHERE id=\\$2 AND user_id=\\$3").WithArgs(model.StatusOrderDelivered, 1, 1).
+func (suite *OrderRepositorySuite) TestRepository_UpdateOrderStatusSuccess() {
+	actor := model.Actor{ID: 5, Role: model.RoleModerator}
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("UPDATE orders SET updated_at = current_timestamp, status=\\$1 WHERE id=\\$2 AND status=\\$3").
+		WithArgs(model.StatusDelivering, 1, model.StatusCreated).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("INSERT INTO order_status_history").
+		WithArgs(1, model.StatusCreated, model.StatusDelivering, sql.NullInt64{Int64: 5, Valid: true}, model.RoleModerator, "shipped").
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectCommit()
 
-	err := suite.repo.UpdateOrder(1, 1, model.StatusOrderDelivered)
+	err := suite.repo.UpdateOrderStatus(context.Background(), 1, model.StatusCreated, model.StatusDelivering, actor, "shipped")
 
 	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *OrderRepositorySuite) TestRepository_UpdateOrderStatusConflict() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("UPDATE orders SET updated_at = current_timestamp, status=\\$1 WHERE id=\\$2 AND status=\\$3").
+		WithArgs(model.StatusCanceled, 1, model.StatusPending).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectRollback()
+
+	err := suite.repo.UpdateOrderStatus(context.Background(), 1, model.StatusPending, model.StatusCanceled, model.SystemActor, "")
+
+	suite.ErrorIs(err, ErrStatusConflict)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *OrderRepositorySuite) TestRepository_UpdateOrderStatusConflictWritesNoHistory() {
+	// The order was confirmed meanwhile, so canceling it as unpaid must neither apply nor be recorded.
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("UPDATE orders SET updated_at = current_timestamp, status=\\$1 WHERE id=\\$2 AND status=\\$3").
+		WithArgs(model.StatusCanceled, 1, model.StatusPending).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectRollback()
+
+	err := suite.repo.UpdateOrderStatus(context.Background(), 1, model.StatusPending, model.StatusCanceled, model.Actor{ID: 2, Role: model.RoleUser}, "changed my mind")
+
+	suite.ErrorIs(err, ErrStatusConflict)
+	suite.NotErrorIs(err, model.ErrInvalidTransition)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *OrderRepositorySuite) TestRepository_UpdateOrderStatusHistoryFailure() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("UPDATE orders SET updated_at = current_timestamp, status=\\$1 WHERE id=\\$2 AND status=\\$3").
+		WithArgs(model.StatusCreated, 1, model.StatusPending).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("INSERT INTO order_status_history").
+		WithArgs(1, model.StatusPending, model.StatusCreated, sql.NullInt64{}, model.RoleSystem, "payment succeeded").
+		WillReturnError(errors.New("error"))
+	suite.mock.ExpectRollback()
+
+	err := suite.repo.UpdateOrderStatus(context.Background(), 1, model.StatusPending, model.StatusCreated, model.SystemActor, "payment succeeded")
+
+	// The status change is rolled back with its history entry.
+	suite.NotNil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
 }
 
-func (suite *OrderRepositorySuite) TestRepository_UpdateOrderFailure() {
-	suite.mock.ExpectExec("UPDATE orders SET updated_at = current_timestamp, status=\\$1 WHERE id=\\$2 AND user_id=\\$3").WithArgs(model.StatusOrderDelivered, 1, 1)
+func (suite *OrderRepositorySuite) TestRepository_UpdateOrderStatusFailure() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("UPDATE orders SET updated_at = current_timestamp, status=\\$1 WHERE id=\\$2 AND status=\\$3").
+		WillReturnError(errors.New("error"))
+	suite.mock.ExpectRollback()
 
-	err := suite.repo.UpdateOrder(1, 1, model.StatusOrderDelivered)
+	err := suite.repo.UpdateOrderStatus(context.Background(), 1, model.StatusCreated, model.StatusDelivering, model.SystemActor, "")
 
 	suite.NotNil(err)
 }
+
+// ====================================================================================================================
+
+func (suite *OrderRepositorySuite) TestRepository_GetStatusHistorySuccess() {
+	rows := sqlmock.NewRows([]string{"id", "order_id", "from_status", "to_status", "actor_id", "actor_role", "reason", "created_at"}).
+		AddRow(1, 1, nil, model.StatusPending, 2, model.RoleUser, "", time.Now()).
+		AddRow(2, 1, model.StatusPending, model.StatusCreated, nil, model.RoleSystem, "payment succeeded", time.Now())
+	suite.mock.ExpectQuery("SELECT .* FROM order_status_history").WithArgs(1).WillReturnRows(rows)
+
+	history, err := suite.repo.GetStatusHistory(context.Background(), 1)
+
+	suite.Nil(err)
+	suite.Len(history, 2)
+	suite.Equal("", history[0].FromStatus)
+	suite.Equal(2, history[0].ActorID)
+	suite.Equal(0, history[1].ActorID)
+	suite.Equal(model.StatusCreated, history[1].ToStatus)
+}
+
+// ====================================================================================================================
+
+func (suite *OrderRepositorySuite) TestRepository_GetExpiredOrders() {
+	before := time.Now().Add(-30 * time.Minute)
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(4).AddRow(7)
+	suite.mock.ExpectQuery("SELECT id FROM orders WHERE status=\\$1 AND created_at<\\$2 ORDER BY created_at LIMIT \\$3").
+		WithArgs(model.StatusPending, before, 100).WillReturnRows(rows)
+
+	ids, err := suite.repo.GetExpiredOrders(context.Background(), before, 100)
+
+	suite.Nil(err)
+	suite.Equal([]int{4, 7}, ids)
+}
+
+// ====================================================================================================================
+
+func (suite *OrderRepositorySuite) TestRepository_SetPaymentID() {
+	suite.mock.ExpectExec("UPDATE orders SET updated_at = current_timestamp, payment_id=\\$1 WHERE id=\\$2").
+		WithArgs("pay-1", 4).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := suite.repo.SetPaymentID(context.Background(), 4, "pay-1")
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/pkg/db"
+	"time"
+)
+
+//go:generate mockery --name=IOutboxRepository
+
+type IOutboxRepository interface {
+	Add(ctx context.Context, topic, key string, payload any) error
+	FetchDue(ctx context.Context, limit int) ([]model.OutboxMessage, error)
+	MarkSent(ctx context.Context, id int) error
+	MarkRetry(ctx context.Context, id int, lastErr string, nextAttemptAt time.Time) error
+	MarkFailed(ctx context.Context, id int, lastErr string) error
+	GetMessages(ctx context.Context, status string, limit int) ([]model.OutboxMessage, error)
+	Requeue(ctx context.Context, id int) error
+}
+
+// ErrOutboxMessageNotFound is returned by Requeue when there is no failed message with the id.
+var ErrOutboxMessageNotFound = errors.New("failed outbox message not found")
+
+const outboxColumns = `id, topic, key, payload, status, attempts, last_error, next_attempt_at, created_at, sent_at`
+
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{
+		db: db,
+	}
+}
+
+// Add stores an event for the relay. Called with a transaction on ctx, the event is only
+// published if that transaction commits.
+func (r *OutboxRepository) Add(ctx context.Context, topic, key string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Conn(ctx, r.db).ExecContext(ctx, `INSERT INTO outbox (topic, key, payload) VALUES($1, $2, $3);`, topic, key, data)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FetchDue locks up to limit pending messages that are due for an attempt. Rows locked by another
+// relay are skipped, so several instances can drain the outbox at once.
+func (r *OutboxRepository) FetchDue(ctx context.Context, limit int) ([]model.OutboxMessage, error) {
+	rows, err := db.Conn(ctx, r.db).QueryContext(ctx, `SELECT `+outboxColumns+` FROM outbox
+		WHERE status=$1 AND next_attempt_at<=CURRENT_TIMESTAMP ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED;`, model.OutboxPending, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOutboxMessages(rows)
+}
+
+func (r *OutboxRepository) MarkSent(ctx context.Context, id int) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, `UPDATE outbox SET status=$1, attempts=attempts+1, last_error=NULL, sent_at=CURRENT_TIMESTAMP WHERE id=$2;`,
+		model.OutboxSent, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *OutboxRepository) MarkRetry(ctx context.Context, id int, lastErr string, nextAttemptAt time.Time) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, `UPDATE outbox SET attempts=attempts+1, last_error=$1, next_attempt_at=$2 WHERE id=$3;`,
+		lastErr, nextAttemptAt, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id int, lastErr string) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, `UPDATE outbox SET status=$1, attempts=attempts+1, last_error=$2 WHERE id=$3;`,
+		model.OutboxFailed, lastErr, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetMessages returns the newest messages in status, or of any status when it is empty.
+func (r *OutboxRepository) GetMessages(ctx context.Context, status string, limit int) ([]model.OutboxMessage, error) {
+	rows, err := db.Conn(ctx, r.db).QueryContext(ctx, `SELECT `+outboxColumns+` FROM outbox
+		WHERE $1='' OR status=$1 ORDER BY id DESC LIMIT $2;`, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOutboxMessages(rows)
+}
+
+// Requeue puts a failed message back in the queue with a fresh set of attempts.
+func (r *OutboxRepository) Requeue(ctx context.Context, id int) error {
+	res, err := db.Conn(ctx, r.db).ExecContext(ctx, `UPDATE outbox SET status=$1, attempts=0, next_attempt_at=CURRENT_TIMESTAMP WHERE id=$2 AND status=$3;`,
+		model.OutboxPending, id, model.OutboxFailed)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrOutboxMessageNotFound
+	}
+
+	return nil
+}
+
+func scanOutboxMessages(rows *sql.Rows) ([]model.OutboxMessage, error) {
+	var messages []model.OutboxMessage
+
+	for rows.Next() {
+		var message model.OutboxMessage
+		var payload []byte
+		var lastError sql.NullString
+		var sentAt sql.NullTime
+
+		err := rows.Scan(&message.ID, &message.Topic, &message.Key, &payload, &message.Status, &message.Attempts,
+			&lastError, &message.NextAttemptAt, &message.CreatedAt, &sentAt)
+		if err != nil {
+			return nil, err
+		}
+
+		message.Payload = payload
+		message.LastError = lastError.String
+		if sentAt.Valid {
+			message.SentAt = &sentAt.Time
+		}
+
+		messages = append(messages, message)
+	}
+
+	return messages, rows.Err()
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type OutboxRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo *OutboxRepository
+}
+
+func (suite *OutboxRepositorySuite) SetupTest() {
+	var err error
+	suite.db, suite.mock, err = sqlmock.New()
+	assert.NoError(suite.T(), err)
+	suite.repo = NewOutboxRepository(suite.db)
+}
+
+func TestOutboxRepositorySuite(t *testing.T) {
+	suite.Run(t, new(OutboxRepositorySuite))
+}
+
+func outboxRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "topic", "key", "payload", "status", "attempts", "last_error", "next_attempt_at", "created_at", "sent_at"})
+}
+
+// ====================================================================================================================
+
+func (suite *OutboxRepositorySuite) TestRepository_Add() {
+	suite.mock.ExpectExec("INSERT INTO outbox \\(topic, key, payload\\) VALUES\\(\\$1, \\$2, \\$3\\)").
+		WithArgs("order_created", "1", []byte(`{"id":1,"status":"Created"}`)).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := suite.repo.Add(context.Background(), "order_created", "1", map[string]any{"id": 1, "status": "Created"})
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *OutboxRepositorySuite) TestRepository_FetchDue() {
+	rows := outboxRows().
+		AddRow(1, "order_created", "1", []byte(`{"id":1}`), model.OutboxPending, 0, nil, time.Now(), time.Now(), nil).
+		AddRow(2, "order_created", "2", []byte(`{"id":2}`), model.OutboxPending, 3, "broker unavailable", time.Now(), time.Now(), nil)
+	suite.mock.ExpectQuery("SELECT .* FROM outbox .* FOR UPDATE SKIP LOCKED").WithArgs(model.OutboxPending, 100).WillReturnRows(rows)
+
+	messages, err := suite.repo.FetchDue(context.Background(), 100)
+
+	suite.Nil(err)
+	suite.Len(messages, 2)
+	suite.JSONEq(`{"id":1}`, string(messages[0].Payload))
+	suite.Equal("", messages[0].LastError)
+	suite.Equal(3, messages[1].Attempts)
+	suite.Equal("broker unavailable", messages[1].LastError)
+	suite.Nil(messages[1].SentAt)
+}
+
+func (suite *OutboxRepositorySuite) TestRepository_MarkRetry() {
+	next := time.Now().Add(time.Minute)
+	suite.mock.ExpectExec("UPDATE outbox SET attempts=attempts\\+1, last_error=\\$1, next_attempt_at=\\$2 WHERE id=\\$3").
+		WithArgs("broker unavailable", next, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := suite.repo.MarkRetry(context.Background(), 1, "broker unavailable", next)
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+// ====================================================================================================================
+
+func (suite *OutboxRepositorySuite) TestRepository_GetMessages() {
+	sentAt := time.Now()
+	rows := outboxRows().AddRow(1, "order_created", "1", []byte(`{"id":1}`), model.OutboxSent, 1, nil, time.Now(), time.Now(), sentAt)
+	suite.mock.ExpectQuery("SELECT .* FROM outbox").WithArgs(model.OutboxSent, 50).WillReturnRows(rows)
+
+	messages, err := suite.repo.GetMessages(context.Background(), model.OutboxSent, 50)
+
+	suite.Nil(err)
+	suite.Len(messages, 1)
+	suite.Equal(sentAt, *messages[0].SentAt)
+}
+
+func (suite *OutboxRepositorySuite) TestRepository_RequeueSuccess() {
+	suite.mock.ExpectExec("UPDATE outbox SET status=\\$1, attempts=0").
+		WithArgs(model.OutboxPending, 1, model.OutboxFailed).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := suite.repo.Requeue(context.Background(), 1)
+
+	suite.Nil(err)
+}
+
+func (suite *OutboxRepositorySuite) TestRepository_RequeueNotFailed() {
+	suite.mock.ExpectExec("UPDATE outbox SET status=\\$1, attempts=0").
+		WithArgs(model.OutboxPending, 1, model.OutboxFailed).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := suite.repo.Requeue(context.Background(), 1)
+
+	suite.ErrorIs(err, ErrOutboxMessageNotFound)
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/pkg/db"
+	"github.com/aaanger/ecommerce/pkg/money"
+)
+
+//go:generate mockery --name=IReturnRepository
+
+type IReturnRepository interface {
+	CreateReturn(ctx context.Context, orderID int, req *model.ReturnReq) (*model.Return, error)
+	GetReturn(ctx context.Context, id int) (*model.Return, error)
+	GetReturnsByOrder(ctx context.Context, orderID int) ([]model.Return, error)
+	GetReturns(ctx context.Context, status string, limit int) ([]model.Return, error)
+	ApprovedQuantity(ctx context.Context, orderLineID, excludeID int) (int, error)
+	UpdateReturnStatus(ctx context.Context, id int, from, to, comment string) error
+	SetRefund(ctx context.Context, id int, refundID string, amount money.Money) error
+}
+
+var (
+	ErrOrderLineNotFound      = errors.New("order line not found")
+	ErrReturnQuantityExceeded = errors.New("return quantity exceeds what is left to return on the line")
+	ErrReturnStatusConflict   = errors.New("return status was changed concurrently")
+)
+
+const returnColumns = `id, order_id, order_line_id, quantity, reason, status, comment, refund_amount, refund_id, created_at, updated_at`
+
+type ReturnRepository struct {
+	db *sql.DB
+}
+
+func NewReturnRepository(db *sql.DB) *ReturnRepository {
+	return &ReturnRepository{
+		db: db,
+	}
+}
+
+// CreateReturn requests a return for a line of the order. The line is locked while the quantity is checked,
+// so returns that are requested or approved never add up to more than was ordered.
+func (r *ReturnRepository) CreateReturn(ctx context.Context, orderID int, req *model.ReturnReq) (*model.Return, error) {
+	var ret *model.Return
+
+	err := db.RunInTx(ctx, r.db, func(ctx context.Context) error {
+		conn := db.Conn(ctx, r.db)
+
+		var quantity int
+		err := conn.QueryRowContext(ctx, `SELECT quantity FROM orderline WHERE id=$1 AND order_id=$2 FOR UPDATE;`, req.OrderLineID, orderID).Scan(&quantity)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrOrderLineNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var taken int
+		err = conn.QueryRowContext(ctx, `SELECT COALESCE(SUM(quantity), 0) FROM returns WHERE order_line_id=$1 AND status<>$2;`,
+			req.OrderLineID, model.ReturnRejected).Scan(&taken)
+		if err != nil {
+			return err
+		}
+
+		if taken+req.Quantity > quantity {
+			return ErrReturnQuantityExceeded
+		}
+
+		row := conn.QueryRowContext(ctx, `INSERT INTO returns (order_id, order_line_id, quantity, reason, status)
+			VALUES($1, $2, $3, $4, $5) RETURNING `+returnColumns+`;`,
+			orderID, req.OrderLineID, req.Quantity, req.Reason, model.ReturnRequested)
+
+		ret, err = scanReturn(row)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *ReturnRepository) GetReturn(ctx context.Context, id int) (*model.Return, error) {
+	return scanReturn(db.Conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+returnColumns+` FROM returns WHERE id=$1;`, id))
+}
+
+func (r *ReturnRepository) GetReturnsByOrder(ctx context.Context, orderID int) ([]model.Return, error) {
+	rows, err := db.Conn(ctx, r.db).QueryContext(ctx, `SELECT `+returnColumns+` FROM returns WHERE order_id=$1 ORDER BY id;`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanReturns(rows)
+}
+
+// GetReturns lists up to limit returns in the status, oldest first. An empty status lists all of them.
+func (r *ReturnRepository) GetReturns(ctx context.Context, status string, limit int) ([]model.Return, error) {
+	rows, err := db.Conn(ctx, r.db).QueryContext(ctx, `SELECT `+returnColumns+` FROM returns
+		WHERE $1='' OR status=$1 ORDER BY created_at, id LIMIT $2;`, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanReturns(rows)
+}
+
+// ApprovedQuantity counts the items of the line already approved for return, refunded or not, leaving out the return excludeID.
+func (r *ReturnRepository) ApprovedQuantity(ctx context.Context, orderLineID, excludeID int) (int, error) {
+	var quantity int
+
+	err := db.Conn(ctx, r.db).QueryRowContext(ctx, `SELECT COALESCE(SUM(quantity), 0) FROM returns WHERE order_line_id=$1 AND status IN ($2, $3) AND id<>$4;`,
+		orderLineID, model.ReturnApproved, model.ReturnRefunded, excludeID).Scan(&quantity)
+	if err != nil {
+		return 0, err
+	}
+
+	return quantity, nil
+}
+
+// UpdateReturnStatus moves the return on only if it is still in status from and returns ErrReturnStatusConflict otherwise.
+func (r *ReturnRepository) UpdateReturnStatus(ctx context.Context, id int, from, to, comment string) error {
+	res, err := db.Conn(ctx, r.db).ExecContext(ctx, `UPDATE returns SET status=$1, comment=$2, updated_at=current_timestamp WHERE id=$3 AND status=$4;`,
+		to, comment, id, from)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrReturnStatusConflict
+	}
+
+	return nil
+}
+
+// SetRefund records the refund of an approved return and moves it to refunded. An empty refundID means nothing
+// was left to pay back. A return that is not approved any more gives ErrReturnStatusConflict.
+func (r *ReturnRepository) SetRefund(ctx context.Context, id int, refundID string, amount money.Money) error {
+	res, err := db.Conn(ctx, r.db).ExecContext(ctx, `UPDATE returns SET status=$1, refund_id=NULLIF($2, ''), refund_amount=$3, updated_at=current_timestamp
+		WHERE id=$4 AND status=$5;`,
+		model.ReturnRefunded, refundID, amount, id, model.ReturnApproved)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrReturnStatusConflict
+	}
+
+	return nil
+}
+
+func scanReturns(rows *sql.Rows) ([]model.Return, error) {
+	var returns []model.Return
+
+	for rows.Next() {
+		ret, err := scanReturn(rows)
+		if err != nil {
+			return nil, err
+		}
+		returns = append(returns, *ret)
+	}
+
+	return returns, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReturn(row scanner) (*model.Return, error) {
+	var ret model.Return
+	var refundID sql.NullString
+
+	err := row.Scan(&ret.ID, &ret.OrderID, &ret.OrderLineID, &ret.Quantity, &ret.Reason, &ret.Status, &ret.Comment,
+		&ret.RefundAmount, &refundID, &ret.CreatedAt, &ret.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	ret.RefundID = refundID.String
+	return &ret, nil
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type ReturnRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo *ReturnRepository
+}
+
+func (suite *ReturnRepositorySuite) SetupTest() {
+	var err error
+	suite.db, suite.mock, err = sqlmock.New()
+	assert.NoError(suite.T(), err)
+	suite.repo = NewReturnRepository(suite.db)
+}
+
+func TestReturnRepositorySuite(t *testing.T) {
+	suite.Run(t, new(ReturnRepositorySuite))
+}
+
+func returnRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "order_id", "order_line_id", "quantity", "reason", "status", "comment", "refund_amount", "refund_id", "created_at", "updated_at"})
+}
+
+// ====================================================================================================================
+
+func (suite *ReturnRepositorySuite) TestRepository_CreateReturn() {
+	req := &model.ReturnReq{OrderLineID: 10, Quantity: 2, Reason: "broken"}
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT quantity FROM orderline WHERE id=\\$1 AND order_id=\\$2 FOR UPDATE").WithArgs(10, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(3))
+	suite.mock.ExpectQuery("SELECT COALESCE\\(SUM\\(quantity\\), 0\\) FROM returns WHERE order_line_id=\\$1 AND status<>\\$2").
+		WithArgs(10, model.ReturnRejected).WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(1))
+	suite.mock.ExpectQuery("INSERT INTO returns").WithArgs(1, 10, 2, "broken", model.ReturnRequested).
+		WillReturnRows(returnRows().AddRow(7, 1, 10, 2, "broken", model.ReturnRequested, "", 0, nil, time.Now(), time.Now()))
+	suite.mock.ExpectCommit()
+
+	ret, err := suite.repo.CreateReturn(context.Background(), 1, req)
+
+	suite.Nil(err)
+	suite.Equal(7, ret.ID)
+	suite.Equal(model.ReturnRequested, ret.Status)
+	suite.Equal("", ret.RefundID)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *ReturnRepositorySuite) TestRepository_CreateReturnQuantityExceeded() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT quantity FROM orderline").WithArgs(10, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(3))
+	suite.mock.ExpectQuery("SELECT COALESCE\\(SUM\\(quantity\\), 0\\) FROM returns").
+		WithArgs(10, model.ReturnRejected).WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(2))
+	suite.mock.ExpectRollback()
+
+	_, err := suite.repo.CreateReturn(context.Background(), 1, &model.ReturnReq{OrderLineID: 10, Quantity: 2, Reason: "broken"})
+
+	suite.ErrorIs(err, ErrReturnQuantityExceeded)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *ReturnRepositorySuite) TestRepository_CreateReturnLineNotFound() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT quantity FROM orderline").WithArgs(11, 1).WillReturnError(sql.ErrNoRows)
+	suite.mock.ExpectRollback()
+
+	_, err := suite.repo.CreateReturn(context.Background(), 1, &model.ReturnReq{OrderLineID: 11, Quantity: 1, Reason: "broken"})
+
+	suite.ErrorIs(err, ErrOrderLineNotFound)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+// ====================================================================================================================
+
+func (suite *ReturnRepositorySuite) TestRepository_GetReturns() {
+	rows := returnRows().
+		AddRow(7, 1, 10, 1, "broken", model.ReturnApproved, "ok", 30000, "refund-1", time.Now(), time.Now()).
+		AddRow(8, 2, 12, 1, "wrong size", model.ReturnApproved, "", 0, nil, time.Now(), time.Now())
+	suite.mock.ExpectQuery("SELECT .* FROM returns WHERE \\$1='' OR status=\\$1 ORDER BY created_at, id LIMIT \\$2").
+		WithArgs(model.ReturnApproved, 50).WillReturnRows(rows)
+
+	returns, err := suite.repo.GetReturns(context.Background(), model.ReturnApproved, 50)
+
+	suite.Nil(err)
+	suite.Len(returns, 2)
+	suite.Equal(money.FromMinor(30000), returns[0].RefundAmount)
+	suite.Equal("refund-1", returns[0].RefundID)
+	suite.Equal("", returns[1].RefundID)
+}
+
+func (suite *ReturnRepositorySuite) TestRepository_ApprovedQuantity() {
+	suite.mock.ExpectQuery("SELECT COALESCE\\(SUM\\(quantity\\), 0\\) FROM returns WHERE order_line_id=\\$1 AND status IN \\(\\$2, \\$3\\) AND id<>\\$4").
+		WithArgs(10, model.ReturnApproved, model.ReturnRefunded, 7).WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(2))
+
+	quantity, err := suite.repo.ApprovedQuantity(context.Background(), 10, 7)
+
+	suite.Nil(err)
+	suite.Equal(2, quantity)
+}
+
+// ====================================================================================================================
+
+func (suite *ReturnRepositorySuite) TestRepository_UpdateReturnStatus() {
+	suite.mock.ExpectExec("UPDATE returns SET status=\\$1, comment=\\$2, updated_at=current_timestamp WHERE id=\\$3 AND status=\\$4").
+		WithArgs(model.ReturnApproved, "ok", 7, model.ReturnRequested).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := suite.repo.UpdateReturnStatus(context.Background(), 7, model.ReturnRequested, model.ReturnApproved, "ok")
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *ReturnRepositorySuite) TestRepository_UpdateReturnStatusConflict() {
+	suite.mock.ExpectExec("UPDATE returns SET status=\\$1").
+		WithArgs(model.ReturnApproved, "ok", 7, model.ReturnRequested).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := suite.repo.UpdateReturnStatus(context.Background(), 7, model.ReturnRequested, model.ReturnApproved, "ok")
+
+	suite.ErrorIs(err, ErrReturnStatusConflict)
+}
+
+func (suite *ReturnRepositorySuite) TestRepository_SetRefund() {
+	suite.mock.ExpectExec("UPDATE returns SET status=\\$1, refund_id=NULLIF\\(\\$2, ''\\), refund_amount=\\$3").
+		WithArgs(model.ReturnRefunded, "refund-1", int64(30000), 7, model.ReturnApproved).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := suite.repo.SetRefund(context.Background(), 7, "refund-1", money.FromMinor(30000))
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *ReturnRepositorySuite) TestRepository_SetRefundNotApproved() {
+	suite.mock.ExpectExec("UPDATE returns SET status=\\$1, refund_id=NULLIF\\(\\$2, ''\\), refund_amount=\\$3").
+		WithArgs(model.ReturnRefunded, "refund-1", int64(30000), 7, model.ReturnApproved).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := suite.repo.SetRefund(context.Background(), 7, "refund-1", money.FromMinor(30000))
+
+	suite.ErrorIs(err, ErrReturnStatusConflict)
+}
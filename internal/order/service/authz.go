@@ -0,0 +1,49 @@
+package service
+
+import (
+	"errors"
+	"github.com/aaanger/ecommerce/internal/order/model"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrNotOrderOwner     = errors.New("not your order")
+	ErrModeratorRequired = errors.New("moderator role required")
+)
+
+// authorize lets customers act on their own orders only, moderators and the system act on any order.
+// Every denial is logged with the action that was refused.
+func authorize(log *zap.Logger, order *model.Order, actor model.Actor, action string) error {
+	switch actor.Role {
+	case model.RoleModerator, model.RoleSystem:
+		return nil
+	case model.RoleUser:
+		if order.UserID == actor.ID {
+			return nil
+		}
+	}
+
+	deny(log, order, actor, action, ErrNotOrderOwner)
+	return ErrNotOrderOwner
+}
+
+// authorizeModerator allows the action to moderators and the system only.
+func authorizeModerator(log *zap.Logger, order *model.Order, actor model.Actor, action string) error {
+	if actor.Role == model.RoleModerator || actor.Role == model.RoleSystem {
+		return nil
+	}
+
+	deny(log, order, actor, action, ErrModeratorRequired)
+	return ErrModeratorRequired
+}
+
+func deny(log *zap.Logger, order *model.Order, actor model.Actor, action string, err error) {
+	log.Warn("Order access denied",
+		zap.String("action", action),
+		zap.Int("orderID", order.ID),
+		zap.Int("ownerID", order.UserID),
+		zap.Int("actorID", actor.ID),
+		zap.String("role", actor.Role),
+		zap.Error(err))
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	paymentRepository "github.com/aaanger/ecommerce/internal/payment/repository"
+	"github.com/aaanger/ecommerce/pkg/db"
+	"go.uber.org/zap"
+	"time"
+)
+
+// DefaultHoldSettleMargin is how long before a payment hold expires it gets settled. YooKassa keeps card
+// holds for seven days, so a day leaves room for retries.
+const DefaultHoldSettleMargin = 24 * time.Hour
+
+const (
+	// holdSweeperLockKey identifies the sweeper's advisory lock.
+	holdSweeperLockKey = 2002
+	holdSweeperBatch   = 100
+)
+
+// HoldSweeper periodically settles payment holds that are about to expire, so an order waiting to ship
+// does not lose its money and a canceled one does not keep the customer's money blocked. Only the
+// instance holding the advisory lock runs a sweep.
+type HoldSweeper struct {
+	payments paymentRepository.IPaymentRepository
+	orders   IOrderService
+	locker   db.Locker
+	margin   time.Duration
+	interval time.Duration
+	log      *zap.Logger
+}
+
+func NewHoldSweeper(payments paymentRepository.IPaymentRepository, orders IOrderService, locker db.Locker, margin, interval time.Duration, log *zap.Logger) *HoldSweeper {
+	return &HoldSweeper{
+		payments: payments,
+		orders:   orders,
+		locker:   locker,
+		margin:   margin,
+		interval: interval,
+		log:      log,
+	}
+}
+
+func (s *HoldSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Sweep(ctx)
+		}
+	}
+}
+
+func (s *HoldSweeper) Sweep(ctx context.Context) {
+	unlock, ok, err := s.locker.TryLock(ctx, holdSweeperLockKey)
+	if err != nil {
+		s.log.Error("Hold sweeper: failed to take lock", zap.Error(err))
+		return
+	}
+	if !ok {
+		s.log.Debug("Hold sweeper: another instance is running")
+		return
+	}
+	defer unlock()
+
+	holds, err := s.payments.GetExpiringHolds(ctx, time.Now().Add(s.margin), holdSweeperBatch)
+	if err != nil {
+		s.log.Error("Hold sweeper: failed to get expiring holds", zap.Error(err))
+		return
+	}
+
+	var settled int
+	for _, hold := range holds {
+		if err = s.orders.SettleHold(ctx, hold.OrderID, hold.ProviderPaymentID); err != nil {
+			s.log.Error("Hold sweeper: failed to settle hold", zap.Error(err), zap.Int("orderID", hold.OrderID),
+				zap.String("paymentID", hold.ProviderPaymentID))
+			continue
+		}
+		settled++
+	}
+
+	if settled > 0 {
+		s.log.Info("Hold sweeper: expiring holds settled", zap.Int("count", settled))
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	serviceMocks "github.com/aaanger/ecommerce/internal/order/service/mocks"
+	paymentModel "github.com/aaanger/ecommerce/internal/payment/model"
+	paymentMocks "github.com/aaanger/ecommerce/internal/payment/repository/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+type HoldSweeperSuite struct {
+	suite.Suite
+	payments *paymentMocks.IPaymentRepository
+	orders   *serviceMocks.IOrderService
+	locker   *locker
+	sweeper  *HoldSweeper
+}
+
+func (suite *HoldSweeperSuite) SetupTest() {
+	suite.payments = paymentMocks.NewIPaymentRepository(suite.T())
+	suite.orders = serviceMocks.NewIOrderService(suite.T())
+	suite.locker = &locker{}
+	suite.sweeper = NewHoldSweeper(suite.payments, suite.orders, suite.locker, DefaultHoldSettleMargin, time.Hour, zap.NewNop())
+}
+
+func TestHoldSweeperSuite(t *testing.T) {
+	suite.Run(t, new(HoldSweeperSuite))
+}
+
+// ====================================================================================================================
+
+func (suite *HoldSweeperSuite) TestSweeper_SettlesHolds() {
+	suite.payments.On("GetExpiringHolds", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		return before.After(time.Now().Add(23 * time.Hour))
+	}), holdSweeperBatch).Return([]paymentModel.Payment{
+		{ProviderPaymentID: "pay-1", OrderID: 1},
+		{ProviderPaymentID: "pay-2", OrderID: 2},
+	}, nil)
+	suite.orders.On("SettleHold", mock.Anything, 1, "pay-1").Return(errors.New("error"))
+	suite.orders.On("SettleHold", mock.Anything, 2, "pay-2").Return(nil)
+
+	suite.sweeper.Sweep(context.Background())
+
+	// A failing hold does not hold up the rest of the batch.
+	suite.orders.AssertNumberOfCalls(suite.T(), "SettleHold", 2)
+	suite.Equal(1, suite.locker.unlocked)
+}
+
+func (suite *HoldSweeperSuite) TestSweeper_SkipsWhenLocked() {
+	suite.locker.held = true
+
+	suite.sweeper.Sweep(context.Background())
+
+	suite.payments.AssertNotCalled(suite.T(), "GetExpiringHolds", mock.Anything, mock.Anything, mock.Anything)
+}
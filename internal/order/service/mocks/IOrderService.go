@@ -1,9 +1,12 @@
-// Code generated by mockery v2.50.4. DO NOT EDIT.
+// Code generated by mockery v2.53.5. DO NOT EDIT.
 
 package mocks
 
 import (
+	context "context"
+
 	model "github.com/aaanger/ecommerce/internal/order/model"
+	paymentmodel "github.com/aaanger/ecommerce/internal/payment/model"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -12,29 +15,65 @@ type IOrderService struct {
 	mock.Mock
 }
 
-// CancelOrder provides a mock function with given fields: userID, orderID
-func (_m *IOrderService) CancelOrder(userID int, orderID int) (*model.Order, error) {
-	ret := _m.Called(userID, orderID)
+// CancelOrder provides a mock function with given fields: ctx, orderID, actor, reason
+func (_m *IOrderService) CancelOrder(ctx context.Context, orderID int, actor model.Actor, reason string) error {
+	ret := _m.Called(ctx, orderID, actor, reason)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CancelOrder")
 	}
 
-	var r0 *model.Order
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.Actor, string) error); ok {
+		r0 = rf(ctx, orderID, actor, reason)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConfirmOrder provides a mock function with given fields: ctx, orderID, paymentID
+func (_m *IOrderService) ConfirmOrder(ctx context.Context, orderID int, paymentID string) error {
+	ret := _m.Called(ctx, orderID, paymentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConfirmOrder")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, orderID, paymentID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateOrder provides a mock function with given fields: ctx, userID, userEmail, lines
+func (_m *IOrderService) CreateOrder(ctx context.Context, userID int, userEmail string, lines *model.CreateOrderReq) (*model.CreateOrderRes, error) {
+	ret := _m.Called(ctx, userID, userEmail, lines)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOrder")
+	}
+
+	var r0 *model.CreateOrderRes
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int, int) (*model.Order, error)); ok {
-		return rf(userID, orderID)
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, *model.CreateOrderReq) (*model.CreateOrderRes, error)); ok {
+		return rf(ctx, userID, userEmail, lines)
 	}
-	if rf, ok := ret.Get(0).(func(int, int) *model.Order); ok {
-		r0 = rf(userID, orderID)
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, *model.CreateOrderReq) *model.CreateOrderRes); ok {
+		r0 = rf(ctx, userID, userEmail, lines)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.Order)
+			r0 = ret.Get(0).(*model.CreateOrderRes)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int, int) error); ok {
-		r1 = rf(userID, orderID)
+	if rf, ok := ret.Get(1).(func(context.Context, int, string, *model.CreateOrderReq) error); ok {
+		r1 = rf(ctx, userID, userEmail, lines)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -42,29 +81,47 @@ func (_m *IOrderService) CancelOrder(userID int, orderID int) (*model.Order, err
 	return r0, r1
 }
 
-// CreateOrder provides a mock function with given fields: userID, lines
-func (_m *IOrderService) CreateOrder(userID int, lines *model.CreateOrderReq) (*model.Order, error) {
-	ret := _m.Called(userID, lines)
+// ExpireOrder provides a mock function with given fields: ctx, orderID
+func (_m *IOrderService) ExpireOrder(ctx context.Context, orderID int) error {
+	ret := _m.Called(ctx, orderID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreateOrder")
+		panic("no return value specified for ExpireOrder")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetOrderByID provides a mock function with given fields: ctx, orderID, actor
+func (_m *IOrderService) GetOrderByID(ctx context.Context, orderID int, actor model.Actor) (*model.Order, error) {
+	ret := _m.Called(ctx, orderID, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrderByID")
 	}
 
 	var r0 *model.Order
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int, *model.CreateOrderReq) (*model.Order, error)); ok {
-		return rf(userID, lines)
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.Actor) (*model.Order, error)); ok {
+		return rf(ctx, orderID, actor)
 	}
-	if rf, ok := ret.Get(0).(func(int, *model.CreateOrderReq) *model.Order); ok {
-		r0 = rf(userID, lines)
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.Actor) *model.Order); ok {
+		r0 = rf(ctx, orderID, actor)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Order)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int, *model.CreateOrderReq) error); ok {
-		r1 = rf(userID, lines)
+	if rf, ok := ret.Get(1).(func(context.Context, int, model.Actor) error); ok {
+		r1 = rf(ctx, orderID, actor)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -72,29 +129,29 @@ func (_m *IOrderService) CreateOrder(userID int, lines *model.CreateOrderReq) (*
 	return r0, r1
 }
 
-// GetAllOrders provides a mock function with given fields: userID
-func (_m *IOrderService) GetAllOrders(userID int) ([]model.Order, error) {
-	ret := _m.Called(userID)
+// GetOrderPayments provides a mock function with given fields: ctx, orderID, actor
+func (_m *IOrderService) GetOrderPayments(ctx context.Context, orderID int, actor model.Actor) ([]paymentmodel.Payment, error) {
+	ret := _m.Called(ctx, orderID, actor)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetAllOrders")
+		panic("no return value specified for GetOrderPayments")
 	}
 
-	var r0 []model.Order
+	var r0 []paymentmodel.Payment
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int) ([]model.Order, error)); ok {
-		return rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.Actor) ([]paymentmodel.Payment, error)); ok {
+		return rf(ctx, orderID, actor)
 	}
-	if rf, ok := ret.Get(0).(func(int) []model.Order); ok {
-		r0 = rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.Actor) []paymentmodel.Payment); ok {
+		r0 = rf(ctx, orderID, actor)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]model.Order)
+			r0 = ret.Get(0).([]paymentmodel.Payment)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int) error); ok {
-		r1 = rf(userID)
+	if rf, ok := ret.Get(1).(func(context.Context, int, model.Actor) error); ok {
+		r1 = rf(ctx, orderID, actor)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -102,29 +159,29 @@ func (_m *IOrderService) GetAllOrders(userID int) ([]model.Order, error) {
 	return r0, r1
 }
 
-// GetOrderByID provides a mock function with given fields: userID, orderID
-func (_m *IOrderService) GetOrderByID(userID int, orderID int) (*model.Order, error) {
-	ret := _m.Called(userID, orderID)
+// GetOrders provides a mock function with given fields: ctx, actor, req
+func (_m *IOrderService) GetOrders(ctx context.Context, actor model.Actor, req *model.OrderListReq) (*model.OrderListRes, error) {
+	ret := _m.Called(ctx, actor, req)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetOrderByID")
+		panic("no return value specified for GetOrders")
 	}
 
-	var r0 *model.Order
+	var r0 *model.OrderListRes
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int, int) (*model.Order, error)); ok {
-		return rf(userID, orderID)
+	if rf, ok := ret.Get(0).(func(context.Context, model.Actor, *model.OrderListReq) (*model.OrderListRes, error)); ok {
+		return rf(ctx, actor, req)
 	}
-	if rf, ok := ret.Get(0).(func(int, int) *model.Order); ok {
-		r0 = rf(userID, orderID)
+	if rf, ok := ret.Get(0).(func(context.Context, model.Actor, *model.OrderListReq) *model.OrderListRes); ok {
+		r0 = rf(ctx, actor, req)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.Order)
+			r0 = ret.Get(0).(*model.OrderListRes)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int, int) error); ok {
-		r1 = rf(userID, orderID)
+	if rf, ok := ret.Get(1).(func(context.Context, model.Actor, *model.OrderListReq) error); ok {
+		r1 = rf(ctx, actor, req)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -132,9 +189,85 @@ func (_m *IOrderService) GetOrderByID(userID int, orderID int) (*model.Order, er
 	return r0, r1
 }
 
-// UpdateOrderStatus provides a mock function with given fields: userID, orderID, status
-func (_m *IOrderService) UpdateOrderStatus(userID int, orderID int, status string) (*model.Order, error) {
-	ret := _m.Called(userID, orderID, status)
+// GetStatusHistory provides a mock function with given fields: ctx, orderID, actor
+func (_m *IOrderService) GetStatusHistory(ctx context.Context, orderID int, actor model.Actor) ([]model.StatusChange, error) {
+	ret := _m.Called(ctx, orderID, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStatusHistory")
+	}
+
+	var r0 []model.StatusChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.Actor) ([]model.StatusChange, error)); ok {
+		return rf(ctx, orderID, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.Actor) []model.StatusChange); ok {
+		r0 = rf(ctx, orderID, actor)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.StatusChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, model.Actor) error); ok {
+		r1 = rf(ctx, orderID, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReserveProducts provides a mock function with given fields: ctx, orderID, lines
+func (_m *IOrderService) ReserveProducts(ctx context.Context, orderID int, lines []model.OrderLineReq) (int, error) {
+	ret := _m.Called(ctx, orderID, lines)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReserveProducts")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, []model.OrderLineReq) (int, error)); ok {
+		return rf(ctx, orderID, lines)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, []model.OrderLineReq) int); ok {
+		r0 = rf(ctx, orderID, lines)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, []model.OrderLineReq) error); ok {
+		r1 = rf(ctx, orderID, lines)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SettleHold provides a mock function with given fields: ctx, orderID, paymentID
+func (_m *IOrderService) SettleHold(ctx context.Context, orderID int, paymentID string) error {
+	ret := _m.Called(ctx, orderID, paymentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SettleHold")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, orderID, paymentID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateOrderStatus provides a mock function with given fields: ctx, orderID, status, actor, reason
+func (_m *IOrderService) UpdateOrderStatus(ctx context.Context, orderID int, status string, actor model.Actor, reason string) (*model.Order, error) {
+	ret := _m.Called(ctx, orderID, status, actor, reason)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdateOrderStatus")
@@ -142,19 +275,19 @@ func (_m *IOrderService) UpdateOrderStatus(userID int, orderID int, status strin
 
 	var r0 *model.Order
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int, int, string) (*model.Order, error)); ok {
-		return rf(userID, orderID, status)
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, model.Actor, string) (*model.Order, error)); ok {
+		return rf(ctx, orderID, status, actor, reason)
 	}
-	if rf, ok := ret.Get(0).(func(int, int, string) *model.Order); ok {
-		r0 = rf(userID, orderID, status)
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, model.Actor, string) *model.Order); ok {
+		r0 = rf(ctx, orderID, status, actor, reason)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Order)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int, int, string) error); ok {
-		r1 = rf(userID, orderID, status)
+	if rf, ok := ret.Get(1).(func(context.Context, int, string, model.Actor, string) error); ok {
+		r1 = rf(ctx, orderID, status, actor, reason)
 	} else {
 		r1 = ret.Error(1)
 	}
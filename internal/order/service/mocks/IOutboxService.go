@@ -0,0 +1,77 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/aaanger/ecommerce/internal/order/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IOutboxService is an autogenerated mock type for the IOutboxService type
+type IOutboxService struct {
+	mock.Mock
+}
+
+// GetMessages provides a mock function with given fields: ctx, status, limit
+func (_m *IOutboxService) GetMessages(ctx context.Context, status string, limit int) ([]model.OutboxMessage, error) {
+	ret := _m.Called(ctx, status, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMessages")
+	}
+
+	var r0 []model.OutboxMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]model.OutboxMessage, error)); ok {
+		return rf(ctx, status, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []model.OutboxMessage); ok {
+		r0 = rf(ctx, status, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.OutboxMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, status, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RetryMessage provides a mock function with given fields: ctx, id
+func (_m *IOutboxService) RetryMessage(ctx context.Context, id int) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetryMessage")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewIOutboxService creates a new instance of IOutboxService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIOutboxService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IOutboxService {
+	mock := &IOutboxService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
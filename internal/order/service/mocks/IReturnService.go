@@ -0,0 +1,179 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/aaanger/ecommerce/internal/order/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IReturnService is an autogenerated mock type for the IReturnService type
+type IReturnService struct {
+	mock.Mock
+}
+
+// ApproveReturn provides a mock function with given fields: ctx, id, comment
+func (_m *IReturnService) ApproveReturn(ctx context.Context, id int, comment string) (*model.Return, error) {
+	ret := _m.Called(ctx, id, comment)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ApproveReturn")
+	}
+
+	var r0 *model.Return
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) (*model.Return, error)); ok {
+		return rf(ctx, id, comment)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) *model.Return); ok {
+		r0 = rf(ctx, id, comment)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Return)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, id, comment)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrderReturns provides a mock function with given fields: ctx, actor, orderID
+func (_m *IReturnService) GetOrderReturns(ctx context.Context, actor model.Actor, orderID int) ([]model.Return, error) {
+	ret := _m.Called(ctx, actor, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrderReturns")
+	}
+
+	var r0 []model.Return
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.Actor, int) ([]model.Return, error)); ok {
+		return rf(ctx, actor, orderID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.Actor, int) []model.Return); ok {
+		r0 = rf(ctx, actor, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Return)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.Actor, int) error); ok {
+		r1 = rf(ctx, actor, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReturns provides a mock function with given fields: ctx, status, limit
+func (_m *IReturnService) GetReturns(ctx context.Context, status string, limit int) ([]model.Return, error) {
+	ret := _m.Called(ctx, status, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReturns")
+	}
+
+	var r0 []model.Return
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]model.Return, error)); ok {
+		return rf(ctx, status, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []model.Return); ok {
+		r0 = rf(ctx, status, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Return)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, status, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RejectReturn provides a mock function with given fields: ctx, id, comment
+func (_m *IReturnService) RejectReturn(ctx context.Context, id int, comment string) (*model.Return, error) {
+	ret := _m.Called(ctx, id, comment)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RejectReturn")
+	}
+
+	var r0 *model.Return
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) (*model.Return, error)); ok {
+		return rf(ctx, id, comment)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) *model.Return); ok {
+		r0 = rf(ctx, id, comment)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Return)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, id, comment)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RequestReturn provides a mock function with given fields: ctx, actor, orderID, req
+func (_m *IReturnService) RequestReturn(ctx context.Context, actor model.Actor, orderID int, req *model.ReturnReq) (*model.Return, error) {
+	ret := _m.Called(ctx, actor, orderID, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequestReturn")
+	}
+
+	var r0 *model.Return
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.Actor, int, *model.ReturnReq) (*model.Return, error)); ok {
+		return rf(ctx, actor, orderID, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.Actor, int, *model.ReturnReq) *model.Return); ok {
+		r0 = rf(ctx, actor, orderID, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Return)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.Actor, int, *model.ReturnReq) error); ok {
+		r1 = rf(ctx, actor, orderID, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewIReturnService creates a new instance of IReturnService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIReturnService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IReturnService {
+	mock := &IReturnService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
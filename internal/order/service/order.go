@@ -2,56 +2,118 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	couponModel "github.com/aaanger/ecommerce/internal/coupon/model"
+	couponService "github.com/aaanger/ecommerce/internal/coupon/service"
+	deliveryService "github.com/aaanger/ecommerce/internal/delivery/service"
 	grpcorder "github.com/aaanger/ecommerce/internal/order/handler/grpc/product"
 	"github.com/aaanger/ecommerce/internal/order/model"
 	"github.com/aaanger/ecommerce/internal/order/repository"
 	payment "github.com/aaanger/ecommerce/internal/payment/client"
 	paymentModel "github.com/aaanger/ecommerce/internal/payment/model"
+	paymentRepository "github.com/aaanger/ecommerce/internal/payment/repository"
 	productModel "github.com/aaanger/ecommerce/internal/product/model"
 	productRepository "github.com/aaanger/ecommerce/internal/product/repository"
-	"github.com/aaanger/ecommerce/pkg/kafka"
+	"github.com/aaanger/ecommerce/pkg/db"
+	"github.com/aaanger/ecommerce/pkg/money"
 	pb "github.com/aaanger/ecommerce/pkg/proto/gen/product"
 	_ "github.com/vektra/mockery/mockery"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"strconv"
 )
 
 const (
-	CreateOrderTopic = "order_created"
+	CreateOrderTopic  = "order_created"
+	OrderExpiredTopic = "order_expired"
+)
+
+var (
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still being processed")
+	ErrInvalidOrderFilter    = errors.New("invalid order filter")
+	ErrPaymentNotHeld        = errors.New("order payment is neither held nor captured")
 )
 
 //go:generate mockery --name=IOrderService
 
 type IOrderService interface {
 	CreateOrder(ctx context.Context, userID int, userEmail string, lines *model.CreateOrderReq) (*model.CreateOrderRes, error)
-	ConfirmOrder(ctx context.Context, orderID int) error
-	CancelOrder(ctx context.Context, orderID int) error
-	GetOrderByID(orderID int) (*model.Order, error)
-	GetAllOrders(userID int) ([]model.Order, error)
-	UpdateOrderStatus(orderID int, status string) (*model.Order, error)
-	ReserveProducts(ctx context.Context, lines []model.OrderLineReq) error
+	ConfirmOrder(ctx context.Context, orderID int, paymentID string) error
+	CancelOrder(ctx context.Context, orderID int, actor model.Actor, reason string) error
+	ExpireOrder(ctx context.Context, orderID int) error
+	GetOrderByID(ctx context.Context, orderID int, actor model.Actor) (*model.Order, error)
+	GetOrders(ctx context.Context, actor model.Actor, req *model.OrderListReq) (*model.OrderListRes, error)
+	UpdateOrderStatus(ctx context.Context, orderID int, status string, actor model.Actor, reason string) (*model.Order, error)
+	GetStatusHistory(ctx context.Context, orderID int, actor model.Actor) ([]model.StatusChange, error)
+	GetOrderPayments(ctx context.Context, orderID int, actor model.Actor) ([]paymentModel.Payment, error)
+	SettleHold(ctx context.Context, orderID int, paymentID string) error
+	ReserveProducts(ctx context.Context, orderID int, lines []model.OrderLineReq) (int, error)
 }
 
 type OrderService struct {
-	repo          repository.IOrderRepository
-	productRepo   productRepository.IProductRepository
-	grpcClient    *grpcorder.OrderGRPCClient
-	paymentClient *payment.Client
-	producer      *kafka.Producer
-	log           *zap.Logger
+	repo            repository.IOrderRepository
+	idempotencyRepo repository.IIdempotencyRepository
+	tx              db.Transactor
+	productRepo     productRepository.IProductRepository
+	variantRepo     productRepository.IVariantRepository
+	couponService   couponService.ICouponService
+	deliveryService deliveryService.IDeliveryService
+	grpcClient      *grpcorder.OrderGRPCClient
+	paymentClient   payment.PaymentProvider
+	paymentRepo     paymentRepository.IPaymentRepository
+	outboxRepo      repository.IOutboxRepository
+	log             *zap.Logger
+
+	// holdPayments makes checkout only hold the money, it is captured when the order ships.
+	holdPayments bool
+
+	// beforeEnter runs when an order is about to enter a status, a failure keeps it where it was.
+	// onEnter runs in the transaction storing the new status and rolls it back on failure.
+	// afterEnter runs once the new status is stored.
+	beforeEnter map[string]effect
+	onEnter     map[string]effect
+	afterEnter  map[string]effect
 }
 
-func NewOrderService(repo repository.IOrderRepository, productRepo productRepository.IProductRepository, grpcClient *grpcorder.OrderGRPCClient, paymentClient *payment.Client, producer *kafka.Producer, log *zap.Logger) *OrderService {
-	return &OrderService{
-		repo:          repo,
-		productRepo:   productRepo,
-		grpcClient:    grpcClient,
-		paymentClient: paymentClient,
-		producer:      producer,
-		log:           log,
+type effect func(ctx context.Context, order *model.Order) error
+
+func NewOrderService(repo repository.IOrderRepository, idempotencyRepo repository.IIdempotencyRepository, tx db.Transactor, productRepo productRepository.IProductRepository, variantRepo productRepository.IVariantRepository, couponService couponService.ICouponService, deliveryService deliveryService.IDeliveryService, grpcClient *grpcorder.OrderGRPCClient, paymentClient payment.PaymentProvider, paymentRepo paymentRepository.IPaymentRepository, holdPayments bool, outboxRepo repository.IOutboxRepository, log *zap.Logger) *OrderService {
+	s := &OrderService{
+		repo:            repo,
+		idempotencyRepo: idempotencyRepo,
+		tx:              tx,
+		productRepo:     productRepo,
+		variantRepo:     variantRepo,
+		couponService:   couponService,
+		deliveryService: deliveryService,
+		grpcClient:      grpcClient,
+		paymentClient:   paymentClient,
+		paymentRepo:     paymentRepo,
+		outboxRepo:      outboxRepo,
+		log:             log,
+		holdPayments:    holdPayments,
+	}
+
+	s.beforeEnter = map[string]effect{
+		model.StatusCreated:    s.commitOrderReservation,
+		model.StatusDelivering: s.captureOrderPayment,
+		model.StatusCanceled:   s.releaseOrderPayment,
+	}
+	s.onEnter = map[string]effect{
+		model.StatusCreated: s.publishOrder,
 	}
+	s.afterEnter = map[string]effect{
+		model.StatusCanceled: s.releaseOrder,
+	}
+
+	return s
 }
 
 func (s *OrderService) CreateOrder(ctx context.Context, userID int, userEmail string, req *model.CreateOrderReq) (*model.CreateOrderRes, error) {
@@ -61,11 +123,22 @@ func (s *OrderService) CreateOrder(ctx context.Context, userID int, userEmail st
 		zap.String("method", "CreateOrder"),
 		zap.Int("userID", userID))
 
+	var fingerprint string
+	if req.IdempotencyKey != "" {
+		fingerprint = requestFingerprint(req)
+
+		res, found, err := s.replay(ctx, userID, req.IdempotencyKey, fingerprint)
+		if found || err != nil {
+			return res, err
+		}
+	}
+
 	var lines []model.OrderLine
 
 	for _, line := range req.Lines {
 		lines = append(lines, model.OrderLine{
 			ProductID: line.ProductID,
+			VariantID: line.VariantID,
 			Quantity:  line.Quantity,
 		})
 	}
@@ -79,30 +152,213 @@ func (s *OrderService) CreateOrder(ctx context.Context, userID int, userEmail st
 			return nil, err
 		}
 		productMap[product.ID] = product
-		lines[i].Price = product.Price * float64(lines[i].Quantity)
+		lines[i].Price = product.Price.Mul(lines[i].Quantity)
+
+		if lines[i].VariantID != 0 {
+			variant, err := s.variantRepo.GetVariantByID(lines[i].VariantID)
+			if err != nil {
+				log.Error("Error fetching variant data", zap.Error(err), zap.Int("variantID", lines[i].VariantID))
+				return nil, err
+			}
+			if variant.ProductID != product.ID {
+				return nil, fmt.Errorf("variant %d does not belong to product %d", variant.ID, product.ID)
+			}
+			lines[i].Variant = variant
+			lines[i].Price = variant.EffectivePrice(product).Mul(lines[i].Quantity)
+		}
 	}
 
-	if err := s.ReserveProducts(ctx, req.Lines); err != nil {
-		return nil, err
+	var discount *couponModel.Discount
+	var applied *model.AppliedCoupon
+	if req.CouponCode != "" {
+		couponLines := make([]couponModel.Line, len(lines))
+		for i := range lines {
+			couponLines[i] = couponModel.Line{ProductID: lines[i].ProductID, Price: lines[i].Price}
+		}
+
+		var err error
+		discount, err = s.couponService.Apply(ctx, req.CouponCode, userID, couponLines)
+		if err != nil {
+			log.Warn("Coupon rejected", zap.Error(err), zap.String("coupon", req.CouponCode))
+			return nil, err
+		}
+		for i := range lines {
+			lines[i].Discount = discount.Lines[i]
+		}
+		applied = &model.AppliedCoupon{
+			Code:         discount.Code,
+			Discount:     discount.Amount,
+			FreeShipping: discount.FreeShipping,
+		}
 	}
 
-	log.Debug("Starting creating order")
-	order, err := s.repo.CreateOrder(userID, userEmail, lines)
+	method, destination, err := s.deliveryService.Resolve(ctx, userID, &req.Delivery)
 	if err != nil {
-		log.Error("Error creating order", zap.Error(err))
+		log.Warn("Delivery rejected", zap.Error(err), zap.String("method", req.Delivery.Method))
 		return nil, err
 	}
+	delivery := &model.Delivery{
+		Method:  method.Code,
+		Price:   method.Price,
+		Address: *destination,
+	}
+	if applied != nil && applied.FreeShipping {
+		delivery.Price = money.FromMinor(0)
+	}
 
-	for i := range order.Lines {
-		order.Lines[i].Product = productMap[lines[i].ProductID]
+	var order *model.Order
+	var res *model.CreateOrderRes
+
+	// Stock is reserved only once the order exists, so the reservation can be tied to it. Steps after
+	// the order fail by canceling it, which also returns the coupon.
+	createOrder := saga{log: log, steps: []sagaStep{
+		{
+			name: "create order",
+			action: func(ctx context.Context) error {
+				err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+					var err error
+					order, err = s.repo.CreateOrder(ctx, userID, userEmail, lines, applied, delivery)
+					if err != nil {
+						return err
+					}
+
+					// The redemption commits with the order, so a coupon that ran out in the meantime leaves nothing behind.
+					if discount != nil {
+						err = s.couponService.Redeem(ctx, discount.CouponID, userID, order.ID)
+						if err != nil {
+							return err
+						}
+					}
+
+					if req.IdempotencyKey != "" {
+						return s.idempotencyRepo.SaveKey(ctx, userID, req.IdempotencyKey, fingerprint, order.ID)
+					}
+					return nil
+				})
+				if errors.Is(err, repository.ErrKeyExists) {
+					log.Warn("Concurrent request with the same idempotency key", zap.String("key", req.IdempotencyKey))
+					return ErrIdempotencyInProgress
+				}
+				if err != nil {
+					return err
+				}
+
+				for i := range order.Lines {
+					order.Lines[i].Product = productMap[lines[i].ProductID]
+					order.Lines[i].Variant = lines[i].Variant
+				}
+				return nil
+			},
+			compensate: func(ctx context.Context, failedStep string) error {
+				return s.abandonOrder(ctx, order, userID, req.IdempotencyKey, failedStep)
+			},
+		},
+		{
+			name: "reserve stock",
+			action: func(ctx context.Context) error {
+				reservationID, err := s.ReserveProducts(ctx, order.ID, req.Lines)
+				if err != nil {
+					return err
+				}
+				order.ReservationID = reservationID
+				return nil
+			},
+			compensate: func(ctx context.Context, failedStep string) error {
+				if err := s.UnreserveProducts(ctx, order.ReservationID); err != nil {
+					return err
+				}
+				// The stock is back, canceling the order must not release it a second time.
+				order.ReservationID = 0
+				return nil
+			},
+		},
+		{
+			name: "link reservation",
+			action: func(ctx context.Context) error {
+				return s.repo.UpdateOrderReservation(ctx, order.ID, order.ReservationID)
+			},
+		},
+		{
+			// The confirmation URL only reaches the client on success, so a payment YooKassa created
+			// despite an error cannot be paid and expires on its own.
+			name: "create payment",
+			action: func(ctx context.Context) error {
+				var err error
+				res, err = s.pay(ctx, userID, req.IdempotencyKey, order)
+				return err
+			},
+		},
+	}}
+
+	if err := createOrder.run(ctx); err != nil {
+		return nil, err
 	}
 
+	return res, nil
+}
+
+// abandonOrder cancels an order whose creation could not be completed and frees its idempotency key,
+// so a retry of the request starts over instead of finding the canceled order.
+func (s *OrderService) abandonOrder(ctx context.Context, order *model.Order, userID int, key, failedStep string) error {
+	if err := s.transition(ctx, order, model.StatusCanceled, model.SystemActor, failedStep+" failed"); err != nil {
+		return err
+	}
+
+	if key != "" {
+		return s.idempotencyRepo.DeleteKey(ctx, userID, key)
+	}
+	return nil
+}
+
+// replay answers a create request whose Idempotency-Key has been seen before. An order whose payment
+// was never created, because the first attempt failed after reserving stock, gets it created now.
+func (s *OrderService) replay(ctx context.Context, userID int, key, fingerprint string) (*model.CreateOrderRes, bool, error) {
+	record, err := s.idempotencyRepo.GetKey(ctx, userID, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if record.RequestHash != fingerprint {
+		return nil, true, ErrIdempotencyKeyReused
+	}
+
+	if record.Response != nil {
+		return record.Response, true, nil
+	}
+
+	order, err := s.getOrder(ctx, record.OrderID)
+	if err != nil {
+		return nil, true, err
+	}
+
+	if err = s.withProducts(order); err != nil {
+		return nil, true, err
+	}
+
+	if order.Status != model.StatusPending || order.ReservationID == 0 {
+		return nil, true, ErrIdempotencyInProgress
+	}
+
+	res, err := s.pay(ctx, userID, key, order)
+	return res, true, err
+}
+
+// pay creates the payment for the order and, for keyed requests, stores the response to replay.
+// The YooKassa idempotence key is derived from the order, so paying the same order twice yields
+// the same payment.
+func (s *OrderService) pay(ctx context.Context, userID int, key string, order *model.Order) (*model.CreateOrderRes, error) {
+	log := s.log.With(
+		zap.String("service", "order"),
+		zap.String("layer", "service"),
+		zap.String("method", "pay"),
+		zap.Int("orderID", order.ID))
+
 	paymentReq := &paymentModel.CreatePaymentReq{
-		Amount: paymentModel.Amount{
-			Value:    fmt.Sprintf("%.2f", order.TotalPrice),
-			Currency: "RUB",
-		},
-		Capture: true,
+		Amount:  paymentModel.NewAmount(order.TotalPrice),
+		Capture: !s.holdPayments,
 		Confirmation: paymentModel.ConfirmationReq{
 			Type:      "redirect",
 			ReturnURL: "http://localhost:3000/payment/success",
@@ -113,156 +369,531 @@ func (s *OrderService) CreateOrder(ctx context.Context, userID int, userEmail st
 		Description: fmt.Sprintf("Заказ №%d", order.ID),
 	}
 
-	paymentRes, err := s.paymentClient.CreatePayment(ctx, paymentReq)
+	paymentRes, err := s.paymentClient.CreatePayment(ctx, paymentReq, fmt.Sprintf("order-%d", order.ID))
 	if err != nil {
 		log.Error("Failed to create payment", zap.Error(err))
 		return nil, err
 	}
 
-	return &model.CreateOrderRes{
+	// The payment is recorded again from its notifications, a failure here only delays it.
+	if err = s.savePayment(ctx, order.ID, paymentRes); err != nil {
+		log.Error("Failed to save payment", zap.Error(err), zap.String("paymentID", paymentRes.ID))
+	}
+
+	res := &model.CreateOrderRes{
 		Order:   order,
 		Payment: paymentRes,
-	}, nil
+	}
+
+	if key != "" {
+		// The order and payment exist either way, a retry would find them through the key and pay again idempotently.
+		if err = s.idempotencyRepo.SaveResponse(ctx, userID, key, res); err != nil {
+			log.Error("Failed to save idempotent response", zap.Error(err))
+		}
+	}
+
+	return res, nil
 }
 
-func (s *OrderService) ConfirmOrder(ctx context.Context, orderID int) error {
+// requestFingerprint hashes the parts of the request that decide what gets ordered.
+func requestFingerprint(req *model.CreateOrderReq) string {
+	data, _ := json.Marshal(req)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ConfirmOrder moves a paid order on and remembers the payment, which returns are refunded against. A payment
+// held for capture counts as paid.
+func (s *OrderService) ConfirmOrder(ctx context.Context, orderID int, paymentID string) error {
 	log := s.log.With(
 		zap.String("service", "order"),
 		zap.String("layer", "service"),
 		zap.String("method", "ConfirmOrder"),
 		zap.Int("orderID", orderID))
 
-	order, err := s.repo.GetOrderByID(orderID)
+	order, err := s.repo.GetOrderByID(ctx, orderID)
 	if err != nil {
 		log.Error("failed to get order by id", zap.Error(err))
 		return err
 	}
 
-	if order.Status != model.StatusPending {
-		log.Error("order is already paid")
-		return fmt.Errorf("order is already paid")
+	savePayment := func(ctx context.Context, order *model.Order) error {
+		order.PaymentID = paymentID
+		return s.repo.SetPaymentID(ctx, order.ID, paymentID)
 	}
 
-	if err := s.repo.UpdateOrder(orderID, model.StatusCreated); err != nil {
-		log.Error("failed to update order status", zap.Error(err))
+	if err = s.transition(ctx, order, model.StatusCreated, model.SystemActor, "payment succeeded", savePayment); err != nil {
+		log.Error("failed to confirm order", zap.Error(err))
 		return err
 	}
 
-	log.Info("Producing order to Kafka",
-		zap.Int("orderID", order.ID),
-		zap.String("userEmail", order.UserEmail),
-		zap.Any("order", order),
-	)
-	if err = s.producer.Produce(ctx, strconv.Itoa(order.ID), order, 3); err != nil {
-		log.Error("Kafka produce error, topic `order_created`", zap.Int("orderID", order.ID))
-		return err
+	log.Info("Order successfully confirmed", zap.Int("orderID", order.ID), zap.Any("order", order))
+
+	return nil
+}
+
+// GetOrderByID returns the order with its products to its customer or a moderator.
+func (s *OrderService) GetOrderByID(ctx context.Context, orderID int, actor model.Actor) (*model.Order, error) {
+	order, err := s.getOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
 	}
-	log.Info("Kafka message produced in topic `order_created`", zap.Any("order", order))
 
-	log.Info("Order successfully confirmed", zap.Int("orderID", order.ID), zap.Any("order", order))
+	if err = authorize(s.log, order, actor, "get order"); err != nil {
+		return nil, err
+	}
+
+	if err = s.withProducts(order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// GetOrders lists a page of orders. Customers only list their own orders, moderators list everyone's.
+func (s *OrderService) GetOrders(ctx context.Context, actor model.Actor, req *model.OrderListReq) (*model.OrderListRes, error) {
+	if actor.Role != model.RoleModerator {
+		req.UserID = actor.ID
+	}
+
+	if err := normalizeListReq(req); err != nil {
+		return nil, err
+	}
+
+	orders, next, err := s.repo.GetOrders(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &model.OrderListRes{
+		Orders:     make([]model.GetAllOrdersRes, 0, len(orders)),
+		NextCursor: next,
+		Limit:      req.Limit,
+	}
+
+	for i := range orders {
+		res.Orders = append(res.Orders, model.GetAllOrdersRes{
+			ID:         orders[i].ID,
+			UserID:     orders[i].UserID,
+			UserEmail:  orders[i].UserEmail,
+			CreatedAt:  orders[i].CreatedAt,
+			UpdatedAt:  orders[i].UpdatedAt,
+			Status:     orders[i].Status,
+			TotalPrice: orders[i].TotalPrice,
+		})
+	}
+
+	return res, nil
+}
+
+// normalizeListReq fills in the default sort and page size and rejects filters that cannot match anything.
+func normalizeListReq(req *model.OrderListReq) error {
+	if req.Limit < 1 {
+		req.Limit = model.DefaultOrderPageLimit
+	}
+	if req.Limit > model.MaxOrderPageLimit {
+		req.Limit = model.MaxOrderPageLimit
+	}
+
+	switch req.Sort {
+	case "":
+		req.Sort = model.SortCreatedAt
+	case model.SortCreatedAt, model.SortTotal:
+	default:
+		return fmt.Errorf("%w: unknown sort %q", ErrInvalidOrderFilter, req.Sort)
+	}
+
+	switch req.Dir {
+	case "":
+		req.Dir = model.SortDesc
+	case model.SortAsc, model.SortDesc:
+	default:
+		return fmt.Errorf("%w: unknown direction %q", ErrInvalidOrderFilter, req.Dir)
+	}
+
+	if req.MinTotal.IsNegative() || req.MaxTotal.IsNegative() {
+		return fmt.Errorf("%w: totals must not be negative", ErrInvalidOrderFilter)
+	}
+	if !req.MaxTotal.IsZero() && req.MinTotal.Cmp(req.MaxTotal) > 0 {
+		return fmt.Errorf("%w: min_total must not exceed max_total", ErrInvalidOrderFilter)
+	}
+	if !req.From.IsZero() && !req.To.IsZero() && !req.From.Before(req.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidOrderFilter)
+	}
 
 	return nil
 }
 
-func (s *OrderService) GetOrderByID(orderID int) (*model.Order, error) {
-	order, err := s.repo.GetOrderByID(orderID)
+func (s *OrderService) UpdateOrderStatus(ctx context.Context, orderID int, status string, actor model.Actor, reason string) (*model.Order, error) {
+	order, err := s.getOrder(ctx, orderID)
 	if err != nil {
 		return nil, err
 	}
 
-	for i := range order.Lines {
-		product, err := s.productRepo.GetProductByID(order.Lines[i].ProductID)
-		if err != nil {
-			return nil, err
-		}
-		order.Lines[i].Product = product
+	if err = authorizeModerator(s.log, order, actor, "update status"); err != nil {
+		return nil, err
+	}
+
+	if err = s.transition(ctx, order, status, actor, reason); err != nil {
+		return nil, err
 	}
 
 	return order, nil
 }
 
-func (s *OrderService) GetAllOrders(userID int) ([]model.Order, error) {
-	return s.repo.GetAllOrders(userID)
+func (s *OrderService) CancelOrder(ctx context.Context, orderID int, actor model.Actor, reason string) error {
+	order, err := s.getOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	if err = authorize(s.log, order, actor, "cancel order"); err != nil {
+		return err
+	}
+
+	return s.transition(ctx, order, model.StatusCanceled, actor, reason)
+}
+
+// ExpireOrder cancels an order that was not paid in time and queues an order_expired event with the
+// cancellation. An order that got paid in the meantime is left alone.
+func (s *OrderService) ExpireOrder(ctx context.Context, orderID int) error {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	if order.Status != model.StatusPending {
+		return nil
+	}
+
+	return s.transition(ctx, order, model.StatusCanceled, model.SystemActor, "payment timeout", s.publishExpired)
+}
+
+func (s *OrderService) GetStatusHistory(ctx context.Context, orderID int, actor model.Actor) ([]model.StatusChange, error) {
+	order, err := s.getOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = authorize(s.log, order, actor, "get status history"); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetStatusHistory(ctx, orderID)
 }
 
-func (s *OrderService) UpdateOrderStatus(orderID int, status string) (*model.Order, error) {
-	order, err := s.repo.GetOrderByID(orderID)
+// GetOrderPayments lists the payment attempts made for the order.
+func (s *OrderService) GetOrderPayments(ctx context.Context, orderID int, actor model.Actor) ([]paymentModel.Payment, error) {
+	order, err := s.getOrder(ctx, orderID)
 	if err != nil {
 		return nil, err
 	}
 
-	if order.Status == model.StatusDelivered || order.Status == model.StatusCanceled {
-		return nil, errors.New("failed to update status: order delivered or canceled")
+	if err = authorize(s.log, order, actor, "get payments"); err != nil {
+		return nil, err
+	}
+
+	return s.paymentRepo.GetPaymentsByOrder(ctx, orderID)
+}
+
+func (s *OrderService) savePayment(ctx context.Context, orderID int, res *paymentModel.CreatePaymentRes) error {
+	payment, err := paymentModel.NewPayment(orderID, res)
+	if err != nil {
+		return err
 	}
 
-	if status == model.StatusDelivering || status == model.StatusDelivered {
-		err = s.repo.UpdateOrder(orderID, status)
-		if err != nil {
-			return nil, err
+	return s.paymentRepo.SavePayment(ctx, payment)
+}
+
+// SettleHold decides a payment hold about to expire: the money for an order going ahead with this payment
+// is captured, any other hold is released.
+func (s *OrderService) SettleHold(ctx context.Context, orderID int, paymentID string) error {
+	order, err := s.getOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	switch order.Status {
+	case model.StatusCreated, model.StatusDelivering, model.StatusDelivered:
+		if order.PaymentID == paymentID {
+			return s.capturePayment(ctx, order.ID, paymentID)
 		}
-		return order, nil
 	}
 
-	return nil, errors.New("invalid status")
+	return s.cancelHold(ctx, order.ID, paymentID)
 }
 
-func (s *OrderService) CancelOrder(ctx context.Context, orderID int) error {
-	order, err := s.repo.GetOrderByID(orderID)
+// capturePayment takes the money held for the order. A payment taken at checkout is left as it is.
+func (s *OrderService) capturePayment(ctx context.Context, orderID int, paymentID string) error {
+	paid, err := s.paymentClient.GetPayment(ctx, paymentID)
 	if err != nil {
 		return err
 	}
 
-	if order.Status == model.StatusDelivered || order.Status == model.StatusCanceled {
-		return errors.New("invalid order status")
+	switch paid.Status {
+	case payment.StatusSucceeded:
+		return nil
+	case payment.StatusWaitingForCapture:
+	default:
+		s.log.Error("Order payment can no longer be captured", zap.Int("orderID", orderID), zap.String("paymentID", paymentID),
+			zap.String("status", paid.Status))
+		return ErrPaymentNotHeld
 	}
 
-	err = s.UnreserveProducts(ctx, order.Lines)
+	captured, err := s.paymentClient.CapturePayment(ctx, paymentID, nil, "capture-"+paymentID)
 	if err != nil {
 		return err
 	}
-	err = s.repo.UpdateOrder(orderID, model.StatusCanceled)
+
+	if err = s.savePayment(ctx, orderID, captured); err != nil {
+		s.log.Error("Failed to save payment", zap.Error(err), zap.String("paymentID", paymentID))
+	}
+
+	s.log.Info("Order payment captured", zap.Int("orderID", orderID), zap.String("paymentID", paymentID))
+	return nil
+}
+
+// cancelHold releases money held by the payment. Captured money is left alone.
+func (s *OrderService) cancelHold(ctx context.Context, orderID int, paymentID string) error {
+	paid, err := s.paymentClient.GetPayment(ctx, paymentID)
 	if err != nil {
 		return err
 	}
 
+	if paid.Status != payment.StatusWaitingForCapture {
+		return nil
+	}
+
+	return s.releaseHold(ctx, orderID, paymentID)
+}
+
+func (s *OrderService) releaseHold(ctx context.Context, orderID int, paymentID string) error {
+	canceled, err := s.paymentClient.CancelPayment(ctx, paymentID, "cancel-"+paymentID)
+	if err != nil {
+		return err
+	}
+
+	if err = s.savePayment(ctx, orderID, canceled); err != nil {
+		s.log.Error("Failed to save payment", zap.Error(err), zap.String("paymentID", paymentID))
+	}
+
+	s.log.Info("Order payment hold released", zap.Int("orderID", orderID), zap.String("paymentID", paymentID))
 	return nil
 }
 
-func (s *OrderService) ReserveProducts(ctx context.Context, lines []model.OrderLineReq) error {
-	var products []*pb.ReservedProduct
+// refundPayment returns all of a captured payment. The refund is keyed by the payment, so canceling again
+// after a failure does not pay the customer back twice.
+func (s *OrderService) refundPayment(ctx context.Context, orderID int, paid *paymentModel.CreatePaymentRes) error {
+	refund, err := s.paymentClient.CreateRefund(ctx, &paymentModel.CreateRefundReq{
+		PaymentID:   paid.ID,
+		Amount:      paid.Amount,
+		Description: fmt.Sprintf("Отмена заказа №%d", orderID),
+	}, "refund-"+paid.ID)
+	if err != nil {
+		return err
+	}
 
-	for _, line := range lines {
-		products = append(products, &pb.ReservedProduct{
-			ProductID: int32(line.ProductID),
-			Quantity:  int32(line.Quantity),
-		})
+	s.log.Info("Order payment refunded", zap.Int("orderID", orderID), zap.String("paymentID", paid.ID), zap.String("refundID", refund.ID))
+	return nil
+}
+
+// getOrder loads the order without its products, ErrOrderNotFound if there is none.
+func (s *OrderService) getOrder(ctx context.Context, orderID int) (*model.Order, error) {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	res, err := s.grpcClient.Client.ReserveProducts(ctx, &pb.ReserveProductsReq{
-		Products: products,
+	return order, nil
+}
+
+// withProducts fills in the product and variant of every line.
+func (s *OrderService) withProducts(order *model.Order) error {
+	for i := range order.Lines {
+		product, err := s.productRepo.GetProductByID(order.Lines[i].ProductID)
+		if err != nil {
+			return err
+		}
+		order.Lines[i].Product = product
+
+		if order.Lines[i].VariantID != 0 {
+			variant, err := s.variantRepo.GetVariantByID(order.Lines[i].VariantID)
+			if err != nil {
+				return err
+			}
+			order.Lines[i].Variant = variant
+		}
+	}
+
+	return nil
+}
+
+// transition is the only way an order changes status. It checks the move against the state machine,
+// records the change in the order history and runs the side effects of entering the new status.
+// beforeEnter effects reach other services, they run only once the order is locked in its old status
+// and are idempotent, so a change rolled back after them can safely be made again. extra effects are
+// specific to this change and run in its transaction after onEnter.
+func (s *OrderService) transition(ctx context.Context, order *model.Order, to string, actor model.Actor, reason string, extra ...effect) error {
+	log := s.log.With(
+		zap.String("service", "order"),
+		zap.String("layer", "service"),
+		zap.String("method", "transition"),
+		zap.Int("orderID", order.ID),
+		zap.String("from", order.Status),
+		zap.String("to", to))
+
+	if err := model.CheckTransition(order.Status, to, actor); err != nil {
+		log.Warn("Rejected order status transition", zap.Error(err), zap.String("role", actor.Role))
+		return err
+	}
+
+	from := order.Status
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// The conditional update locks the order row until the change commits, so a racing transition
+		// fails here with ErrStatusConflict before it commits stock or moves money.
+		if err := s.repo.UpdateOrderStatus(ctx, order.ID, from, to, actor, reason); err != nil {
+			return err
+		}
+
+		if before, ok := s.beforeEnter[to]; ok {
+			if err := before(ctx, order); err != nil {
+				return err
+			}
+		}
+		order.Status = to
+
+		if on, ok := s.onEnter[to]; ok {
+			if err := on(ctx, order); err != nil {
+				return err
+			}
+		}
+
+		for _, effect := range extra {
+			if err := effect(ctx, order); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 	if err != nil {
+		order.Status = from
+		log.Error("Failed to update order status", zap.Error(err))
 		return err
 	}
-	if !res.Success {
-		return fmt.Errorf("reservation failed")
+
+	if after, ok := s.afterEnter[to]; ok {
+		if err := after(ctx, order); err != nil {
+			return err
+		}
 	}
 
+	log.Info("Order status changed", zap.Int("actorID", actor.ID), zap.String("role", actor.Role), zap.String("reason", reason))
 	return nil
 }
 
-func (s *OrderService) UnreserveProducts(ctx context.Context, lines []model.OrderLine) error {
+func (s *OrderService) commitOrderReservation(ctx context.Context, order *model.Order) error {
+	if order.ReservationID == 0 {
+		return nil
+	}
+
+	err := s.CommitReservation(ctx, order.ReservationID, false)
+	if status.Code(err) != codes.FailedPrecondition {
+		return err
+	}
+
+	// The customer has already paid, so the stock is taken anyway and goes below zero: moderators see the
+	// backorder in the product amounts and returns can still restock the committed reservation.
+	s.log.Error("Reservation expired and stock ran out before payment, committing as backorder", zap.Error(err), zap.Int("orderID", order.ID))
+
+	return s.CommitReservation(ctx, order.ReservationID, true)
+}
+
+// publishOrder queues the order_created event in the outbox, the relay sends it once the status change commits.
+func (s *OrderService) publishOrder(ctx context.Context, order *model.Order) error {
+	return s.outboxRepo.Add(ctx, CreateOrderTopic, strconv.Itoa(order.ID), order)
+}
+
+func (s *OrderService) publishExpired(ctx context.Context, order *model.Order) error {
+	return s.outboxRepo.Add(ctx, OrderExpiredTopic, strconv.Itoa(order.ID), order)
+}
+
+func (s *OrderService) captureOrderPayment(ctx context.Context, order *model.Order) error {
+	if order.PaymentID == "" {
+		return nil
+	}
+
+	return s.capturePayment(ctx, order.ID, order.PaymentID)
+}
+
+// releaseOrderPayment gives the customer their money back when a paid order is canceled: a hold is
+// released and a captured payment refunded.
+func (s *OrderService) releaseOrderPayment(ctx context.Context, order *model.Order) error {
+	if order.PaymentID == "" {
+		return nil
+	}
+
+	paid, err := s.paymentClient.GetPayment(ctx, order.PaymentID)
+	if err != nil {
+		return err
+	}
+
+	switch paid.Status {
+	case payment.StatusWaitingForCapture:
+		return s.releaseHold(ctx, order.ID, order.PaymentID)
+	case payment.StatusSucceeded:
+		return s.refundPayment(ctx, order.ID, paid)
+	default:
+		return nil
+	}
+}
+
+func (s *OrderService) releaseOrder(ctx context.Context, order *model.Order) error {
+	if order.ReservationID != 0 {
+		if err := s.UnreserveProducts(ctx, order.ReservationID); err != nil {
+			return err
+		}
+	}
+
+	if order.Coupon != nil {
+		if err := s.couponService.Release(ctx, order.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *OrderService) ReserveProducts(ctx context.Context, orderID int, lines []model.OrderLineReq) (int, error) {
 	var products []*pb.ReservedProduct
 
 	for _, line := range lines {
 		products = append(products, &pb.ReservedProduct{
 			ProductID: int32(line.ProductID),
+			VariantID: int32(line.VariantID),
 			Quantity:  int32(line.Quantity),
 		})
 	}
 
-	res, err := s.grpcClient.Client.UnreserveProducts(ctx, &pb.ReserveProductsReq{
+	res, err := s.grpcClient.Client.ReserveProducts(ctx, &pb.ReserveProductsReq{
 		Products: products,
+		OrderID:  int32(orderID),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !res.Success {
+		return 0, fmt.Errorf("reservation failed")
+	}
+
+	return int(res.ReservationID), nil
+}
+
+func (s *OrderService) UnreserveProducts(ctx context.Context, reservationID int) error {
+	res, err := s.grpcClient.Client.UnreserveProducts(ctx, &pb.UnreserveProductsReq{
+		ReservationID: int32(reservationID),
 	})
 	if err != nil {
 		return err
@@ -273,3 +904,18 @@ func (s *OrderService) UnreserveProducts(ctx context.Context, lines []model.Orde
 
 	return nil
 }
+
+func (s *OrderService) CommitReservation(ctx context.Context, reservationID int, backorder bool) error {
+	res, err := s.grpcClient.Client.CommitReservation(ctx, &pb.CommitReservationReq{
+		ReservationID: int32(reservationID),
+		Backorder:     backorder,
+	})
+	if err != nil {
+		return err
+	}
+	if !res.Success {
+		return fmt.Errorf("reservation commit failed")
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"github.com/aaanger/ecommerce/internal/order/repository"
+	"github.com/aaanger/ecommerce/pkg/db"
+	"go.uber.org/zap"
+	"time"
+)
+
+// DefaultOrderPaymentTTL is how long an order may wait for payment, it matches the default stock hold.
+const DefaultOrderPaymentTTL = 30 * time.Minute
+
+const (
+	// orderExpirerLockKey identifies the expirer's advisory lock.
+	orderExpirerLockKey = 2001
+	orderExpirerBatch   = 100
+)
+
+// OrderExpirer periodically cancels orders left Pending for longer than the payment TTL, releasing their
+// stock and coupons. Only the instance holding the advisory lock runs a sweep. A customer who pays right as
+// the order expires gets the money back when the payment notification finds the order canceled.
+type OrderExpirer struct {
+	repo     repository.IOrderRepository
+	orders   IOrderService
+	locker   db.Locker
+	ttl      time.Duration
+	interval time.Duration
+	log      *zap.Logger
+}
+
+func NewOrderExpirer(repo repository.IOrderRepository, orders IOrderService, locker db.Locker, ttl, interval time.Duration, log *zap.Logger) *OrderExpirer {
+	return &OrderExpirer{
+		repo:     repo,
+		orders:   orders,
+		locker:   locker,
+		ttl:      ttl,
+		interval: interval,
+		log:      log,
+	}
+}
+
+func (e *OrderExpirer) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.Expire(ctx)
+		}
+	}
+}
+
+func (e *OrderExpirer) Expire(ctx context.Context) {
+	unlock, ok, err := e.locker.TryLock(ctx, orderExpirerLockKey)
+	if err != nil {
+		e.log.Error("Order expirer: failed to take lock", zap.Error(err))
+		return
+	}
+	if !ok {
+		e.log.Debug("Order expirer: another instance is running")
+		return
+	}
+	defer unlock()
+
+	ids, err := e.repo.GetExpiredOrders(ctx, time.Now().Add(-e.ttl), orderExpirerBatch)
+	if err != nil {
+		e.log.Error("Order expirer: failed to get expired orders", zap.Error(err))
+		return
+	}
+
+	var expired int
+	for _, id := range ids {
+		if err = e.orders.ExpireOrder(ctx, id); err != nil {
+			e.log.Error("Order expirer: failed to expire order", zap.Error(err), zap.Int("orderID", id))
+			continue
+		}
+		expired++
+	}
+
+	if expired > 0 {
+		e.log.Info("Order expirer: unpaid orders canceled", zap.Int("count", expired))
+	}
+}
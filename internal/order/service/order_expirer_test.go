@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/aaanger/ecommerce/internal/order/repository/mocks"
+	serviceMocks "github.com/aaanger/ecommerce/internal/order/service/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+// locker grants the lock unless held is set and counts releases.
+type locker struct {
+	held     bool
+	err      error
+	unlocked int
+}
+
+func (l *locker) TryLock(ctx context.Context, key int64) (func(), bool, error) {
+	if l.err != nil || l.held {
+		return nil, false, l.err
+	}
+	return func() { l.unlocked++ }, true, nil
+}
+
+type OrderExpirerSuite struct {
+	suite.Suite
+	repo    *mocks.IOrderRepository
+	orders  *serviceMocks.IOrderService
+	locker  *locker
+	expirer *OrderExpirer
+}
+
+func (suite *OrderExpirerSuite) SetupTest() {
+	suite.repo = mocks.NewIOrderRepository(suite.T())
+	suite.orders = serviceMocks.NewIOrderService(suite.T())
+	suite.locker = &locker{}
+	suite.expirer = NewOrderExpirer(suite.repo, suite.orders, suite.locker, 30*time.Minute, time.Minute, zap.NewNop())
+}
+
+func TestOrderExpirerSuite(t *testing.T) {
+	suite.Run(t, new(OrderExpirerSuite))
+}
+
+// ====================================================================================================================
+
+func (suite *OrderExpirerSuite) TestExpirer_ExpiresOrders() {
+	suite.repo.On("GetExpiredOrders", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		return before.Before(time.Now().Add(-29 * time.Minute))
+	}), orderExpirerBatch).Return([]int{1, 2, 3}, nil)
+	suite.orders.On("ExpireOrder", mock.Anything, 1).Return(nil)
+	suite.orders.On("ExpireOrder", mock.Anything, 2).Return(errors.New("error"))
+	suite.orders.On("ExpireOrder", mock.Anything, 3).Return(nil)
+
+	suite.expirer.Expire(context.Background())
+
+	// A failing order does not hold up the rest of the batch.
+	suite.orders.AssertNumberOfCalls(suite.T(), "ExpireOrder", 3)
+	suite.Equal(1, suite.locker.unlocked)
+}
+
+func (suite *OrderExpirerSuite) TestExpirer_SkipsWhenLocked() {
+	suite.locker.held = true
+
+	suite.expirer.Expire(context.Background())
+
+	suite.repo.AssertNotCalled(suite.T(), "GetExpiredOrders", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *OrderExpirerSuite) TestExpirer_LockFailure() {
+	suite.locker.err = errors.New("connection refused")
+
+	suite.expirer.Expire(context.Background())
+
+	suite.repo.AssertNotCalled(suite.T(), "GetExpiredOrders", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *OrderExpirerSuite) TestExpirer_QueryFailureReleasesLock() {
+	suite.repo.On("GetExpiredOrders", mock.Anything, mock.Anything, orderExpirerBatch).Return(nil, errors.New("error"))
+
+	suite.expirer.Expire(context.Background())
+
+	suite.Equal(1, suite.locker.unlocked)
+}
@@ -1,127 +1,496 @@
 package service
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	couponModel "github.com/aaanger/ecommerce/internal/coupon/model"
+	couponRepository "github.com/aaanger/ecommerce/internal/coupon/repository"
+	couponMocks "github.com/aaanger/ecommerce/internal/coupon/service/mocks"
+	deliveryModel "github.com/aaanger/ecommerce/internal/delivery/model"
+	deliveryService "github.com/aaanger/ecommerce/internal/delivery/service"
+	deliveryMocks "github.com/aaanger/ecommerce/internal/delivery/service/mocks"
+	grpcorder "github.com/aaanger/ecommerce/internal/order/handler/grpc/product"
 	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/internal/order/repository"
 	"github.com/aaanger/ecommerce/internal/order/repository/mocks"
+	payment "github.com/aaanger/ecommerce/internal/payment/client"
+	paymentModel "github.com/aaanger/ecommerce/internal/payment/model"
+	paymentMocks "github.com/aaanger/ecommerce/internal/payment/repository/mocks"
 	productModel "github.com/aaanger/ecommerce/internal/product/model"
 	productMocks "github.com/aaanger/ecommerce/internal/product/repository/mocks"
+	"github.com/aaanger/ecommerce/pkg/money"
+	pb "github.com/aaanger/ecommerce/pkg/proto/gen/product"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
+// productClient stands in for the product service, recording which reservations were released, committed
+// and which items were returned.
+type productClient struct {
+	pb.ProductServiceClient
+	reservationID int32
+	reserveErr    error
+	returnErr     error
+	outOfStock    bool
+	unreserved    []int32
+	returned      []*pb.ReservedProduct
+	commits       []*pb.CommitReservationReq
+}
+
+func (c *productClient) ReserveProducts(ctx context.Context, in *pb.ReserveProductsReq, opts ...grpc.CallOption) (*pb.ReserveProductsRes, error) {
+	if c.reserveErr != nil {
+		return nil, c.reserveErr
+	}
+	return &pb.ReserveProductsRes{Success: true, ReservationID: c.reservationID}, nil
+}
+
+func (c *productClient) UnreserveProducts(ctx context.Context, in *pb.UnreserveProductsReq, opts ...grpc.CallOption) (*pb.ReserveProductsRes, error) {
+	if len(in.Products) > 0 {
+		if c.returnErr != nil {
+			return nil, c.returnErr
+		}
+		c.returned = append(c.returned, in.Products...)
+		return &pb.ReserveProductsRes{Success: true}, nil
+	}
+	c.unreserved = append(c.unreserved, in.ReservationID)
+	return &pb.ReserveProductsRes{Success: true}, nil
+}
+
+func (c *productClient) CommitReservation(ctx context.Context, in *pb.CommitReservationReq, opts ...grpc.CallOption) (*pb.ReserveProductsRes, error) {
+	c.commits = append(c.commits, in)
+	if c.outOfStock && !in.Backorder {
+		return nil, status.Error(codes.FailedPrecondition, "not enough stock for: Phone")
+	}
+	return &pb.ReserveProductsRes{Success: true, ReservationID: in.ReservationID}, nil
+}
+
+// inlineTx runs the unit of work without a database, the repository mocks take its place.
+type inlineTx struct{}
+
+func (inlineTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 type OrderServiceSuite struct {
 	suite.Suite
-	repo        *mocks.IOrderRepository
-	productRepo *productMocks.IProductRepository
-	service     *OrderService
+	repo            *mocks.IOrderRepository
+	idempotencyRepo *mocks.IIdempotencyRepository
+	outboxRepo      *mocks.IOutboxRepository
+	productRepo     *productMocks.IProductRepository
+	variantRepo     *productMocks.IVariantRepository
+	couponService   *couponMocks.ICouponService
+	deliveryService *deliveryMocks.IDeliveryService
+	paymentRepo     *paymentMocks.IPaymentRepository
+	productClient   *productClient
+	paymentServer   *httptest.Server
+	paymentKeys     []string
+	paymentCalls    []string
+	paymentBody     string
+	paymentStatus   string
+	paymentDown     bool
+	service         *OrderService
 }
 
 func (suite *OrderServiceSuite) SetupTest() {
 	suite.repo = mocks.NewIOrderRepository(suite.T())
+	suite.idempotencyRepo = mocks.NewIIdempotencyRepository(suite.T())
+	suite.outboxRepo = mocks.NewIOutboxRepository(suite.T())
 	suite.productRepo = productMocks.NewIProductRepository(suite.T())
-	suite.service = NewOrderService(suite.repo, suite.productRepo)
+	suite.variantRepo = productMocks.NewIVariantRepository(suite.T())
+	suite.couponService = couponMocks.NewICouponService(suite.T())
+	suite.deliveryService = deliveryMocks.NewIDeliveryService(suite.T())
+	suite.deliveryService.On("Resolve", mock.Anything, 1, &deliveryModel.DeliveryReq{Method: deliveryModel.MethodCourier, AddressID: 7}).
+		Return(&deliveryModel.Method{Code: deliveryModel.MethodCourier, Price: money.FromMinor(300), Active: true}, &suite.delivery().Address, nil).Maybe()
+	suite.paymentRepo = paymentMocks.NewIPaymentRepository(suite.T())
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil).Maybe()
+	suite.productClient = &productClient{reservationID: 3}
+	suite.paymentKeys = nil
+	suite.paymentCalls = nil
+	suite.paymentStatus = "pending"
+	suite.paymentDown = false
+	suite.paymentServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		suite.paymentBody = string(body)
+		suite.paymentCalls = append(suite.paymentCalls, r.Method+" "+r.URL.Path)
+		if r.Method == "POST" {
+			suite.paymentKeys = append(suite.paymentKeys, r.Header.Get("Idempotence-Key"))
+		}
+		if suite.paymentDown {
+			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": "pay-1", "status": "` + suite.paymentStatus + `", "amount": {"value": "10.00", "currency": "RUB"}, "confirmation": {"confirmation_url": "https://pay.test/1"}}`))
+	}))
+
+	paymentClient := payment.NewClient("shop", "secret")
+	paymentClient.APIEndpoint = suite.paymentServer.URL + "/"
+
+	suite.service = NewOrderService(suite.repo, suite.idempotencyRepo, inlineTx{}, suite.productRepo, suite.variantRepo, suite.couponService, suite.deliveryService,
+		&grpcorder.OrderGRPCClient{Client: suite.productClient}, paymentClient, suite.paymentRepo, false, suite.outboxRepo, zap.NewNop())
+}
+
+func (suite *OrderServiceSuite) TearDownTest() {
+	suite.paymentServer.Close()
 }
 
 func TestOrderServiceSuite(t *testing.T) {
 	suite.Run(t, new(OrderServiceSuite))
 }
 
-// ====================================================================================================================
-
-func (suite *OrderServiceSuite) TestService_CreateOrderSuccess() {
-	req := &model.CreateOrderReq{
+func (suite *OrderServiceSuite) createReq() *model.CreateOrderReq {
+	return &model.CreateOrderReq{
 		Lines: []model.OrderLineReq{
 			{
 				ProductID: 1,
-				Quantity:  1,
+				Quantity:  2,
 			},
 		},
+		Delivery: deliveryModel.DeliveryReq{Method: deliveryModel.MethodCourier, AddressID: 7},
 	}
+}
 
+// delivery is what createReq's delivery resolves to.
+func (suite *OrderServiceSuite) delivery() *model.Delivery {
+	return &model.Delivery{
+		Method:  deliveryModel.MethodCourier,
+		Price:   money.FromMinor(300),
+		Address: deliveryModel.Destination{Recipient: "Ivan", Phone: "+79990000000", Country: "RU", City: "Moscow", Street: "Tverskaya 1", PostalCode: "125009"},
+	}
+}
+
+func (suite *OrderServiceSuite) expectProduct() {
 	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{
 		ID:          1,
 		Name:        "test",
 		Description: "test",
-		Price:       5,
+		Price:       money.FromMinor(500),
 	}, nil)
+}
 
-	suite.repo.On("CreateOrder", 1, []model.OrderLine{
+// ====================================================================================================================
+
+func (suite *OrderServiceSuite) TestService_CreateOrderSuccess() {
+	suite.expectProduct()
+
+	suite.repo.On("CreateOrder", mock.Anything, 1, "test@test.com", []model.OrderLine{
 		{
 			ProductID: 1,
-			Quantity:  1,
-			Price:     5,
+			Quantity:  2,
+			Price:     money.FromMinor(1000),
 		},
-	}).Return(&model.Order{
-		ID:     1,
-		UserID: 1,
+	}, (*model.AppliedCoupon)(nil), suite.delivery()).Return(&model.Order{
+		ID:         1,
+		UserID:     1,
+		Status:     model.StatusPending,
+		TotalPrice: money.FromMinor(1000),
 		Lines: []model.OrderLine{
 			{
 				ProductID: 1,
-				Quantity:  1,
+				Quantity:  2,
 			},
 		},
 	}, nil)
+	suite.repo.On("UpdateOrderReservation", mock.Anything, 1, 3).Return(nil)
 
-	order, err := suite.service.CreateOrder(1, req)
+	res, err := suite.service.CreateOrder(context.Background(), 1, "test@test.com", suite.createReq())
 
-	suite.NotNil(order)
 	suite.Nil(err)
+	suite.Equal(3, res.Order.ReservationID)
+	suite.Equal("https://pay.test/1", res.Payment.Confirmation.ConfirmationURL)
+	suite.Equal([]string{"order-1"}, suite.paymentKeys)
+	suite.paymentRepo.AssertCalled(suite.T(), "SavePayment", mock.Anything, mock.MatchedBy(func(p *paymentModel.Payment) bool {
+		return p.ProviderPaymentID == "pay-1" && p.OrderID == 1 && p.Amount == money.FromMinor(1000) &&
+			p.ConfirmationURL == "https://pay.test/1" && len(p.Payload) > 0
+	}))
+}
+
+func (suite *OrderServiceSuite) TestService_CreateOrderHoldsPayment() {
+	suite.service.holdPayments = true
+	suite.expectProduct()
+
+	suite.repo.On("CreateOrder", mock.Anything, 1, "test@test.com", mock.Anything, (*model.AppliedCoupon)(nil), suite.delivery()).
+		Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusPending, TotalPrice: money.FromMinor(1000)}, nil)
+	suite.repo.On("UpdateOrderReservation", mock.Anything, 1, 3).Return(nil)
+
+	_, err := suite.service.CreateOrder(context.Background(), 1, "test@test.com", suite.createReq())
 
+	suite.Nil(err)
+	suite.Contains(suite.paymentBody, `"capture":false`)
 }
 
 func (suite *OrderServiceSuite) TestService_CreateOrderFailure() {
-	req := &model.CreateOrderReq{
-		Lines: []model.OrderLineReq{
-			{
-				ProductID: 1,
-				Quantity:  1,
-			},
-		},
-	}
+	suite.expectProduct()
 
-	suite.productRepo.On("GetProductByID", 1).Return(&productModel.Product{
-		ID:          1,
-		Name:        "test",
-		Description: "test",
-		Price:       5,
-	}, nil)
+	suite.repo.On("CreateOrder", mock.Anything, 1, "test@test.com", mock.Anything, (*model.AppliedCoupon)(nil), mock.Anything).
+		Return(nil, errors.New("error"))
 
-	suite.repo.On("CreateOrder", 1, []model.OrderLine{
-		{
-			ProductID: 1,
-			Quantity:  1,
-			Price:     5,
-		}}).Return(nil, errors.New("error"))
+	res, err := suite.service.CreateOrder(context.Background(), 1, "test@test.com", suite.createReq())
 
-	order, err := suite.service.CreateOrder(1, req)
-
-	suite.Nil(order)
+	suite.Nil(res)
 	suite.NotNil(err)
 }
 
 func (suite *OrderServiceSuite) TestService_CreateOrderGetProductFailure() {
-	req := &model.CreateOrderReq{
-		Lines: []model.OrderLineReq{
-			{
-				ProductID: 1,
-				Quantity:  1,
-			},
-		},
-	}
-
 	suite.productRepo.On("GetProductByID", 1).Return(nil, errors.New("error"))
 
-	order, err := suite.service.CreateOrder(1, req)
+	res, err := suite.service.CreateOrder(context.Background(), 1, "test@test.com", suite.createReq())
 
-	suite.Nil(order)
+	suite.Nil(res)
 	suite.NotNil(err)
 }
 
+func (suite *OrderServiceSuite) TestService_CreateOrderDeliveryRejected() {
+	req := suite.createReq()
+	req.Delivery = deliveryModel.DeliveryReq{Method: deliveryModel.MethodPost, AddressID: 8}
+
+	suite.expectProduct()
+	suite.deliveryService.On("Resolve", mock.Anything, 1, &req.Delivery).Return(nil, nil, deliveryService.ErrAddressNotFound)
+
+	res, err := suite.service.CreateOrder(context.Background(), 1, "test@test.com", req)
+
+	suite.Nil(res)
+	suite.ErrorIs(err, deliveryService.ErrAddressNotFound)
+	suite.repo.AssertNotCalled(suite.T(), "CreateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *OrderServiceSuite) TestService_CreateOrderFreeShipping() {
+	req := suite.createReq()
+	req.CouponCode = "SHIPFREE"
+	delivery := suite.delivery()
+	delivery.Price = money.FromMinor(0)
+
+	suite.expectProduct()
+	suite.couponService.On("Apply", mock.Anything, "SHIPFREE", 1, mock.Anything).
+		Return(&couponModel.Discount{CouponID: 5, Code: "SHIPFREE", FreeShipping: true, Lines: []money.Money{money.FromMinor(0)}}, nil)
+	suite.repo.On("CreateOrder", mock.Anything, 1, "test@test.com", mock.Anything, mock.Anything, delivery).
+		Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusPending, TotalPrice: money.FromMinor(1000), Delivery: delivery}, nil)
+	suite.couponService.On("Redeem", mock.Anything, 5, 1, 1).Return(nil)
+	suite.repo.On("UpdateOrderReservation", mock.Anything, 1, 3).Return(nil)
+
+	res, err := suite.service.CreateOrder(context.Background(), 1, "test@test.com", req)
+
+	suite.Nil(err)
+	suite.Equal(money.FromMinor(0), res.Order.Delivery.Price)
+}
+
+func (suite *OrderServiceSuite) TestService_CreateOrderRedeemFailure() {
+	req := suite.createReq()
+	req.CouponCode = "SALE10"
+
+	suite.expectProduct()
+	suite.couponService.On("Apply", mock.Anything, "SALE10", 1, []couponModel.Line{{ProductID: 1, Price: money.FromMinor(1000)}}).
+		Return(&couponModel.Discount{CouponID: 4, Code: "SALE10", Amount: money.FromMinor(100), Lines: []money.Money{money.FromMinor(100)}}, nil)
+	suite.repo.On("CreateOrder", mock.Anything, 1, "test@test.com", mock.Anything, &model.AppliedCoupon{Code: "SALE10", Discount: money.FromMinor(100)}, mock.Anything).
+		Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusPending}, nil)
+	suite.couponService.On("Redeem", mock.Anything, 4, 1, 1).Return(couponRepository.ErrUsageLimitReached)
+
+	res, err := suite.service.CreateOrder(context.Background(), 1, "test@test.com", req)
+
+	// The redemption shares the order's transaction, so nothing is left to cancel or reserve.
+	suite.Nil(res)
+	suite.ErrorIs(err, couponRepository.ErrUsageLimitReached)
+	suite.repo.AssertNotCalled(suite.T(), "UpdateOrderStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *OrderServiceSuite) TestService_CreateOrderReservationFailure() {
+	suite.productClient.reserveErr = errors.New("error")
+
+	suite.expectProduct()
+	suite.repo.On("CreateOrder", mock.Anything, 1, "test@test.com", mock.Anything, (*model.AppliedCoupon)(nil), mock.Anything).
+		Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusPending}, nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusPending, model.StatusCanceled, model.SystemActor, "reserve stock failed").
+		Return(nil)
+
+	res, err := suite.service.CreateOrder(context.Background(), 1, "test@test.com", suite.createReq())
+
+	suite.Nil(res)
+	suite.NotNil(err)
+	suite.Empty(suite.productClient.unreserved)
+	suite.Empty(suite.paymentKeys)
+}
+
+func (suite *OrderServiceSuite) TestService_CreateOrderLinkReservationFailure() {
+	suite.expectProduct()
+	suite.repo.On("CreateOrder", mock.Anything, 1, "test@test.com", mock.Anything, (*model.AppliedCoupon)(nil), mock.Anything).
+		Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusPending}, nil)
+	suite.repo.On("UpdateOrderReservation", mock.Anything, 1, 3).Return(errors.New("error"))
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusPending, model.StatusCanceled, model.SystemActor, "link reservation failed").
+		Return(nil)
+
+	res, err := suite.service.CreateOrder(context.Background(), 1, "test@test.com", suite.createReq())
+
+	suite.Nil(res)
+	suite.NotNil(err)
+	suite.Equal([]int32{3}, suite.productClient.unreserved)
+	suite.Empty(suite.paymentKeys)
+}
+
+func (suite *OrderServiceSuite) TestService_CreateOrderPaymentFailure() {
+	suite.paymentDown = true
+
+	suite.expectProduct()
+	suite.repo.On("CreateOrder", mock.Anything, 1, "test@test.com", mock.Anything, (*model.AppliedCoupon)(nil), mock.Anything).
+		Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusPending}, nil)
+	suite.repo.On("UpdateOrderReservation", mock.Anything, 1, 3).Return(nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusPending, model.StatusCanceled, model.SystemActor, "create payment failed").
+		Return(nil)
+
+	res, err := suite.service.CreateOrder(context.Background(), 1, "test@test.com", suite.createReq())
+
+	// The stock is released once, by the reservation step, not again when the order is canceled.
+	suite.Nil(res)
+	suite.NotNil(err)
+	suite.Equal([]int32{3}, suite.productClient.unreserved)
+}
+
+func (suite *OrderServiceSuite) TestService_CreateOrderPaymentFailureReleasesCouponAndKey() {
+	suite.paymentDown = true
+	req := suite.createReq()
+	req.CouponCode = "SALE10"
+	req.IdempotencyKey = "key-1"
+
+	suite.idempotencyRepo.On("GetKey", mock.Anything, 1, "key-1").Return(nil, sql.ErrNoRows)
+	suite.expectProduct()
+	suite.couponService.On("Apply", mock.Anything, "SALE10", 1, mock.Anything).
+		Return(&couponModel.Discount{CouponID: 4, Code: "SALE10", Amount: money.FromMinor(100), Lines: []money.Money{money.FromMinor(100)}}, nil)
+	suite.repo.On("CreateOrder", mock.Anything, 1, "test@test.com", mock.Anything, mock.Anything, mock.Anything).
+		Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusPending, Coupon: &model.AppliedCoupon{Code: "SALE10"}}, nil)
+	suite.couponService.On("Redeem", mock.Anything, 4, 1, 1).Return(nil)
+	suite.idempotencyRepo.On("SaveKey", mock.Anything, 1, "key-1", requestFingerprint(req), 1).Return(nil)
+	suite.repo.On("UpdateOrderReservation", mock.Anything, 1, 3).Return(nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusPending, model.StatusCanceled, model.SystemActor, "create payment failed").
+		Return(nil)
+	suite.couponService.On("Release", mock.Anything, 1).Return(nil)
+	suite.idempotencyRepo.On("DeleteKey", mock.Anything, 1, "key-1").Return(nil)
+
+	res, err := suite.service.CreateOrder(context.Background(), 1, "test@test.com", req)
+
+	suite.Nil(res)
+	suite.NotNil(err)
+	suite.Equal([]int32{3}, suite.productClient.unreserved)
+}
+
+func (suite *OrderServiceSuite) TestService_CreateOrderCancelFailureKeepsKey() {
+	suite.paymentDown = true
+	req := suite.createReq()
+	req.IdempotencyKey = "key-1"
+
+	suite.idempotencyRepo.On("GetKey", mock.Anything, 1, "key-1").Return(nil, sql.ErrNoRows)
+	suite.expectProduct()
+	suite.repo.On("CreateOrder", mock.Anything, 1, "test@test.com", mock.Anything, (*model.AppliedCoupon)(nil), mock.Anything).
+		Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusPending}, nil)
+	suite.idempotencyRepo.On("SaveKey", mock.Anything, 1, "key-1", requestFingerprint(req), 1).Return(nil)
+	suite.repo.On("UpdateOrderReservation", mock.Anything, 1, 3).Return(nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusPending, model.StatusCanceled, model.SystemActor, "create payment failed").
+		Return(errors.New("db error"))
+
+	res, err := suite.service.CreateOrder(context.Background(), 1, "test@test.com", req)
+
+	// The stock is still released, and the payment error is the one reported.
+	suite.Nil(res)
+	suite.ErrorContains(err, "create payment")
+	suite.Equal([]int32{3}, suite.productClient.unreserved)
+	suite.idempotencyRepo.AssertNotCalled(suite.T(), "DeleteKey", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// ====================================================================================================================
+
+func (suite *OrderServiceSuite) TestService_CreateOrderIdempotentFirstRequest() {
+	req := suite.createReq()
+	req.IdempotencyKey = "key-1"
+
+	suite.idempotencyRepo.On("GetKey", mock.Anything, 1, "key-1").Return(nil, sql.ErrNoRows)
+	suite.expectProduct()
+	suite.repo.On("CreateOrder", mock.Anything, 1, "test@test.com", mock.Anything, (*model.AppliedCoupon)(nil), mock.Anything).
+		Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusPending}, nil)
+	suite.idempotencyRepo.On("SaveKey", mock.Anything, 1, "key-1", requestFingerprint(req), 1).Return(nil)
+	suite.repo.On("UpdateOrderReservation", mock.Anything, 1, 3).Return(nil)
+	suite.idempotencyRepo.On("SaveResponse", mock.Anything, 1, "key-1", mock.Anything).Return(nil)
+
+	res, err := suite.service.CreateOrder(context.Background(), 1, "test@test.com", req)
+
+	suite.Nil(err)
+	suite.Equal(1, res.Order.ID)
+}
+
+func (suite *OrderServiceSuite) TestService_CreateOrderIdempotentReplay() {
+	req := suite.createReq()
+	req.IdempotencyKey = "key-1"
+	stored := &model.CreateOrderRes{Order: &model.Order{ID: 1, UserID: 1, Status: model.StatusPending}}
+
+	suite.idempotencyRepo.On("GetKey", mock.Anything, 1, "key-1").Return(&model.IdempotencyKey{
+		UserID: 1, Key: "key-1", RequestHash: requestFingerprint(req), OrderID: 1, Response: stored,
+	}, nil)
+
+	res, err := suite.service.CreateOrder(context.Background(), 1, "test@test.com", req)
+
+	suite.Nil(err)
+	suite.Equal(stored, res)
+	suite.Empty(suite.paymentKeys)
+}
+
+func (suite *OrderServiceSuite) TestService_CreateOrderIdempotentKeyReused() {
+	req := suite.createReq()
+	req.IdempotencyKey = "key-1"
+
+	suite.idempotencyRepo.On("GetKey", mock.Anything, 1, "key-1").Return(&model.IdempotencyKey{
+		UserID: 1, Key: "key-1", RequestHash: "other", OrderID: 1,
+	}, nil)
+
+	res, err := suite.service.CreateOrder(context.Background(), 1, "test@test.com", req)
+
+	suite.Nil(res)
+	suite.ErrorIs(err, ErrIdempotencyKeyReused)
+}
+
+func (suite *OrderServiceSuite) TestService_CreateOrderIdempotentResumesPayment() {
+	req := suite.createReq()
+	req.IdempotencyKey = "key-1"
+
+	suite.idempotencyRepo.On("GetKey", mock.Anything, 1, "key-1").Return(&model.IdempotencyKey{
+		UserID: 1, Key: "key-1", RequestHash: requestFingerprint(req), OrderID: 7,
+	}, nil)
+	suite.repo.On("GetOrderByID", mock.Anything, 7).Return(&model.Order{ID: 7, UserID: 1, Status: model.StatusPending, ReservationID: 3}, nil)
+	suite.idempotencyRepo.On("SaveResponse", mock.Anything, 1, "key-1", mock.Anything).Return(nil)
+
+	res, err := suite.service.CreateOrder(context.Background(), 1, "test@test.com", req)
+
+	// No new order, the payment for the first one is requested again under the same YooKassa key.
+	suite.Nil(err)
+	suite.Equal(7, res.Order.ID)
+	suite.Equal([]string{"order-7"}, suite.paymentKeys)
+}
+
+func (suite *OrderServiceSuite) TestService_CreateOrderIdempotentConcurrent() {
+	req := suite.createReq()
+	req.IdempotencyKey = "key-1"
+
+	suite.idempotencyRepo.On("GetKey", mock.Anything, 1, "key-1").Return(nil, sql.ErrNoRows)
+	suite.expectProduct()
+	suite.repo.On("CreateOrder", mock.Anything, 1, "test@test.com", mock.Anything, (*model.AppliedCoupon)(nil), mock.Anything).
+		Return(&model.Order{ID: 2, UserID: 1, Status: model.StatusPending}, nil)
+	suite.idempotencyRepo.On("SaveKey", mock.Anything, 1, "key-1", requestFingerprint(req), 2).Return(repository.ErrKeyExists)
+
+	res, err := suite.service.CreateOrder(context.Background(), 1, "test@test.com", req)
+
+	suite.Nil(res)
+	suite.ErrorIs(err, ErrIdempotencyInProgress)
+}
+
 // ====================================================================================================================
 
 func (suite *OrderServiceSuite) TestService_GetOrderByIDSuccess() {
-	suite.repo.On("GetOrderByID", 1, 1).Return(&model.Order{
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{
 		ID:     1,
 		UserID: 1,
 		Lines: []model.OrderLine{
@@ -136,23 +505,49 @@ func (suite *OrderServiceSuite) TestService_GetOrderByIDSuccess() {
 		Name: "test",
 	}, nil)
 
-	order, err := suite.service.GetOrderByID(1, 1)
+	order, err := suite.service.GetOrderByID(context.Background(), 1, model.Actor{ID: 1, Role: model.RoleUser})
 
-	suite.NotNil(order)
 	suite.Nil(err)
+	suite.Equal("test", order.Lines[0].Product.Name)
 }
 
 func (suite *OrderServiceSuite) TestService_GetOrderByIDFailure() {
-	suite.repo.On("GetOrderByID", 1, 1).Return(nil, errors.New("error"))
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(nil, errors.New("error"))
 
-	order, err := suite.service.GetOrderByID(1, 1)
+	order, err := suite.service.GetOrderByID(context.Background(), 1, model.Actor{ID: 1, Role: model.RoleUser})
 
 	suite.Nil(order)
 	suite.NotNil(err)
 }
 
+func (suite *OrderServiceSuite) TestService_GetOrderByIDNotFound() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(nil, sql.ErrNoRows)
+
+	_, err := suite.service.GetOrderByID(context.Background(), 1, model.Actor{ID: 1, Role: model.RoleUser})
+
+	suite.ErrorIs(err, ErrOrderNotFound)
+}
+
+func (suite *OrderServiceSuite) TestService_GetOrderByIDNotOwner() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, UserID: 1}, nil)
+
+	order, err := suite.service.GetOrderByID(context.Background(), 1, model.Actor{ID: 2, Role: model.RoleUser})
+
+	suite.Nil(order)
+	suite.ErrorIs(err, ErrNotOrderOwner)
+}
+
+func (suite *OrderServiceSuite) TestService_GetOrderByIDModerator() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, UserID: 1}, nil)
+
+	order, err := suite.service.GetOrderByID(context.Background(), 1, model.Actor{ID: 5, Role: model.RoleModerator})
+
+	suite.Nil(err)
+	suite.Equal(1, order.UserID)
+}
+
 func (suite *OrderServiceSuite) TestService_GetOrderByIDGetProductFailure() {
-	suite.repo.On("GetOrderByID", 1, 1).Return(&model.Order{
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{
 		ID:     1,
 		UserID: 1,
 		Lines: []model.OrderLine{
@@ -164,7 +559,7 @@ func (suite *OrderServiceSuite) TestService_GetOrderByIDGetProductFailure() {
 
 	suite.productRepo.On("GetProductByID", 1).Return(nil, errors.New("error"))
 
-	order, err := suite.service.GetOrderByID(1, 1)
+	order, err := suite.service.GetOrderByID(context.Background(), 1, model.Actor{ID: 1, Role: model.RoleUser})
 
 	suite.Nil(order)
 	suite.NotNil(err)
@@ -172,70 +567,396 @@ func (suite *OrderServiceSuite) TestService_GetOrderByIDGetProductFailure() {
 
 // ====================================================================================================================
 
-func (suite *OrderServiceSuite) TestService_GetAllOrdersSuccess() {
-	suite.repo.On("GetAllOrders", 1).Return([]model.Order{
-		{
-			ID:     1,
-			UserID: 1,
-			Lines: []model.OrderLine{
-				{
-					ProductID: 1,
-				},
-			},
-		},
-	}, nil)
+func (suite *OrderServiceSuite) TestService_GetOrdersCustomerDefaults() {
+	expected := &model.OrderListReq{UserID: 1, Sort: model.SortCreatedAt, Dir: model.SortDesc, Limit: model.DefaultOrderPageLimit}
+	suite.repo.On("GetOrders", mock.Anything, expected).Return([]model.Order{
+		{ID: 1, UserID: 1, UserEmail: "test@test.com", Status: model.StatusCreated, TotalPrice: money.FromMinor(500)},
+	}, "next", nil)
 
-	orders, err := suite.service.GetAllOrders(1)
+	// A customer asking for someone else's orders still only gets their own.
+	res, err := suite.service.GetOrders(context.Background(), model.Actor{ID: 1, Role: model.RoleUser}, &model.OrderListReq{UserID: 2})
 
-	suite.NotNil(orders)
 	suite.Nil(err)
+	suite.Len(res.Orders, 1)
+	suite.Equal(money.FromMinor(500), res.Orders[0].TotalPrice)
+	suite.Equal("next", res.NextCursor)
+	suite.Equal(model.DefaultOrderPageLimit, res.Limit)
 }
 
-func (suite *OrderServiceSuite) TestService_GetAllOrdersFailure() {
-	suite.repo.On("GetAllOrders", 1).Return(nil, errors.New("error"))
+func (suite *OrderServiceSuite) TestService_GetOrdersModerator() {
+	expected := &model.OrderListReq{Status: model.StatusCreated, Sort: model.SortTotal, Dir: model.SortAsc, Limit: model.MaxOrderPageLimit}
+	suite.repo.On("GetOrders", mock.Anything, expected).Return([]model.Order{}, "", nil)
+
+	res, err := suite.service.GetOrders(context.Background(), model.Actor{ID: 5, Role: model.RoleModerator},
+		&model.OrderListReq{Status: model.StatusCreated, Sort: model.SortTotal, Dir: model.SortAsc, Limit: 1000})
+
+	suite.Nil(err)
+	suite.Empty(res.Orders)
+	suite.Equal("", res.NextCursor)
+}
 
-	orders, err := suite.service.GetAllOrders(1)
+func (suite *OrderServiceSuite) TestService_GetOrdersInvalidFilter() {
+	actor := model.Actor{ID: 1, Role: model.RoleUser}
 
-	suite.Nil(orders)
+	_, err := suite.service.GetOrders(context.Background(), actor, &model.OrderListReq{Sort: "name"})
+	suite.ErrorIs(err, ErrInvalidOrderFilter)
+
+	_, err = suite.service.GetOrders(context.Background(), actor, &model.OrderListReq{MinTotal: money.FromMinor(1000), MaxTotal: money.FromMinor(100)})
+	suite.ErrorIs(err, ErrInvalidOrderFilter)
+
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	_, err = suite.service.GetOrders(context.Background(), actor, &model.OrderListReq{From: from, To: from.AddDate(0, -1, 0)})
+	suite.ErrorIs(err, ErrInvalidOrderFilter)
+}
+
+func (suite *OrderServiceSuite) TestService_GetOrdersFailure() {
+	suite.repo.On("GetOrders", mock.Anything, mock.Anything).Return(nil, "", errors.New("error"))
+
+	res, err := suite.service.GetOrders(context.Background(), model.Actor{ID: 1, Role: model.RoleUser}, &model.OrderListReq{})
+
+	suite.Nil(res)
 	suite.NotNil(err)
 }
 
 // ====================================================================================================================
 
 func (suite *OrderServiceSuite) TestService_CancelOrderSuccess() {
-	suite.repo.On("GetOrderByID", 1, 1).Return(&model.Order{
-		ID:     1,
-		UserID: 1,
-		Lines: []model.OrderLine{
-			{
-				ProductID: 1,
-			},
-		},
+	actor := model.Actor{ID: 1, Role: model.RoleUser}
+
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{
+		ID:            1,
+		UserID:        1,
+		Status:        model.StatusCreated,
+		ReservationID: 3,
+		Coupon:        &model.AppliedCoupon{Code: "SALE10"},
 	}, nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusCreated, model.StatusCanceled, actor, "changed my mind").Return(nil)
+	suite.couponService.On("Release", mock.Anything, 1).Return(nil)
 
-	suite.repo.On("UpdateOrder", 1, 1, model.StatusOrderCanceled).Return(nil)
+	err := suite.service.CancelOrder(context.Background(), 1, actor, "changed my mind")
 
-	order, err := suite.service.CancelOrder(1, 1)
-
-	suite.NotNil(order)
 	suite.Nil(err)
+	suite.Equal([]int32{3}, suite.productClient.unreserved)
 }
 
 func (suite *OrderServiceSuite) TestService_CancelOrderFailure() {
-	suite.repo.On("GetOrderByID", 1, 1).Return(&model.Order{
-		ID:     1,
-		UserID: 1,
-		Lines: []model.OrderLine{
-			{
-				ProductID: 1,
-			},
-		},
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{
+		ID:            1,
+		UserID:        1,
+		Status:        model.StatusPending,
+		ReservationID: 3,
 	}, nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusPending, model.StatusCanceled, model.SystemActor, "").
+		Return(repository.ErrStatusConflict)
+
+	err := suite.service.CancelOrder(context.Background(), 1, model.SystemActor, "")
+
+	// Stock stays reserved when the status change loses a race.
+	suite.ErrorIs(err, repository.ErrStatusConflict)
+	suite.Empty(suite.productClient.unreserved)
+}
+
+func (suite *OrderServiceSuite) TestService_ExpireOrderSuccess() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusPending, ReservationID: 3}, nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusPending, model.StatusCanceled, model.SystemActor, "payment timeout").Return(nil)
+	suite.outboxRepo.On("Add", mock.Anything, OrderExpiredTopic, "1", mock.Anything).Return(nil)
+
+	err := suite.service.ExpireOrder(context.Background(), 1)
+
+	suite.Nil(err)
+	suite.Equal([]int32{3}, suite.productClient.unreserved)
+}
+
+func (suite *OrderServiceSuite) TestService_ExpireOrderPaidMeanwhile() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusCreated, ReservationID: 3}, nil)
+
+	err := suite.service.ExpireOrder(context.Background(), 1)
+
+	suite.Nil(err)
+	suite.Empty(suite.productClient.unreserved)
+}
+
+func (suite *OrderServiceSuite) TestService_ExpireOrderOutboxFailure() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusPending, ReservationID: 3}, nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusPending, model.StatusCanceled, model.SystemActor, "payment timeout").Return(nil)
+	suite.outboxRepo.On("Add", mock.Anything, OrderExpiredTopic, "1", mock.Anything).Return(errors.New("db error"))
+
+	err := suite.service.ExpireOrder(context.Background(), 1)
+
+	// Without the event the cancellation rolls back and the stock stays held for the next sweep.
+	suite.NotNil(err)
+	suite.Empty(suite.productClient.unreserved)
+}
+
+func (suite *OrderServiceSuite) TestService_CancelOrderDelivered() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusDelivered}, nil)
+
+	err := suite.service.CancelOrder(context.Background(), 1, model.Actor{ID: 5, Role: model.RoleModerator}, "")
+
+	suite.ErrorIs(err, model.ErrInvalidTransition)
+}
+
+func (suite *OrderServiceSuite) TestService_CancelOrderNotOwner() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusCreated, ReservationID: 3}, nil)
+
+	err := suite.service.CancelOrder(context.Background(), 1, model.Actor{ID: 2, Role: model.RoleUser}, "")
+
+	suite.ErrorIs(err, ErrNotOrderOwner)
+	suite.Empty(suite.productClient.unreserved)
+}
+
+func (suite *OrderServiceSuite) TestService_CancelOrderDeliveringAsUser() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusDelivering}, nil)
+
+	err := suite.service.CancelOrder(context.Background(), 1, model.Actor{ID: 1, Role: model.RoleUser}, "")
+
+	suite.ErrorIs(err, model.ErrTransitionForbidden)
+}
+
+// ====================================================================================================================
+
+func (suite *OrderServiceSuite) TestService_UpdateOrderStatusSuccess() {
+	actor := model.Actor{ID: 5, Role: model.RoleModerator}
+
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, Status: model.StatusCreated}, nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusCreated, model.StatusDelivering, actor, "shipped").Return(nil)
+
+	order, err := suite.service.UpdateOrderStatus(context.Background(), 1, model.StatusDelivering, actor, "shipped")
+
+	suite.Nil(err)
+	suite.Equal(model.StatusDelivering, order.Status)
+}
+
+func (suite *OrderServiceSuite) TestService_UpdateOrderStatusSkipsStatus() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, Status: model.StatusPending}, nil)
+
+	order, err := suite.service.UpdateOrderStatus(context.Background(), 1, model.StatusDelivered, model.Actor{ID: 5, Role: model.RoleModerator}, "")
+
+	suite.Nil(order)
+	suite.ErrorIs(err, model.ErrInvalidTransition)
+}
+
+func (suite *OrderServiceSuite) TestService_UpdateOrderStatusAsUser() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusCreated}, nil)
+
+	order, err := suite.service.UpdateOrderStatus(context.Background(), 1, model.StatusCanceled, model.Actor{ID: 1, Role: model.RoleUser}, "")
+
+	suite.Nil(order)
+	suite.ErrorIs(err, ErrModeratorRequired)
+}
+
+func (suite *OrderServiceSuite) TestService_UpdateOrderStatusCapturesHold() {
+	actor := model.Actor{ID: 5, Role: model.RoleModerator}
+	suite.paymentStatus = "waiting_for_capture"
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, Status: model.StatusCreated, PaymentID: "pay-1"}, nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusCreated, model.StatusDelivering, actor, "").Return(nil)
+
+	order, err := suite.service.UpdateOrderStatus(context.Background(), 1, model.StatusDelivering, actor, "")
+
+	suite.Nil(err)
+	suite.Equal(model.StatusDelivering, order.Status)
+	suite.Equal([]string{"GET /payments/pay-1", "POST /payments/pay-1/capture"}, suite.paymentCalls)
+	suite.Equal([]string{"capture-pay-1"}, suite.paymentKeys)
+}
+
+func (suite *OrderServiceSuite) TestService_UpdateOrderStatusCapturedAtCheckout() {
+	actor := model.Actor{ID: 5, Role: model.RoleModerator}
+	suite.paymentStatus = "succeeded"
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, Status: model.StatusCreated, PaymentID: "pay-1"}, nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusCreated, model.StatusDelivering, actor, "").Return(nil)
 
-	suite.repo.On("UpdateOrder", 1, 1, model.StatusOrderCanceled).Return(errors.New("error"))
+	_, err := suite.service.UpdateOrderStatus(context.Background(), 1, model.StatusDelivering, actor, "")
 
-	order, err := suite.service.CancelOrder(1, 1)
+	suite.Nil(err)
+	suite.Equal([]string{"GET /payments/pay-1"}, suite.paymentCalls)
+}
+
+func (suite *OrderServiceSuite) TestService_UpdateOrderStatusHoldExpired() {
+	actor := model.Actor{ID: 5, Role: model.RoleModerator}
+	suite.paymentStatus = "canceled"
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, Status: model.StatusCreated, PaymentID: "pay-1"}, nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusCreated, model.StatusDelivering, actor, "").Return(nil)
 
+	order, err := suite.service.UpdateOrderStatus(context.Background(), 1, model.StatusDelivering, actor, "")
+
+	// The order does not ship without its money.
 	suite.Nil(order)
+	suite.ErrorIs(err, ErrPaymentNotHeld)
+}
+
+func (suite *OrderServiceSuite) TestService_CancelOrderReleasesHold() {
+	actor := model.Actor{ID: 1, Role: model.RoleUser}
+	suite.paymentStatus = "waiting_for_capture"
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusCreated, PaymentID: "pay-1"}, nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusCreated, model.StatusCanceled, actor, "").Return(nil)
+
+	err := suite.service.CancelOrder(context.Background(), 1, actor, "")
+
+	suite.Nil(err)
+	suite.Equal([]string{"GET /payments/pay-1", "POST /payments/pay-1/cancel"}, suite.paymentCalls)
+	suite.Equal([]string{"cancel-pay-1"}, suite.paymentKeys)
+}
+
+func (suite *OrderServiceSuite) TestService_CancelOrderRefundsCapturedPayment() {
+	actor := model.Actor{ID: 1, Role: model.RoleUser}
+	suite.paymentStatus = "succeeded"
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusCreated, PaymentID: "pay-1"}, nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusCreated, model.StatusCanceled, actor, "").Return(nil)
+
+	err := suite.service.CancelOrder(context.Background(), 1, actor, "")
+
+	suite.Nil(err)
+	suite.Equal([]string{"GET /payments/pay-1", "POST /refunds"}, suite.paymentCalls)
+	suite.Equal([]string{"refund-pay-1"}, suite.paymentKeys)
+}
+
+func (suite *OrderServiceSuite) TestService_CancelOrderReleaseHoldFailure() {
+	actor := model.Actor{ID: 1, Role: model.RoleUser}
+	suite.paymentDown = true
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, UserID: 1, Status: model.StatusCreated, PaymentID: "pay-1", ReservationID: 3}, nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusCreated, model.StatusCanceled, actor, "").Return(nil)
+
+	err := suite.service.CancelOrder(context.Background(), 1, actor, "")
+
+	// The status change is rolled back with the failed release, so the stock stays with the order.
 	suite.NotNil(err)
+	suite.Empty(suite.productClient.unreserved)
+}
+
+func (suite *OrderServiceSuite) TestService_SettleHoldCaptures() {
+	suite.paymentStatus = "waiting_for_capture"
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, Status: model.StatusCreated, PaymentID: "pay-1"}, nil)
+
+	err := suite.service.SettleHold(context.Background(), 1, "pay-1")
+
+	suite.Nil(err)
+	suite.Equal([]string{"GET /payments/pay-1", "POST /payments/pay-1/capture"}, suite.paymentCalls)
+}
+
+func (suite *OrderServiceSuite) TestService_SettleHoldReleasesCanceledOrder() {
+	suite.paymentStatus = "waiting_for_capture"
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, Status: model.StatusCanceled, PaymentID: "pay-1"}, nil)
+
+	err := suite.service.SettleHold(context.Background(), 1, "pay-1")
+
+	suite.Nil(err)
+	suite.Equal([]string{"GET /payments/pay-1", "POST /payments/pay-1/cancel"}, suite.paymentCalls)
+}
+
+func (suite *OrderServiceSuite) TestService_SettleHoldReleasesOtherPayment() {
+	suite.paymentStatus = "waiting_for_capture"
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, Status: model.StatusCreated, PaymentID: "pay-2"}, nil)
+
+	err := suite.service.SettleHold(context.Background(), 1, "pay-1")
+
+	suite.Nil(err)
+	suite.Equal([]string{"GET /payments/pay-1", "POST /payments/pay-1/cancel"}, suite.paymentCalls)
+}
+
+func (suite *OrderServiceSuite) TestService_GetStatusHistoryNotOwner() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, UserID: 1}, nil)
+
+	history, err := suite.service.GetStatusHistory(context.Background(), 1, model.Actor{ID: 2, Role: model.RoleUser})
+
+	suite.Nil(history)
+	suite.ErrorIs(err, ErrNotOrderOwner)
+}
+
+func (suite *OrderServiceSuite) TestService_GetOrderPaymentsSuccess() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, UserID: 1}, nil)
+	suite.paymentRepo.On("GetPaymentsByOrder", mock.Anything, 1).Return([]paymentModel.Payment{
+		{ID: 1, ProviderPaymentID: "pay-1", OrderID: 1, Status: "succeeded"},
+	}, nil)
+
+	payments, err := suite.service.GetOrderPayments(context.Background(), 1, model.Actor{ID: 1, Role: model.RoleUser})
+
+	suite.Nil(err)
+	suite.Len(payments, 1)
+	suite.Equal("pay-1", payments[0].ProviderPaymentID)
+}
+
+func (suite *OrderServiceSuite) TestService_GetOrderPaymentsNotOwner() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, UserID: 1}, nil)
+
+	payments, err := suite.service.GetOrderPayments(context.Background(), 1, model.Actor{ID: 2, Role: model.RoleUser})
+
+	suite.Nil(payments)
+	suite.ErrorIs(err, ErrNotOrderOwner)
+	suite.paymentRepo.AssertNotCalled(suite.T(), "GetPaymentsByOrder", mock.Anything, mock.Anything)
+}
+
+func (suite *OrderServiceSuite) TestService_ConfirmOrderSuccess() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, Status: model.StatusPending}, nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusPending, model.StatusCreated, model.SystemActor, "payment succeeded").Return(nil)
+	suite.outboxRepo.On("Add", mock.Anything, CreateOrderTopic, "1", mock.MatchedBy(func(order *model.Order) bool {
+		return order.Status == model.StatusCreated
+	})).Return(nil)
+	suite.repo.On("SetPaymentID", mock.Anything, 1, "pay-1").Return(nil)
+
+	err := suite.service.ConfirmOrder(context.Background(), 1, "pay-1")
+
+	suite.Nil(err)
+}
+
+func (suite *OrderServiceSuite) TestService_ConfirmOrderCommitsReservation() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, Status: model.StatusPending, ReservationID: 3}, nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusPending, model.StatusCreated, model.SystemActor, "payment succeeded").Return(nil)
+	suite.outboxRepo.On("Add", mock.Anything, CreateOrderTopic, "1", mock.Anything).Return(nil)
+	suite.repo.On("SetPaymentID", mock.Anything, 1, "pay-1").Return(nil)
+
+	err := suite.service.ConfirmOrder(context.Background(), 1, "pay-1")
+
+	suite.Nil(err)
+	suite.Len(suite.productClient.commits, 1)
+	suite.False(suite.productClient.commits[0].Backorder)
+}
+
+func (suite *OrderServiceSuite) TestService_ConfirmOrderOutOfStockBackorders() {
+	suite.productClient.outOfStock = true
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, Status: model.StatusPending, ReservationID: 3}, nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusPending, model.StatusCreated, model.SystemActor, "payment succeeded").Return(nil)
+	suite.outboxRepo.On("Add", mock.Anything, CreateOrderTopic, "1", mock.Anything).Return(nil)
+	suite.repo.On("SetPaymentID", mock.Anything, 1, "pay-1").Return(nil)
+
+	err := suite.service.ConfirmOrder(context.Background(), 1, "pay-1")
+
+	// The order is paid, so the reservation is committed as a backorder rather than left uncommitted.
+	suite.Nil(err)
+	suite.Len(suite.productClient.commits, 2)
+	suite.Equal(int32(3), suite.productClient.commits[1].ReservationID)
+	suite.True(suite.productClient.commits[1].Backorder)
+}
+
+func (suite *OrderServiceSuite) TestService_ConfirmOrderConflictCommitsNothing() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, Status: model.StatusPending, ReservationID: 3}, nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusPending, model.StatusCreated, model.SystemActor, "payment succeeded").
+		Return(repository.ErrStatusConflict)
+
+	err := suite.service.ConfirmOrder(context.Background(), 1, "pay-1")
+
+	// The order was canceled meanwhile and its reservation released, committing it would take the stock for good.
+	suite.ErrorIs(err, repository.ErrStatusConflict)
+	suite.Empty(suite.productClient.commits)
+	suite.outboxRepo.AssertNotCalled(suite.T(), "Add", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *OrderServiceSuite) TestService_ConfirmOrderOutboxFailure() {
+	order := &model.Order{ID: 1, Status: model.StatusPending}
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(order, nil)
+	suite.repo.On("UpdateOrderStatus", mock.Anything, 1, model.StatusPending, model.StatusCreated, model.SystemActor, "payment succeeded").Return(nil)
+	suite.outboxRepo.On("Add", mock.Anything, CreateOrderTopic, "1", mock.Anything).Return(errors.New("db error"))
+
+	err := suite.service.ConfirmOrder(context.Background(), 1, "pay-1")
+
+	// The status change is rolled back with the event, so the order is still awaiting payment.
+	suite.NotNil(err)
+	suite.Equal(model.StatusPending, order.Status)
+}
+
+func (suite *OrderServiceSuite) TestService_ConfirmOrderAlreadyConfirmed() {
+	suite.repo.On("GetOrderByID", mock.Anything, 1).Return(&model.Order{ID: 1, Status: model.StatusCreated}, nil)
+
+	err := suite.service.ConfirmOrder(context.Background(), 1, "pay-1")
+
+	suite.ErrorIs(err, model.ErrInvalidTransition)
 }
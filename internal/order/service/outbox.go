@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/internal/order/repository"
+	"github.com/aaanger/ecommerce/pkg/db"
+	"go.uber.org/zap"
+	"time"
+)
+
+// DefaultOutboxInterval is how often the relay looks for messages to publish.
+const DefaultOutboxInterval = 5 * time.Second
+
+const (
+	outboxBatchSize   = 100
+	outboxMaxAttempts = 10
+	outboxBaseBackoff = 5 * time.Second
+	outboxMaxBackoff  = 30 * time.Minute
+)
+
+// EventPublisher delivers an event to a single topic, kafka.Producer is the production implementation.
+type EventPublisher interface {
+	Produce(ctx context.Context, key string, value interface{}, retries int) error
+}
+
+//go:generate mockery --name=IOutboxService
+
+type IOutboxService interface {
+	GetMessages(ctx context.Context, status string, limit int) ([]model.OutboxMessage, error)
+	RetryMessage(ctx context.Context, id int) error
+}
+
+// OutboxService relays events written to the outbox to their topics. A message that keeps failing
+// is retried with exponential backoff and marked failed after outboxMaxAttempts, after which only
+// RetryMessage puts it back in the queue. Delivery is at least once: a message published right before
+// its batch fails to commit is sent again.
+type OutboxService struct {
+	repo       repository.IOutboxRepository
+	tx         db.Transactor
+	publishers map[string]EventPublisher
+	interval   time.Duration
+	log        *zap.Logger
+}
+
+func NewOutboxService(repo repository.IOutboxRepository, tx db.Transactor, publishers map[string]EventPublisher, interval time.Duration, log *zap.Logger) *OutboxService {
+	return &OutboxService{
+		repo:       repo,
+		tx:         tx,
+		publishers: publishers,
+		interval:   interval,
+		log:        log,
+	}
+}
+
+func (s *OutboxService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Dispatch(ctx)
+		}
+	}
+}
+
+// Dispatch publishes one batch of due messages. The batch stays locked until its results are stored,
+// so concurrent relays never pick up the same message.
+func (s *OutboxService) Dispatch(ctx context.Context) {
+	var sent, retried, failed int
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		messages, err := s.repo.FetchDue(ctx, outboxBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			publishErr := s.publish(ctx, message)
+			attempts := message.Attempts + 1
+
+			switch {
+			case publishErr == nil:
+				err = s.repo.MarkSent(ctx, message.ID)
+				sent++
+			case attempts >= outboxMaxAttempts:
+				err = s.repo.MarkFailed(ctx, message.ID, publishErr.Error())
+				failed++
+				s.log.Error("Outbox relay: giving up on message", zap.Error(publishErr), zap.Int("messageID", message.ID),
+					zap.String("topic", message.Topic), zap.Int("attempts", attempts))
+			default:
+				err = s.repo.MarkRetry(ctx, message.ID, publishErr.Error(), time.Now().Add(outboxBackoff(attempts)))
+				retried++
+				s.log.Warn("Outbox relay: failed to publish message", zap.Error(publishErr), zap.Int("messageID", message.ID),
+					zap.String("topic", message.Topic), zap.Int("attempts", attempts))
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.log.Error("Outbox relay: failed to dispatch messages", zap.Error(err))
+		return
+	}
+
+	if sent+retried+failed > 0 {
+		s.log.Info("Outbox relay: batch dispatched", zap.Int("sent", sent), zap.Int("retried", retried), zap.Int("failed", failed))
+	}
+}
+
+func (s *OutboxService) publish(ctx context.Context, message model.OutboxMessage) error {
+	publisher, ok := s.publishers[message.Topic]
+	if !ok {
+		return fmt.Errorf("no publisher for topic %s", message.Topic)
+	}
+
+	return publisher.Produce(ctx, message.Key, message.Payload, 1)
+}
+
+// outboxBackoff is the delay before the next attempt, doubling from outboxBaseBackoff up to outboxMaxBackoff.
+func outboxBackoff(attempts int) time.Duration {
+	backoff := outboxBaseBackoff
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, outboxMaxBackoff)
+}
+
+func (s *OutboxService) GetMessages(ctx context.Context, status string, limit int) ([]model.OutboxMessage, error) {
+	return s.repo.GetMessages(ctx, status, limit)
+}
+
+func (s *OutboxService) RetryMessage(ctx context.Context, id int) error {
+	return s.repo.Requeue(ctx, id)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/internal/order/repository/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+// publisher records what the relay publishes and fails while err is set.
+type publisher struct {
+	err  error
+	keys []string
+}
+
+func (p *publisher) Produce(ctx context.Context, key string, value interface{}, retries int) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.keys = append(p.keys, key)
+	return nil
+}
+
+type OutboxServiceSuite struct {
+	suite.Suite
+	repo      *mocks.IOutboxRepository
+	publisher *publisher
+	service   *OutboxService
+}
+
+func (suite *OutboxServiceSuite) SetupTest() {
+	suite.repo = mocks.NewIOutboxRepository(suite.T())
+	suite.publisher = &publisher{}
+	suite.service = NewOutboxService(suite.repo, inlineTx{}, map[string]EventPublisher{CreateOrderTopic: suite.publisher}, time.Second, zap.NewNop())
+}
+
+func TestOutboxServiceSuite(t *testing.T) {
+	suite.Run(t, new(OutboxServiceSuite))
+}
+
+func outboxMessage(id, attempts int) model.OutboxMessage {
+	return model.OutboxMessage{
+		ID:       id,
+		Topic:    CreateOrderTopic,
+		Key:      "1",
+		Payload:  json.RawMessage(`{"id":1}`),
+		Status:   model.OutboxPending,
+		Attempts: attempts,
+	}
+}
+
+// ====================================================================================================================
+
+func (suite *OutboxServiceSuite) TestService_DispatchSent() {
+	suite.repo.On("FetchDue", mock.Anything, outboxBatchSize).Return([]model.OutboxMessage{outboxMessage(1, 0), outboxMessage(2, 4)}, nil)
+	suite.repo.On("MarkSent", mock.Anything, 1).Return(nil)
+	suite.repo.On("MarkSent", mock.Anything, 2).Return(nil)
+
+	suite.service.Dispatch(context.Background())
+
+	suite.Equal([]string{"1", "1"}, suite.publisher.keys)
+}
+
+func (suite *OutboxServiceSuite) TestService_DispatchRetry() {
+	suite.publisher.err = errors.New("broker unavailable")
+	suite.repo.On("FetchDue", mock.Anything, outboxBatchSize).Return([]model.OutboxMessage{outboxMessage(1, 2)}, nil)
+	suite.repo.On("MarkRetry", mock.Anything, 1, "broker unavailable", mock.MatchedBy(func(next time.Time) bool {
+		return next.After(time.Now().Add(15*time.Second)) && next.Before(time.Now().Add(25*time.Second))
+	})).Return(nil)
+
+	suite.service.Dispatch(context.Background())
+}
+
+func (suite *OutboxServiceSuite) TestService_DispatchGivesUp() {
+	suite.publisher.err = errors.New("broker unavailable")
+	suite.repo.On("FetchDue", mock.Anything, outboxBatchSize).Return([]model.OutboxMessage{outboxMessage(1, outboxMaxAttempts-1)}, nil)
+	suite.repo.On("MarkFailed", mock.Anything, 1, "broker unavailable").Return(nil)
+
+	suite.service.Dispatch(context.Background())
+}
+
+func (suite *OutboxServiceSuite) TestService_DispatchUnknownTopic() {
+	message := outboxMessage(1, 0)
+	message.Topic = "order_shipped"
+	suite.repo.On("FetchDue", mock.Anything, outboxBatchSize).Return([]model.OutboxMessage{message}, nil)
+	suite.repo.On("MarkRetry", mock.Anything, 1, "no publisher for topic order_shipped", mock.Anything).Return(nil)
+
+	suite.service.Dispatch(context.Background())
+
+	suite.Empty(suite.publisher.keys)
+}
+
+func (suite *OutboxServiceSuite) TestService_DispatchStopsOnStoreFailure() {
+	suite.repo.On("FetchDue", mock.Anything, outboxBatchSize).Return([]model.OutboxMessage{outboxMessage(1, 0), outboxMessage(2, 0)}, nil)
+	suite.repo.On("MarkSent", mock.Anything, 1).Return(errors.New("connection lost"))
+
+	suite.service.Dispatch(context.Background())
+
+	suite.Equal([]string{"1"}, suite.publisher.keys)
+}
+
+// ====================================================================================================================
+
+func (suite *OutboxServiceSuite) TestService_Backoff() {
+	suite.Equal(5*time.Second, outboxBackoff(1))
+	suite.Equal(20*time.Second, outboxBackoff(3))
+	suite.Equal(outboxMaxBackoff, outboxBackoff(20))
+}
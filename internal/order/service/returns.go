@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	grpcorder "github.com/aaanger/ecommerce/internal/order/handler/grpc/product"
+	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/internal/order/repository"
+	payment "github.com/aaanger/ecommerce/internal/payment/client"
+	paymentModel "github.com/aaanger/ecommerce/internal/payment/model"
+	"github.com/aaanger/ecommerce/pkg/money"
+	pb "github.com/aaanger/ecommerce/pkg/proto/gen/product"
+	"go.uber.org/zap"
+)
+
+//go:generate mockery --name=IReturnService
+
+type IReturnService interface {
+	RequestReturn(ctx context.Context, actor model.Actor, orderID int, req *model.ReturnReq) (*model.Return, error)
+	GetOrderReturns(ctx context.Context, actor model.Actor, orderID int) ([]model.Return, error)
+	GetReturns(ctx context.Context, status string, limit int) ([]model.Return, error)
+	ApproveReturn(ctx context.Context, id int, comment string) (*model.Return, error)
+	RejectReturn(ctx context.Context, id int, comment string) (*model.Return, error)
+}
+
+var (
+	ErrReturnNotFound     = errors.New("return not found")
+	ErrOrderNotReturnable = errors.New("only delivered orders can be returned")
+	ErrNoPayment          = errors.New("order has no payment to refund")
+	ErrReturnRefunded     = errors.New("return has already been refunded")
+)
+
+// ReturnService handles returns of delivered orders. A customer asks to return some items of a line, a
+// moderator approves or rejects the request. Approval puts the items back in stock and refunds what the
+// customer paid for them.
+type ReturnService struct {
+	repo          repository.IReturnRepository
+	orderRepo     repository.IOrderRepository
+	grpcClient    *grpcorder.OrderGRPCClient
+	paymentClient payment.PaymentProvider
+	log           *zap.Logger
+}
+
+func NewReturnService(repo repository.IReturnRepository, orderRepo repository.IOrderRepository, grpcClient *grpcorder.OrderGRPCClient, paymentClient payment.PaymentProvider, log *zap.Logger) *ReturnService {
+	return &ReturnService{
+		repo:          repo,
+		orderRepo:     orderRepo,
+		grpcClient:    grpcClient,
+		paymentClient: paymentClient,
+		log:           log,
+	}
+}
+
+func (s *ReturnService) RequestReturn(ctx context.Context, actor model.Actor, orderID int, req *model.ReturnReq) (*model.Return, error) {
+	order, err := s.getOwnOrder(ctx, actor, orderID, "request return")
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status != model.StatusDelivered {
+		return nil, ErrOrderNotReturnable
+	}
+
+	ret, err := s.repo.CreateReturn(ctx, orderID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Return requested", zap.Int("returnID", ret.ID), zap.Int("orderID", orderID), zap.Int("orderLineID", ret.OrderLineID),
+		zap.Int("quantity", ret.Quantity))
+	return ret, nil
+}
+
+func (s *ReturnService) GetOrderReturns(ctx context.Context, actor model.Actor, orderID int) ([]model.Return, error) {
+	if _, err := s.getOwnOrder(ctx, actor, orderID, "get returns"); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetReturnsByOrder(ctx, orderID)
+}
+
+func (s *ReturnService) GetReturns(ctx context.Context, status string, limit int) ([]model.Return, error) {
+	return s.repo.GetReturns(ctx, status, limit)
+}
+
+// ApproveReturn restocks the returned items and then refunds them. If restocking fails the return goes back
+// to requested. Once restocked the return stays approved, so when the refund fails approving it again only
+// retries the refund, which is keyed by the return. A refunded return can be neither approved nor rejected.
+func (s *ReturnService) ApproveReturn(ctx context.Context, id int, comment string) (*model.Return, error) {
+	log := s.log.With(
+		zap.String("service", "order"),
+		zap.String("layer", "service"),
+		zap.String("method", "ApproveReturn"),
+		zap.Int("returnID", id))
+
+	ret, err := s.getReturn(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if refunded(ret) {
+		return nil, ErrReturnRefunded
+	}
+
+	order, err := s.orderRepo.GetOrderByID(ctx, ret.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := findLine(order, ret.OrderLineID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret.Status != model.ReturnApproved {
+		sg := &saga{log: log, steps: []sagaStep{
+			{
+				name: "approve return",
+				action: func(ctx context.Context) error {
+					return s.repo.UpdateReturnStatus(ctx, ret.ID, model.ReturnRequested, model.ReturnApproved, comment)
+				},
+				compensate: func(ctx context.Context, _ string) error {
+					return s.repo.UpdateReturnStatus(ctx, ret.ID, model.ReturnApproved, model.ReturnRequested, ret.Comment)
+				},
+			},
+			{
+				name: "restock",
+				action: func(ctx context.Context) error {
+					return s.restock(ctx, order, line, ret.Quantity)
+				},
+			},
+		}}
+
+		if err = sg.run(ctx); err != nil {
+			return nil, err
+		}
+
+		ret.Status = model.ReturnApproved
+		ret.Comment = comment
+	}
+
+	if err = s.refund(ctx, order, line, ret); err != nil {
+		log.Error("Failed to refund approved return", zap.Error(err), zap.Int("orderID", order.ID))
+		return nil, err
+	}
+
+	log.Info("Return refunded", zap.Int("orderID", order.ID), zap.Stringer("refund", ret.RefundAmount))
+	return ret, nil
+}
+
+func (s *ReturnService) RejectReturn(ctx context.Context, id int, comment string) (*model.Return, error) {
+	ret, err := s.getReturn(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if refunded(ret) {
+		return nil, ErrReturnRefunded
+	}
+
+	if err = s.repo.UpdateReturnStatus(ctx, id, model.ReturnRequested, model.ReturnRejected, comment); err != nil {
+		return nil, err
+	}
+
+	ret.Status = model.ReturnRejected
+	ret.Comment = comment
+
+	s.log.Info("Return rejected", zap.Int("returnID", id), zap.Int("orderID", ret.OrderID))
+	return ret, nil
+}
+
+// refund pays back the customer's share of the line for the returned items and moves the return to refunded.
+// Shares are taken cumulatively over the line's approved returns, so returning a line in parts refunds exactly
+// what was paid for it.
+func (s *ReturnService) refund(ctx context.Context, order *model.Order, line *model.OrderLine, ret *model.Return) error {
+	returned, err := s.repo.ApprovedQuantity(ctx, line.ID, ret.ID)
+	if err != nil {
+		return err
+	}
+
+	paid := line.Price.Sub(line.Discount)
+	amount := lineShare(paid, line.Quantity, returned+ret.Quantity).Sub(lineShare(paid, line.Quantity, returned))
+
+	var refundID string
+	if !amount.IsZero() {
+		if order.PaymentID == "" {
+			return ErrNoPayment
+		}
+
+		refund, err := s.paymentClient.CreateRefund(ctx, &paymentModel.CreateRefundReq{
+			PaymentID:   order.PaymentID,
+			Amount:      paymentModel.NewAmount(amount),
+			Description: fmt.Sprintf("Возврат №%d по заказу №%d", ret.ID, order.ID),
+		}, fmt.Sprintf("return-%d", ret.ID))
+		if err != nil {
+			return err
+		}
+		refundID = refund.ID
+	}
+
+	if err = s.repo.SetRefund(ctx, ret.ID, refundID, amount); err != nil {
+		return err
+	}
+
+	ret.Status = model.ReturnRefunded
+	ret.RefundID = refundID
+	ret.RefundAmount = amount
+	return nil
+}
+
+func (s *ReturnService) restock(ctx context.Context, order *model.Order, line *model.OrderLine, quantity int) error {
+	if order.ReservationID == 0 {
+		return nil
+	}
+
+	res, err := s.grpcClient.Client.UnreserveProducts(ctx, &pb.UnreserveProductsReq{
+		ReservationID: int32(order.ReservationID),
+		Products: []*pb.ReservedProduct{{
+			ProductID: int32(line.ProductID),
+			VariantID: int32(line.VariantID),
+			Quantity:  int32(quantity),
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	if !res.Success {
+		return fmt.Errorf("restock failed")
+	}
+
+	return nil
+}
+
+func (s *ReturnService) getReturn(ctx context.Context, id int) (*model.Return, error) {
+	ret, err := s.repo.GetReturn(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReturnNotFound
+	}
+
+	return ret, err
+}
+
+// getOwnOrder loads the order, which only its customer and moderators may see.
+func (s *ReturnService) getOwnOrder(ctx context.Context, actor model.Actor, orderID int, action string) (*model.Order, error) {
+	order, err := s.orderRepo.GetOrderByID(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = authorize(s.log, order, actor, action); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// refunded tells whether the money for the return has gone out, after which it must not be reviewed again.
+func refunded(ret *model.Return) bool {
+	return ret.Status == model.ReturnRefunded || ret.RefundID != ""
+}
+
+func findLine(order *model.Order, lineID int) (*model.OrderLine, error) {
+	for i := range order.Lines {
+		if order.Lines[i].ID == lineID {
+			return &order.Lines[i], nil
+		}
+	}
+
+	return nil, repository.ErrOrderLineNotFound
+}
+
+// lineShare is what the first items of a line of quantity items cost out of its total, rounded down.
+func lineShare(total money.Money, quantity, items int) money.Money {
+	return money.New(total.Amount*int64(items)/int64(quantity), total.Currency)
+}
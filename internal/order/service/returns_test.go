@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	grpcorder "github.com/aaanger/ecommerce/internal/order/handler/grpc/product"
+	"github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/internal/order/repository"
+	"github.com/aaanger/ecommerce/internal/order/repository/mocks"
+	payment "github.com/aaanger/ecommerce/internal/payment/client"
+	paymentModel "github.com/aaanger/ecommerce/internal/payment/model"
+	"github.com/aaanger/ecommerce/pkg/money"
+	pb "github.com/aaanger/ecommerce/pkg/proto/gen/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type ReturnServiceSuite struct {
+	suite.Suite
+	repo          *mocks.IReturnRepository
+	orderRepo     *mocks.IOrderRepository
+	productClient *productClient
+	refundServer  *httptest.Server
+	refunds       []paymentModel.CreateRefundReq
+	refundKeys    []string
+	refundDown    bool
+	service       *ReturnService
+}
+
+func (suite *ReturnServiceSuite) SetupTest() {
+	suite.repo = mocks.NewIReturnRepository(suite.T())
+	suite.orderRepo = mocks.NewIOrderRepository(suite.T())
+	suite.productClient = &productClient{}
+	suite.refunds = nil
+	suite.refundKeys = nil
+	suite.refundDown = false
+	suite.refundServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if suite.refundDown {
+			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var req paymentModel.CreateRefundReq
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		suite.refunds = append(suite.refunds, req)
+		suite.refundKeys = append(suite.refundKeys, r.Header.Get("Idempotence-Key"))
+		_, _ = w.Write([]byte(`{"id": "refund-1", "payment_id": "pay-1", "status": "succeeded"}`))
+	}))
+
+	paymentClient := payment.NewClient("shop", "secret")
+	paymentClient.APIEndpoint = suite.refundServer.URL + "/"
+
+	suite.service = NewReturnService(suite.repo, suite.orderRepo, &grpcorder.OrderGRPCClient{Client: suite.productClient}, paymentClient, zap.NewNop())
+}
+
+func (suite *ReturnServiceSuite) TearDownTest() {
+	suite.refundServer.Close()
+}
+
+func TestReturnServiceSuite(t *testing.T) {
+	suite.Run(t, new(ReturnServiceSuite))
+}
+
+// order is a delivered order of user 1 with a line of 3 items for 1000.00, 100.00 of which was discounted.
+func (suite *ReturnServiceSuite) order() *model.Order {
+	return &model.Order{
+		ID:            1,
+		UserID:        1,
+		Status:        model.StatusDelivered,
+		ReservationID: 4,
+		PaymentID:     "pay-1",
+		Lines: []model.OrderLine{
+			{ID: 10, ProductID: 2, VariantID: 5, Quantity: 3, Price: money.FromMinor(100000), Discount: money.FromMinor(10000)},
+		},
+	}
+}
+
+// =====================================================================================================================
+
+func (suite *ReturnServiceSuite) TestService_RequestReturnSuccess() {
+	req := &model.ReturnReq{OrderLineID: 10, Quantity: 1, Reason: "broken"}
+
+	suite.orderRepo.On("GetOrderByID", mock.Anything, 1).Return(suite.order(), nil)
+	suite.repo.On("CreateReturn", mock.Anything, 1, req).Return(&model.Return{ID: 7, OrderID: 1, OrderLineID: 10, Quantity: 1, Status: model.ReturnRequested}, nil)
+
+	ret, err := suite.service.RequestReturn(context.Background(), model.Actor{ID: 1, Role: model.RoleUser}, 1, req)
+
+	suite.NoError(err)
+	suite.Equal(7, ret.ID)
+	suite.Equal(model.ReturnRequested, ret.Status)
+}
+
+func (suite *ReturnServiceSuite) TestService_RequestReturnNotOwner() {
+	suite.orderRepo.On("GetOrderByID", mock.Anything, 1).Return(suite.order(), nil)
+
+	_, err := suite.service.RequestReturn(context.Background(), model.Actor{ID: 2, Role: model.RoleUser}, 1, &model.ReturnReq{OrderLineID: 10, Quantity: 1, Reason: "broken"})
+
+	suite.ErrorIs(err, ErrNotOrderOwner)
+}
+
+func (suite *ReturnServiceSuite) TestService_RequestReturnNotDelivered() {
+	order := suite.order()
+	order.Status = model.StatusDelivering
+
+	suite.orderRepo.On("GetOrderByID", mock.Anything, 1).Return(order, nil)
+
+	_, err := suite.service.RequestReturn(context.Background(), model.Actor{ID: 1, Role: model.RoleUser}, 1, &model.ReturnReq{OrderLineID: 10, Quantity: 1, Reason: "broken"})
+
+	suite.ErrorIs(err, ErrOrderNotReturnable)
+}
+
+// =====================================================================================================================
+
+func (suite *ReturnServiceSuite) TestService_ApproveReturnRefundsAndRestocks() {
+	suite.repo.On("GetReturn", mock.Anything, 7).Return(&model.Return{ID: 7, OrderID: 1, OrderLineID: 10, Quantity: 1, Status: model.ReturnRequested}, nil)
+	suite.orderRepo.On("GetOrderByID", mock.Anything, 1).Return(suite.order(), nil)
+	suite.repo.On("UpdateReturnStatus", mock.Anything, 7, model.ReturnRequested, model.ReturnApproved, "ok").Return(nil)
+	suite.repo.On("ApprovedQuantity", mock.Anything, 10, 7).Return(0, nil)
+	suite.repo.On("SetRefund", mock.Anything, 7, "refund-1", money.FromMinor(30000)).Return(nil)
+
+	ret, err := suite.service.ApproveReturn(context.Background(), 7, "ok")
+
+	suite.NoError(err)
+	suite.Equal(model.ReturnRefunded, ret.Status)
+	suite.Equal("refund-1", ret.RefundID)
+	suite.Equal(money.FromMinor(30000), ret.RefundAmount)
+	suite.Equal([]string{"return-7"}, suite.refundKeys)
+	suite.Equal("pay-1", suite.refunds[0].PaymentID)
+	suite.Equal("300.00", suite.refunds[0].Amount.Value)
+	suite.Equal([]*pb.ReservedProduct{{ProductID: 2, VariantID: 5, Quantity: 1}}, suite.productClient.returned)
+}
+
+func (suite *ReturnServiceSuite) TestService_ApproveReturnLastPartRefundsRemainder() {
+	suite.repo.On("GetReturn", mock.Anything, 8).Return(&model.Return{ID: 8, OrderID: 1, OrderLineID: 10, Quantity: 2, Status: model.ReturnRequested}, nil)
+	suite.orderRepo.On("GetOrderByID", mock.Anything, 1).Return(suite.order(), nil)
+	suite.repo.On("UpdateReturnStatus", mock.Anything, 8, model.ReturnRequested, model.ReturnApproved, "").Return(nil)
+	suite.repo.On("ApprovedQuantity", mock.Anything, 10, 8).Return(1, nil)
+	suite.repo.On("SetRefund", mock.Anything, 8, "refund-1", money.FromMinor(60000)).Return(nil)
+
+	ret, err := suite.service.ApproveReturn(context.Background(), 8, "")
+
+	suite.NoError(err)
+	suite.Equal(money.FromMinor(60000), ret.RefundAmount)
+}
+
+func (suite *ReturnServiceSuite) TestService_ApproveReturnRestockFailsRevertsStatus() {
+	suite.productClient.returnErr = errors.New("product service unavailable")
+
+	suite.repo.On("GetReturn", mock.Anything, 7).Return(&model.Return{ID: 7, OrderID: 1, OrderLineID: 10, Quantity: 1, Status: model.ReturnRequested}, nil)
+	suite.orderRepo.On("GetOrderByID", mock.Anything, 1).Return(suite.order(), nil)
+	suite.repo.On("UpdateReturnStatus", mock.Anything, 7, model.ReturnRequested, model.ReturnApproved, "ok").Return(nil)
+	suite.repo.On("UpdateReturnStatus", mock.Anything, 7, model.ReturnApproved, model.ReturnRequested, "").Return(nil)
+
+	_, err := suite.service.ApproveReturn(context.Background(), 7, "ok")
+
+	// Nothing was refunded, so the return can simply be reviewed again.
+	suite.Error(err)
+	suite.Empty(suite.refundKeys)
+}
+
+func (suite *ReturnServiceSuite) TestService_ApproveReturnRefundFailsKeepsApproved() {
+	suite.refundDown = true
+
+	suite.repo.On("GetReturn", mock.Anything, 7).Return(&model.Return{ID: 7, OrderID: 1, OrderLineID: 10, Quantity: 1, Status: model.ReturnRequested}, nil)
+	suite.orderRepo.On("GetOrderByID", mock.Anything, 1).Return(suite.order(), nil)
+	suite.repo.On("UpdateReturnStatus", mock.Anything, 7, model.ReturnRequested, model.ReturnApproved, "ok").Return(nil)
+	suite.repo.On("ApprovedQuantity", mock.Anything, 10, 7).Return(0, nil)
+
+	_, err := suite.service.ApproveReturn(context.Background(), 7, "ok")
+
+	// The items are back in stock, so the return stays approved rather than going back to requested.
+	suite.Error(err)
+	suite.Equal([]*pb.ReservedProduct{{ProductID: 2, VariantID: 5, Quantity: 1}}, suite.productClient.returned)
+	suite.repo.AssertNotCalled(suite.T(), "UpdateReturnStatus", mock.Anything, 7, model.ReturnApproved, model.ReturnRequested, mock.Anything)
+}
+
+func (suite *ReturnServiceSuite) TestService_ApproveReturnRetriesRefund() {
+	suite.repo.On("GetReturn", mock.Anything, 7).Return(&model.Return{ID: 7, OrderID: 1, OrderLineID: 10, Quantity: 1, Status: model.ReturnApproved, Comment: "ok"}, nil)
+	suite.orderRepo.On("GetOrderByID", mock.Anything, 1).Return(suite.order(), nil)
+	suite.repo.On("ApprovedQuantity", mock.Anything, 10, 7).Return(0, nil)
+	suite.repo.On("SetRefund", mock.Anything, 7, "refund-1", money.FromMinor(30000)).Return(nil)
+
+	ret, err := suite.service.ApproveReturn(context.Background(), 7, "again")
+
+	suite.NoError(err)
+	suite.Equal(model.ReturnRefunded, ret.Status)
+	suite.Equal("ok", ret.Comment)
+	suite.Equal([]string{"return-7"}, suite.refundKeys)
+	suite.Empty(suite.productClient.returned)
+}
+
+func (suite *ReturnServiceSuite) TestService_ApproveReturnRefunded() {
+	suite.repo.On("GetReturn", mock.Anything, 7).Return(&model.Return{ID: 7, OrderID: 1, OrderLineID: 10, Quantity: 1, Status: model.ReturnRequested, RefundID: "refund-1"}, nil)
+
+	_, err := suite.service.ApproveReturn(context.Background(), 7, "ok")
+
+	suite.ErrorIs(err, ErrReturnRefunded)
+	suite.Empty(suite.refundKeys)
+	suite.Empty(suite.productClient.returned)
+}
+
+func (suite *ReturnServiceSuite) TestService_ApproveReturnAlreadyReviewed() {
+	suite.repo.On("GetReturn", mock.Anything, 7).Return(&model.Return{ID: 7, OrderID: 1, OrderLineID: 10, Quantity: 1, Status: model.ReturnRejected}, nil)
+	suite.orderRepo.On("GetOrderByID", mock.Anything, 1).Return(suite.order(), nil)
+	suite.repo.On("UpdateReturnStatus", mock.Anything, 7, model.ReturnRequested, model.ReturnApproved, "ok").Return(repository.ErrReturnStatusConflict)
+
+	_, err := suite.service.ApproveReturn(context.Background(), 7, "ok")
+
+	suite.ErrorIs(err, repository.ErrReturnStatusConflict)
+	suite.Empty(suite.refundKeys)
+}
+
+func (suite *ReturnServiceSuite) TestService_ApproveReturnNotFound() {
+	suite.repo.On("GetReturn", mock.Anything, 7).Return(nil, sql.ErrNoRows)
+
+	_, err := suite.service.ApproveReturn(context.Background(), 7, "ok")
+
+	suite.ErrorIs(err, ErrReturnNotFound)
+}
+
+// =====================================================================================================================
+
+func (suite *ReturnServiceSuite) TestService_RejectReturnSuccess() {
+	suite.repo.On("GetReturn", mock.Anything, 7).Return(&model.Return{ID: 7, OrderID: 1, OrderLineID: 10, Quantity: 1, Status: model.ReturnRequested}, nil)
+	suite.repo.On("UpdateReturnStatus", mock.Anything, 7, model.ReturnRequested, model.ReturnRejected, "used").Return(nil)
+
+	ret, err := suite.service.RejectReturn(context.Background(), 7, "used")
+
+	suite.NoError(err)
+	suite.Equal(model.ReturnRejected, ret.Status)
+	suite.Equal("used", ret.Comment)
+}
+
+func (suite *ReturnServiceSuite) TestService_RejectReturnRefunded() {
+	suite.repo.On("GetReturn", mock.Anything, 7).Return(&model.Return{ID: 7, OrderID: 1, OrderLineID: 10, Quantity: 1, Status: model.ReturnRefunded, RefundID: "refund-1"}, nil)
+
+	_, err := suite.service.RejectReturn(context.Background(), 7, "used")
+
+	suite.ErrorIs(err, ErrReturnRefunded)
+	suite.repo.AssertNotCalled(suite.T(), "UpdateReturnStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
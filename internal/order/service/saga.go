@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"go.uber.org/zap"
+)
+
+// sagaStep is one step of a saga. compensate undoes action and is nil when there is nothing to undo,
+// it gets the name of the step that failed.
+type sagaStep struct {
+	name       string
+	action     func(ctx context.Context) error
+	compensate func(ctx context.Context, failedStep string) error
+}
+
+// saga runs its steps in order. When a step fails, the steps that already completed are compensated in
+// reverse order and the step's error is returned. Compensations run even if ctx has been canceled and a
+// failing one does not stop the others.
+type saga struct {
+	steps []sagaStep
+	log   *zap.Logger
+}
+
+func (sg *saga) run(ctx context.Context) error {
+	for i, step := range sg.steps {
+		if err := step.action(ctx); err != nil {
+			sg.log.Error("Saga step failed", zap.String("step", step.name), zap.Error(err))
+			sg.compensate(context.WithoutCancel(ctx), i)
+			return err
+		}
+		sg.log.Info("Saga step completed", zap.String("step", step.name))
+	}
+
+	return nil
+}
+
+func (sg *saga) compensate(ctx context.Context, failed int) {
+	for i := failed - 1; i >= 0; i-- {
+		step := sg.steps[i]
+		if step.compensate == nil {
+			continue
+		}
+
+		if err := step.compensate(ctx, sg.steps[failed].name); err != nil {
+			sg.log.Error("Saga compensation failed", zap.String("step", step.name), zap.Error(err))
+			continue
+		}
+		sg.log.Info("Saga step compensated", zap.String("step", step.name))
+	}
+}
@@ -0,0 +1,307 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aaanger/ecommerce/internal/payment/model"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusPending           = "pending"
+	StatusWaitingForCapture = "waiting_for_capture"
+	StatusSucceeded         = "succeeded"
+	StatusCanceled          = "canceled"
+)
+
+// fakeHoldTTL is how long the fake provider holds money waiting for capture, as YooKassa does for cards.
+const fakeHoldTTL = 7 * 24 * time.Hour
+
+var (
+	ErrPaymentStatus  = errors.New("operation not allowed in the payment status")
+	ErrRefundExceeded = errors.New("refund exceeds the paid amount")
+)
+
+// FakeProvider is an in-process PaymentProvider for local development and tests. A payment confirms, or
+// is declined, by itself after ConfirmDelay and every status change is posted to WebhookURL the way
+// YooKassa notifies, so the whole payment flow runs without network access.
+type FakeProvider struct {
+	WebhookURL   string
+	ConfirmDelay time.Duration
+	Decline      bool
+	HTTPClient   *http.Client
+	log          *zap.Logger
+
+	mu       sync.Mutex
+	payments map[string]*fakePayment
+	// keys maps the idempotence keys of created payments and refunds to what they created.
+	keys    map[string]string
+	refunds map[string]*model.CreateRefundRes
+}
+
+type fakePayment struct {
+	res      model.CreatePaymentRes
+	capture  bool
+	refunded money.Money
+}
+
+func NewFakeProvider(webhookURL string, confirmDelay time.Duration, decline bool, log *zap.Logger) *FakeProvider {
+	return &FakeProvider{
+		WebhookURL:   webhookURL,
+		ConfirmDelay: confirmDelay,
+		Decline:      decline,
+		HTTPClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		log:      log,
+		payments: make(map[string]*fakePayment),
+		keys:     make(map[string]string),
+		refunds:  make(map[string]*model.CreateRefundRes),
+	}
+}
+
+// CreatePayment registers a pending payment. The confirmation URL leads straight back to the return URL,
+// as if the customer had paid.
+func (p *FakeProvider) CreatePayment(ctx context.Context, req *model.CreatePaymentReq, idempotenceKey string) (*model.CreatePaymentRes, error) {
+	if _, err := req.Amount.Money(); err != nil {
+		return nil, fmt.Errorf("create payment: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if id, ok := p.keys["payment:"+idempotenceKey]; ok && idempotenceKey != "" {
+		return p.payments[id].snapshot(), nil
+	}
+
+	payment := &fakePayment{
+		capture: req.Capture,
+		res: model.CreatePaymentRes{
+			ID:     uuid.New().String(),
+			Status: StatusPending,
+			Amount: req.Amount,
+			Confirmation: model.ConfirmationRes{
+				Type:            req.Confirmation.Type,
+				ConfirmationURL: req.Confirmation.ReturnURL,
+			},
+			CreatedAt:   time.Now(),
+			Description: req.Description,
+			Metadata:    req.Metadata,
+			Test:        true,
+		},
+	}
+
+	p.payments[payment.res.ID] = payment
+	if idempotenceKey != "" {
+		p.keys["payment:"+idempotenceKey] = payment.res.ID
+	}
+
+	id := payment.res.ID
+	time.AfterFunc(p.ConfirmDelay, func() { p.confirm(id) })
+
+	return payment.snapshot(), nil
+}
+
+func (p *FakeProvider) GetPayment(ctx context.Context, paymentID string) (*model.CreatePaymentRes, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[paymentID]
+	if !ok {
+		return nil, ErrPaymentNotFound
+	}
+
+	return payment.snapshot(), nil
+}
+
+// CapturePayment takes a held payment, or the given part of it. Capturing a captured payment returns it unchanged.
+func (p *FakeProvider) CapturePayment(ctx context.Context, paymentID string, req *model.CapturePaymentReq, idempotenceKey string) (*model.CreatePaymentRes, error) {
+	return p.transition(paymentID, func(payment *fakePayment) (bool, error) {
+		switch payment.res.Status {
+		case StatusSucceeded:
+			return false, nil
+		case StatusWaitingForCapture:
+		default:
+			return false, fmt.Errorf("capture payment: %w", ErrPaymentStatus)
+		}
+
+		if req != nil && req.Amount != nil {
+			held, err := payment.res.Amount.Money()
+			if err != nil {
+				return false, err
+			}
+			amount, err := req.Amount.Money()
+			if err != nil {
+				return false, err
+			}
+			if amount.Currency != held.Currency || amount.Cmp(held) > 0 || amount.IsZero() || amount.IsNegative() {
+				return false, fmt.Errorf("capture payment: invalid amount %s", amount)
+			}
+			payment.res.Amount = *req.Amount
+		}
+
+		payment.res.Status = StatusSucceeded
+		payment.res.ExpiresAt = nil
+		return true, nil
+	})
+}
+
+// CancelPayment releases a held payment, or declines one the customer has not paid yet. Canceling a canceled
+// payment returns it unchanged.
+func (p *FakeProvider) CancelPayment(ctx context.Context, paymentID string, idempotenceKey string) (*model.CreatePaymentRes, error) {
+	return p.transition(paymentID, func(payment *fakePayment) (bool, error) {
+		switch payment.res.Status {
+		case StatusCanceled:
+			return false, nil
+		case StatusPending, StatusWaitingForCapture:
+			payment.res.Status = StatusCanceled
+			payment.res.ExpiresAt = nil
+			return true, nil
+		default:
+			return false, fmt.Errorf("cancel payment: %w", ErrPaymentStatus)
+		}
+	})
+}
+
+// CreateRefund returns money from a succeeded payment, at most what was paid over all its refunds.
+func (p *FakeProvider) CreateRefund(ctx context.Context, req *model.CreateRefundReq, idempotenceKey string) (*model.CreateRefundRes, error) {
+	amount, err := req.Amount.Money()
+	if err != nil {
+		return nil, fmt.Errorf("create refund: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if id, ok := p.keys["refund:"+idempotenceKey]; ok && idempotenceKey != "" {
+		refund := *p.refunds[id]
+		return &refund, nil
+	}
+
+	payment, ok := p.payments[req.PaymentID]
+	if !ok {
+		return nil, ErrPaymentNotFound
+	}
+	if payment.res.Status != StatusSucceeded {
+		return nil, fmt.Errorf("create refund: %w", ErrPaymentStatus)
+	}
+
+	paid, err := payment.res.Amount.Money()
+	if err != nil {
+		return nil, fmt.Errorf("create refund: %w", err)
+	}
+	if amount.Currency != paid.Currency || amount.IsZero() || amount.IsNegative() || payment.refunded.Add(amount).Cmp(paid) > 0 {
+		return nil, fmt.Errorf("create refund: %w", ErrRefundExceeded)
+	}
+
+	payment.refunded = payment.refunded.Add(amount)
+
+	refund := &model.CreateRefundRes{
+		ID:        uuid.New().String(),
+		PaymentID: req.PaymentID,
+		Status:    StatusSucceeded,
+		Amount:    req.Amount,
+		CreatedAt: time.Now(),
+	}
+
+	p.refunds[refund.ID] = refund
+	if idempotenceKey != "" {
+		p.keys["refund:"+idempotenceKey] = refund.ID
+	}
+
+	res := *refund
+	return &res, nil
+}
+
+// confirm plays the customer's part: the payment is paid, or declined, once they are back from the confirmation page.
+func (p *FakeProvider) confirm(paymentID string) {
+	_, err := p.transition(paymentID, func(payment *fakePayment) (bool, error) {
+		if payment.res.Status != StatusPending {
+			return false, nil
+		}
+
+		switch {
+		case p.Decline:
+			payment.res.Status = StatusCanceled
+		case payment.capture:
+			payment.res.Status = StatusSucceeded
+			payment.res.Paid = true
+		default:
+			expiresAt := time.Now().Add(fakeHoldTTL)
+			payment.res.Status = StatusWaitingForCapture
+			payment.res.Paid = true
+			payment.res.ExpiresAt = &expiresAt
+		}
+		return true, nil
+	})
+	if err != nil {
+		p.log.Error("Failed to confirm fake payment", zap.Error(err), zap.String("paymentID", paymentID))
+	}
+}
+
+// transition applies change to the payment and, when it reports a change, notifies about the new status.
+func (p *FakeProvider) transition(paymentID string, change func(payment *fakePayment) (bool, error)) (*model.CreatePaymentRes, error) {
+	p.mu.Lock()
+
+	payment, ok := p.payments[paymentID]
+	if !ok {
+		p.mu.Unlock()
+		return nil, ErrPaymentNotFound
+	}
+
+	changed, err := change(payment)
+	if err != nil {
+		p.mu.Unlock()
+		return nil, err
+	}
+
+	res := payment.snapshot()
+	p.mu.Unlock()
+
+	if changed {
+		go p.notify(res)
+	}
+
+	return res, nil
+}
+
+// notify posts a YooKassa style notification for the payment's current status.
+func (p *FakeProvider) notify(payment *model.CreatePaymentRes) {
+	log := p.log.With(zap.String("paymentID", payment.ID), zap.String("status", payment.Status))
+
+	body, err := json.Marshal(map[string]any{
+		"type":   "notification",
+		"event":  "payment." + payment.Status,
+		"object": json.RawMessage(payment.Raw),
+	})
+	if err != nil {
+		log.Error("Failed to encode fake payment notification", zap.Error(err))
+		return
+	}
+
+	res, err := p.HTTPClient.Post(p.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Error("Failed to send fake payment notification", zap.Error(err))
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		log.Warn("Fake payment notification rejected", zap.String("response", res.Status))
+	}
+}
+
+// snapshot copies the payment as the provider would answer with it, raw body included.
+func (f *fakePayment) snapshot() *model.CreatePaymentRes {
+	res := f.res
+	res.Raw, _ = json.Marshal(res)
+	return &res
+}
@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aaanger/ecommerce/internal/payment/model"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type FakeProviderSuite struct {
+	suite.Suite
+	webhook  *httptest.Server
+	events   chan model.Webhook
+	provider *FakeProvider
+}
+
+func (suite *FakeProviderSuite) SetupTest() {
+	suite.events = make(chan model.Webhook, 10)
+	suite.webhook = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var webhook model.Webhook
+		_ = json.NewDecoder(r.Body).Decode(&webhook)
+		suite.events <- webhook
+	}))
+
+	suite.provider = NewFakeProvider(suite.webhook.URL, 0, false, zap.NewNop())
+}
+
+func (suite *FakeProviderSuite) TearDownTest() {
+	suite.webhook.Close()
+}
+
+func TestFakeProviderSuite(t *testing.T) {
+	suite.Run(t, new(FakeProviderSuite))
+}
+
+func (suite *FakeProviderSuite) createReq(capture bool) *model.CreatePaymentReq {
+	return &model.CreatePaymentReq{
+		Amount:       model.Amount{Value: "123.00", Currency: "RUB"},
+		Capture:      capture,
+		Confirmation: model.ConfirmationReq{Type: "redirect", ReturnURL: "http://localhost:3000/payment/success"},
+		Metadata:     map[string]string{"order_id": "1"},
+	}
+}
+
+func (suite *FakeProviderSuite) nextEvent() model.Webhook {
+	select {
+	case webhook := <-suite.events:
+		return webhook
+	case <-time.After(time.Second):
+		suite.FailNow("no notification sent")
+		return model.Webhook{}
+	}
+}
+
+// ====================================================================================================================
+
+func (suite *FakeProviderSuite) TestFake_PaymentConfirmed() {
+	payment, err := suite.provider.CreatePayment(context.Background(), suite.createReq(true), "order-1")
+
+	suite.Nil(err)
+	suite.Equal(StatusPending, payment.Status)
+	suite.Equal("http://localhost:3000/payment/success", payment.Confirmation.ConfirmationURL)
+
+	webhook := suite.nextEvent()
+	suite.Equal("payment.succeeded", webhook.Event)
+	suite.Equal(payment.ID, webhook.Object.ID)
+	suite.Equal("1", webhook.Object.Metadata["order_id"])
+
+	paid, err := suite.provider.GetPayment(context.Background(), payment.ID)
+
+	suite.Nil(err)
+	suite.Equal(StatusSucceeded, paid.Status)
+	suite.True(paid.Paid)
+	suite.NotEmpty(paid.Raw)
+}
+
+func (suite *FakeProviderSuite) TestFake_PaymentDeclined() {
+	suite.provider.Decline = true
+
+	payment, err := suite.provider.CreatePayment(context.Background(), suite.createReq(true), "order-1")
+
+	suite.Nil(err)
+	suite.Equal("payment.canceled", suite.nextEvent().Event)
+
+	paid, _ := suite.provider.GetPayment(context.Background(), payment.ID)
+	suite.Equal(StatusCanceled, paid.Status)
+}
+
+func (suite *FakeProviderSuite) TestFake_CreatePaymentIdempotent() {
+	first, _ := suite.provider.CreatePayment(context.Background(), suite.createReq(true), "order-1")
+	second, _ := suite.provider.CreatePayment(context.Background(), suite.createReq(true), "order-1")
+	other, _ := suite.provider.CreatePayment(context.Background(), suite.createReq(true), "order-2")
+
+	suite.Equal(first.ID, second.ID)
+	suite.NotEqual(first.ID, other.ID)
+}
+
+func (suite *FakeProviderSuite) TestFake_GetPaymentNotFound() {
+	_, err := suite.provider.GetPayment(context.Background(), "unknown")
+
+	suite.ErrorIs(err, ErrPaymentNotFound)
+}
+
+// ====================================================================================================================
+
+func (suite *FakeProviderSuite) TestFake_CaptureHold() {
+	payment, _ := suite.provider.CreatePayment(context.Background(), suite.createReq(false), "order-1")
+	suite.Equal("payment.waiting_for_capture", suite.nextEvent().Event)
+
+	held, _ := suite.provider.GetPayment(context.Background(), payment.ID)
+	suite.Equal(StatusWaitingForCapture, held.Status)
+	suite.NotNil(held.ExpiresAt)
+
+	captured, err := suite.provider.CapturePayment(context.Background(), payment.ID, &model.CapturePaymentReq{
+		Amount: &model.Amount{Value: "100.00", Currency: "RUB"},
+	}, "capture-1")
+
+	suite.Nil(err)
+	suite.Equal(StatusSucceeded, captured.Status)
+	suite.Equal("100.00", captured.Amount.Value)
+	suite.Nil(captured.ExpiresAt)
+	suite.Equal("payment.succeeded", suite.nextEvent().Event)
+}
+
+func (suite *FakeProviderSuite) TestFake_CapturePendingPayment() {
+	suite.provider.ConfirmDelay = time.Hour
+
+	payment, _ := suite.provider.CreatePayment(context.Background(), suite.createReq(false), "order-1")
+
+	_, err := suite.provider.CapturePayment(context.Background(), payment.ID, nil, "capture-1")
+
+	suite.ErrorIs(err, ErrPaymentStatus)
+}
+
+func (suite *FakeProviderSuite) TestFake_CancelHold() {
+	payment, _ := suite.provider.CreatePayment(context.Background(), suite.createReq(false), "order-1")
+	suite.nextEvent()
+
+	canceled, err := suite.provider.CancelPayment(context.Background(), payment.ID, "cancel-1")
+
+	suite.Nil(err)
+	suite.Equal(StatusCanceled, canceled.Status)
+	suite.Equal("payment.canceled", suite.nextEvent().Event)
+
+	_, err = suite.provider.CancelPayment(context.Background(), payment.ID, "cancel-1")
+	suite.Nil(err)
+}
+
+// ====================================================================================================================
+
+func (suite *FakeProviderSuite) TestFake_Refunds() {
+	payment, _ := suite.provider.CreatePayment(context.Background(), suite.createReq(true), "order-1")
+	suite.nextEvent()
+
+	refundReq := &model.CreateRefundReq{PaymentID: payment.ID, Amount: model.Amount{Value: "100.00", Currency: "RUB"}}
+
+	refund, err := suite.provider.CreateRefund(context.Background(), refundReq, "return-1")
+	suite.Nil(err)
+	suite.Equal(StatusSucceeded, refund.Status)
+
+	repeated, err := suite.provider.CreateRefund(context.Background(), refundReq, "return-1")
+	suite.Nil(err)
+	suite.Equal(refund.ID, repeated.ID)
+
+	_, err = suite.provider.CreateRefund(context.Background(), refundReq, "return-2")
+	suite.ErrorIs(err, ErrRefundExceeded)
+}
+
+func (suite *FakeProviderSuite) TestFake_RefundUnpaid() {
+	suite.provider.ConfirmDelay = time.Hour
+
+	payment, _ := suite.provider.CreatePayment(context.Background(), suite.createReq(true), "order-1")
+
+	_, err := suite.provider.CreateRefund(context.Background(), &model.CreateRefundReq{
+		PaymentID: payment.ID,
+		Amount:    model.Amount{Value: "1.00", Currency: "RUB"},
+	}, "return-1")
+
+	suite.ErrorIs(err, ErrPaymentStatus)
+}
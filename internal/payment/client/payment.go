@@ -4,13 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/aaanger/ecommerce/internal/payment/model"
 	"github.com/google/uuid"
+	"io"
 	"net/http"
+	"net/url"
 	"time"
 )
 
+// ErrPaymentNotFound is returned when YooKassa has no payment with the requested id.
+var ErrPaymentNotFound = errors.New("payment not found")
+
+// Client is the PaymentProvider backed by the YooKassa API.
 type Client struct {
 	ShopID      string
 	SecretKey   string
@@ -29,18 +36,52 @@ func NewClient(shopID, secretKey string) *Client {
 	}
 }
 
-func (c *Client) CreatePayment(ctx context.Context, req *model.CreatePaymentReq) (*model.CreatePaymentRes, error) {
-	body, err := json.Marshal(req)
+// CreatePayment registers the payment with YooKassa. Requests repeated with the same idempotenceKey
+// return the payment created by the first one; an empty key makes every call create a new payment.
+func (c *Client) CreatePayment(ctx context.Context, req *model.CreatePaymentReq, idempotenceKey string) (*model.CreatePaymentRes, error) {
+	if idempotenceKey == "" {
+		idempotenceKey = uuid.New().String()
+	}
+
+	payment, err := c.postPayment(ctx, "payments", req, idempotenceKey)
 	if err != nil {
 		return nil, fmt.Errorf("create payment: %w", err)
 	}
 
-	r, err := http.NewRequestWithContext(ctx, "POST", c.APIEndpoint+"payments", bytes.NewReader(body))
+	return payment, nil
+}
+
+// CapturePayment takes the money held by a payment waiting for capture. A nil amount captures the whole hold.
+func (c *Client) CapturePayment(ctx context.Context, paymentID string, req *model.CapturePaymentReq, idempotenceKey string) (*model.CreatePaymentRes, error) {
+	payment, err := c.postPayment(ctx, "payments/"+url.PathEscape(paymentID)+"/capture", req, idempotenceKey)
 	if err != nil {
-		return nil, fmt.Errorf("create payment: %w", err)
+		return nil, fmt.Errorf("capture payment: %w", err)
+	}
+
+	return payment, nil
+}
+
+// CancelPayment releases the money held by a payment waiting for capture.
+func (c *Client) CancelPayment(ctx context.Context, paymentID string, idempotenceKey string) (*model.CreatePaymentRes, error) {
+	payment, err := c.postPayment(ctx, "payments/"+url.PathEscape(paymentID)+"/cancel", struct{}{}, idempotenceKey)
+	if err != nil {
+		return nil, fmt.Errorf("cancel payment: %w", err)
+	}
+
+	return payment, nil
+}
+
+// postPayment sends body to a payments endpoint and reads back the payment it answers with.
+func (c *Client) postPayment(ctx context.Context, path string, body any, idempotenceKey string) (*model.CreatePaymentRes, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
 	}
 
-	idempotenceKey := uuid.New().String()
+	r, err := http.NewRequestWithContext(ctx, "POST", c.APIEndpoint+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
 
 	r.Header.Set("Idempotence-Key", idempotenceKey)
 	r.Header.Set("Content-Type", "application/json")
@@ -48,13 +89,97 @@ func (c *Client) CreatePayment(ctx context.Context, req *model.CreatePaymentReq)
 
 	res, err := c.HTTPClient.Do(r)
 	if err != nil {
-		return nil, fmt.Errorf("create payment: %w", err)
+		return nil, err
 	}
+	defer res.Body.Close()
 
-	var paymentRes model.CreatePaymentRes
-	if err = json.NewDecoder(res.Body).Decode(&paymentRes); err != nil {
-		return nil, fmt.Errorf("create payment: %w", err)
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrPaymentNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", res.Status)
+	}
+
+	return decodePayment(res.Body)
+}
+
+// CreateRefund refunds part or all of a payment. Like payments, refunds repeated with the same idempotenceKey
+// return the refund created by the first request.
+func (c *Client) CreateRefund(ctx context.Context, req *model.CreateRefundReq, idempotenceKey string) (*model.CreateRefundRes, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("create refund: %w", err)
+	}
+
+	r, err := http.NewRequestWithContext(ctx, "POST", c.APIEndpoint+"refunds", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create refund: %w", err)
+	}
+
+	r.Header.Set("Idempotence-Key", idempotenceKey)
+	r.Header.Set("Content-Type", "application/json")
+	r.SetBasicAuth(c.ShopID, c.SecretKey)
+
+	res, err := c.HTTPClient.Do(r)
+	if err != nil {
+		return nil, fmt.Errorf("create refund: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("create refund: unexpected status %s", res.Status)
+	}
+
+	var refundRes model.CreateRefundRes
+	if err = json.NewDecoder(res.Body).Decode(&refundRes); err != nil {
+		return nil, fmt.Errorf("create refund: %w", err)
+	}
+
+	return &refundRes, nil
+}
+
+// GetPayment fetches the payment as YooKassa currently sees it, which is how notifications are verified.
+func (c *Client) GetPayment(ctx context.Context, paymentID string) (*model.CreatePaymentRes, error) {
+	r, err := http.NewRequestWithContext(ctx, "GET", c.APIEndpoint+"payments/"+url.PathEscape(paymentID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("get payment: %w", err)
+	}
+
+	r.SetBasicAuth(c.ShopID, c.SecretKey)
+
+	res, err := c.HTTPClient.Do(r)
+	if err != nil {
+		return nil, fmt.Errorf("get payment: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrPaymentNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get payment: unexpected status %s", res.Status)
+	}
+
+	payment, err := decodePayment(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("get payment: %w", err)
+	}
+
+	return payment, nil
+}
+
+// decodePayment reads a payment object and keeps the body as it came.
+func decodePayment(body io.Reader) (*model.CreatePaymentRes, error) {
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var payment model.CreatePaymentRes
+	if err = json.Unmarshal(raw, &payment); err != nil {
+		return nil, err
 	}
 
-	return &paymentRes, nil
+	payment.Raw = raw
+	return &payment, nil
 }
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"github.com/aaanger/ecommerce/internal/payment/model"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type ClientSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	status   int
+	requests []*http.Request
+	bodies   []string
+	client   *Client
+}
+
+func (suite *ClientSuite) SetupTest() {
+	suite.status = http.StatusOK
+	suite.requests = nil
+	suite.bodies = nil
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		suite.requests = append(suite.requests, r)
+		suite.bodies = append(suite.bodies, string(body))
+		w.WriteHeader(suite.status)
+		_, _ = w.Write([]byte(`{"id": "pay-1", "status": "succeeded", "amount": {"value": "100.00", "currency": "RUB"}}`))
+	}))
+
+	suite.client = NewClient("shop", "secret")
+	suite.client.APIEndpoint = suite.server.URL + "/"
+}
+
+func (suite *ClientSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func TestClientSuite(t *testing.T) {
+	suite.Run(t, new(ClientSuite))
+}
+
+// ====================================================================================================================
+
+func (suite *ClientSuite) TestClient_CreatePaymentFailure() {
+	suite.status = http.StatusUnauthorized
+
+	payment, err := suite.client.CreatePayment(context.Background(), &model.CreatePaymentReq{}, "order-1")
+
+	suite.Nil(payment)
+	suite.ErrorContains(err, "create payment: unexpected status 401")
+}
+
+func (suite *ClientSuite) TestClient_CapturePayment() {
+	payment, err := suite.client.CapturePayment(context.Background(), "pay-1", &model.CapturePaymentReq{
+		Amount: &model.Amount{Value: "100.00", Currency: "RUB"},
+	}, "capture-1")
+
+	suite.Nil(err)
+	suite.Equal(StatusSucceeded, payment.Status)
+	suite.Equal("/payments/pay-1/capture", suite.requests[0].URL.Path)
+	suite.Equal("capture-1", suite.requests[0].Header.Get("Idempotence-Key"))
+	suite.JSONEq(`{"amount": {"value": "100.00", "currency": "RUB"}}`, suite.bodies[0])
+}
+
+func (suite *ClientSuite) TestClient_CancelPayment() {
+	_, err := suite.client.CancelPayment(context.Background(), "pay-1", "cancel-1")
+
+	suite.Nil(err)
+	suite.Equal("/payments/pay-1/cancel", suite.requests[0].URL.Path)
+	suite.Equal("cancel-1", suite.requests[0].Header.Get("Idempotence-Key"))
+}
+
+func (suite *ClientSuite) TestClient_CancelPaymentNotFound() {
+	suite.status = http.StatusNotFound
+
+	_, err := suite.client.CancelPayment(context.Background(), "pay-1", "cancel-1")
+
+	suite.ErrorIs(err, ErrPaymentNotFound)
+}
+
+// ====================================================================================================================
+
+func (suite *ClientSuite) TestProvider_Selection() {
+	provider, err := NewProvider(ProviderConfig{ShopID: "shop", SecretKey: "secret"}, zap.NewNop())
+	suite.Nil(err)
+	suite.IsType(&Client{}, provider)
+
+	provider, err = NewProvider(ProviderConfig{Provider: ProviderFake, WebhookURL: "http://localhost/payment/webhook"}, zap.NewNop())
+	suite.Nil(err)
+	suite.IsType(&FakeProvider{}, provider)
+
+	_, err = NewProvider(ProviderConfig{Provider: ProviderFake}, zap.NewNop())
+	suite.Error(err)
+
+	_, err = NewProvider(ProviderConfig{Provider: "stripe"}, zap.NewNop())
+	suite.Error(err)
+}
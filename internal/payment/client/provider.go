@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"github.com/aaanger/ecommerce/internal/payment/model"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	ProviderYooKassa = "yookassa"
+	ProviderFake     = "fake"
+)
+
+// PaymentProvider takes payments for orders. Payments and refunds repeated with the same idempotence key
+// return what the first request created.
+type PaymentProvider interface {
+	CreatePayment(ctx context.Context, req *model.CreatePaymentReq, idempotenceKey string) (*model.CreatePaymentRes, error)
+	GetPayment(ctx context.Context, paymentID string) (*model.CreatePaymentRes, error)
+	CapturePayment(ctx context.Context, paymentID string, req *model.CapturePaymentReq, idempotenceKey string) (*model.CreatePaymentRes, error)
+	CancelPayment(ctx context.Context, paymentID string, idempotenceKey string) (*model.CreatePaymentRes, error)
+	CreateRefund(ctx context.Context, req *model.CreateRefundReq, idempotenceKey string) (*model.CreateRefundRes, error)
+}
+
+type ProviderConfig struct {
+	// Provider is ProviderYooKassa, the default, or ProviderFake.
+	Provider  string
+	ShopID    string
+	SecretKey string
+	// WebhookURL is where the fake provider posts its notifications.
+	WebhookURL string
+	// ConfirmDelay is how long the fake provider waits before a payment is confirmed.
+	ConfirmDelay time.Duration
+	// Decline makes the fake provider cancel payments instead of confirming them.
+	Decline bool
+}
+
+func NewProvider(cfg ProviderConfig, log *zap.Logger) (PaymentProvider, error) {
+	switch cfg.Provider {
+	case "", ProviderYooKassa:
+		return NewClient(cfg.ShopID, cfg.SecretKey), nil
+	case ProviderFake:
+		if cfg.WebhookURL == "" {
+			return nil, fmt.Errorf("fake payment provider: webhook url is required")
+		}
+		return NewFakeProvider(cfg.WebhookURL, cfg.ConfirmDelay, cfg.Decline, log), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.Provider)
+	}
+}
@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"time"
+)
 
 type CreatePaymentReq struct {
 	Amount       Amount            `json:"amount"`
@@ -17,11 +21,67 @@ type CreatePaymentRes struct {
 	Amount       Amount            `json:"amount"`
 	Confirmation ConfirmationRes   `json:"confirmation"`
 	CreatedAt    time.Time         `json:"created_at"`
+	ExpiresAt    *time.Time        `json:"expires_at,omitempty"`
 	Description  string            `json:"description"`
 	Metadata     map[string]string `json:"metadata"`
 	Recipient    Recipient         `json:"recipient"`
 	Refundable   bool              `json:"refundable"`
 	Test         bool              `json:"test"`
+	// Raw is the response body as YooKassa sent it.
+	Raw json.RawMessage `json:"-"`
+}
+
+// Payment is a YooKassa payment made for an order. Payload is the provider's last view of the payment,
+// ExpiresAt is when the provider cancels a payment left waiting for capture.
+type Payment struct {
+	ID                int             `json:"id"`
+	ProviderPaymentID string          `json:"provider_payment_id"`
+	OrderID           int             `json:"order_id"`
+	Amount            money.Money     `json:"amount"`
+	Status            string          `json:"status"`
+	ConfirmationURL   string          `json:"confirmation_url,omitempty"`
+	Payload           json.RawMessage `json:"payload,omitempty"`
+	ExpiresAt         *time.Time      `json:"expires_at,omitempty"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+// NewPayment records the provider's response for the order.
+func NewPayment(orderID int, res *CreatePaymentRes) (*Payment, error) {
+	amount, err := res.Amount.Money()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Payment{
+		ProviderPaymentID: res.ID,
+		OrderID:           orderID,
+		Amount:            amount,
+		Status:            res.Status,
+		ConfirmationURL:   res.Confirmation.ConfirmationURL,
+		Payload:           res.Raw,
+		ExpiresAt:         res.ExpiresAt,
+	}, nil
+}
+
+// CapturePaymentReq takes held money. Amount may be less than the hold, the rest is released.
+type CapturePaymentReq struct {
+	Amount *Amount `json:"amount,omitempty"`
+}
+
+// CreateRefundReq returns money from a succeeded payment. Amount may be less than the payment for a partial refund.
+type CreateRefundReq struct {
+	PaymentID   string `json:"payment_id"`
+	Amount      Amount `json:"amount"`
+	Description string `json:"description,omitempty"`
+}
+
+type CreateRefundRes struct {
+	ID        string    `json:"id"`
+	PaymentID string    `json:"payment_id"`
+	Status    string    `json:"status"`
+	Amount    Amount    `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Amount struct {
@@ -29,6 +89,17 @@ type Amount struct {
 	Currency string `json:"currency"`
 }
 
+func NewAmount(m money.Money) Amount {
+	return Amount{
+		Value:    m.Decimal(),
+		Currency: m.Currency,
+	}
+}
+
+func (a Amount) Money() (money.Money, error) {
+	return money.Parse(a.Value, a.Currency)
+}
+
 type ConfirmationReq struct {
 	Type      string `json:"type"`
 	ReturnURL string `json:"return_url"`
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"github.com/aaanger/ecommerce/pkg/money"
+	"time"
+)
+
+// What reconciliation did about a discrepancy.
+const (
+	ResolutionOrderConfirmed = "order confirmed"
+	ResolutionOrderCanceled  = "order canceled"
+	ResolutionHoldReleased   = "hold released"
+	ResolutionRefunded       = "paid order is canceled, payment refunded"
+	ResolutionAmountMismatch = "paid amount differs from the order total, left for review"
+	ResolutionUnknownPayment = "payment unknown to the provider"
+)
+
+// OrderPayment is a payment together with the current status of its order.
+type OrderPayment struct {
+	Payment
+	OrderStatus string `json:"order_status"`
+}
+
+// Discrepancy is a payment whose provider status did not match its order.
+type Discrepancy struct {
+	OrderID       int         `json:"order_id"`
+	OrderStatus   string      `json:"order_status"`
+	PaymentID     string      `json:"payment_id"`
+	PaymentStatus string      `json:"payment_status,omitempty"`
+	Amount        money.Money `json:"amount"`
+	Resolution    string      `json:"resolution"`
+}
+
+// ReconciliationReport is the outcome of one reconciliation run. Failed counts payments that could not be checked.
+type ReconciliationReport struct {
+	ID            int           `json:"id"`
+	StartedAt     time.Time     `json:"started_at"`
+	FinishedAt    time.Time     `json:"finished_at"`
+	Checked       int           `json:"checked"`
+	Failed        int           `json:"failed"`
+	Discrepancies []Discrepancy `json:"discrepancies"`
+}
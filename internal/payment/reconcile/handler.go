@@ -0,0 +1,51 @@
+package reconcile
+
+import (
+	"github.com/aaanger/ecommerce/internal/payment/repository"
+	"github.com/aaanger/ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultReportLimit = 20
+	maxReportLimit     = 100
+)
+
+type ReportHandler struct {
+	reports repository.IReconciliationRepository
+	log     *zap.Logger
+}
+
+func NewReportHandler(reports repository.IReconciliationRepository, log *zap.Logger) *ReportHandler {
+	return &ReportHandler{
+		reports: reports,
+		log:     log,
+	}
+}
+
+// GetReports lists the latest reconciliation reports that found discrepancies, every run with ?all=true.
+func (h *ReportHandler) GetReports(c *gin.Context) {
+	all := c.Query("all") == "true"
+
+	limit := defaultReportLimit
+	if param := c.Query("limit"); param != "" {
+		var err error
+		limit, err = strconv.Atoi(param)
+		if err != nil || limit < 1 || limit > maxReportLimit {
+			response.Error(c, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	reports, err := h.reports.GetReports(c.Request.Context(), !all, limit)
+	if err != nil {
+		h.log.Error("Get reconciliation reports: failed to get reports", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "Failed to get reconciliation reports")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, reports)
+}
@@ -0,0 +1,86 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/aaanger/ecommerce/internal/payment/model"
+	"github.com/aaanger/ecommerce/internal/payment/repository/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type ReportHandlerSuite struct {
+	suite.Suite
+	reports *mocks.IReconciliationRepository
+	router  *gin.Engine
+}
+
+func (suite *ReportHandlerSuite) SetupTest() {
+	suite.reports = mocks.NewIReconciliationRepository(suite.T())
+
+	h := NewReportHandler(suite.reports, zap.NewNop())
+
+	suite.router = gin.New()
+	suite.router.GET("/reconciliations", h.GetReports)
+}
+
+func TestReportHandlerSuite(t *testing.T) {
+	suite.Run(t, new(ReportHandlerSuite))
+}
+
+// ====================================================================================================================
+
+func (suite *ReportHandlerSuite) TestHandler_GetReports() {
+	suite.reports.On("GetReports", mock.Anything, true, defaultReportLimit).Return([]model.ReconciliationReport{
+		{ID: 2, Checked: 3, Discrepancies: []model.Discrepancy{{OrderID: 1, PaymentID: "pay-1", Resolution: model.ResolutionOrderConfirmed}}},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/reconciliations", nil)
+
+	suite.router.ServeHTTP(w, r)
+
+	var reports []model.ReconciliationReport
+	_ = json.Unmarshal(w.Body.Bytes(), &reports)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Len(reports, 1)
+	suite.Equal(model.ResolutionOrderConfirmed, reports[0].Discrepancies[0].Resolution)
+}
+
+func (suite *ReportHandlerSuite) TestHandler_GetReportsAll() {
+	suite.reports.On("GetReports", mock.Anything, false, 5).Return([]model.ReconciliationReport{}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/reconciliations?all=true&limit=5", nil)
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *ReportHandlerSuite) TestHandler_GetReportsInvalidLimit() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/reconciliations?limit=1000", nil)
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(`"invalid limit"`, w.Body.String())
+}
+
+func (suite *ReportHandlerSuite) TestHandler_GetReportsFailure() {
+	suite.reports.On("GetReports", mock.Anything, true, defaultReportLimit).Return(nil, errors.New("error"))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/reconciliations", nil)
+
+	suite.router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusInternalServerError, w.Code)
+}
@@ -0,0 +1,187 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	orderModel "github.com/aaanger/ecommerce/internal/order/model"
+	payment "github.com/aaanger/ecommerce/internal/payment/client"
+	"github.com/aaanger/ecommerce/internal/payment/model"
+	"github.com/aaanger/ecommerce/internal/payment/repository"
+	"github.com/aaanger/ecommerce/internal/payment/webhook"
+	"github.com/aaanger/ecommerce/pkg/db"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	// DefaultWindow is how far back reconciliation looks at orders.
+	DefaultWindow = 24 * time.Hour
+	// DefaultInterval is how often reconciliation runs. It is well under the order payment TTL, so a missed
+	// notification is usually caught before the order expires.
+	DefaultInterval = 5 * time.Minute
+)
+
+const (
+	// reconcilerLockKey identifies the reconciler's advisory lock.
+	reconcilerLockKey = 2003
+	reconcilerBatch   = 500
+)
+
+// statusEvents is the notification the provider sends when a payment reaches the status.
+var statusEvents = map[string]string{
+	payment.StatusSucceeded:         webhook.EventPaymentSucceeded,
+	payment.StatusWaitingForCapture: webhook.EventPaymentWaitingForCapture,
+	payment.StatusCanceled:          webhook.EventPaymentCanceled,
+}
+
+// Reconciler periodically asks the provider about the payments of recent orders and applies whatever
+// notifications were lost, through the same processing as the webhook. Every run stores a report of the
+// discrepancies found. Only the instance holding the advisory lock runs.
+type Reconciler struct {
+	payments repository.IPaymentRepository
+	reports  repository.IReconciliationRepository
+	provider payment.PaymentProvider
+	webhook  *webhook.Handler
+	locker   db.Locker
+	window   time.Duration
+	interval time.Duration
+	log      *zap.Logger
+}
+
+func NewReconciler(payments repository.IPaymentRepository, reports repository.IReconciliationRepository, provider payment.PaymentProvider, processor *webhook.Handler, locker db.Locker, window, interval time.Duration, log *zap.Logger) *Reconciler {
+	return &Reconciler{
+		payments: payments,
+		reports:  reports,
+		provider: provider,
+		webhook:  processor,
+		locker:   locker,
+		window:   window,
+		interval: interval,
+		log:      log,
+	}
+}
+
+func (r *Reconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Reconcile(ctx)
+		}
+	}
+}
+
+func (r *Reconciler) Reconcile(ctx context.Context) {
+	unlock, ok, err := r.locker.TryLock(ctx, reconcilerLockKey)
+	if err != nil {
+		r.log.Error("Reconciler: failed to take lock", zap.Error(err))
+		return
+	}
+	if !ok {
+		r.log.Debug("Reconciler: another instance is running")
+		return
+	}
+	defer unlock()
+
+	report := &model.ReconciliationReport{
+		StartedAt:     time.Now(),
+		Discrepancies: make([]model.Discrepancy, 0),
+	}
+
+	payments, err := r.payments.GetPaymentsToReconcile(ctx, report.StartedAt.Add(-r.window), reconcilerBatch)
+	if err != nil {
+		r.log.Error("Reconciler: failed to get payments", zap.Error(err))
+		return
+	}
+
+	for _, p := range payments {
+		report.Checked++
+
+		discrepancy, err := r.check(ctx, p)
+		if err != nil {
+			report.Failed++
+			r.log.Error("Reconciler: failed to check payment", zap.Error(err), zap.Int("orderID", p.OrderID),
+				zap.String("paymentID", p.ProviderPaymentID))
+			continue
+		}
+		if discrepancy != nil {
+			report.Discrepancies = append(report.Discrepancies, *discrepancy)
+		}
+	}
+
+	report.FinishedAt = time.Now()
+
+	if err = r.reports.SaveReport(ctx, report); err != nil {
+		r.log.Error("Reconciler: failed to save report", zap.Error(err), zap.Any("report", report))
+		return
+	}
+
+	if len(report.Discrepancies) > 0 || report.Failed > 0 {
+		r.log.Warn("Reconciler: payments out of step with orders", zap.Int("reportID", report.ID),
+			zap.Int("discrepancies", len(report.Discrepancies)), zap.Int("failed", report.Failed))
+	}
+}
+
+// check brings the order in step with the provider's view of its payment and describes what was out of step,
+// nil if nothing was.
+func (r *Reconciler) check(ctx context.Context, p model.OrderPayment) (*model.Discrepancy, error) {
+	discrepancy := &model.Discrepancy{
+		OrderID:     p.OrderID,
+		OrderStatus: p.OrderStatus,
+		PaymentID:   p.ProviderPaymentID,
+		Amount:      p.Amount,
+	}
+
+	paid, err := r.provider.GetPayment(ctx, p.ProviderPaymentID)
+	if errors.Is(err, payment.ErrPaymentNotFound) {
+		discrepancy.Resolution = model.ResolutionUnknownPayment
+		return discrepancy, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	event, ok := statusEvents[paid.Status]
+	if !ok {
+		// The customer has not paid yet.
+		return nil, nil
+	}
+
+	discrepancy.PaymentStatus = paid.Status
+	if discrepancy.Amount, err = paid.Amount.Money(); err != nil {
+		return nil, err
+	}
+
+	applied, err := r.webhook.Process(ctx, event, p.OrderID, paid)
+	if errors.Is(err, webhook.ErrAmountMismatch) {
+		discrepancy.Resolution = model.ResolutionAmountMismatch
+		return discrepancy, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !applied {
+		// The notification was handled meanwhile, the order was already in step when we looked again.
+		return nil, nil
+	}
+
+	switch {
+	case p.OrderStatus == orderModel.StatusPending && paid.Status == payment.StatusCanceled:
+		discrepancy.Resolution = model.ResolutionOrderCanceled
+	case p.OrderStatus == orderModel.StatusPending:
+		discrepancy.Resolution = model.ResolutionOrderConfirmed
+	case paid.Status == payment.StatusWaitingForCapture:
+		discrepancy.Resolution = model.ResolutionHoldReleased
+	case paid.Status == payment.StatusSucceeded:
+		discrepancy.Resolution = model.ResolutionRefunded
+	default:
+		// A canceled order whose payment was canceled too, only our record of the payment lagged behind.
+		return nil, nil
+	}
+
+	return discrepancy, nil
+}
@@ -0,0 +1,262 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	orderModel "github.com/aaanger/ecommerce/internal/order/model"
+	orderMocks "github.com/aaanger/ecommerce/internal/order/service/mocks"
+	payment "github.com/aaanger/ecommerce/internal/payment/client"
+	"github.com/aaanger/ecommerce/internal/payment/model"
+	"github.com/aaanger/ecommerce/internal/payment/repository/mocks"
+	"github.com/aaanger/ecommerce/internal/payment/webhook"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// inlineTx runs the unit of work without a database, the repository mocks take its place.
+type inlineTx struct{}
+
+func (inlineTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// locker grants the lock unless held is set and counts releases.
+type locker struct {
+	held     bool
+	unlocked int
+}
+
+func (l *locker) TryLock(ctx context.Context, key int64) (func(), bool, error) {
+	if l.held {
+		return nil, false, nil
+	}
+	return func() { l.unlocked++ }, true, nil
+}
+
+type ReconcilerSuite struct {
+	suite.Suite
+	orderService *orderMocks.IOrderService
+	events       *mocks.IEventRepository
+	paymentRepo  *mocks.IPaymentRepository
+	reports      *mocks.IReconciliationRepository
+	locker       *locker
+	yookassa     *httptest.Server
+	payments     map[string]string
+	report       *model.ReconciliationReport
+	reconciler   *Reconciler
+}
+
+func (suite *ReconcilerSuite) SetupTest() {
+	suite.orderService = orderMocks.NewIOrderService(suite.T())
+	suite.events = mocks.NewIEventRepository(suite.T())
+	suite.paymentRepo = mocks.NewIPaymentRepository(suite.T())
+	suite.reports = mocks.NewIReconciliationRepository(suite.T())
+	suite.locker = &locker{}
+	suite.payments = map[string]string{}
+	suite.report = nil
+	suite.yookassa = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := suite.payments[r.Method+" "+r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+
+	paymentClient := payment.NewClient("shop", "secret")
+	paymentClient.APIEndpoint = suite.yookassa.URL + "/"
+
+	processor := webhook.NewWebhookHandler(suite.orderService, paymentClient, suite.events, suite.paymentRepo, inlineTx{}, zap.NewNop())
+	suite.reconciler = NewReconciler(suite.paymentRepo, suite.reports, paymentClient, processor, suite.locker, DefaultWindow, DefaultInterval, zap.NewNop())
+
+	suite.reports.On("SaveReport", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		suite.report = args.Get(1).(*model.ReconciliationReport)
+	}).Return(nil).Maybe()
+}
+
+func (suite *ReconcilerSuite) TearDownTest() {
+	suite.yookassa.Close()
+}
+
+func TestReconcilerSuite(t *testing.T) {
+	suite.Run(t, new(ReconcilerSuite))
+}
+
+func (suite *ReconcilerSuite) expectPayments(payments ...model.OrderPayment) {
+	suite.paymentRepo.On("GetPaymentsToReconcile", mock.Anything, mock.MatchedBy(func(since time.Time) bool {
+		return since.Before(time.Now().Add(-23 * time.Hour))
+	}), reconcilerBatch).Return(payments, nil)
+}
+
+func orderPayment(orderID int, paymentID, orderStatus string) model.OrderPayment {
+	return model.OrderPayment{
+		Payment:     model.Payment{ProviderPaymentID: paymentID, OrderID: orderID, Amount: money.FromMinor(12300), Status: "pending"},
+		OrderStatus: orderStatus,
+	}
+}
+
+// ====================================================================================================================
+
+func (suite *ReconcilerSuite) TestReconciler_ConfirmsMissedPayment() {
+	suite.payments["GET /payments/pay-1"] = `{"id": "pay-1", "status": "succeeded", "paid": true, "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+	suite.expectPayments(orderPayment(1, "pay-1", orderModel.StatusPending))
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", webhook.EventPaymentSucceeded, 1).Return(true, nil)
+	suite.events.On("MarkApplied", mock.Anything, "pay-1", webhook.EventPaymentSucceeded).Return(nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusPending, TotalPrice: money.FromMinor(12300)}, nil)
+	suite.orderService.On("ConfirmOrder", mock.Anything, 1, "pay-1").Return(nil)
+
+	suite.reconciler.Reconcile(context.Background())
+
+	suite.Equal(1, suite.report.Checked)
+	suite.Equal([]model.Discrepancy{{
+		OrderID:       1,
+		OrderStatus:   orderModel.StatusPending,
+		PaymentID:     "pay-1",
+		PaymentStatus: "succeeded",
+		Amount:        money.FromMinor(12300),
+		Resolution:    model.ResolutionOrderConfirmed,
+	}}, suite.report.Discrepancies)
+	suite.Equal(1, suite.locker.unlocked)
+}
+
+func (suite *ReconcilerSuite) TestReconciler_SkipsPaymentHandledMeanwhile() {
+	suite.payments["GET /payments/pay-1"] = `{"id": "pay-1", "status": "succeeded", "paid": true, "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+	suite.expectPayments(orderPayment(1, "pay-1", orderModel.StatusPending))
+
+	// The webhook recorded the event between listing the order and checking it.
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", webhook.EventPaymentSucceeded, 1).Return(false, nil)
+
+	suite.reconciler.Reconcile(context.Background())
+
+	suite.Equal(1, suite.report.Checked)
+	suite.Empty(suite.report.Discrepancies)
+	suite.orderService.AssertNotCalled(suite.T(), "ConfirmOrder", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *ReconcilerSuite) TestReconciler_CancelsMissedCancellation() {
+	suite.payments["GET /payments/pay-1"] = `{"id": "pay-1", "status": "canceled", "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+	suite.expectPayments(orderPayment(1, "pay-1", orderModel.StatusPending))
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", webhook.EventPaymentCanceled, 1).Return(true, nil)
+	suite.events.On("MarkApplied", mock.Anything, "pay-1", webhook.EventPaymentCanceled).Return(nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusPending}, nil)
+	suite.orderService.On("CancelOrder", mock.Anything, 1, orderModel.SystemActor, "payment canceled").Return(nil)
+
+	suite.reconciler.Reconcile(context.Background())
+
+	suite.Len(suite.report.Discrepancies, 1)
+	suite.Equal(model.ResolutionOrderCanceled, suite.report.Discrepancies[0].Resolution)
+}
+
+func (suite *ReconcilerSuite) TestReconciler_SkipsUnpaid() {
+	suite.payments["GET /payments/pay-1"] = `{"id": "pay-1", "status": "pending", "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+	suite.expectPayments(orderPayment(1, "pay-1", orderModel.StatusPending))
+
+	suite.reconciler.Reconcile(context.Background())
+
+	suite.Equal(1, suite.report.Checked)
+	suite.Empty(suite.report.Discrepancies)
+	suite.orderService.AssertNotCalled(suite.T(), "ConfirmOrder", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *ReconcilerSuite) TestReconciler_AmountMismatch() {
+	suite.payments["GET /payments/pay-1"] = `{"id": "pay-1", "status": "succeeded", "amount": {"value": "1.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+	suite.expectPayments(orderPayment(1, "pay-1", orderModel.StatusPending))
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", webhook.EventPaymentSucceeded, 1).Return(true, nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusPending, TotalPrice: money.FromMinor(12300)}, nil)
+
+	suite.reconciler.Reconcile(context.Background())
+
+	suite.Len(suite.report.Discrepancies, 1)
+	suite.Equal(model.ResolutionAmountMismatch, suite.report.Discrepancies[0].Resolution)
+	suite.Equal(money.FromMinor(100), suite.report.Discrepancies[0].Amount)
+}
+
+func (suite *ReconcilerSuite) TestReconciler_UnknownPayment() {
+	suite.expectPayments(orderPayment(1, "pay-1", orderModel.StatusPending))
+
+	suite.reconciler.Reconcile(context.Background())
+
+	suite.Len(suite.report.Discrepancies, 1)
+	suite.Equal(model.ResolutionUnknownPayment, suite.report.Discrepancies[0].Resolution)
+}
+
+// ====================================================================================================================
+
+func (suite *ReconcilerSuite) TestReconciler_PaidCanceledOrder() {
+	suite.payments["GET /payments/pay-1"] = `{"id": "pay-1", "status": "succeeded", "paid": true, "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+	suite.payments["POST /refunds"] = `{"id": "refund-1", "payment_id": "pay-1", "status": "succeeded", "amount": {"value": "123.00", "currency": "RUB"}}`
+	suite.expectPayments(orderPayment(1, "pay-1", orderModel.StatusCanceled))
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", webhook.EventPaymentSucceeded, 1).Return(true, nil)
+	suite.events.On("MarkApplied", mock.Anything, "pay-1", webhook.EventPaymentSucceeded).Return(nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusCanceled, TotalPrice: money.FromMinor(12300)}, nil)
+
+	suite.reconciler.Reconcile(context.Background())
+
+	suite.Len(suite.report.Discrepancies, 1)
+	suite.Equal(model.ResolutionRefunded, suite.report.Discrepancies[0].Resolution)
+}
+
+func (suite *ReconcilerSuite) TestReconciler_ReleasesHoldOfCanceledOrder() {
+	suite.payments["GET /payments/pay-1"] = `{"id": "pay-1", "status": "waiting_for_capture", "paid": true, "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+	suite.payments["POST /payments/pay-1/cancel"] = `{"id": "pay-1", "status": "canceled", "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+	suite.expectPayments(orderPayment(1, "pay-1", orderModel.StatusCanceled))
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", webhook.EventPaymentWaitingForCapture, 1).Return(true, nil)
+	suite.events.On("MarkApplied", mock.Anything, "pay-1", webhook.EventPaymentWaitingForCapture).Return(nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusCanceled, TotalPrice: money.FromMinor(12300)}, nil)
+
+	suite.reconciler.Reconcile(context.Background())
+
+	suite.Len(suite.report.Discrepancies, 1)
+	suite.Equal(model.ResolutionHoldReleased, suite.report.Discrepancies[0].Resolution)
+}
+
+func (suite *ReconcilerSuite) TestReconciler_CountsFailures() {
+	suite.payments["GET /payments/pay-1"] = `{"id": "pay-1", "status": "succeeded", "paid": true, "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+	suite.payments["GET /payments/pay-2"] = `{"id": "pay-2", "status": "canceled", "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "2"}}`
+	suite.expectPayments(orderPayment(1, "pay-1", orderModel.StatusPending), orderPayment(2, "pay-2", orderModel.StatusPending))
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", webhook.EventPaymentSucceeded, 1).Return(false, errors.New("error"))
+	suite.events.On("SaveEvent", mock.Anything, "pay-2", webhook.EventPaymentCanceled, 2).Return(true, nil)
+	suite.events.On("MarkApplied", mock.Anything, "pay-2", webhook.EventPaymentCanceled).Return(nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 2, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 2, Status: orderModel.StatusPending}, nil)
+	suite.orderService.On("CancelOrder", mock.Anything, 2, orderModel.SystemActor, "payment canceled").Return(nil)
+
+	suite.reconciler.Reconcile(context.Background())
+
+	// A failing payment does not hold up the rest of the batch.
+	suite.Equal(2, suite.report.Checked)
+	suite.Equal(1, suite.report.Failed)
+	suite.Len(suite.report.Discrepancies, 1)
+}
+
+func (suite *ReconcilerSuite) TestReconciler_SkipsWhenLocked() {
+	suite.locker.held = true
+
+	suite.reconciler.Reconcile(context.Background())
+
+	suite.paymentRepo.AssertNotCalled(suite.T(), "GetPaymentsToReconcile", mock.Anything, mock.Anything, mock.Anything)
+	suite.Nil(suite.report)
+}
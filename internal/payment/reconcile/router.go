@@ -0,0 +1,29 @@
+package reconcile
+
+import (
+	"database/sql"
+	"github.com/aaanger/ecommerce/internal/order/service"
+	payment "github.com/aaanger/ecommerce/internal/payment/client"
+	"github.com/aaanger/ecommerce/internal/payment/repository"
+	"github.com/aaanger/ecommerce/internal/payment/webhook"
+	database "github.com/aaanger/ecommerce/pkg/db"
+	"github.com/aaanger/ecommerce/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ReconcileRoutes registers the report endpoint under /admin/payments and returns the reconciler, which the
+// caller starts with Run.
+func ReconcileRoutes(r *gin.Engine, db *sql.DB, orderService service.IOrderService, paymentClient payment.PaymentProvider, log *zap.Logger) *Reconciler {
+	paymentRepo := repository.NewPaymentRepository(db)
+	reportRepo := repository.NewReconciliationRepository(db)
+	processor := webhook.NewWebhookHandler(orderService, paymentClient, repository.NewEventRepository(db), paymentRepo, database.NewTxManager(db), log)
+
+	h := NewReportHandler(reportRepo, log)
+
+	admin := r.Group("/admin/payments", middleware.UserIdentity, middleware.ModeratorIdentity)
+
+	admin.GET("/reconciliations", h.GetReports)
+
+	return NewReconciler(paymentRepo, reportRepo, paymentClient, processor, database.NewAdvisoryLocker(db), DefaultWindow, DefaultInterval, log)
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/aaanger/ecommerce/pkg/db"
+)
+
+//go:generate mockery --name=IEventRepository
+
+type IEventRepository interface {
+	SaveEvent(ctx context.Context, paymentID, event string, orderID int) (bool, error)
+	MarkApplied(ctx context.Context, paymentID, event string) error
+}
+
+type EventRepository struct {
+	db *sql.DB
+}
+
+func NewEventRepository(db *sql.DB) *EventRepository {
+	return &EventRepository{
+		db: db,
+	}
+}
+
+// SaveEvent records a payment notification and reports whether it still has to be applied. A notification
+// already applied returns false and is left as it was.
+func (r *EventRepository) SaveEvent(ctx context.Context, paymentID, event string, orderID int) (bool, error) {
+	res, err := db.Conn(ctx, r.db).ExecContext(ctx, `INSERT INTO payment_events (payment_id, event, order_id) VALUES($1, $2, $3)
+		ON CONFLICT (payment_id, event) DO UPDATE SET received_at = payment_events.received_at WHERE payment_events.applied_at IS NULL;`,
+		paymentID, event, orderID)
+	if err != nil {
+		return false, err
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return inserted == 1, nil
+}
+
+// MarkApplied records that the notification has moved its order, so redeliveries are skipped.
+func (r *EventRepository) MarkApplied(ctx context.Context, paymentID, event string) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, `UPDATE payment_events SET applied_at = CURRENT_TIMESTAMP WHERE payment_id=$1 AND event=$2;`,
+		paymentID, event)
+	return err
+}
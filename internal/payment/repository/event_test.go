@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type EventRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo *EventRepository
+}
+
+func (suite *EventRepositorySuite) SetupTest() {
+	var err error
+	suite.db, suite.mock, err = sqlmock.New()
+	assert.NoError(suite.T(), err)
+	suite.repo = NewEventRepository(suite.db)
+}
+
+func TestEventRepositorySuite(t *testing.T) {
+	suite.Run(t, new(EventRepositorySuite))
+}
+
+// ====================================================================================================================
+
+func (suite *EventRepositorySuite) TestRepository_SaveEventNew() {
+	suite.mock.ExpectExec("INSERT INTO payment_events \\(payment_id, event, order_id\\) VALUES\\(\\$1, \\$2, \\$3\\)\\s+ON CONFLICT \\(payment_id, event\\) DO UPDATE SET received_at = payment_events.received_at WHERE payment_events.applied_at IS NULL").
+		WithArgs("pay-1", "payment.succeeded", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	fresh, err := suite.repo.SaveEvent(context.Background(), "pay-1", "payment.succeeded", 1)
+
+	suite.Nil(err)
+	suite.True(fresh)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *EventRepositorySuite) TestRepository_SaveEventDuplicate() {
+	suite.mock.ExpectExec("INSERT INTO payment_events").
+		WithArgs("pay-1", "payment.succeeded", 1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	fresh, err := suite.repo.SaveEvent(context.Background(), "pay-1", "payment.succeeded", 1)
+
+	suite.Nil(err)
+	suite.False(fresh)
+}
+
+func (suite *EventRepositorySuite) TestRepository_MarkApplied() {
+	suite.mock.ExpectExec("UPDATE payment_events SET applied_at = CURRENT_TIMESTAMP WHERE payment_id=\\$1 AND event=\\$2").
+		WithArgs("pay-1", "payment.succeeded").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := suite.repo.MarkApplied(context.Background(), "pay-1", "payment.succeeded")
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
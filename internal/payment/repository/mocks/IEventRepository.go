@@ -0,0 +1,74 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IEventRepository is an autogenerated mock type for the IEventRepository type
+type IEventRepository struct {
+	mock.Mock
+}
+
+// MarkApplied provides a mock function with given fields: ctx, paymentID, event
+func (_m *IEventRepository) MarkApplied(ctx context.Context, paymentID string, event string) error {
+	ret := _m.Called(ctx, paymentID, event)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkApplied")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, paymentID, event)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveEvent provides a mock function with given fields: ctx, paymentID, event, orderID
+func (_m *IEventRepository) SaveEvent(ctx context.Context, paymentID string, event string, orderID int) (bool, error) {
+	ret := _m.Called(ctx, paymentID, event, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveEvent")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) (bool, error)); ok {
+		return rf(ctx, paymentID, event, orderID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) bool); ok {
+		r0 = rf(ctx, paymentID, event, orderID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int) error); ok {
+		r1 = rf(ctx, paymentID, event, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewIEventRepository creates a new instance of IEventRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIEventRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IEventRepository {
+	mock := &IEventRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
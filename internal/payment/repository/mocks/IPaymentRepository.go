@@ -0,0 +1,139 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/aaanger/ecommerce/internal/payment/model"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// IPaymentRepository is an autogenerated mock type for the IPaymentRepository type
+type IPaymentRepository struct {
+	mock.Mock
+}
+
+// GetExpiringHolds provides a mock function with given fields: ctx, before, limit
+func (_m *IPaymentRepository) GetExpiringHolds(ctx context.Context, before time.Time, limit int) ([]model.Payment, error) {
+	ret := _m.Called(ctx, before, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetExpiringHolds")
+	}
+
+	var r0 []model.Payment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) ([]model.Payment, error)); ok {
+		return rf(ctx, before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) []model.Payment); ok {
+		r0 = rf(ctx, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Payment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPaymentsByOrder provides a mock function with given fields: ctx, orderID
+func (_m *IPaymentRepository) GetPaymentsByOrder(ctx context.Context, orderID int) ([]model.Payment, error) {
+	ret := _m.Called(ctx, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPaymentsByOrder")
+	}
+
+	var r0 []model.Payment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]model.Payment, error)); ok {
+		return rf(ctx, orderID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []model.Payment); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Payment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPaymentsToReconcile provides a mock function with given fields: ctx, since, limit
+func (_m *IPaymentRepository) GetPaymentsToReconcile(ctx context.Context, since time.Time, limit int) ([]model.OrderPayment, error) {
+	ret := _m.Called(ctx, since, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPaymentsToReconcile")
+	}
+
+	var r0 []model.OrderPayment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) ([]model.OrderPayment, error)); ok {
+		return rf(ctx, since, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) []model.OrderPayment); ok {
+		r0 = rf(ctx, since, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.OrderPayment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, since, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SavePayment provides a mock function with given fields: ctx, payment
+func (_m *IPaymentRepository) SavePayment(ctx context.Context, payment *model.Payment) error {
+	ret := _m.Called(ctx, payment)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SavePayment")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Payment) error); ok {
+		r0 = rf(ctx, payment)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewIPaymentRepository creates a new instance of IPaymentRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIPaymentRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IPaymentRepository {
+	mock := &IPaymentRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,77 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/aaanger/ecommerce/internal/payment/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IReconciliationRepository is an autogenerated mock type for the IReconciliationRepository type
+type IReconciliationRepository struct {
+	mock.Mock
+}
+
+// GetReports provides a mock function with given fields: ctx, withDiscrepancies, limit
+func (_m *IReconciliationRepository) GetReports(ctx context.Context, withDiscrepancies bool, limit int) ([]model.ReconciliationReport, error) {
+	ret := _m.Called(ctx, withDiscrepancies, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReports")
+	}
+
+	var r0 []model.ReconciliationReport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, bool, int) ([]model.ReconciliationReport, error)); ok {
+		return rf(ctx, withDiscrepancies, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, bool, int) []model.ReconciliationReport); ok {
+		r0 = rf(ctx, withDiscrepancies, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.ReconciliationReport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, bool, int) error); ok {
+		r1 = rf(ctx, withDiscrepancies, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveReport provides a mock function with given fields: ctx, report
+func (_m *IReconciliationRepository) SaveReport(ctx context.Context, report *model.ReconciliationReport) error {
+	ret := _m.Called(ctx, report)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveReport")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.ReconciliationReport) error); ok {
+		r0 = rf(ctx, report)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewIReconciliationRepository creates a new instance of IReconciliationRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIReconciliationRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IReconciliationRepository {
+	mock := &IReconciliationRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
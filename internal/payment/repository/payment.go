@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	orderModel "github.com/aaanger/ecommerce/internal/order/model"
+	"github.com/aaanger/ecommerce/internal/payment/model"
+	"github.com/aaanger/ecommerce/pkg/db"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"time"
+)
+
+//go:generate mockery --name=IPaymentRepository
+
+type IPaymentRepository interface {
+	SavePayment(ctx context.Context, payment *model.Payment) error
+	GetPaymentsByOrder(ctx context.Context, orderID int) ([]model.Payment, error)
+	GetExpiringHolds(ctx context.Context, before time.Time, limit int) ([]model.Payment, error)
+	GetPaymentsToReconcile(ctx context.Context, since time.Time, limit int) ([]model.OrderPayment, error)
+}
+
+type PaymentRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentRepository(db *sql.DB) *PaymentRepository {
+	return &PaymentRepository{
+		db: db,
+	}
+}
+
+// SavePayment stores the payment or, if it is already known, updates its status and payload. A
+// confirmation URL is kept once seen, YooKassa leaves it out of later responses.
+func (r *PaymentRepository) SavePayment(ctx context.Context, payment *model.Payment) error {
+	var payload []byte
+	if len(payment.Payload) > 0 {
+		payload = payment.Payload
+	}
+
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, `INSERT INTO payments (provider_payment_id, order_id, amount, currency, status, confirmation_url, payload, expires_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (provider_payment_id) DO UPDATE SET amount=EXCLUDED.amount, status=EXCLUDED.status, payload=EXCLUDED.payload,
+		confirmation_url=COALESCE(NULLIF(EXCLUDED.confirmation_url, ''), payments.confirmation_url), expires_at=EXCLUDED.expires_at,
+		updated_at=current_timestamp;`,
+		payment.ProviderPaymentID, payment.OrderID, payment.Amount, payment.Amount.Currency, payment.Status, payment.ConfirmationURL, payload, payment.ExpiresAt)
+	return err
+}
+
+// GetPaymentsByOrder lists the payment attempts of the order, oldest first.
+func (r *PaymentRepository) GetPaymentsByOrder(ctx context.Context, orderID int) ([]model.Payment, error) {
+	rows, err := db.Conn(ctx, r.db).QueryContext(ctx, `SELECT id, provider_payment_id, order_id, amount, currency, status, confirmation_url, payload,
+		expires_at, created_at, updated_at FROM payments WHERE order_id=$1 ORDER BY created_at, id;`, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanPayments(rows)
+}
+
+// GetExpiringHolds lists payments still waiting for capture whose hold expires before the given time, soonest first.
+func (r *PaymentRepository) GetExpiringHolds(ctx context.Context, before time.Time, limit int) ([]model.Payment, error) {
+	rows, err := db.Conn(ctx, r.db).QueryContext(ctx, `SELECT id, provider_payment_id, order_id, amount, currency, status, confirmation_url, payload,
+		expires_at, created_at, updated_at FROM payments WHERE status='waiting_for_capture' AND expires_at < $1
+		ORDER BY expires_at LIMIT $2;`, before, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanPayments(rows)
+}
+
+// GetPaymentsToReconcile lists the payments of orders created since the given time that may still be out of
+// step with the provider: those of Pending orders, and those of Canceled orders not known to be settled. Newest
+// orders come first.
+func (r *PaymentRepository) GetPaymentsToReconcile(ctx context.Context, since time.Time, limit int) ([]model.OrderPayment, error) {
+	rows, err := db.Conn(ctx, r.db).QueryContext(ctx, `SELECT p.id, p.provider_payment_id, p.order_id, p.amount, p.currency, p.status,
+		p.confirmation_url, p.payload, p.expires_at, p.created_at, p.updated_at, o.status FROM payments p JOIN orders o ON o.id=p.order_id
+		WHERE o.created_at > $1 AND (o.status=$2 OR (o.status=$3 AND p.status IN ('pending', 'waiting_for_capture')))
+		ORDER BY o.created_at DESC, p.id LIMIT $4;`, since, orderModel.StatusPending, orderModel.StatusCanceled, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := make([]model.OrderPayment, 0)
+
+	for rows.Next() {
+		var payment model.OrderPayment
+		var amount int64
+		var currency string
+		var payload []byte
+
+		err = rows.Scan(&payment.ID, &payment.ProviderPaymentID, &payment.OrderID, &amount, &currency, &payment.Status, &payment.ConfirmationURL,
+			&payload, &payment.ExpiresAt, &payment.CreatedAt, &payment.UpdatedAt, &payment.OrderStatus)
+		if err != nil {
+			return nil, err
+		}
+
+		payment.Amount = money.New(amount, currency)
+		payment.Payload = payload
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
+
+func scanPayments(rows *sql.Rows) ([]model.Payment, error) {
+	defer rows.Close()
+
+	payments := make([]model.Payment, 0)
+
+	for rows.Next() {
+		var payment model.Payment
+		var amount int64
+		var currency string
+		var payload []byte
+
+		err := rows.Scan(&payment.ID, &payment.ProviderPaymentID, &payment.OrderID, &amount, &currency, &payment.Status, &payment.ConfirmationURL,
+			&payload, &payment.ExpiresAt, &payment.CreatedAt, &payment.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		payment.Amount = money.New(amount, currency)
+		payment.Payload = payload
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
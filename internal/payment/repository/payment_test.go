@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/aaanger/ecommerce/internal/payment/model"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type PaymentRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo *PaymentRepository
+}
+
+func (suite *PaymentRepositorySuite) SetupTest() {
+	var err error
+	suite.db, suite.mock, err = sqlmock.New()
+	assert.NoError(suite.T(), err)
+	suite.repo = NewPaymentRepository(suite.db)
+}
+
+func TestPaymentRepositorySuite(t *testing.T) {
+	suite.Run(t, new(PaymentRepositorySuite))
+}
+
+// ====================================================================================================================
+
+func (suite *PaymentRepositorySuite) TestRepository_SavePayment() {
+	payload := json.RawMessage(`{"id": "pay-1", "status": "pending"}`)
+
+	suite.mock.ExpectExec("INSERT INTO payments \\(provider_payment_id, order_id, amount, currency, status, confirmation_url, payload, expires_at\\)\\s+VALUES\\(\\$1, \\$2, \\$3, \\$4, \\$5, \\$6, \\$7, \\$8\\)\\s+ON CONFLICT \\(provider_payment_id\\) DO UPDATE").
+		WithArgs("pay-1", 1, int64(12300), "RUB", "pending", "https://pay.test/1", []byte(payload), nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := suite.repo.SavePayment(context.Background(), &model.Payment{
+		ProviderPaymentID: "pay-1",
+		OrderID:           1,
+		Amount:            money.FromMinor(12300),
+		Status:            "pending",
+		ConfirmationURL:   "https://pay.test/1",
+		Payload:           payload,
+	})
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *PaymentRepositorySuite) TestRepository_SavePaymentWithoutPayload() {
+	suite.mock.ExpectExec("INSERT INTO payments").
+		WithArgs("pay-1", 1, int64(12300), "RUB", "succeeded", "", []byte(nil), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := suite.repo.SavePayment(context.Background(), &model.Payment{
+		ProviderPaymentID: "pay-1",
+		OrderID:           1,
+		Amount:            money.FromMinor(12300),
+		Status:            "succeeded",
+	})
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+// ====================================================================================================================
+
+func (suite *PaymentRepositorySuite) TestRepository_GetPaymentsByOrder() {
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "provider_payment_id", "order_id", "amount", "currency", "status", "confirmation_url", "payload", "expires_at", "created_at", "updated_at"}).
+		AddRow(1, "pay-1", 1, 12300, "RUB", "canceled", "https://pay.test/1", []byte(`{"id": "pay-1"}`), nil, now, now).
+		AddRow(2, "pay-2", 1, 12300, "RUB", "succeeded", "https://pay.test/2", nil, nil, now, now)
+
+	suite.mock.ExpectQuery("SELECT id, provider_payment_id, order_id, amount, currency, status, confirmation_url, payload,\\s+expires_at, created_at, updated_at FROM payments WHERE order_id=\\$1 ORDER BY created_at, id").
+		WithArgs(1).WillReturnRows(rows)
+
+	payments, err := suite.repo.GetPaymentsByOrder(context.Background(), 1)
+
+	suite.Nil(err)
+	suite.Len(payments, 2)
+	suite.Equal("pay-1", payments[0].ProviderPaymentID)
+	suite.Equal(money.FromMinor(12300), payments[0].Amount)
+	suite.JSONEq(`{"id": "pay-1"}`, string(payments[0].Payload))
+	suite.Nil(payments[1].Payload)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *PaymentRepositorySuite) TestRepository_GetPaymentsByOrderFailure() {
+	suite.mock.ExpectQuery("SELECT (.+) FROM payments").WithArgs(1).WillReturnError(sql.ErrConnDone)
+
+	payments, err := suite.repo.GetPaymentsByOrder(context.Background(), 1)
+
+	suite.Nil(payments)
+	suite.ErrorIs(err, sql.ErrConnDone)
+}
+
+func (suite *PaymentRepositorySuite) TestRepository_GetExpiringHolds() {
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	rows := sqlmock.NewRows([]string{"id", "provider_payment_id", "order_id", "amount", "currency", "status", "confirmation_url", "payload", "expires_at", "created_at", "updated_at"}).
+		AddRow(1, "pay-1", 1, 12300, "RUB", "waiting_for_capture", "", nil, expiresAt, now, now)
+
+	suite.mock.ExpectQuery("SELECT (.+) FROM payments WHERE status='waiting_for_capture' AND expires_at < \\$1\\s+ORDER BY expires_at LIMIT \\$2").
+		WithArgs(now, 100).WillReturnRows(rows)
+
+	payments, err := suite.repo.GetExpiringHolds(context.Background(), now, 100)
+
+	suite.Nil(err)
+	suite.Len(payments, 1)
+	suite.Equal("pay-1", payments[0].ProviderPaymentID)
+	suite.Equal(expiresAt, *payments[0].ExpiresAt)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *PaymentRepositorySuite) TestRepository_GetPaymentsToReconcile() {
+	now := time.Now()
+	since := now.Add(-24 * time.Hour)
+	rows := sqlmock.NewRows([]string{"id", "provider_payment_id", "order_id", "amount", "currency", "status", "confirmation_url", "payload", "expires_at", "created_at", "updated_at", "status"}).
+		AddRow(1, "pay-1", 1, 12300, "RUB", "pending", "https://pay.test/1", nil, nil, now, now, "Pending").
+		AddRow(2, "pay-2", 2, 5000, "RUB", "waiting_for_capture", "", nil, now, now, now, "Canceled")
+
+	suite.mock.ExpectQuery("SELECT (.+) FROM payments p JOIN orders o ON o.id=p.order_id\\s+WHERE o.created_at > \\$1 AND \\(o.status=\\$2 OR \\(o.status=\\$3 AND p.status IN \\('pending', 'waiting_for_capture'\\)\\)\\)").
+		WithArgs(since, "Pending", "Canceled", 500).WillReturnRows(rows)
+
+	payments, err := suite.repo.GetPaymentsToReconcile(context.Background(), since, 500)
+
+	suite.Nil(err)
+	suite.Len(payments, 2)
+	suite.Equal("Pending", payments[0].OrderStatus)
+	suite.Equal("pay-2", payments[1].ProviderPaymentID)
+	suite.Equal("Canceled", payments[1].OrderStatus)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/aaanger/ecommerce/internal/payment/model"
+	"github.com/aaanger/ecommerce/pkg/db"
+)
+
+//go:generate mockery --name=IReconciliationRepository
+
+type IReconciliationRepository interface {
+	SaveReport(ctx context.Context, report *model.ReconciliationReport) error
+	GetReports(ctx context.Context, withDiscrepancies bool, limit int) ([]model.ReconciliationReport, error)
+}
+
+type ReconciliationRepository struct {
+	db *sql.DB
+}
+
+func NewReconciliationRepository(db *sql.DB) *ReconciliationRepository {
+	return &ReconciliationRepository{
+		db: db,
+	}
+}
+
+func (r *ReconciliationRepository) SaveReport(ctx context.Context, report *model.ReconciliationReport) error {
+	discrepancies, err := json.Marshal(report.Discrepancies)
+	if err != nil {
+		return err
+	}
+
+	return db.Conn(ctx, r.db).QueryRowContext(ctx, `INSERT INTO reconciliation_reports (started_at, finished_at, checked, failed, discrepancies)
+		VALUES($1, $2, $3, $4, $5) RETURNING id;`, report.StartedAt, report.FinishedAt, report.Checked, report.Failed, discrepancies).Scan(&report.ID)
+}
+
+// GetReports lists the latest reports first, only those that found discrepancies if withDiscrepancies is set.
+func (r *ReconciliationRepository) GetReports(ctx context.Context, withDiscrepancies bool, limit int) ([]model.ReconciliationReport, error) {
+	rows, err := db.Conn(ctx, r.db).QueryContext(ctx, `SELECT id, started_at, finished_at, checked, failed, discrepancies FROM reconciliation_reports
+		WHERE NOT $1 OR jsonb_array_length(discrepancies) > 0 ORDER BY id DESC LIMIT $2;`, withDiscrepancies, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := make([]model.ReconciliationReport, 0)
+
+	for rows.Next() {
+		var report model.ReconciliationReport
+		var discrepancies []byte
+
+		err = rows.Scan(&report.ID, &report.StartedAt, &report.FinishedAt, &report.Checked, &report.Failed, &discrepancies)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = json.Unmarshal(discrepancies, &report.Discrepancies); err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, rows.Err()
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/aaanger/ecommerce/internal/payment/model"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type ReconciliationRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo *ReconciliationRepository
+}
+
+func (suite *ReconciliationRepositorySuite) SetupTest() {
+	var err error
+	suite.db, suite.mock, err = sqlmock.New()
+	assert.NoError(suite.T(), err)
+	suite.repo = NewReconciliationRepository(suite.db)
+}
+
+func TestReconciliationRepositorySuite(t *testing.T) {
+	suite.Run(t, new(ReconciliationRepositorySuite))
+}
+
+// ====================================================================================================================
+
+func (suite *ReconciliationRepositorySuite) TestRepository_SaveReport() {
+	now := time.Now()
+	report := &model.ReconciliationReport{
+		StartedAt:  now,
+		FinishedAt: now,
+		Checked:    2,
+		Discrepancies: []model.Discrepancy{
+			{OrderID: 1, OrderStatus: "Pending", PaymentID: "pay-1", PaymentStatus: "succeeded", Amount: money.FromMinor(12300), Resolution: model.ResolutionOrderConfirmed},
+		},
+	}
+
+	suite.mock.ExpectQuery("INSERT INTO reconciliation_reports \\(started_at, finished_at, checked, failed, discrepancies\\)\\s+VALUES\\(\\$1, \\$2, \\$3, \\$4, \\$5\\) RETURNING id").
+		WithArgs(now, now, 2, 0, sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	err := suite.repo.SaveReport(context.Background(), report)
+
+	suite.Nil(err)
+	suite.Equal(7, report.ID)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *ReconciliationRepositorySuite) TestRepository_GetReports() {
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "started_at", "finished_at", "checked", "failed", "discrepancies"}).
+		AddRow(2, now, now, 3, 1, []byte(`[{"order_id": 1, "order_status": "Pending", "payment_id": "pay-1", "payment_status": "succeeded", "amount": "123.00", "resolution": "order confirmed"}]`))
+
+	suite.mock.ExpectQuery("SELECT id, started_at, finished_at, checked, failed, discrepancies FROM reconciliation_reports\\s+WHERE NOT \\$1 OR jsonb_array_length\\(discrepancies\\) > 0 ORDER BY id DESC LIMIT \\$2").
+		WithArgs(true, 20).WillReturnRows(rows)
+
+	reports, err := suite.repo.GetReports(context.Background(), true, 20)
+
+	suite.Nil(err)
+	suite.Len(reports, 1)
+	suite.Equal(1, reports[0].Failed)
+	suite.Equal("pay-1", reports[0].Discrepancies[0].PaymentID)
+	suite.Equal(money.FromMinor(12300), reports[0].Discrepancies[0].Amount)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
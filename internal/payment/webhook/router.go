@@ -1,10 +1,17 @@
 package webhook
 
 import (
+	"database/sql"
+	"github.com/aaanger/ecommerce/internal/order/service"
+	payment "github.com/aaanger/ecommerce/internal/payment/client"
+	"github.com/aaanger/ecommerce/internal/payment/repository"
+	database "github.com/aaanger/ecommerce/pkg/db"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-func WebhookRoute(r *gin.Engine, log *zap.Logger) {
+func WebhookRoute(r *gin.Engine, db *sql.DB, orderService service.IOrderService, paymentClient payment.PaymentProvider, log *zap.Logger) {
+	h := NewWebhookHandler(orderService, paymentClient, repository.NewEventRepository(db), repository.NewPaymentRepository(db), database.NewTxManager(db), log)
 
+	r.POST("/payment/webhook", h.Handle)
 }
@@ -1,8 +1,15 @@
 package webhook
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	orderModel "github.com/aaanger/ecommerce/internal/order/model"
 	"github.com/aaanger/ecommerce/internal/order/service"
+	payment "github.com/aaanger/ecommerce/internal/payment/client"
 	"github.com/aaanger/ecommerce/internal/payment/model"
+	"github.com/aaanger/ecommerce/internal/payment/repository"
+	"github.com/aaanger/ecommerce/pkg/db"
 	"github.com/aaanger/ecommerce/pkg/response"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -10,15 +17,41 @@ import (
 	"strconv"
 )
 
+const (
+	EventPaymentSucceeded         = "payment.succeeded"
+	EventPaymentWaitingForCapture = "payment.waiting_for_capture"
+	EventPaymentCanceled          = "payment.canceled"
+)
+
+// eventStatuses is the status a payment must have in YooKassa for the notification to be genuine.
+var eventStatuses = map[string]string{
+	EventPaymentSucceeded:         payment.StatusSucceeded,
+	EventPaymentWaitingForCapture: payment.StatusWaitingForCapture,
+	EventPaymentCanceled:          payment.StatusCanceled,
+}
+
+// ErrAmountMismatch is returned when the paid amount differs from the order total.
+var ErrAmountMismatch = errors.New("paid amount does not match the order total")
+
+// Handler applies YooKassa payment notifications to orders. The notification body is not trusted:
+// the payment is fetched back from YooKassa and only its status, metadata and amount are used.
 type Handler struct {
-	orderService service.IOrderService
-	log          *zap.Logger
+	orderService  service.IOrderService
+	paymentClient payment.PaymentProvider
+	events        repository.IEventRepository
+	payments      repository.IPaymentRepository
+	tx            db.Transactor
+	log           *zap.Logger
 }
 
-func NewWebhookHandler(orderService service.IOrderService, log *zap.Logger) *Handler {
+func NewWebhookHandler(orderService service.IOrderService, paymentClient payment.PaymentProvider, events repository.IEventRepository, payments repository.IPaymentRepository, tx db.Transactor, log *zap.Logger) *Handler {
 	return &Handler{
-		orderService: orderService,
-		log:          log,
+		orderService:  orderService,
+		paymentClient: paymentClient,
+		events:        events,
+		payments:      payments,
+		tx:            tx,
+		log:           log,
 	}
 }
 
@@ -30,28 +63,172 @@ func (h *Handler) Handle(c *gin.Context) {
 		return
 	}
 
-	if _, ok := webhook.Object.Metadata["order_id"]; !ok {
-		c.Status(http.StatusNotFound)
+	log := h.log.With(zap.String("event", webhook.Event), zap.String("paymentID", webhook.Object.ID))
+
+	status, ok := eventStatuses[webhook.Event]
+	if !ok {
+		log.Info("Ignoring payment notification")
+		c.Status(http.StatusOK)
+		return
+	}
+
+	paid, err := h.paymentClient.GetPayment(c.Request.Context(), webhook.Object.ID)
+	if errors.Is(err, payment.ErrPaymentNotFound) {
+		log.Warn("Rejected notification for unknown payment", zap.String("ip", c.ClientIP()))
+		response.Error(c, http.StatusBadRequest, "unknown payment")
+		return
+	}
+	if err != nil {
+		log.Error("Failed to verify payment", zap.Error(err))
+		response.Error(c, http.StatusBadGateway, "failed to verify payment")
+		return
+	}
+
+	if paid.Status != status {
+		log.Warn("Rejected notification not matching payment status", zap.String("status", paid.Status), zap.String("ip", c.ClientIP()))
+		response.Error(c, http.StatusBadRequest, "payment status does not match the event")
+		return
+	}
+
+	orderID, err := strconv.Atoi(paid.Metadata["order_id"])
+	if err != nil {
+		log.Warn("Rejected notification for payment without order", zap.Any("metadata", paid.Metadata))
+		response.Error(c, http.StatusBadRequest, "payment has no order")
+		return
+	}
+
+	applied, err := h.Process(c.Request.Context(), webhook.Event, orderID, paid)
+	if errors.Is(err, ErrAmountMismatch) {
+		log.Error("Rejected payment not matching order total", zap.Int("orderID", orderID), zap.String("amount", paid.Amount.Value))
+		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
-	orderID, err := strconv.Atoi(webhook.Object.Metadata["order_id"])
 	if err != nil {
-		c.Status(http.StatusInternalServerError)
+		log.Error("Failed to apply payment notification", zap.Error(err), zap.Int("orderID", orderID))
+		response.Error(c, http.StatusInternalServerError, "failed to apply payment notification")
 		return
 	}
 
-	switch webhook.Event {
-	case "payment.succeeded":
-		if err := h.orderService.ConfirmOrder(c.Request.Context(), orderID); err != nil {
-			response.Error(c, http.StatusInternalServerError, "failed to confirm order")
-			return
+	if !applied {
+		log.Info("Skipped duplicate payment notification", zap.Int("orderID", orderID))
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// Process applies a verified payment event to its order. The event and the payment are recorded in one
+// transaction, the order is moved after it commits so the provider calls it makes do not run inside it.
+// The event is marked applied only once the order has moved, a failure leaves it to be applied again when
+// redelivered. An event already applied is skipped and reported as not applied.
+func (h *Handler) Process(ctx context.Context, event string, orderID int, paid *model.CreatePaymentRes) (bool, error) {
+	fresh := false
+	err := h.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		fresh, err = h.events.SaveEvent(ctx, paid.ID, event, orderID)
+		if err != nil || !fresh {
+			return err
 		}
-	case "payment.canceled":
-		if err := h.orderService.CancelOrder(c.Request.Context(), orderID); err != nil {
-			response.Error(c, http.StatusInternalServerError, "failed to cancel order")
-			return
+
+		return h.savePayment(ctx, orderID, paid)
+	})
+	if err != nil || !fresh {
+		return false, err
+	}
+
+	if err = h.apply(ctx, event, orderID, paid); err != nil {
+		return true, err
+	}
+
+	return true, h.events.MarkApplied(ctx, paid.ID, event)
+}
+
+// savePayment records the payment as YooKassa reported it. Payments whose creation was never recorded
+// by the order service get their first record here.
+func (h *Handler) savePayment(ctx context.Context, orderID int, paid *model.CreatePaymentRes) error {
+	payment, err := model.NewPayment(orderID, paid)
+	if err != nil {
+		return err
+	}
+
+	return h.payments.SavePayment(ctx, payment)
+}
+
+// apply moves the order according to the verified payment. Money held for capture pays for the order
+// just like money taken.
+func (h *Handler) apply(ctx context.Context, event string, orderID int, paid *model.CreatePaymentRes) error {
+	if event != EventPaymentCanceled {
+		return h.confirm(ctx, orderID, paid)
+	}
+
+	order, err := h.orderService.GetOrderByID(ctx, orderID, orderModel.SystemActor)
+	if err != nil {
+		return err
+	}
+
+	// Only the order's own payment failing leaves it unpaid. An order already expired, or paid by another
+	// payment, needs no change.
+	if order.Status != orderModel.StatusPending || (order.PaymentID != "" && order.PaymentID != paid.ID) {
+		h.log.Info("Ignoring canceled payment", zap.Int("orderID", orderID), zap.String("paymentID", paid.ID),
+			zap.String("status", order.Status))
+		return nil
+	}
+
+	return h.orderService.CancelOrder(ctx, orderID, orderModel.SystemActor, "payment canceled")
+}
+
+func (h *Handler) confirm(ctx context.Context, orderID int, paid *model.CreatePaymentRes) error {
+	order, err := h.orderService.GetOrderByID(ctx, orderID, orderModel.SystemActor)
+	if err != nil {
+		return err
+	}
+
+	if order.Status != orderModel.StatusPending {
+		return h.unexpected(ctx, order, paid)
+	}
+
+	amount, err := paid.Amount.Money()
+	if err != nil {
+		return err
+	}
+
+	if amount != order.TotalPrice {
+		return ErrAmountMismatch
+	}
+
+	return h.orderService.ConfirmOrder(ctx, orderID, paid.ID)
+}
+
+// unexpected handles a payment for an order that no longer awaits one.
+func (h *Handler) unexpected(ctx context.Context, order *orderModel.Order, paid *model.CreatePaymentRes) error {
+	log := h.log.With(zap.Int("orderID", order.ID), zap.String("paymentID", paid.ID), zap.String("status", order.Status))
+
+	if order.PaymentID == paid.ID {
+		// The order's own hold was captured.
+		return nil
+	}
+
+	if paid.Status == payment.StatusWaitingForCapture {
+		// The order was canceled or paid by another payment, nothing has been taken yet so the hold is released.
+		log.Warn("Releasing payment hold for an order not awaiting payment")
+		canceled, err := h.paymentClient.CancelPayment(ctx, paid.ID, "cancel-"+paid.ID)
+		if err != nil {
+			return err
 		}
+		return h.savePayment(ctx, order.ID, canceled)
 	}
 
-	c.Status(http.StatusOK)
+	// The money has been taken for an order that will not use it. The refund is keyed by the payment,
+	// so a redelivered notification does not return it twice.
+	log.Warn("Refunding payment for an order not awaiting payment")
+	refund, err := h.paymentClient.CreateRefund(ctx, &model.CreateRefundReq{
+		PaymentID:   paid.ID,
+		Amount:      paid.Amount,
+		Description: fmt.Sprintf("Возврат оплаты по заказу №%d", order.ID),
+	}, "refund-"+paid.ID)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Payment refunded", zap.String("refundID", refund.ID))
+	return nil
 }
@@ -0,0 +1,350 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	orderModel "github.com/aaanger/ecommerce/internal/order/model"
+	orderMocks "github.com/aaanger/ecommerce/internal/order/service/mocks"
+	payment "github.com/aaanger/ecommerce/internal/payment/client"
+	"github.com/aaanger/ecommerce/internal/payment/model"
+	"github.com/aaanger/ecommerce/internal/payment/repository/mocks"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// inlineTx runs the unit of work without a database, the repository mocks take its place. open tells
+// whether the unit of work is running.
+type inlineTx struct {
+	open bool
+}
+
+func (tx *inlineTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx.open = true
+	defer func() { tx.open = false }()
+	return fn(ctx)
+}
+
+type WebhookHandlerSuite struct {
+	suite.Suite
+	orderService *orderMocks.IOrderService
+	events       *mocks.IEventRepository
+	paymentRepo  *mocks.IPaymentRepository
+	yookassa     *httptest.Server
+	payments     map[string]string
+	tx           *inlineTx
+	calls        []string
+	keys         map[string]string
+	router       *gin.Engine
+}
+
+func (suite *WebhookHandlerSuite) SetupTest() {
+	suite.orderService = orderMocks.NewIOrderService(suite.T())
+	suite.events = mocks.NewIEventRepository(suite.T())
+	suite.paymentRepo = mocks.NewIPaymentRepository(suite.T())
+	suite.payments = map[string]string{}
+	suite.tx = &inlineTx{}
+	suite.calls = nil
+	suite.keys = map[string]string{}
+	suite.yookassa = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.calls = append(suite.calls, r.Method+" "+r.URL.Path)
+		suite.keys[r.URL.Path] = r.Header.Get("Idempotence-Key")
+		body, ok := suite.payments[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+
+	paymentClient := payment.NewClient("shop", "secret")
+	paymentClient.APIEndpoint = suite.yookassa.URL + "/"
+
+	h := NewWebhookHandler(suite.orderService, paymentClient, suite.events, suite.paymentRepo, suite.tx, zap.NewNop())
+
+	suite.router = gin.New()
+	suite.router.POST("/payment/webhook", h.Handle)
+}
+
+func (suite *WebhookHandlerSuite) TearDownTest() {
+	suite.yookassa.Close()
+}
+
+func TestWebhookHandlerSuite(t *testing.T) {
+	suite.Run(t, new(WebhookHandlerSuite))
+}
+
+func (suite *WebhookHandlerSuite) notify(event, paymentID, orderID string) *httptest.ResponseRecorder {
+	body := `{"type": "notification", "event": "` + event + `", "object": {"id": "` + paymentID + `", "status": "succeeded", "metadata": {"order_id": "` + orderID + `"}}}`
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/payment/webhook", bytes.NewBufferString(body))
+	suite.router.ServeHTTP(w, r)
+	return w
+}
+
+// =====================================================================================================================
+
+func (suite *WebhookHandlerSuite) TestHandler_PaymentSucceeded() {
+	suite.payments["/payments/pay-1"] = `{"id": "pay-1", "status": "succeeded", "paid": true, "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", EventPaymentSucceeded, 1).Return(true, nil)
+	suite.events.On("MarkApplied", mock.Anything, "pay-1", EventPaymentSucceeded).Return(nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusPending, TotalPrice: money.FromMinor(12300)}, nil)
+	suite.orderService.On("ConfirmOrder", mock.Anything, 1, "pay-1").Return(nil)
+
+	w := suite.notify(EventPaymentSucceeded, "pay-1", "1")
+
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *WebhookHandlerSuite) TestHandler_PaymentStored() {
+	suite.payments["/payments/pay-1"] = `{"id": "pay-1", "status": "succeeded", "paid": true, "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", EventPaymentSucceeded, 1).Return(true, nil)
+	suite.events.On("MarkApplied", mock.Anything, "pay-1", EventPaymentSucceeded).Return(nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.MatchedBy(func(p *model.Payment) bool {
+		return p.ProviderPaymentID == "pay-1" && p.OrderID == 1 && p.Status == "succeeded" &&
+			p.Amount == money.FromMinor(12300) && len(p.Payload) > 0
+	})).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusPending, TotalPrice: money.FromMinor(12300)}, nil)
+	suite.orderService.On("ConfirmOrder", mock.Anything, 1, "pay-1").Return(nil)
+
+	w := suite.notify(EventPaymentSucceeded, "pay-1", "1")
+
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *WebhookHandlerSuite) TestHandler_OrderIDTakenFromProvider() {
+	// The posted body points at order 2, YooKassa says the payment belongs to order 1.
+	suite.payments["/payments/pay-1"] = `{"id": "pay-1", "status": "succeeded", "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", EventPaymentSucceeded, 1).Return(true, nil)
+	suite.events.On("MarkApplied", mock.Anything, "pay-1", EventPaymentSucceeded).Return(nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusPending, TotalPrice: money.FromMinor(12300)}, nil)
+	suite.orderService.On("ConfirmOrder", mock.Anything, 1, "pay-1").Return(nil)
+
+	w := suite.notify(EventPaymentSucceeded, "pay-1", "2")
+
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *WebhookHandlerSuite) TestHandler_OrderMovedAfterCommit() {
+	suite.payments["/payments/pay-1"] = `{"id": "pay-1", "status": "succeeded", "paid": true, "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", EventPaymentSucceeded, 1).Return(true, nil)
+	suite.events.On("MarkApplied", mock.Anything, "pay-1", EventPaymentSucceeded).Return(nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusPending, TotalPrice: money.FromMinor(12300)}, nil)
+	suite.orderService.On("ConfirmOrder", mock.Anything, 1, "pay-1").Return(nil).Run(func(mock.Arguments) {
+		suite.False(suite.tx.open)
+	})
+
+	w := suite.notify(EventPaymentSucceeded, "pay-1", "1")
+
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *WebhookHandlerSuite) TestHandler_FailedOrderChangeNotMarkedApplied() {
+	suite.payments["/payments/pay-1"] = `{"id": "pay-1", "status": "succeeded", "paid": true, "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", EventPaymentSucceeded, 1).Return(true, nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusPending, TotalPrice: money.FromMinor(12300)}, nil)
+	suite.orderService.On("ConfirmOrder", mock.Anything, 1, "pay-1").Return(errors.New("error"))
+
+	w := suite.notify(EventPaymentSucceeded, "pay-1", "1")
+
+	// The event stays unapplied, so the redelivered notification confirms the order.
+	suite.Equal(http.StatusInternalServerError, w.Code)
+	suite.events.AssertNotCalled(suite.T(), "MarkApplied", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *WebhookHandlerSuite) TestHandler_ForgedPayment() {
+	w := suite.notify(EventPaymentSucceeded, "forged", "1")
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(`"unknown payment"`, w.Body.String())
+}
+
+func (suite *WebhookHandlerSuite) TestHandler_StatusMismatch() {
+	suite.payments["/payments/pay-1"] = `{"id": "pay-1", "status": "pending", "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+
+	w := suite.notify(EventPaymentSucceeded, "pay-1", "1")
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *WebhookHandlerSuite) TestHandler_AmountMismatch() {
+	suite.payments["/payments/pay-1"] = `{"id": "pay-1", "status": "succeeded", "amount": {"value": "1.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", EventPaymentSucceeded, 1).Return(true, nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusPending, TotalPrice: money.FromMinor(12300)}, nil)
+
+	w := suite.notify(EventPaymentSucceeded, "pay-1", "1")
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(`"paid amount does not match the order total"`, w.Body.String())
+}
+
+func (suite *WebhookHandlerSuite) TestHandler_DuplicateSkipped() {
+	suite.payments["/payments/pay-1"] = `{"id": "pay-1", "status": "succeeded", "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", EventPaymentSucceeded, 1).Return(false, nil)
+
+	w := suite.notify(EventPaymentSucceeded, "pay-1", "1")
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.orderService.AssertNotCalled(suite.T(), "ConfirmOrder", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// =====================================================================================================================
+
+func (suite *WebhookHandlerSuite) TestHandler_PaymentHeld() {
+	suite.payments["/payments/pay-1"] = `{"id": "pay-1", "status": "waiting_for_capture", "paid": true, "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", EventPaymentWaitingForCapture, 1).Return(true, nil)
+	suite.events.On("MarkApplied", mock.Anything, "pay-1", EventPaymentWaitingForCapture).Return(nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusPending, TotalPrice: money.FromMinor(12300)}, nil)
+	suite.orderService.On("ConfirmOrder", mock.Anything, 1, "pay-1").Return(nil)
+
+	w := suite.notify(EventPaymentWaitingForCapture, "pay-1", "1")
+
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *WebhookHandlerSuite) TestHandler_HeldPaymentCaptured() {
+	suite.payments["/payments/pay-1"] = `{"id": "pay-1", "status": "succeeded", "paid": true, "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", EventPaymentSucceeded, 1).Return(true, nil)
+	suite.events.On("MarkApplied", mock.Anything, "pay-1", EventPaymentSucceeded).Return(nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusDelivering, PaymentID: "pay-1", TotalPrice: money.FromMinor(12300)}, nil)
+
+	w := suite.notify(EventPaymentSucceeded, "pay-1", "1")
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.orderService.AssertNotCalled(suite.T(), "ConfirmOrder", mock.Anything, mock.Anything, mock.Anything)
+	suite.Equal([]string{"GET /payments/pay-1"}, suite.calls)
+}
+
+func (suite *WebhookHandlerSuite) TestHandler_HoldForCanceledOrderReleased() {
+	suite.payments["/payments/pay-1"] = `{"id": "pay-1", "status": "waiting_for_capture", "paid": true, "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+	suite.payments["/payments/pay-1/cancel"] = `{"id": "pay-1", "status": "canceled", "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", EventPaymentWaitingForCapture, 1).Return(true, nil)
+	suite.events.On("MarkApplied", mock.Anything, "pay-1", EventPaymentWaitingForCapture).Return(nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusCanceled, TotalPrice: money.FromMinor(12300)}, nil)
+
+	w := suite.notify(EventPaymentWaitingForCapture, "pay-1", "1")
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal([]string{"GET /payments/pay-1", "POST /payments/pay-1/cancel"}, suite.calls)
+	suite.paymentRepo.AssertCalled(suite.T(), "SavePayment", mock.Anything, mock.MatchedBy(func(p *model.Payment) bool {
+		return p.ProviderPaymentID == "pay-1" && p.Status == "canceled"
+	}))
+}
+
+func (suite *WebhookHandlerSuite) TestHandler_PaymentForCanceledOrderRefunded() {
+	suite.payments["/payments/pay-1"] = `{"id": "pay-1", "status": "succeeded", "paid": true, "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+	suite.payments["/refunds"] = `{"id": "refund-1", "payment_id": "pay-1", "status": "succeeded", "amount": {"value": "123.00", "currency": "RUB"}}`
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", EventPaymentSucceeded, 1).Return(true, nil)
+	suite.events.On("MarkApplied", mock.Anything, "pay-1", EventPaymentSucceeded).Return(nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusCanceled, TotalPrice: money.FromMinor(12300)}, nil)
+
+	w := suite.notify(EventPaymentSucceeded, "pay-1", "1")
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal([]string{"GET /payments/pay-1", "POST /refunds"}, suite.calls)
+	suite.Equal("refund-pay-1", suite.keys["/refunds"])
+}
+
+func (suite *WebhookHandlerSuite) TestHandler_PaymentCanceled() {
+	suite.payments["/payments/pay-1"] = `{"id": "pay-1", "status": "canceled", "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", EventPaymentCanceled, 1).Return(true, nil)
+	suite.events.On("MarkApplied", mock.Anything, "pay-1", EventPaymentCanceled).Return(nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusPending}, nil)
+	suite.orderService.On("CancelOrder", mock.Anything, 1, orderModel.SystemActor, "payment canceled").Return(nil)
+
+	w := suite.notify(EventPaymentCanceled, "pay-1", "1")
+
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *WebhookHandlerSuite) TestHandler_PaymentCanceledOrderAlreadyExpired() {
+	suite.payments["/payments/pay-1"] = `{"id": "pay-1", "status": "canceled", "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", EventPaymentCanceled, 1).Return(true, nil)
+	suite.events.On("MarkApplied", mock.Anything, "pay-1", EventPaymentCanceled).Return(nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusCanceled}, nil)
+
+	w := suite.notify(EventPaymentCanceled, "pay-1", "1")
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.orderService.AssertNotCalled(suite.T(), "CancelOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *WebhookHandlerSuite) TestHandler_PaymentCanceledOrderDelivering() {
+	suite.payments["/payments/pay-1"] = `{"id": "pay-1", "status": "canceled", "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", EventPaymentCanceled, 1).Return(true, nil)
+	suite.events.On("MarkApplied", mock.Anything, "pay-1", EventPaymentCanceled).Return(nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusDelivering, PaymentID: "pay-2"}, nil)
+
+	w := suite.notify(EventPaymentCanceled, "pay-1", "1")
+
+	// A retry of a failed payment does not undo the order paid by another one.
+	suite.Equal(http.StatusOK, w.Code)
+	suite.orderService.AssertNotCalled(suite.T(), "CancelOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *WebhookHandlerSuite) TestHandler_PaymentCanceledOtherPaymentOfPendingOrder() {
+	suite.payments["/payments/pay-1"] = `{"id": "pay-1", "status": "canceled", "amount": {"value": "123.00", "currency": "RUB"}, "metadata": {"order_id": "1"}}`
+
+	suite.events.On("SaveEvent", mock.Anything, "pay-1", EventPaymentCanceled, 1).Return(true, nil)
+	suite.events.On("MarkApplied", mock.Anything, "pay-1", EventPaymentCanceled).Return(nil)
+	suite.paymentRepo.On("SavePayment", mock.Anything, mock.Anything).Return(nil)
+	suite.orderService.On("GetOrderByID", mock.Anything, 1, orderModel.SystemActor).
+		Return(&orderModel.Order{ID: 1, Status: orderModel.StatusPending, PaymentID: "pay-2"}, nil)
+
+	w := suite.notify(EventPaymentCanceled, "pay-1", "1")
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.orderService.AssertNotCalled(suite.T(), "CancelOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *WebhookHandlerSuite) TestHandler_OtherEventIgnored() {
+	w := suite.notify("refund.succeeded", "refund-1", "1")
+
+	suite.Equal(http.StatusOK, w.Code)
+}
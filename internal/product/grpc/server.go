@@ -7,6 +7,7 @@ import (
 	"github.com/aaanger/ecommerce/internal/product/service"
 	"github.com/aaanger/ecommerce/pkg/proto/gen/product"
 	"google.golang.org/grpc"
+	"time"
 )
 
 type ProductGRPCHandler struct {
@@ -20,9 +21,11 @@ func NewProductGRPCServer(service service.IProductService) *ProductGRPCHandler {
 	}
 }
 
-func RegisterProductGRPCServer(srv *grpc.Server, db *sql.DB) {
+func RegisterProductGRPCServer(srv *grpc.Server, db *sql.DB, reservationTTL time.Duration) {
 	repo := repository.NewProductRepository(db)
-	svc := service.NewProductService(repo)
+	variantRepo := repository.NewVariantRepository(db)
+	stockRepo := repository.NewStockRepository(db)
+	svc := service.NewProductService(repo, variantRepo, stockRepo, reservationTTL)
 
 	grpcHandler := NewProductGRPCServer(svc)
 
@@ -32,3 +35,11 @@ func RegisterProductGRPCServer(srv *grpc.Server, db *sql.DB) {
 func (h *ProductGRPCHandler) ReserveProducts(ctx context.Context, req *product.ReserveProductsReq) (*product.ReserveProductsRes, error) {
 	return h.service.ReserveProducts(ctx, req)
 }
+
+func (h *ProductGRPCHandler) UnreserveProducts(ctx context.Context, req *product.UnreserveProductsReq) (*product.ReserveProductsRes, error) {
+	return h.service.UnreserveProducts(ctx, req)
+}
+
+func (h *ProductGRPCHandler) CommitReservation(ctx context.Context, req *product.CommitReservationReq) (*product.ReserveProductsRes, error) {
+	return h.service.CommitReservation(ctx, req)
+}
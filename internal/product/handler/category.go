@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"errors"
+	"github.com/aaanger/ecommerce/internal/product/model"
+	"github.com/aaanger/ecommerce/internal/product/repository"
+	"github.com/aaanger/ecommerce/internal/product/service"
+	"github.com/aaanger/ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type CategoryHandler struct {
+	service service.ICategoryService
+}
+
+func NewCategoryHandler(service service.ICategoryService) *CategoryHandler {
+	return &CategoryHandler{
+		service: service,
+	}
+}
+
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var req model.CategoryReq
+
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid input parameters")
+		return
+	}
+
+	category, err := h.service.CreateCategory(&req)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to create category")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, category)
+}
+
+func (h *CategoryHandler) GetCategoryTree(c *gin.Context) {
+	categories, err := h.service.GetCategoryTree()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get categories")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, categories)
+}
+
+func (h *CategoryHandler) GetCategoryByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid category id")
+		return
+	}
+
+	category, err := h.service.GetCategoryByID(id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get category")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, category)
+}
+
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid category id")
+		return
+	}
+
+	var input model.UpdateCategory
+
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid input parameters")
+		return
+	}
+
+	err = h.service.UpdateCategory(id, input)
+	if errors.Is(err, service.ErrCategoryCycle) {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to update category")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, input)
+}
+
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid category id")
+		return
+	}
+
+	err = h.service.DeleteCategory(id)
+	if errors.Is(err, repository.ErrCategoryHasChildren) {
+		response.Error(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to delete category")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, id)
+}
+
+func (h *CategoryHandler) SetProductCategories(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product id")
+		return
+	}
+
+	var req model.ProductCategoriesReq
+
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid input parameters")
+		return
+	}
+
+	err = h.service.SetProductCategories(productID, req.CategoryIDs)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to set product categories")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, req)
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/aaanger/ecommerce/internal/product/model"
+	"github.com/aaanger/ecommerce/internal/product/repository"
+	"github.com/aaanger/ecommerce/internal/product/service"
+	"github.com/aaanger/ecommerce/internal/product/service/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type CategoryHandlerSuite struct {
+	suite.Suite
+	service *mocks.ICategoryService
+	handler *CategoryHandler
+}
+
+func (suite *CategoryHandlerSuite) SetupTest() {
+	suite.service = mocks.NewICategoryService(suite.T())
+	suite.handler = NewCategoryHandler(suite.service)
+}
+
+func TestCategoryHandlerSuite(t *testing.T) {
+	suite.Run(t, new(CategoryHandlerSuite))
+}
+
+func (suite *CategoryHandlerSuite) TestHandler_GetCategoryTreeSuccess() {
+	tree := []model.Category{
+		{
+			ID:   1,
+			Name: "Electronics",
+			Slug: "electronics",
+			Children: []model.Category{
+				{ID: 2, Name: "Phones", Slug: "phones", ParentID: intPtr(1)},
+			},
+		},
+	}
+
+	suite.service.On("GetCategoryTree").Return(tree, nil)
+
+	router := gin.New()
+	router.GET("/", suite.handler.GetCategoryTree)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+
+	router.ServeHTTP(w, r)
+
+	var res []model.Category
+	_ = json.Unmarshal(w.Body.Bytes(), &res)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(tree, res)
+}
+
+func (suite *CategoryHandlerSuite) TestHandler_UpdateCategoryCycle() {
+	input := model.UpdateCategory{ParentID: intPtr(3)}
+
+	suite.service.On("UpdateCategory", 1, input).Return(service.ErrCategoryCycle)
+
+	router := gin.New()
+	router.PUT("/:id", suite.handler.UpdateCategory)
+
+	requestBody, _ := json.Marshal(input)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("PUT", "/1", bytes.NewBuffer(requestBody))
+
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *CategoryHandlerSuite) TestHandler_DeleteCategoryWithChildren() {
+	suite.service.On("DeleteCategory", 1).Return(repository.ErrCategoryHasChildren)
+
+	router := gin.New()
+	router.DELETE("/:id", suite.handler.DeleteCategory)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("DELETE", "/1", nil)
+
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusConflict, w.Code)
+}
+
+func (suite *CategoryHandlerSuite) TestHandler_SetProductCategoriesSuccess() {
+	suite.service.On("SetProductCategories", 1, []int{2, 3}).Return(nil)
+
+	router := gin.New()
+	router.PUT("/:id/categories", suite.handler.SetProductCategories)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("PUT", "/1/categories", bytes.NewBufferString(`{"category_ids":[2,3]}`))
+
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *CategoryHandlerSuite) TestHandler_SetProductCategoriesServiceFailure() {
+	suite.service.On("SetProductCategories", 1, []int{2}).Return(errors.New("error"))
+
+	router := gin.New()
+	router.PUT("/:id/categories", suite.handler.SetProductCategories)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("PUT", "/1/categories", bytes.NewBufferString(`{"category_ids":[2]}`))
+
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusInternalServerError, w.Code)
+	suite.Equal(`"Failed to set product categories"`, w.Body.String())
+}
@@ -28,6 +28,11 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
+	if product.Price.Amount <= 0 {
+		response.Error(c, http.StatusBadRequest, "Price must be positive")
+		return
+	}
+
 	createdProduct, err := h.service.CreateProduct(&product)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Failed to create product")
@@ -38,7 +43,25 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 }
 
 func (h *ProductHandler) GetProducts(c *gin.Context) {
-	products, err := h.service.GetAllProducts()
+	var req model.ProductSearchReq
+
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters")
+		return
+	}
+
+	if req.MinPrice.IsNegative() || req.MaxPrice.IsNegative() {
+		response.Error(c, http.StatusBadRequest, "Prices must not be negative")
+		return
+	}
+
+	if !req.MaxPrice.IsZero() && req.MinPrice.Cmp(req.MaxPrice) > 0 {
+		response.Error(c, http.StatusBadRequest, "min_price must not exceed max_price")
+		return
+	}
+
+	products, err := h.service.GetProducts(&req)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Failed to get products")
 		return
@@ -77,6 +100,11 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	if input.Price != nil && input.Price.Amount <= 0 {
+		response.Error(c, http.StatusBadRequest, "Price must be positive")
+		return
+	}
+
 	err = h.service.UpdateProduct(id, input)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Failed to update product")
@@ -101,3 +129,93 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 
 	response.JSON(c, http.StatusOK, id)
 }
+
+func (h *ProductHandler) CreateVariant(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product id")
+		return
+	}
+
+	var req model.VariantReq
+
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid input parameters")
+		return
+	}
+
+	if req.Price != nil && req.Price.Amount <= 0 {
+		response.Error(c, http.StatusBadRequest, "Price must be positive")
+		return
+	}
+
+	variant, err := h.service.CreateVariant(productID, &req)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to create variant")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, variant)
+}
+
+func (h *ProductHandler) GetVariants(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product id")
+		return
+	}
+
+	variants, err := h.service.GetVariants(productID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get variants")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, variants)
+}
+
+func (h *ProductHandler) UpdateVariant(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("variantID"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid variant id")
+		return
+	}
+
+	var input model.UpdateVariant
+
+	err = c.ShouldBindJSON(&input)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid input parameters")
+		return
+	}
+
+	if input.Price != nil && input.Price.Amount <= 0 {
+		response.Error(c, http.StatusBadRequest, "Price must be positive")
+		return
+	}
+
+	err = h.service.UpdateVariant(id, input)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to update variant")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, input)
+}
+
+func (h *ProductHandler) DeleteVariant(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("variantID"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid variant id")
+		return
+	}
+
+	err = h.service.DeleteVariant(id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to delete variant")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, id)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/aaanger/ecommerce/internal/product/model"
 	"github.com/aaanger/ecommerce/internal/product/service/mocks"
 	"github.com/aaanger/ecommerce/pkg/lib"
+	"github.com/aaanger/ecommerce/pkg/money"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/suite"
 	"net/http"
@@ -34,7 +35,7 @@ func (suite *ProductHandlerSuite) TestHandler_CreateProductSuccess() {
 	req := &model.ProductReq{
 		Name:        "test",
 		Description: "test",
-		Price:       1,
+		Price:       money.FromMinor(100),
 		Amount:      1,
 		InStock:     true,
 	}
@@ -43,7 +44,7 @@ func (suite *ProductHandlerSuite) TestHandler_CreateProductSuccess() {
 		ID:          1,
 		Name:        "test",
 		Description: "test",
-		Price:       1,
+		Price:       money.FromMinor(100),
 		Amount:      1,
 		InStock:     true,
 	}
@@ -74,11 +75,47 @@ func (suite *ProductHandlerSuite) TestHandler_CreateProductSuccess() {
 	suite.Equal(res, &productRes)
 }
 
-func (suite *ProductHandlerSuite) TestHandler_CreateProductEmptyFields() {
+func (suite *ProductHandlerSuite) TestHandler_CreateProductDecimalPrice() {
 	req := &model.ProductReq{
 		Name:        "test",
 		Description: "test",
-		Price:       1,
+		Price:       money.FromMinor(129990),
+		Amount:      1,
+		InStock:     true,
+	}
+
+	suite.service.On("CreateProduct", req).Return(&model.Product{ID: 1, Price: req.Price}, nil)
+
+	router := gin.New()
+	router.POST("/create", suite.handler.CreateProduct)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/create", bytes.NewBufferString(`{"name":"test","description":"test","price":"1299.90","amount":1,"in_stock":true}`))
+
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Contains(w.Body.String(), `"price":{"value":"1299.90","currency":"RUB"}`)
+}
+
+func (suite *ProductHandlerSuite) TestHandler_CreateProductInvalidPrice() {
+	router := gin.New()
+	router.POST("/create", suite.handler.CreateProduct)
+
+	for _, price := range []string{`"12.345"`, `0`, `"-1"`} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/create", bytes.NewBufferString(`{"name":"test","description":"test","price":`+price+`,"amount":1,"in_stock":true}`))
+
+		router.ServeHTTP(w, r)
+
+		suite.Equal(http.StatusBadRequest, w.Code, price)
+	}
+}
+
+func (suite *ProductHandlerSuite) TestHandler_CreateProductEmptyFields() {
+	req := &model.ProductReq{
+		Description: "test",
+		Price:       money.FromMinor(100),
 		Amount:      1,
 		InStock:     true,
 	}
@@ -106,7 +143,7 @@ func (suite *ProductHandlerSuite) TestHandler_CreateProductServiceFailure() {
 	req := &model.ProductReq{
 		Name:        "test",
 		Description: "test",
-		Price:       1,
+		Price:       money.FromMinor(100),
 		Amount:      1,
 		InStock:     true,
 	}
@@ -134,21 +171,28 @@ func (suite *ProductHandlerSuite) TestHandler_CreateProductServiceFailure() {
 
 // =====================================================================================================================
 
-func (suite *ProductHandlerSuite) TestHandler_GetAllProductsSuccess() {
-	res := []model.Product{
-		{
-			ID:          1,
-			Name:        "1",
-			Description: "1",
-		},
-		{
-			ID:          2,
-			Name:        "2",
-			Description: "2",
+func (suite *ProductHandlerSuite) TestHandler_GetProductsSuccess() {
+	res := &model.ProductListRes{
+		Products: []model.Product{
+			{
+				ID:          1,
+				Name:        "1",
+				Description: "1",
+				Price:       money.FromMinor(100),
+			},
+			{
+				ID:          2,
+				Name:        "2",
+				Description: "2",
+				Price:       money.FromMinor(200),
+			},
 		},
+		Total: 2,
+		Page:  1,
+		Limit: 20,
 	}
 
-	suite.service.On("GetAllProducts").Return(res, nil)
+	suite.service.On("GetProducts", &model.ProductSearchReq{Search: "phone", MinPrice: money.FromMinor(1000), Page: 1, Limit: 20}).Return(res, nil)
 
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
@@ -156,23 +200,36 @@ func (suite *ProductHandlerSuite) TestHandler_GetAllProductsSuccess() {
 		c.Set("role", "moderator")
 		c.Next()
 	})
-	router.GET("/all", suite.handler.GetAllProducts)
+	router.GET("/", suite.handler.GetProducts)
 
 	w := httptest.NewRecorder()
-	r := httptest.NewRequest("GET", "/all", nil)
+	r := httptest.NewRequest("GET", "/?search=phone&min_price=10&page=1&limit=20", nil)
 
 	router.ServeHTTP(w, r)
 
 	var response interface{}
-	var productsRes []model.Product
+	var productsRes model.ProductListRes
 	_ = json.Unmarshal(w.Body.Bytes(), &response)
 	lib.Copy(&productsRes, &response)
 	suite.Equal(http.StatusOK, w.Code)
-	suite.Equal(res, productsRes)
+	suite.Equal(res, &productsRes)
 }
 
-func (suite *ProductHandlerSuite) TestHandler_GetAllProductsServiceFailure() {
-	suite.service.On("GetAllProducts").Return(nil, errors.New("error"))
+func (suite *ProductHandlerSuite) TestHandler_GetProductsInvalidPriceRange() {
+	router := gin.New()
+	router.GET("/", suite.handler.GetProducts)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/?min_price=100&max_price=10", nil)
+
+	router.ServeHTTP(w, r)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(`"min_price must not exceed max_price"`, w.Body.String())
+}
+
+func (suite *ProductHandlerSuite) TestHandler_GetProductsServiceFailure() {
+	suite.service.On("GetProducts", &model.ProductSearchReq{}).Return(nil, errors.New("error"))
 
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
@@ -180,10 +237,10 @@ func (suite *ProductHandlerSuite) TestHandler_GetAllProductsServiceFailure() {
 		c.Set("role", "moderator")
 		c.Next()
 	})
-	router.GET("/all", suite.handler.GetAllProducts)
+	router.GET("/", suite.handler.GetProducts)
 
 	w := httptest.NewRecorder()
-	r := httptest.NewRequest("GET", "/all", nil)
+	r := httptest.NewRequest("GET", "/", nil)
 
 	router.ServeHTTP(w, r)
 
@@ -198,7 +255,7 @@ func (suite *ProductHandlerSuite) TestHandler_GetProductByIDSuccess() {
 		ID:          1,
 		Name:        "test",
 		Description: "test",
-		Price:       1,
+		Price:       money.FromMinor(100),
 		Amount:      1,
 		InStock:     true,
 	}
@@ -257,6 +314,10 @@ func intPtr(i int) *int {
 	return &i
 }
 
+func moneyPtr(m money.Money) *money.Money {
+	return &m
+}
+
 func boolPtr(b bool) *bool {
 	return &b
 }
@@ -265,7 +326,7 @@ func (suite *ProductHandlerSuite) TestHandler_UpdateProductSuccess() {
 	req := model.UpdateProduct{
 		Name:        stringPtr("test"),
 		Description: stringPtr("test"),
-		Price:       intPtr(1),
+		Price:       moneyPtr(money.FromMinor(100)),
 		Amount:      intPtr(1),
 		InStock:     boolPtr(true),
 	}
@@ -300,7 +361,7 @@ func (suite *ProductHandlerSuite) TestHandler_UpdateProductServiceFailure() {
 	req := model.UpdateProduct{
 		Name:        stringPtr("test"),
 		Description: stringPtr("test"),
-		Price:       intPtr(1),
+		Price:       moneyPtr(money.FromMinor(100)),
 		Amount:      intPtr(1),
 		InStock:     boolPtr(true),
 	}
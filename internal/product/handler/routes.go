@@ -10,9 +10,15 @@ import (
 
 func ProductRoutes(r *gin.Engine, db *sql.DB) {
 	repo := repository.NewProductRepository(db)
-	svc := service.NewProductService(repo)
+	variantRepo := repository.NewVariantRepository(db)
+	stockRepo := repository.NewStockRepository(db)
+	svc := service.NewProductService(repo, variantRepo, stockRepo, service.DefaultReservationTTL)
 	h := NewProductHandler(svc)
 
+	categoryRepo := repository.NewCategoryRepository(db)
+	categorySvc := service.NewCategoryService(categoryRepo)
+	categoryHandler := NewCategoryHandler(categorySvc)
+
 	p := r.Group("/products", middleware.UserIdentity)
 
 	p.POST("/create", middleware.ModeratorIdentity, h.CreateProduct)
@@ -20,5 +26,18 @@ func ProductRoutes(r *gin.Engine, db *sql.DB) {
 	p.GET("/:id", h.GetProductByID)
 	p.PUT("/:id", middleware.ModeratorIdentity, h.UpdateProduct)
 	p.DELETE("/:id", middleware.ModeratorIdentity, h.DeleteProduct)
+	p.PUT("/:id/categories", middleware.ModeratorIdentity, categoryHandler.SetProductCategories)
+	p.GET("/:id/variants", h.GetVariants)
+	p.POST("/:id/variants", middleware.ModeratorIdentity, h.CreateVariant)
+	p.PUT("/variants/:variantID", middleware.ModeratorIdentity, h.UpdateVariant)
+	p.DELETE("/variants/:variantID", middleware.ModeratorIdentity, h.DeleteVariant)
 
+	categories := p.Group("/categories")
+	{
+		categories.GET("/", categoryHandler.GetCategoryTree)
+		categories.GET("/:id", categoryHandler.GetCategoryByID)
+		categories.POST("/create", middleware.ModeratorIdentity, categoryHandler.CreateCategory)
+		categories.PUT("/:id", middleware.ModeratorIdentity, categoryHandler.UpdateCategory)
+		categories.DELETE("/:id", middleware.ModeratorIdentity, categoryHandler.DeleteCategory)
+	}
 }
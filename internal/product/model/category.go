@@ -0,0 +1,26 @@
+package model
+
+type Category struct {
+	ID       int        `json:"id"`
+	Name     string     `json:"name"`
+	Slug     string     `json:"slug"`
+	ParentID *int       `json:"parent_id"`
+	Children []Category `json:"children,omitempty"`
+}
+
+type CategoryReq struct {
+	Name     string `json:"name" binding:"required"`
+	Slug     string `json:"slug" binding:"required"`
+	ParentID *int   `json:"parent_id"`
+}
+
+// UpdateCategory changes only the fields given. A parent_id of 0 moves the category to the root.
+type UpdateCategory struct {
+	Name     *string `json:"name"`
+	Slug     *string `json:"slug"`
+	ParentID *int    `json:"parent_id"`
+}
+
+type ProductCategoriesReq struct {
+	CategoryIDs []int `json:"category_ids" binding:"required"`
+}
@@ -1,35 +1,50 @@
 package model
 
+import "github.com/aaanger/ecommerce/pkg/money"
+
 type Product struct {
-	ID          int     `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Amount      int     `json:"amount"`
-	InStock     bool    `json:"in_stock"`
+	ID          int         `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       money.Money `json:"price"`
+	Amount      int         `json:"amount"`
+	InStock     bool        `json:"in_stock"`
+	Variants    []Variant   `json:"variants,omitempty"`
 }
 
 type UpdateProduct struct {
-	Name        *string `json:"name"`
-	Description *string `json:"description"`
-	Price       *int    `json:"price"`
-	Amount      *int    `json:"amount"`
-	InStock     *bool   `json:"in_stock"`
+	Name        *string      `json:"name"`
+	Description *string      `json:"description"`
+	Price       *money.Money `json:"price"`
+	Amount      *int         `json:"amount"`
+	InStock     *bool        `json:"in_stock"`
 }
 
 type ProductReq struct {
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description" binding:"required"`
-	Price       float64 `json:"price" binding:"required"`
-	Amount      int     `json:"amount" binding:"required"`
-	InStock     bool    `json:"in_stock" binding:"required"`
+	Name        string      `json:"name" binding:"required"`
+	Description string      `json:"description" binding:"required"`
+	Price       money.Money `json:"price"`
+	Amount      int         `json:"amount" binding:"required"`
+	InStock     bool        `json:"in_stock" binding:"required"`
 }
 
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
 type ProductSearchReq struct {
-	Category string
-	Search   string
-	MinPrice float64
-	MaxPrice float64
-	Page     int
-	Limit    int
+	Category string      `form:"category"`
+	Search   string      `form:"search"`
+	MinPrice money.Money `form:"min_price"`
+	MaxPrice money.Money `form:"max_price"`
+	Page     int         `form:"page" binding:"gte=0"`
+	Limit    int         `form:"limit" binding:"gte=0"`
+}
+
+type ProductListRes struct {
+	Products []Product `json:"products"`
+	Total    int       `json:"total"`
+	Page     int       `json:"page"`
+	Limit    int       `json:"limit"`
 }
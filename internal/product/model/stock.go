@@ -0,0 +1,21 @@
+package model
+
+const (
+	ReservationActive    = "active"
+	ReservationCommitted = "committed"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+)
+
+type StockItem struct {
+	ProductID int
+	VariantID int
+	Quantity  int
+}
+
+// StockShortage describes a line that could not be reserved.
+type StockShortage struct {
+	ProductID int
+	VariantID int
+	Name      string
+}
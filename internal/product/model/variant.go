@@ -0,0 +1,37 @@
+package model
+
+import "github.com/aaanger/ecommerce/pkg/money"
+
+type Variant struct {
+	ID         int               `json:"id"`
+	ProductID  int               `json:"product_id"`
+	SKU        string            `json:"sku"`
+	Attributes map[string]string `json:"attributes"`
+	Price      *money.Money      `json:"price"`
+	Amount     int               `json:"amount"`
+	InStock    bool              `json:"in_stock"`
+}
+
+// EffectivePrice returns the variant price override, falling back to the product price.
+func (v *Variant) EffectivePrice(product *Product) money.Money {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return product.Price
+}
+
+type VariantReq struct {
+	SKU        string            `json:"sku" binding:"required"`
+	Attributes map[string]string `json:"attributes"`
+	Price      *money.Money      `json:"price"`
+	Amount     int               `json:"amount"`
+	InStock    bool              `json:"in_stock"`
+}
+
+type UpdateVariant struct {
+	SKU        *string           `json:"sku"`
+	Attributes map[string]string `json:"attributes"`
+	Price      *money.Money      `json:"price"`
+	Amount     *int              `json:"amount"`
+	InStock    *bool             `json:"in_stock"`
+}
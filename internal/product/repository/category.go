@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/aaanger/ecommerce/internal/product/model"
+	"github.com/aaanger/ecommerce/pkg/db"
+	"github.com/jackc/pgx/v5/pgconn"
+	"strings"
+)
+
+//go:generate mockery --name=ICategoryRepository
+
+type ICategoryRepository interface {
+	CreateCategory(req *model.CategoryReq) (*model.Category, error)
+	GetAllCategories() ([]model.Category, error)
+	GetCategoryByID(id int) (*model.Category, error)
+	UpdateCategory(id int, input model.UpdateCategory) error
+	DeleteCategory(id int) error
+	SetProductCategories(productID int, categoryIDs []int) error
+}
+
+// ErrCategoryHasChildren is returned when deleting a category that other categories are nested under.
+var ErrCategoryHasChildren = errors.New("category has subcategories")
+
+const foreignKeyViolation = "23503"
+
+type CategoryRepository struct {
+	db *sql.DB
+}
+
+func NewCategoryRepository(db *sql.DB) *CategoryRepository {
+	return &CategoryRepository{
+		db: db,
+	}
+}
+
+func (r *CategoryRepository) CreateCategory(req *model.CategoryReq) (*model.Category, error) {
+	category := model.Category{
+		Name:     req.Name,
+		Slug:     req.Slug,
+		ParentID: req.ParentID,
+	}
+
+	row := r.db.QueryRow(`INSERT INTO categories (name, slug, parent_id) VALUES($1, $2, $3) RETURNING id;`, req.Name, req.Slug, req.ParentID)
+	err := row.Scan(&category.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (r *CategoryRepository) GetAllCategories() ([]model.Category, error) {
+	rows, err := r.db.Query(`SELECT id, name, slug, parent_id FROM categories ORDER BY id;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := make([]model.Category, 0)
+
+	for rows.Next() {
+		var category model.Category
+
+		err = rows.Scan(&category.ID, &category.Name, &category.Slug, &category.ParentID)
+		if err != nil {
+			return nil, err
+		}
+
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+func (r *CategoryRepository) GetCategoryByID(id int) (*model.Category, error) {
+	category := model.Category{
+		ID: id,
+	}
+
+	row := r.db.QueryRow(`SELECT name, slug, parent_id FROM categories WHERE id=$1;`, id)
+	err := row.Scan(&category.Name, &category.Slug, &category.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (r *CategoryRepository) UpdateCategory(id int, input model.UpdateCategory) error {
+	keys := make([]string, 0)
+	values := make([]interface{}, 0)
+	arg := 1
+
+	if input.Name != nil {
+		keys = append(keys, fmt.Sprintf("name=$%d", arg))
+		values = append(values, *input.Name)
+		arg++
+	}
+	if input.Slug != nil {
+		keys = append(keys, fmt.Sprintf("slug=$%d", arg))
+		values = append(values, *input.Slug)
+		arg++
+	}
+	if input.ParentID != nil {
+		keys = append(keys, fmt.Sprintf("parent_id=$%d", arg))
+		// A zero parent moves the category to the root.
+		values = append(values, db.NullInt(*input.ParentID))
+		arg++
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	joinKeys := strings.Join(keys, ", ")
+
+	query := fmt.Sprintf(`UPDATE categories SET %s WHERE id=$%d;`, joinKeys, arg)
+
+	values = append(values, id)
+
+	_, err := r.db.Exec(query, values...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *CategoryRepository) DeleteCategory(id int) error {
+	_, err := r.db.Exec(`DELETE FROM categories WHERE id=$1;`, id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return ErrCategoryHasChildren
+	}
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *CategoryRepository) SetProductCategories(productID int, categoryIDs []int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM product_categories WHERE product_id=$1;`, productID)
+	if err != nil {
+		return err
+	}
+
+	for _, categoryID := range categoryIDs {
+		_, err = tx.Exec(`INSERT INTO product_categories (product_id, category_id) VALUES($1, $2) ON CONFLICT DO NOTHING;`, productID, categoryID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/aaanger/ecommerce/internal/product/model"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type CategoryRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo *CategoryRepository
+}
+
+func (suite *CategoryRepositorySuite) SetupTest() {
+	var err error
+	suite.db, suite.mock, err = sqlmock.New()
+	assert.NoError(suite.T(), err)
+	suite.repo = NewCategoryRepository(suite.db)
+}
+
+func TestCategoryRepositorySuite(t *testing.T) {
+	suite.Run(t, new(CategoryRepositorySuite))
+}
+
+// ====================================================================================================================
+
+func (suite *CategoryRepositorySuite) TestRepository_CreateCategorySuccess() {
+	parentID := 1
+	req := &model.CategoryReq{
+		Name:     "Phones",
+		Slug:     "phones",
+		ParentID: &parentID,
+	}
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(2)
+	suite.mock.ExpectQuery("INSERT INTO categories").WithArgs(req.Name, req.Slug, req.ParentID).WillReturnRows(rows)
+
+	category, err := suite.repo.CreateCategory(req)
+	suite.Nil(err)
+	suite.Equal(2, category.ID)
+	suite.Equal(&parentID, category.ParentID)
+}
+
+func (suite *CategoryRepositorySuite) TestRepository_CreateCategoryFailure() {
+	suite.mock.ExpectQuery("INSERT INTO categories").WillReturnError(errors.New("error"))
+
+	category, err := suite.repo.CreateCategory(&model.CategoryReq{})
+	suite.Nil(category)
+	suite.NotNil(err)
+}
+
+// ====================================================================================================================
+
+func (suite *CategoryRepositorySuite) TestRepository_GetAllCategoriesSuccess() {
+	rows := sqlmock.NewRows([]string{"id", "name", "slug", "parent_id"}).AddRow(1, "Electronics", "electronics", nil).AddRow(2, "Phones", "phones", 1)
+	suite.mock.ExpectQuery("SELECT id, name, slug, parent_id FROM categories").WillReturnRows(rows)
+
+	categories, err := suite.repo.GetAllCategories()
+	suite.Nil(err)
+	suite.Len(categories, 2)
+	suite.Nil(categories[0].ParentID)
+	suite.Equal(1, *categories[1].ParentID)
+}
+
+// ====================================================================================================================
+
+func (suite *CategoryRepositorySuite) TestRepository_UpdateCategoryToRoot() {
+	suite.mock.ExpectExec("UPDATE categories SET parent_id=\\$1 WHERE id=\\$2").
+		WithArgs(nil, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	root := 0
+	err := suite.repo.UpdateCategory(2, model.UpdateCategory{ParentID: &root})
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *CategoryRepositorySuite) TestRepository_UpdateCategoryNothing() {
+	err := suite.repo.UpdateCategory(2, model.UpdateCategory{})
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *CategoryRepositorySuite) TestRepository_DeleteCategoryWithChildren() {
+	suite.mock.ExpectExec("DELETE FROM categories WHERE id=\\$1").
+		WithArgs(1).WillReturnError(&pgconn.PgError{Code: foreignKeyViolation})
+
+	err := suite.repo.DeleteCategory(1)
+
+	suite.ErrorIs(err, ErrCategoryHasChildren)
+}
+
+func (suite *CategoryRepositorySuite) TestRepository_SetProductCategoriesSuccess() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("DELETE FROM product_categories").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("INSERT INTO product_categories").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("INSERT INTO product_categories").WithArgs(1, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	err := suite.repo.SetProductCategories(1, []int{2, 3})
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *CategoryRepositorySuite) TestRepository_SetProductCategoriesRollback() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("DELETE FROM product_categories").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("INSERT INTO product_categories").WithArgs(1, 2).WillReturnError(errors.New("error"))
+	suite.mock.ExpectRollback()
+
+	err := suite.repo.SetProductCategories(1, []int{2})
+	suite.NotNil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,171 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	model "github.com/aaanger/ecommerce/internal/product/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ICategoryRepository is an autogenerated mock type for the ICategoryRepository type
+type ICategoryRepository struct {
+	mock.Mock
+}
+
+// CreateCategory provides a mock function with given fields: req
+func (_m *ICategoryRepository) CreateCategory(req *model.CategoryReq) (*model.Category, error) {
+	ret := _m.Called(req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateCategory")
+	}
+
+	var r0 *model.Category
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*model.CategoryReq) (*model.Category, error)); ok {
+		return rf(req)
+	}
+	if rf, ok := ret.Get(0).(func(*model.CategoryReq) *model.Category); ok {
+		r0 = rf(req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Category)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*model.CategoryReq) error); ok {
+		r1 = rf(req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteCategory provides a mock function with given fields: id
+func (_m *ICategoryRepository) DeleteCategory(id int) error {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteCategory")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAllCategories provides a mock function with no fields
+func (_m *ICategoryRepository) GetAllCategories() ([]model.Category, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAllCategories")
+	}
+
+	var r0 []model.Category
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]model.Category, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []model.Category); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Category)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCategoryByID provides a mock function with given fields: id
+func (_m *ICategoryRepository) GetCategoryByID(id int) (*model.Category, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCategoryByID")
+	}
+
+	var r0 *model.Category
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (*model.Category, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(int) *model.Category); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Category)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetProductCategories provides a mock function with given fields: productID, categoryIDs
+func (_m *ICategoryRepository) SetProductCategories(productID int, categoryIDs []int) error {
+	ret := _m.Called(productID, categoryIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetProductCategories")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, []int) error); ok {
+		r0 = rf(productID, categoryIDs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateCategory provides a mock function with given fields: id, input
+func (_m *ICategoryRepository) UpdateCategory(id int, input model.UpdateCategory) error {
+	ret := _m.Called(id, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateCategory")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, model.UpdateCategory) error); ok {
+		r0 = rf(id, input)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewICategoryRepository creates a new instance of ICategoryRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewICategoryRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ICategoryRepository {
+	mock := &ICategoryRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
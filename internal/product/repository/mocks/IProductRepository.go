@@ -1,4 +1,4 @@
-// Code generated by mockery v2.50.4. DO NOT EDIT.
+// Code generated by mockery v2.53.5. DO NOT EDIT.
 
 package mocks
 
@@ -60,29 +60,29 @@ func (_m *IProductRepository) DeleteProduct(id int) error {
 	return r0
 }
 
-// GetAllProducts provides a mock function with no fields
-func (_m *IProductRepository) GetAllProducts() ([]model.Product, error) {
-	ret := _m.Called()
+// GetProductByID provides a mock function with given fields: id
+func (_m *IProductRepository) GetProductByID(id int) (*model.Product, error) {
+	ret := _m.Called(id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetAllProducts")
+		panic("no return value specified for GetProductByID")
 	}
 
-	var r0 []model.Product
+	var r0 *model.Product
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]model.Product, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(int) (*model.Product, error)); ok {
+		return rf(id)
 	}
-	if rf, ok := ret.Get(0).(func() []model.Product); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(int) *model.Product); ok {
+		r0 = rf(id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]model.Product)
+			r0 = ret.Get(0).(*model.Product)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -90,34 +90,41 @@ func (_m *IProductRepository) GetAllProducts() ([]model.Product, error) {
 	return r0, r1
 }
 
-// GetProductByID provides a mock function with given fields: id
-func (_m *IProductRepository) GetProductByID(id int) (*model.Product, error) {
-	ret := _m.Called(id)
+// GetProducts provides a mock function with given fields: req
+func (_m *IProductRepository) GetProducts(req *model.ProductSearchReq) ([]model.Product, int, error) {
+	ret := _m.Called(req)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetProductByID")
+		panic("no return value specified for GetProducts")
 	}
 
-	var r0 *model.Product
-	var r1 error
-	if rf, ok := ret.Get(0).(func(int) (*model.Product, error)); ok {
-		return rf(id)
+	var r0 []model.Product
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(*model.ProductSearchReq) ([]model.Product, int, error)); ok {
+		return rf(req)
 	}
-	if rf, ok := ret.Get(0).(func(int) *model.Product); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(*model.ProductSearchReq) []model.Product); ok {
+		r0 = rf(req)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.Product)
+			r0 = ret.Get(0).([]model.Product)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(*model.ProductSearchReq) int); ok {
+		r1 = rf(req)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(int)
 	}
 
-	return r0, r1
+	if rf, ok := ret.Get(2).(func(*model.ProductSearchReq) error); ok {
+		r2 = rf(req)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // UpdateProduct provides a mock function with given fields: id, input
@@ -0,0 +1,162 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/aaanger/ecommerce/internal/product/model"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// IStockRepository is an autogenerated mock type for the IStockRepository type
+type IStockRepository struct {
+	mock.Mock
+}
+
+// CommitReservation provides a mock function with given fields: ctx, reservationID, backorder
+func (_m *IStockRepository) CommitReservation(ctx context.Context, reservationID int, backorder bool) ([]model.StockShortage, error) {
+	ret := _m.Called(ctx, reservationID, backorder)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CommitReservation")
+	}
+
+	var r0 []model.StockShortage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, bool) ([]model.StockShortage, error)); ok {
+		return rf(ctx, reservationID, backorder)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, bool) []model.StockShortage); ok {
+		r0 = rf(ctx, reservationID, backorder)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.StockShortage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, bool) error); ok {
+		r1 = rf(ctx, reservationID, backorder)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExpireReservations provides a mock function with given fields: ctx
+func (_m *IStockRepository) ExpireReservations(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExpireReservations")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReleaseReservation provides a mock function with given fields: ctx, reservationID
+func (_m *IStockRepository) ReleaseReservation(ctx context.Context, reservationID int) error {
+	ret := _m.Called(ctx, reservationID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseReservation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, reservationID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReserveStock provides a mock function with given fields: ctx, orderID, items, expiresAt
+func (_m *IStockRepository) ReserveStock(ctx context.Context, orderID int, items []model.StockItem, expiresAt time.Time) (int, []model.StockShortage, error) {
+	ret := _m.Called(ctx, orderID, items, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReserveStock")
+	}
+
+	var r0 int
+	var r1 []model.StockShortage
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, []model.StockItem, time.Time) (int, []model.StockShortage, error)); ok {
+		return rf(ctx, orderID, items, expiresAt)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, []model.StockItem, time.Time) int); ok {
+		r0 = rf(ctx, orderID, items, expiresAt)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, []model.StockItem, time.Time) []model.StockShortage); ok {
+		r1 = rf(ctx, orderID, items, expiresAt)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]model.StockShortage)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int, []model.StockItem, time.Time) error); ok {
+		r2 = rf(ctx, orderID, items, expiresAt)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ReturnItems provides a mock function with given fields: ctx, reservationID, items
+func (_m *IStockRepository) ReturnItems(ctx context.Context, reservationID int, items []model.StockItem) error {
+	ret := _m.Called(ctx, reservationID, items)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReturnItems")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, []model.StockItem) error); ok {
+		r0 = rf(ctx, reservationID, items)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewIStockRepository creates a new instance of IStockRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIStockRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IStockRepository {
+	mock := &IStockRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,153 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	model "github.com/aaanger/ecommerce/internal/product/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IVariantRepository is an autogenerated mock type for the IVariantRepository type
+type IVariantRepository struct {
+	mock.Mock
+}
+
+// CreateVariant provides a mock function with given fields: productID, req
+func (_m *IVariantRepository) CreateVariant(productID int, req *model.VariantReq) (*model.Variant, error) {
+	ret := _m.Called(productID, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateVariant")
+	}
+
+	var r0 *model.Variant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, *model.VariantReq) (*model.Variant, error)); ok {
+		return rf(productID, req)
+	}
+	if rf, ok := ret.Get(0).(func(int, *model.VariantReq) *model.Variant); ok {
+		r0 = rf(productID, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Variant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, *model.VariantReq) error); ok {
+		r1 = rf(productID, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteVariant provides a mock function with given fields: id
+func (_m *IVariantRepository) DeleteVariant(id int) error {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteVariant")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetVariantByID provides a mock function with given fields: id
+func (_m *IVariantRepository) GetVariantByID(id int) (*model.Variant, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVariantByID")
+	}
+
+	var r0 *model.Variant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (*model.Variant, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(int) *model.Variant); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Variant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetVariantsByProductID provides a mock function with given fields: productID
+func (_m *IVariantRepository) GetVariantsByProductID(productID int) ([]model.Variant, error) {
+	ret := _m.Called(productID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVariantsByProductID")
+	}
+
+	var r0 []model.Variant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]model.Variant, error)); ok {
+		return rf(productID)
+	}
+	if rf, ok := ret.Get(0).(func(int) []model.Variant); ok {
+		r0 = rf(productID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Variant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(productID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateVariant provides a mock function with given fields: id, input
+func (_m *IVariantRepository) UpdateVariant(id int, input model.UpdateVariant) error {
+	ret := _m.Called(id, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateVariant")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, model.UpdateVariant) error); ok {
+		r0 = rf(id, input)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewIVariantRepository creates a new instance of IVariantRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIVariantRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IVariantRepository {
+	mock := &IVariantRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -9,9 +9,12 @@ import (
 
 //go:generate mockery --name=IProductRepository
 
+// likeEscaper makes wildcards in a search term match literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type IProductRepository interface {
 	CreateProduct(req *model.ProductReq) (*model.Product, error)
-	GetAllProducts() ([]model.Product, error)
+	GetProducts(req *model.ProductSearchReq) ([]model.Product, int, error)
 	GetProductByID(id int) (*model.Product, error)
 	UpdateProduct(id int, input model.UpdateProduct) error
 	DeleteProduct(id int) error
@@ -45,26 +48,74 @@ func (r *ProductRepository) CreateProduct(req *model.ProductReq) (*model.Product
 	return &product, nil
 }
 
-func (r *ProductRepository) GetAllProducts() ([]model.Product, error) {
-	var products []model.Product
+func (r *ProductRepository) GetProducts(req *model.ProductSearchReq) ([]model.Product, int, error) {
+	conditions := make([]string, 0)
+	values := make([]interface{}, 0)
+	arg := 1
 
-	rows, err := r.db.Query(`SELECT id, name, description, price, amount, in_stock FROM products;`)
+	if req.Category != "" {
+		// Products assigned to the category or to any of its descendants.
+		conditions = append(conditions, fmt.Sprintf(`id IN (SELECT pc.product_id FROM product_categories pc WHERE pc.category_id IN (
+			WITH RECURSIVE tree AS (
+				SELECT id FROM categories WHERE slug=$%d
+				UNION ALL
+				SELECT c.id FROM categories c INNER JOIN tree t ON c.parent_id=t.id
+			) SELECT id FROM tree))`, arg))
+		values = append(values, req.Category)
+		arg++
+	}
+	if req.Search != "" {
+		conditions = append(conditions, fmt.Sprintf(`(name ILIKE $%d ESCAPE '\' OR description ILIKE $%d ESCAPE '\')`, arg, arg))
+		values = append(values, "%"+likeEscaper.Replace(req.Search)+"%")
+		arg++
+	}
+	if !req.MinPrice.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("price >= $%d", arg))
+		values = append(values, req.MinPrice)
+		arg++
+	}
+	if !req.MaxPrice.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("price <= $%d", arg))
+		values = append(values, req.MaxPrice)
+		arg++
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+
+	row := r.db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM products%s;`, where), values...)
+	err := row.Scan(&total)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
+	query := fmt.Sprintf(`SELECT id, name, description, price, amount, in_stock FROM products%s ORDER BY id LIMIT $%d OFFSET $%d;`, where, arg, arg+1)
+	values = append(values, req.Limit, (req.Page-1)*req.Limit)
+
+	rows, err := r.db.Query(query, values...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	products := make([]model.Product, 0)
+
 	for rows.Next() {
 		var product model.Product
 
 		err = rows.Scan(&product.ID, &product.Name, &product.Description, &product.Price, &product.Amount, &product.InStock)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 
 		products = append(products, product)
 	}
 
-	return products, rows.Err()
+	return products, total, rows.Err()
 }
 
 func (r *ProductRepository) GetProductByID(id int) (*model.Product, error) {
@@ -73,7 +124,7 @@ func (r *ProductRepository) GetProductByID(id int) (*model.Product, error) {
 	}
 
 	row := r.db.QueryRow(`SELECT name, description, price, amount, in_stock FROM products WHERE id=$1;`, id)
-	err := row.Scan(&product.Name, &product.Description, &product.Amount, &product.Price, &product.InStock)
+	err := row.Scan(&product.Name, &product.Description, &product.Price, &product.Amount, &product.InStock)
 	if err != nil {
 		return nil, err
 	}
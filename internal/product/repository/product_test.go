@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/aaanger/ecommerce/internal/product/model"
+	"github.com/aaanger/ecommerce/pkg/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"testing"
@@ -33,7 +34,7 @@ func (suite *ProductRepositorySuite) TestRepository_CreateProductSuccess() {
 	req := &model.ProductReq{
 		Name:        "test",
 		Description: "test",
-		Price:       5,
+		Price:       money.FromMinor(500),
 		Amount:      5,
 		InStock:     true,
 	}
@@ -61,18 +62,20 @@ func (suite *ProductRepositorySuite) TestRepository_CreateProductFailure() {
 
 // ====================================================================================================================
 
-func (suite *ProductRepositorySuite) TestRepository_GetAllProductsSuccess() {
-	rows := sqlmock.NewRows([]string{"id", "name", "description", "price", "amount", "in_stock"}).AddRow(1, "test", "test", float64(5), 5, true).AddRow(2, "test2", "test2", float64(10), 10, true)
-	suite.mock.ExpectQuery("SELECT id, name, description, price, amount, in_stock FROM products").WillReturnRows(rows)
+func (suite *ProductRepositorySuite) TestRepository_GetProductsSuccess() {
+	suite.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM products").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
 
-	products, err := suite.repo.GetAllProducts()
+	rows := sqlmock.NewRows([]string{"id", "name", "description", "price", "amount", "in_stock"}).AddRow(1, "test", "test", int64(500), 5, true).AddRow(2, "test2", "test2", int64(1000), 10, true)
+	suite.mock.ExpectQuery("SELECT id, name, description, price, amount, in_stock FROM products ORDER BY id LIMIT").WithArgs(20, 0).WillReturnRows(rows)
+
+	products, total, err := suite.repo.GetProducts(&model.ProductSearchReq{Page: 1, Limit: 20})
 
 	expected := []model.Product{
 		{
 			ID:          1,
 			Name:        "test",
 			Description: "test",
-			Price:       5,
+			Price:       money.FromMinor(500),
 			Amount:      5,
 			InStock:     true,
 		},
@@ -80,7 +83,7 @@ func (suite *ProductRepositorySuite) TestRepository_GetAllProductsSuccess() {
 			ID:          2,
 			Name:        "test2",
 			Description: "test2",
-			Price:       10,
+			Price:       money.FromMinor(1000),
 			Amount:      10,
 			InStock:     true,
 		},
@@ -88,22 +91,75 @@ func (suite *ProductRepositorySuite) TestRepository_GetAllProductsSuccess() {
 
 	suite.NotNil(products)
 	suite.Nil(err)
+	suite.Equal(2, total)
 	suite.Equal(expected, products)
 }
 
-func (suite *ProductRepositorySuite) TestRepository_GetAllProductFailure() {
+func (suite *ProductRepositorySuite) TestRepository_GetProductsWithFilters() {
+	suite.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM products WHERE \\(name ILIKE \\$1 ESCAPE '\\\\' OR description ILIKE \\$1 ESCAPE '\\\\'\\) AND price >= \\$2 AND price <= \\$3").
+		WithArgs("%phone%", int64(1000), int64(10000)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(21))
+
+	rows := sqlmock.NewRows([]string{"id", "name", "description", "price", "amount", "in_stock"}).AddRow(21, "phone", "test", int64(5000), 5, true)
+	suite.mock.ExpectQuery("SELECT id, name, description, price, amount, in_stock FROM products WHERE .* LIMIT \\$4 OFFSET \\$5").
+		WithArgs("%phone%", int64(1000), int64(10000), 20, 20).WillReturnRows(rows)
+
+	products, total, err := suite.repo.GetProducts(&model.ProductSearchReq{
+		Search:   "phone",
+		MinPrice: money.FromMinor(1000),
+		MaxPrice: money.FromMinor(10000),
+		Page:     2,
+		Limit:    20,
+	})
+
+	suite.Nil(err)
+	suite.Equal(21, total)
+	suite.Len(products, 1)
+	suite.Equal(21, products[0].ID)
+}
+
+func (suite *ProductRepositorySuite) TestRepository_GetProductsSearchEscapesWildcards() {
+	suite.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM products WHERE").
+		WithArgs(`%100\% \_off\\%`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	suite.mock.ExpectQuery("SELECT id, name, description, price, amount, in_stock FROM products WHERE").
+		WithArgs(`%100\% \_off\\%`, 20, 0).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "price", "amount", "in_stock"}))
+
+	products, total, err := suite.repo.GetProducts(&model.ProductSearchReq{Search: `100% _off\`, Page: 1, Limit: 20})
+
+	suite.Nil(err)
+	suite.Equal(0, total)
+	suite.Empty(products)
+}
+
+func (suite *ProductRepositorySuite) TestRepository_GetProductsByCategory() {
+	suite.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM products WHERE id IN .*WITH RECURSIVE tree").
+		WithArgs("phones").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	rows := sqlmock.NewRows([]string{"id", "name", "description", "price", "amount", "in_stock"}).AddRow(3, "phone", "test", int64(5000), 5, true)
+	suite.mock.ExpectQuery("SELECT id, name, description, price, amount, in_stock FROM products WHERE id IN").
+		WithArgs("phones", 20, 0).WillReturnRows(rows)
+
+	products, total, err := suite.repo.GetProducts(&model.ProductSearchReq{Category: "phones", Page: 1, Limit: 20})
+
+	suite.Nil(err)
+	suite.Equal(1, total)
+	suite.Equal(3, products[0].ID)
+}
+
+func (suite *ProductRepositorySuite) TestRepository_GetProductsFailure() {
+	suite.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM products").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
 	suite.mock.ExpectQuery("SELECT id, name, description, price, amount, in_stock FROM products")
 
-	products, err := suite.repo.GetAllProducts()
+	products, total, err := suite.repo.GetProducts(&model.ProductSearchReq{Page: 1, Limit: 20})
 
 	suite.Nil(products)
+	suite.Equal(0, total)
 	suite.NotNil(err)
 }
 
 // ====================================================================================================================
 
 func (suite *ProductRepositorySuite) TestRepository_GetProductByIDSuccess() {
-	rows := sqlmock.NewRows([]string{"name", "description", "price", "amount", "in_stock"}).AddRow("test", "test", float64(5), 5, true)
+	rows := sqlmock.NewRows([]string{"name", "description", "price", "amount", "in_stock"}).AddRow("test", "test", int64(500), 5, true)
 
 	suite.mock.ExpectQuery("SELECT name, description, price, amount, in_stock FROM products").WithArgs(1).WillReturnRows(rows)
 
@@ -113,7 +169,7 @@ func (suite *ProductRepositorySuite) TestRepository_GetProductByIDSuccess() {
 		ID:          1,
 		Name:        "test",
 		Description: "test",
-		Price:       5,
+		Price:       money.FromMinor(500),
 		Amount:      5,
 		InStock:     true,
 	}
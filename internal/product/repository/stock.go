@@ -0,0 +1,385 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/aaanger/ecommerce/internal/product/model"
+	"github.com/aaanger/ecommerce/pkg/db"
+	"sort"
+	"time"
+)
+
+//go:generate mockery --name=IStockRepository
+
+type IStockRepository interface {
+	ReserveStock(ctx context.Context, orderID int, items []model.StockItem, expiresAt time.Time) (int, []model.StockShortage, error)
+	ReleaseReservation(ctx context.Context, reservationID int) error
+	ReturnItems(ctx context.Context, reservationID int, items []model.StockItem) error
+	CommitReservation(ctx context.Context, reservationID int, backorder bool) ([]model.StockShortage, error)
+	ExpireReservations(ctx context.Context) (int64, error)
+}
+
+var (
+	ErrReservationNotFound     = errors.New("reservation not found")
+	ErrReservationNotCommitted = errors.New("reservation is not committed")
+	ErrReservationReleased     = errors.New("reservation has been released")
+	ErrInvalidQuantity         = errors.New("quantity must be positive")
+	ErrReturnExceedsReserved   = errors.New("returned quantity exceeds the reserved quantity")
+)
+
+type StockRepository struct {
+	db *sql.DB
+}
+
+func NewStockRepository(db *sql.DB) *StockRepository {
+	return &StockRepository{
+		db: db,
+	}
+}
+
+// ReserveStock places a hold on all items for the order in a single transaction.
+// Product rows are locked while availability (physical stock minus active holds)
+// is checked, so concurrent reservations cannot oversell. If any item falls short,
+// nothing is reserved and the shortages are returned. An item of no or negative quantity gives ErrInvalidQuantity.
+func (r *StockRepository) ReserveStock(ctx context.Context, orderID int, items []model.StockItem, expiresAt time.Time) (int, []model.StockShortage, error) {
+	if err := checkQuantities(items); err != nil {
+		return 0, nil, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	items = mergeStockItems(items)
+
+	shortages, err := r.checkAvailability(ctx, tx, items)
+	if err != nil || len(shortages) > 0 {
+		return 0, shortages, err
+	}
+
+	var reservationID int
+
+	row := tx.QueryRowContext(ctx, `INSERT INTO reservations (order_id, status, expires_at, created_at, updated_at) VALUES($1, $2, $3, current_timestamp, current_timestamp) RETURNING id;`,
+		orderID, model.ReservationActive, expiresAt)
+	err = row.Scan(&reservationID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	for _, item := range items {
+		_, err = tx.ExecContext(ctx, `INSERT INTO reservation_items (reservation_id, product_id, variant_id, quantity) VALUES($1, $2, $3, $4);`,
+			reservationID, item.ProductID, db.NullInt(item.VariantID), item.Quantity)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	return reservationID, nil, tx.Commit()
+}
+
+// ReleaseReservation drops the hold. Releasing twice is a no-op; releasing a committed
+// reservation returns its items to physical stock.
+func (r *StockRepository) ReleaseReservation(ctx context.Context, reservationID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	status, _, err := r.lockReservation(ctx, tx, reservationID)
+	if err != nil {
+		return err
+	}
+
+	if status == model.ReservationReleased {
+		return nil
+	}
+
+	if status == model.ReservationCommitted {
+		items, err := r.reservationItems(ctx, tx, reservationID)
+		if err != nil {
+			return err
+		}
+
+		if err = r.restock(ctx, tx, items); err != nil {
+			return err
+		}
+	}
+
+	err = r.setReservationStatus(ctx, tx, reservationID, model.ReservationReleased)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReturnItems puts part of a committed reservation back in stock, for goods the customer sent back.
+// The reservation stays committed, and an item can never be returned beyond the quantity it reserved.
+func (r *StockRepository) ReturnItems(ctx context.Context, reservationID int, items []model.StockItem) error {
+	if err := checkQuantities(items); err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	status, _, err := r.lockReservation(ctx, tx, reservationID)
+	if err != nil {
+		return err
+	}
+
+	if status != model.ReservationCommitted {
+		return ErrReservationNotCommitted
+	}
+
+	items = mergeStockItems(items)
+
+	for _, item := range items {
+		res, err := tx.ExecContext(ctx, `UPDATE reservation_items SET returned = returned + $1
+			WHERE reservation_id=$2 AND product_id=$3 AND COALESCE(variant_id, 0)=$4 AND returned + $1 <= quantity;`,
+			item.Quantity, reservationID, item.ProductID, item.VariantID)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrReturnExceedsReserved
+		}
+	}
+
+	if err = r.restock(ctx, tx, items); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CommitReservation turns the hold into a physical stock decrement once the order is paid.
+// A hold that has already expired is re-checked against current availability first, a released one
+// gives ErrReservationReleased. With backorder the hold is committed even when stock no longer covers
+// it, the amount goes below zero and the shortages are still returned so the caller can report them.
+func (r *StockRepository) CommitReservation(ctx context.Context, reservationID int, backorder bool) ([]model.StockShortage, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	status, expiresAt, err := r.lockReservation(ctx, tx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == model.ReservationCommitted {
+		return nil, nil
+	}
+	if status == model.ReservationReleased {
+		// The order was canceled, its hold must not come back to life.
+		return nil, ErrReservationReleased
+	}
+
+	items, err := r.reservationItems(ctx, tx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var shortages []model.StockShortage
+
+	if status != model.ReservationActive || !expiresAt.After(time.Now()) {
+		shortages, err = r.checkAvailability(ctx, tx, items)
+		if err != nil || (len(shortages) > 0 && !backorder) {
+			return shortages, err
+		}
+	}
+
+	for _, item := range items {
+		if item.VariantID != 0 {
+			_, err = tx.ExecContext(ctx, `UPDATE product_variants SET amount = amount - $1, in_stock = amount - $1 > 0 WHERE id=$2;`, item.Quantity, item.VariantID)
+		} else {
+			_, err = tx.ExecContext(ctx, `UPDATE products SET amount = amount - $1, in_stock = amount - $1 > 0 WHERE id=$2;`, item.Quantity, item.ProductID)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = r.setReservationStatus(ctx, tx, reservationID, model.ReservationCommitted)
+	if err != nil {
+		return nil, err
+	}
+
+	return shortages, tx.Commit()
+}
+
+// ExpireReservations marks active holds past their deadline as expired. Holds never
+// touch physical stock, so expiring them is enough to make the stock available again.
+func (r *StockRepository) ExpireReservations(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE reservations SET status=$1, updated_at=current_timestamp WHERE status=$2 AND expires_at <= current_timestamp;`,
+		model.ReservationExpired, model.ReservationActive)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (r *StockRepository) checkAvailability(ctx context.Context, tx *sql.Tx, items []model.StockItem) ([]model.StockShortage, error) {
+	var shortages []model.StockShortage
+
+	for _, item := range items {
+		shortage := model.StockShortage{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+		}
+
+		var amount, held int
+		var inStock bool
+		var row *sql.Row
+
+		if item.VariantID != 0 {
+			row = tx.QueryRowContext(ctx, `SELECT p.name || ' (' || v.sku || ')', v.amount, v.in_stock FROM product_variants v INNER JOIN products p ON p.id=v.product_id WHERE v.id=$1 AND v.product_id=$2 FOR UPDATE OF v;`,
+				item.VariantID, item.ProductID)
+		} else {
+			row = tx.QueryRowContext(ctx, `SELECT name, amount, in_stock FROM products WHERE id=$1 FOR UPDATE;`, item.ProductID)
+		}
+
+		err := row.Scan(&shortage.Name, &amount, &inStock)
+		if errors.Is(err, sql.ErrNoRows) {
+			shortages = append(shortages, shortage)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row = tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(ri.quantity), 0) FROM reservation_items ri INNER JOIN reservations r ON r.id=ri.reservation_id
+			WHERE r.status=$1 AND r.expires_at > current_timestamp AND ri.product_id=$2 AND COALESCE(ri.variant_id, 0)=$3;`,
+			model.ReservationActive, item.ProductID, item.VariantID)
+		err = row.Scan(&held)
+		if err != nil {
+			return nil, err
+		}
+
+		if !inStock || amount-held < item.Quantity {
+			shortages = append(shortages, shortage)
+		}
+	}
+
+	return shortages, nil
+}
+
+func (r *StockRepository) lockReservation(ctx context.Context, tx *sql.Tx, reservationID int) (string, time.Time, error) {
+	var status string
+	var expiresAt time.Time
+
+	row := tx.QueryRowContext(ctx, `SELECT status, expires_at FROM reservations WHERE id=$1 FOR UPDATE;`, reservationID)
+	err := row.Scan(&status, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", time.Time{}, ErrReservationNotFound
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return status, expiresAt, nil
+}
+
+func (r *StockRepository) restock(ctx context.Context, tx *sql.Tx, items []model.StockItem) error {
+	for _, item := range items {
+		var err error
+		if item.VariantID != 0 {
+			_, err = tx.ExecContext(ctx, `UPDATE product_variants SET amount = amount + $1, in_stock = true WHERE id=$2;`, item.Quantity, item.VariantID)
+		} else {
+			_, err = tx.ExecContext(ctx, `UPDATE products SET amount = amount + $1, in_stock = true WHERE id=$2;`, item.Quantity, item.ProductID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reservationItems lists what the reservation still holds, items already returned are left out. Items come
+// in the order mergeStockItems sorts them, so stock rows are always locked in the same order.
+func (r *StockRepository) reservationItems(ctx context.Context, tx *sql.Tx, reservationID int) ([]model.StockItem, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT product_id, variant_id, quantity - returned FROM reservation_items
+		WHERE reservation_id=$1 AND quantity > returned ORDER BY product_id, COALESCE(variant_id, 0);`, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []model.StockItem
+
+	for rows.Next() {
+		var item model.StockItem
+		var variantID sql.NullInt64
+
+		err = rows.Scan(&item.ProductID, &variantID, &item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+
+		item.VariantID = int(variantID.Int64)
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *StockRepository) setReservationStatus(ctx context.Context, tx *sql.Tx, reservationID int, status string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE reservations SET status=$1, updated_at=current_timestamp WHERE id=$2;`, status, reservationID)
+	return err
+}
+
+// checkQuantities rejects items that would add stock instead of taking it.
+func checkQuantities(items []model.StockItem) error {
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return fmt.Errorf("%w: %d of product %d", ErrInvalidQuantity, item.Quantity, item.ProductID)
+		}
+	}
+	return nil
+}
+
+// mergeStockItems sums quantities of repeated lines and sorts them so that
+// concurrent transactions always lock rows in the same order.
+func mergeStockItems(items []model.StockItem) []model.StockItem {
+	type key struct{ productID, variantID int }
+
+	quantities := make(map[key]int)
+	for _, item := range items {
+		quantities[key{item.ProductID, item.VariantID}] += item.Quantity
+	}
+
+	merged := make([]model.StockItem, 0, len(quantities))
+	for k, quantity := range quantities {
+		merged = append(merged, model.StockItem{
+			ProductID: k.productID,
+			VariantID: k.variantID,
+			Quantity:  quantity,
+		})
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].ProductID != merged[j].ProductID {
+			return merged[i].ProductID < merged[j].ProductID
+		}
+		return merged[i].VariantID < merged[j].VariantID
+	})
+
+	return merged
+}
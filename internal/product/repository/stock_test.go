@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/aaanger/ecommerce/internal/product/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type StockRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo *StockRepository
+}
+
+func (suite *StockRepositorySuite) SetupTest() {
+	var err error
+	suite.db, suite.mock, err = sqlmock.New()
+	assert.NoError(suite.T(), err)
+	suite.repo = NewStockRepository(suite.db)
+}
+
+func TestStockRepositorySuite(t *testing.T) {
+	suite.Run(t, new(StockRepositorySuite))
+}
+
+// ====================================================================================================================
+
+func (suite *StockRepositorySuite) expectAvailability(productID, variantID int, name string, amount, held int) {
+	if variantID != 0 {
+		suite.mock.ExpectQuery("SELECT p.name").WithArgs(variantID, productID).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "amount", "in_stock"}).AddRow(name, amount, amount > 0))
+	} else {
+		suite.mock.ExpectQuery("SELECT name, amount, in_stock FROM products WHERE id=\\$1 FOR UPDATE").WithArgs(productID).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "amount", "in_stock"}).AddRow(name, amount, amount > 0))
+	}
+	suite.mock.ExpectQuery("SELECT COALESCE\\(SUM\\(ri.quantity\\), 0\\)").WithArgs(model.ReservationActive, productID, variantID).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(held))
+}
+
+func (suite *StockRepositorySuite) TestRepository_ReserveStockSuccess() {
+	expiresAt := time.Now().Add(time.Hour)
+
+	suite.mock.ExpectBegin()
+	suite.expectAvailability(1, 0, "Phone", 5, 2)
+	suite.expectAvailability(2, 5, "Shirt (shirt-xl)", 1, 0)
+	suite.mock.ExpectQuery("INSERT INTO reservations").WithArgs(10, model.ReservationActive, expiresAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	suite.mock.ExpectExec("INSERT INTO reservation_items").WithArgs(7, 1, nil, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("INSERT INTO reservation_items").WithArgs(7, 2, 5, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	reservationID, shortages, err := suite.repo.ReserveStock(context.Background(), 10, []model.StockItem{
+		{ProductID: 2, VariantID: 5, Quantity: 1},
+		{ProductID: 1, Quantity: 1},
+		{ProductID: 1, Quantity: 2},
+	}, expiresAt)
+
+	suite.Nil(err)
+	suite.Nil(shortages)
+	suite.Equal(7, reservationID)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StockRepositorySuite) TestRepository_ReserveStockShortageRollsBack() {
+	suite.mock.ExpectBegin()
+	suite.expectAvailability(1, 0, "Mouse", 5, 0)
+	suite.expectAvailability(2, 0, "Phone", 5, 2)
+	suite.expectAvailability(3, 7, "Shirt (shirt-xl)", 0, 0)
+	suite.mock.ExpectRollback()
+
+	reservationID, shortages, err := suite.repo.ReserveStock(context.Background(), 10, []model.StockItem{
+		{ProductID: 1, Quantity: 1},
+		{ProductID: 2, Quantity: 4},
+		{ProductID: 3, VariantID: 7, Quantity: 1},
+	}, time.Now().Add(time.Hour))
+
+	suite.Nil(err)
+	suite.Equal(0, reservationID)
+	suite.Equal([]model.StockShortage{
+		{ProductID: 2, Name: "Phone"},
+		{ProductID: 3, VariantID: 7, Name: "Shirt (shirt-xl)"},
+	}, shortages)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StockRepositorySuite) TestRepository_ReserveStockNegativeQuantity() {
+	// A negative line would add stock on commit and lower the order total.
+	reservationID, shortages, err := suite.repo.ReserveStock(context.Background(), 10, []model.StockItem{
+		{ProductID: 1, Quantity: 10},
+		{ProductID: 2, Quantity: -9},
+	}, time.Now())
+
+	suite.ErrorIs(err, ErrInvalidQuantity)
+	suite.Equal(0, reservationID)
+	suite.Nil(shortages)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StockRepositorySuite) TestRepository_ReserveStockZeroQuantity() {
+	_, _, err := suite.repo.ReserveStock(context.Background(), 10, []model.StockItem{{ProductID: 1, Quantity: 0}}, time.Now())
+
+	suite.ErrorIs(err, ErrInvalidQuantity)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StockRepositorySuite) TestRepository_ReserveStockFailure() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT name, amount, in_stock FROM products").WithArgs(1).WillReturnError(errors.New("error"))
+	suite.mock.ExpectRollback()
+
+	reservationID, shortages, err := suite.repo.ReserveStock(context.Background(), 10, []model.StockItem{{ProductID: 1, Quantity: 1}}, time.Now())
+
+	suite.NotNil(err)
+	suite.Equal(0, reservationID)
+	suite.Nil(shortages)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+// ====================================================================================================================
+
+func (suite *StockRepositorySuite) TestRepository_ReleaseReservationActive() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT status, expires_at FROM reservations WHERE id=\\$1 FOR UPDATE").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "expires_at"}).AddRow(model.ReservationActive, time.Now().Add(time.Hour)))
+	suite.mock.ExpectExec("UPDATE reservations SET status=\\$1").WithArgs(model.ReservationReleased, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	err := suite.repo.ReleaseReservation(context.Background(), 7)
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StockRepositorySuite) TestRepository_ReleaseReservationCommittedRestocks() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT status, expires_at FROM reservations").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "expires_at"}).AddRow(model.ReservationCommitted, time.Now()))
+	// Items are locked in the order mergeStockItems sorts them, a product without a variant first.
+	suite.mock.ExpectQuery("SELECT product_id, variant_id, quantity - returned FROM reservation_items\\s+WHERE reservation_id=\\$1 AND quantity > returned ORDER BY product_id, COALESCE\\(variant_id, 0\\)").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "variant_id", "quantity"}).AddRow(1, nil, 2).AddRow(3, 4, 1))
+	suite.mock.ExpectExec("UPDATE products SET amount = amount \\+ \\$1").WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("UPDATE product_variants SET amount = amount \\+ \\$1").WithArgs(1, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("UPDATE reservations SET status=\\$1").WithArgs(model.ReservationReleased, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	err := suite.repo.ReleaseReservation(context.Background(), 7)
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StockRepositorySuite) TestRepository_ReleaseReservationTwice() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT status, expires_at FROM reservations").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "expires_at"}).AddRow(model.ReservationReleased, time.Now()))
+	suite.mock.ExpectRollback()
+
+	err := suite.repo.ReleaseReservation(context.Background(), 7)
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StockRepositorySuite) TestRepository_ReleaseReservationNotFound() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT status, expires_at FROM reservations").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "expires_at"}))
+	suite.mock.ExpectRollback()
+
+	err := suite.repo.ReleaseReservation(context.Background(), 7)
+
+	suite.ErrorIs(err, ErrReservationNotFound)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+// ====================================================================================================================
+
+func (suite *StockRepositorySuite) TestRepository_ReturnItemsRestocks() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT status, expires_at FROM reservations").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "expires_at"}).AddRow(model.ReservationCommitted, time.Now()))
+	suite.mock.ExpectExec("UPDATE reservation_items SET returned = returned \\+ \\$1").WithArgs(1, 7, 3, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("UPDATE product_variants SET amount = amount \\+ \\$1").WithArgs(1, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	err := suite.repo.ReturnItems(context.Background(), 7, []model.StockItem{{ProductID: 3, VariantID: 4, Quantity: 1}})
+
+	suite.Nil(err)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StockRepositorySuite) TestRepository_ReturnItemsExceedsReserved() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT status, expires_at FROM reservations").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "expires_at"}).AddRow(model.ReservationCommitted, time.Now()))
+	suite.mock.ExpectExec("UPDATE reservation_items SET returned = returned \\+ \\$1").WithArgs(3, 7, 1, 0).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectRollback()
+
+	err := suite.repo.ReturnItems(context.Background(), 7, []model.StockItem{{ProductID: 1, Quantity: 3}})
+
+	suite.ErrorIs(err, ErrReturnExceedsReserved)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StockRepositorySuite) TestRepository_ReturnItemsNotCommitted() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT status, expires_at FROM reservations").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "expires_at"}).AddRow(model.ReservationActive, time.Now().Add(time.Hour)))
+	suite.mock.ExpectRollback()
+
+	err := suite.repo.ReturnItems(context.Background(), 7, []model.StockItem{{ProductID: 1, Quantity: 1}})
+
+	suite.ErrorIs(err, ErrReservationNotCommitted)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+// ====================================================================================================================
+
+func (suite *StockRepositorySuite) TestRepository_CommitReservationSuccess() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT status, expires_at FROM reservations").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "expires_at"}).AddRow(model.ReservationActive, time.Now().Add(time.Hour)))
+	suite.mock.ExpectQuery("SELECT product_id, variant_id, quantity - returned FROM reservation_items").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "variant_id", "quantity"}).AddRow(1, nil, 2))
+	suite.mock.ExpectExec("UPDATE products SET amount = amount - \\$1").WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("UPDATE reservations SET status=\\$1").WithArgs(model.ReservationCommitted, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	shortages, err := suite.repo.CommitReservation(context.Background(), 7, false)
+
+	suite.Nil(err)
+	suite.Nil(shortages)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StockRepositorySuite) TestRepository_CommitReservationExpiredShortage() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT status, expires_at FROM reservations").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "expires_at"}).AddRow(model.ReservationExpired, time.Now().Add(-time.Hour)))
+	suite.mock.ExpectQuery("SELECT product_id, variant_id, quantity - returned FROM reservation_items").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "variant_id", "quantity"}).AddRow(1, nil, 2))
+	suite.expectAvailability(1, 0, "Phone", 3, 2)
+	suite.mock.ExpectRollback()
+
+	shortages, err := suite.repo.CommitReservation(context.Background(), 7, false)
+
+	suite.Nil(err)
+	suite.Equal([]model.StockShortage{{ProductID: 1, Name: "Phone"}}, shortages)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StockRepositorySuite) TestRepository_CommitReservationReleased() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT status, expires_at FROM reservations").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "expires_at"}).AddRow(model.ReservationReleased, time.Now().Add(time.Hour)))
+	suite.mock.ExpectRollback()
+
+	shortages, err := suite.repo.CommitReservation(context.Background(), 7, true)
+
+	suite.ErrorIs(err, ErrReservationReleased)
+	suite.Nil(shortages)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StockRepositorySuite) TestRepository_CommitReservationBackorder() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("SELECT status, expires_at FROM reservations").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "expires_at"}).AddRow(model.ReservationExpired, time.Now().Add(-time.Hour)))
+	suite.mock.ExpectQuery("SELECT product_id, variant_id, quantity - returned FROM reservation_items").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "variant_id", "quantity"}).AddRow(1, nil, 2))
+	suite.expectAvailability(1, 0, "Phone", 3, 2)
+	suite.mock.ExpectExec("UPDATE products SET amount = amount - \\$1").WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("UPDATE reservations SET status=\\$1").WithArgs(model.ReservationCommitted, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	shortages, err := suite.repo.CommitReservation(context.Background(), 7, true)
+
+	suite.Nil(err)
+	suite.Equal([]model.StockShortage{{ProductID: 1, Name: "Phone"}}, shortages)
+	suite.Nil(suite.mock.ExpectationsWereMet())
+}
+
+// ====================================================================================================================
+
+func (suite *StockRepositorySuite) TestRepository_ExpireReservations() {
+	suite.mock.ExpectExec("UPDATE reservations SET status=\\$1, updated_at=current_timestamp WHERE status=\\$2 AND expires_at <= current_timestamp").
+		WithArgs(model.ReservationExpired, model.ReservationActive).WillReturnResult(sqlmock.NewResult(0, 3))
+
+	expired, err := suite.repo.ExpireReservations(context.Background())
+
+	suite.Nil(err)
+	suite.Equal(int64(3), expired)
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/aaanger/ecommerce/internal/product/model"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"strings"
+)
+
+//go:generate mockery --name=IVariantRepository
+
+type IVariantRepository interface {
+	CreateVariant(productID int, req *model.VariantReq) (*model.Variant, error)
+	GetVariantsByProductID(productID int) ([]model.Variant, error)
+	GetVariantByID(id int) (*model.Variant, error)
+	UpdateVariant(id int, input model.UpdateVariant) error
+	DeleteVariant(id int) error
+}
+
+type VariantRepository struct {
+	db *sql.DB
+}
+
+func NewVariantRepository(db *sql.DB) *VariantRepository {
+	return &VariantRepository{
+		db: db,
+	}
+}
+
+func (r *VariantRepository) CreateVariant(productID int, req *model.VariantReq) (*model.Variant, error) {
+	variant := model.Variant{
+		ProductID:  productID,
+		SKU:        req.SKU,
+		Attributes: req.Attributes,
+		Price:      req.Price,
+		Amount:     req.Amount,
+		InStock:    req.InStock,
+	}
+
+	if variant.Attributes == nil {
+		variant.Attributes = map[string]string{}
+	}
+
+	attributes, err := json.Marshal(variant.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	row := r.db.QueryRow(`INSERT INTO product_variants (product_id, sku, attributes, price, amount, in_stock) VALUES($1, $2, $3, $4, $5, $6) RETURNING id;`,
+		productID, req.SKU, attributes, req.Price, req.Amount, req.InStock)
+	err = row.Scan(&variant.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &variant, nil
+}
+
+func (r *VariantRepository) GetVariantsByProductID(productID int) ([]model.Variant, error) {
+	rows, err := r.db.Query(`SELECT id, product_id, sku, attributes, price, amount, in_stock FROM product_variants WHERE product_id=$1 ORDER BY id;`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := make([]model.Variant, 0)
+
+	for rows.Next() {
+		variant, err := scanVariant(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		variants = append(variants, *variant)
+	}
+
+	return variants, rows.Err()
+}
+
+func (r *VariantRepository) GetVariantByID(id int) (*model.Variant, error) {
+	row := r.db.QueryRow(`SELECT id, product_id, sku, attributes, price, amount, in_stock FROM product_variants WHERE id=$1;`, id)
+	return scanVariant(row)
+}
+
+func (r *VariantRepository) UpdateVariant(id int, input model.UpdateVariant) error {
+	keys := make([]string, 0)
+	values := make([]interface{}, 0)
+	arg := 1
+
+	if input.SKU != nil {
+		keys = append(keys, fmt.Sprintf("sku=$%d", arg))
+		values = append(values, *input.SKU)
+		arg++
+	}
+	if input.Attributes != nil {
+		attributes, err := json.Marshal(input.Attributes)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, fmt.Sprintf("attributes=$%d", arg))
+		values = append(values, attributes)
+		arg++
+	}
+	if input.Price != nil {
+		keys = append(keys, fmt.Sprintf("price=$%d", arg))
+		values = append(values, *input.Price)
+		arg++
+	}
+	if input.Amount != nil {
+		keys = append(keys, fmt.Sprintf("amount=$%d", arg))
+		values = append(values, *input.Amount)
+		arg++
+	}
+	if input.InStock != nil {
+		keys = append(keys, fmt.Sprintf("in_stock=$%d", arg))
+		values = append(values, *input.InStock)
+		arg++
+	}
+
+	joinKeys := strings.Join(keys, ", ")
+
+	query := fmt.Sprintf(`UPDATE product_variants SET %s WHERE id=$%d;`, joinKeys, arg)
+
+	values = append(values, id)
+
+	_, err := r.db.Exec(query, values...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *VariantRepository) DeleteVariant(id int) error {
+	_, err := r.db.Exec(`DELETE FROM product_variants WHERE id=$1;`, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanVariant(row scanner) (*model.Variant, error) {
+	var variant model.Variant
+	var attributes []byte
+	var price sql.NullInt64
+
+	err := row.Scan(&variant.ID, &variant.ProductID, &variant.SKU, &attributes, &price, &variant.Amount, &variant.InStock)
+	if err != nil {
+		return nil, err
+	}
+
+	if price.Valid {
+		p := money.FromMinor(price.Int64)
+		variant.Price = &p
+	}
+
+	err = json.Unmarshal(attributes, &variant.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &variant, nil
+}
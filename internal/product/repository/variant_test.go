@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/aaanger/ecommerce/internal/product/model"
+	"github.com/aaanger/ecommerce/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type VariantRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo *VariantRepository
+}
+
+func (suite *VariantRepositorySuite) SetupTest() {
+	var err error
+	suite.db, suite.mock, err = sqlmock.New()
+	assert.NoError(suite.T(), err)
+	suite.repo = NewVariantRepository(suite.db)
+}
+
+func TestVariantRepositorySuite(t *testing.T) {
+	suite.Run(t, new(VariantRepositorySuite))
+}
+
+// ====================================================================================================================
+
+func (suite *VariantRepositorySuite) TestRepository_CreateVariantSuccess() {
+	price := money.FromMinor(1500)
+	req := &model.VariantReq{
+		SKU:        "shirt-red-xl",
+		Attributes: map[string]string{"color": "red", "size": "XL"},
+		Price:      &price,
+		Amount:     3,
+		InStock:    true,
+	}
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+	suite.mock.ExpectQuery("INSERT INTO product_variants").
+		WithArgs(1, req.SKU, []byte(`{"color":"red","size":"XL"}`), req.Price, req.Amount, req.InStock).WillReturnRows(rows)
+
+	variant, err := suite.repo.CreateVariant(1, req)
+
+	suite.Nil(err)
+	suite.Equal(1, variant.ID)
+	suite.Equal(1, variant.ProductID)
+	suite.Equal("red", variant.Attributes["color"])
+}
+
+func (suite *VariantRepositorySuite) TestRepository_CreateVariantFailure() {
+	suite.mock.ExpectQuery("INSERT INTO product_variants").WillReturnError(errors.New("error"))
+
+	variant, err := suite.repo.CreateVariant(1, &model.VariantReq{SKU: "test"})
+
+	suite.Nil(variant)
+	suite.NotNil(err)
+}
+
+// ====================================================================================================================
+
+func (suite *VariantRepositorySuite) TestRepository_GetVariantByIDSuccess() {
+	rows := sqlmock.NewRows([]string{"id", "product_id", "sku", "attributes", "price", "amount", "in_stock"}).
+		AddRow(2, 1, "shirt-red-xl", []byte(`{"size":"XL"}`), nil, 3, true)
+	suite.mock.ExpectQuery("SELECT id, product_id, sku, attributes, price, amount, in_stock FROM product_variants WHERE id=\\$1").
+		WithArgs(2).WillReturnRows(rows)
+
+	variant, err := suite.repo.GetVariantByID(2)
+
+	suite.Nil(err)
+	suite.Equal(&model.Variant{
+		ID:         2,
+		ProductID:  1,
+		SKU:        "shirt-red-xl",
+		Attributes: map[string]string{"size": "XL"},
+		Amount:     3,
+		InStock:    true,
+	}, variant)
+}
+
+func (suite *VariantRepositorySuite) TestRepository_GetVariantsByProductIDSuccess() {
+	rows := sqlmock.NewRows([]string{"id", "product_id", "sku", "attributes", "price", "amount", "in_stock"}).
+		AddRow(2, 1, "shirt-red-xl", []byte(`{}`), int64(2000), 3, true).
+		AddRow(3, 1, "shirt-red-l", []byte(`{}`), nil, 0, false)
+	suite.mock.ExpectQuery("SELECT id, product_id, sku, attributes, price, amount, in_stock FROM product_variants WHERE product_id=\\$1").
+		WithArgs(1).WillReturnRows(rows)
+
+	variants, err := suite.repo.GetVariantsByProductID(1)
+
+	suite.Nil(err)
+	suite.Len(variants, 2)
+	suite.Equal(money.FromMinor(2000), *variants[0].Price)
+	suite.Nil(variants[1].Price)
+}
+
+// ====================================================================================================================
+
+func (suite *VariantRepositorySuite) TestRepository_UpdateVariantSuccess() {
+	amount := 2
+	suite.mock.ExpectExec("UPDATE product_variants SET amount=\\$1 WHERE id=\\$2").WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := suite.repo.UpdateVariant(1, model.UpdateVariant{Amount: &amount})
+
+	suite.Nil(err)
+}
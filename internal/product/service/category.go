@@ -0,0 +1,98 @@
+package service
+
+import (
+	"errors"
+	"github.com/aaanger/ecommerce/internal/product/model"
+	"github.com/aaanger/ecommerce/internal/product/repository"
+)
+
+//go:generate mockery --name=ICategoryService
+
+type ICategoryService interface {
+	CreateCategory(req *model.CategoryReq) (*model.Category, error)
+	GetCategoryTree() ([]model.Category, error)
+	GetCategoryByID(id int) (*model.Category, error)
+	UpdateCategory(id int, input model.UpdateCategory) error
+	DeleteCategory(id int) error
+	SetProductCategories(productID int, categoryIDs []int) error
+}
+
+var ErrCategoryCycle = errors.New("category cannot be moved under itself or its descendant")
+
+type CategoryService struct {
+	repo repository.ICategoryRepository
+}
+
+func NewCategoryService(repo repository.ICategoryRepository) *CategoryService {
+	return &CategoryService{
+		repo: repo,
+	}
+}
+
+func (s *CategoryService) CreateCategory(req *model.CategoryReq) (*model.Category, error) {
+	return s.repo.CreateCategory(req)
+}
+
+// GetCategoryTree returns root categories with their descendants nested in Children.
+func (s *CategoryService) GetCategoryTree() ([]model.Category, error) {
+	categories, err := s.repo.GetAllCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]model.Category)
+	roots := make([]model.Category, 0)
+
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.ParentID] = append(children[*category.ParentID], category)
+	}
+
+	var attach func(nodes []model.Category) []model.Category
+	attach = func(nodes []model.Category) []model.Category {
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].ID])
+		}
+		return nodes
+	}
+
+	return attach(roots), nil
+}
+
+func (s *CategoryService) GetCategoryByID(id int) (*model.Category, error) {
+	return s.repo.GetCategoryByID(id)
+}
+
+func (s *CategoryService) UpdateCategory(id int, input model.UpdateCategory) error {
+	if input.ParentID != nil && *input.ParentID != 0 {
+		categories, err := s.repo.GetAllCategories()
+		if err != nil {
+			return err
+		}
+
+		parents := make(map[int]*int, len(categories))
+		for _, category := range categories {
+			parents[category.ID] = category.ParentID
+		}
+
+		// Walk up from the new parent; reaching the category itself would close a loop.
+		for next := input.ParentID; next != nil; next = parents[*next] {
+			if *next == id {
+				return ErrCategoryCycle
+			}
+		}
+	}
+
+	return s.repo.UpdateCategory(id, input)
+}
+
+func (s *CategoryService) DeleteCategory(id int) error {
+	return s.repo.DeleteCategory(id)
+}
+
+func (s *CategoryService) SetProductCategories(productID int, categoryIDs []int) error {
+	return s.repo.SetProductCategories(productID, categoryIDs)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+	"github.com/aaanger/ecommerce/internal/product/model"
+	"github.com/aaanger/ecommerce/internal/product/repository/mocks"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type CategoryServiceSuite struct {
+	suite.Suite
+	repo    *mocks.ICategoryRepository
+	service *CategoryService
+}
+
+func (suite *CategoryServiceSuite) SetupTest() {
+	suite.repo = mocks.NewICategoryRepository(suite.T())
+	suite.service = NewCategoryService(suite.repo)
+}
+
+func TestCategoryServiceSuite(t *testing.T) {
+	suite.Run(t, new(CategoryServiceSuite))
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func categories() []model.Category {
+	return []model.Category{
+		{ID: 1, Name: "Electronics", Slug: "electronics"},
+		{ID: 2, Name: "Phones", Slug: "phones", ParentID: intPtr(1)},
+		{ID: 3, Name: "Smartphones", Slug: "smartphones", ParentID: intPtr(2)},
+		{ID: 4, Name: "Books", Slug: "books"},
+	}
+}
+
+// ====================================================================================================================
+
+func (suite *CategoryServiceSuite) TestService_GetCategoryTreeSuccess() {
+	suite.repo.On("GetAllCategories").Return(categories(), nil)
+
+	tree, err := suite.service.GetCategoryTree()
+
+	suite.Nil(err)
+	suite.Len(tree, 2)
+	suite.Equal("electronics", tree[0].Slug)
+	suite.Equal("phones", tree[0].Children[0].Slug)
+	suite.Equal("smartphones", tree[0].Children[0].Children[0].Slug)
+	suite.Empty(tree[1].Children)
+}
+
+func (suite *CategoryServiceSuite) TestService_GetCategoryTreeRepoFailure() {
+	suite.repo.On("GetAllCategories").Return(nil, errors.New("error"))
+
+	tree, err := suite.service.GetCategoryTree()
+
+	suite.Nil(tree)
+	suite.NotNil(err)
+}
+
+// ====================================================================================================================
+
+func (suite *CategoryServiceSuite) TestService_UpdateCategorySuccess() {
+	input := model.UpdateCategory{ParentID: intPtr(4)}
+
+	suite.repo.On("GetAllCategories").Return(categories(), nil)
+	suite.repo.On("UpdateCategory", 2, input).Return(nil)
+
+	err := suite.service.UpdateCategory(2, input)
+
+	suite.Nil(err)
+}
+
+func (suite *CategoryServiceSuite) TestService_UpdateCategoryToRoot() {
+	input := model.UpdateCategory{ParentID: intPtr(0)}
+
+	suite.repo.On("UpdateCategory", 3, input).Return(nil)
+
+	err := suite.service.UpdateCategory(3, input)
+
+	suite.Nil(err)
+	suite.repo.AssertNotCalled(suite.T(), "GetAllCategories")
+}
+
+func (suite *CategoryServiceSuite) TestService_UpdateCategoryUnderDescendant() {
+	suite.repo.On("GetAllCategories").Return(categories(), nil)
+
+	err := suite.service.UpdateCategory(1, model.UpdateCategory{ParentID: intPtr(3)})
+
+	suite.ErrorIs(err, ErrCategoryCycle)
+}
+
+func (suite *CategoryServiceSuite) TestService_UpdateCategoryUnderItself() {
+	suite.repo.On("GetAllCategories").Return(categories(), nil)
+
+	err := suite.service.UpdateCategory(2, model.UpdateCategory{ParentID: intPtr(2)})
+
+	suite.ErrorIs(err, ErrCategoryCycle)
+}
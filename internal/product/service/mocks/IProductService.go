@@ -1,9 +1,12 @@
-// Code generated by mockery v2.50.4. DO NOT EDIT.
+// Code generated by mockery v2.53.5. DO NOT EDIT.
 
 package mocks
 
 import (
+	context "context"
+
 	model "github.com/aaanger/ecommerce/internal/product/model"
+	product "github.com/aaanger/ecommerce/pkg/proto/gen/product"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -12,6 +15,36 @@ type IProductService struct {
 	mock.Mock
 }
 
+// CommitReservation provides a mock function with given fields: ctx, req
+func (_m *IProductService) CommitReservation(ctx context.Context, req *product.CommitReservationReq) (*product.ReserveProductsRes, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CommitReservation")
+	}
+
+	var r0 *product.ReserveProductsRes
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *product.CommitReservationReq) (*product.ReserveProductsRes, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *product.CommitReservationReq) *product.ReserveProductsRes); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*product.ReserveProductsRes)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *product.CommitReservationReq) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateProduct provides a mock function with given fields: req
 func (_m *IProductService) CreateProduct(req *model.ProductReq) (*model.Product, error) {
 	ret := _m.Called(req)
@@ -42,6 +75,36 @@ func (_m *IProductService) CreateProduct(req *model.ProductReq) (*model.Product,
 	return r0, r1
 }
 
+// CreateVariant provides a mock function with given fields: productID, req
+func (_m *IProductService) CreateVariant(productID int, req *model.VariantReq) (*model.Variant, error) {
+	ret := _m.Called(productID, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateVariant")
+	}
+
+	var r0 *model.Variant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, *model.VariantReq) (*model.Variant, error)); ok {
+		return rf(productID, req)
+	}
+	if rf, ok := ret.Get(0).(func(int, *model.VariantReq) *model.Variant); ok {
+		r0 = rf(productID, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Variant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, *model.VariantReq) error); ok {
+		r1 = rf(productID, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteProduct provides a mock function with given fields: id
 func (_m *IProductService) DeleteProduct(id int) error {
 	ret := _m.Called(id)
@@ -60,34 +123,22 @@ func (_m *IProductService) DeleteProduct(id int) error {
 	return r0
 }
 
-// GetAllProducts provides a mock function with no fields
-func (_m *IProductService) GetAllProducts() ([]model.Product, error) {
-	ret := _m.Called()
+// DeleteVariant provides a mock function with given fields: id
+func (_m *IProductService) DeleteVariant(id int) error {
+	ret := _m.Called(id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetAllProducts")
-	}
-
-	var r0 []model.Product
-	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]model.Product, error)); ok {
-		return rf()
-	}
-	if rf, ok := ret.Get(0).(func() []model.Product); ok {
-		r0 = rf()
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]model.Product)
-		}
+		panic("no return value specified for DeleteVariant")
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(id)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
 // GetProductByID provides a mock function with given fields: id
@@ -120,6 +171,126 @@ func (_m *IProductService) GetProductByID(id int) (*model.Product, error) {
 	return r0, r1
 }
 
+// GetProducts provides a mock function with given fields: req
+func (_m *IProductService) GetProducts(req *model.ProductSearchReq) (*model.ProductListRes, error) {
+	ret := _m.Called(req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetProducts")
+	}
+
+	var r0 *model.ProductListRes
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*model.ProductSearchReq) (*model.ProductListRes, error)); ok {
+		return rf(req)
+	}
+	if rf, ok := ret.Get(0).(func(*model.ProductSearchReq) *model.ProductListRes); ok {
+		r0 = rf(req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.ProductListRes)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*model.ProductSearchReq) error); ok {
+		r1 = rf(req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetVariants provides a mock function with given fields: productID
+func (_m *IProductService) GetVariants(productID int) ([]model.Variant, error) {
+	ret := _m.Called(productID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVariants")
+	}
+
+	var r0 []model.Variant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]model.Variant, error)); ok {
+		return rf(productID)
+	}
+	if rf, ok := ret.Get(0).(func(int) []model.Variant); ok {
+		r0 = rf(productID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Variant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(productID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReserveProducts provides a mock function with given fields: ctx, req
+func (_m *IProductService) ReserveProducts(ctx context.Context, req *product.ReserveProductsReq) (*product.ReserveProductsRes, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReserveProducts")
+	}
+
+	var r0 *product.ReserveProductsRes
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *product.ReserveProductsReq) (*product.ReserveProductsRes, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *product.ReserveProductsReq) *product.ReserveProductsRes); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*product.ReserveProductsRes)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *product.ReserveProductsReq) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnreserveProducts provides a mock function with given fields: ctx, req
+func (_m *IProductService) UnreserveProducts(ctx context.Context, req *product.UnreserveProductsReq) (*product.ReserveProductsRes, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UnreserveProducts")
+	}
+
+	var r0 *product.ReserveProductsRes
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *product.UnreserveProductsReq) (*product.ReserveProductsRes, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *product.UnreserveProductsReq) *product.ReserveProductsRes); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*product.ReserveProductsRes)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *product.UnreserveProductsReq) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateProduct provides a mock function with given fields: id, input
 func (_m *IProductService) UpdateProduct(id int, input model.UpdateProduct) error {
 	ret := _m.Called(id, input)
@@ -138,6 +309,24 @@ func (_m *IProductService) UpdateProduct(id int, input model.UpdateProduct) erro
 	return r0
 }
 
+// UpdateVariant provides a mock function with given fields: id, input
+func (_m *IProductService) UpdateVariant(id int, input model.UpdateVariant) error {
+	ret := _m.Called(id, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateVariant")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, model.UpdateVariant) error); ok {
+		r0 = rf(id, input)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewIProductService creates a new instance of IProductService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewIProductService(t interface {
@@ -2,33 +2,52 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/aaanger/ecommerce/internal/product/model"
 	"github.com/aaanger/ecommerce/internal/product/repository"
 	pb "github.com/aaanger/ecommerce/pkg/proto/gen/product"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"strings"
+	"time"
 )
 
 //go:generate mockery --name=IProductService
 
 type IProductService interface {
 	CreateProduct(req *model.ProductReq) (*model.Product, error)
-	GetAllProducts() ([]model.Product, error)
+	GetProducts(req *model.ProductSearchReq) (*model.ProductListRes, error)
 	GetProductByID(id int) (*model.Product, error)
 	UpdateProduct(id int, input model.UpdateProduct) error
 	DeleteProduct(id int) error
+	CreateVariant(productID int, req *model.VariantReq) (*model.Variant, error)
+	GetVariants(productID int) ([]model.Variant, error)
+	UpdateVariant(id int, input model.UpdateVariant) error
+	DeleteVariant(id int) error
 	ReserveProducts(ctx context.Context, req *pb.ReserveProductsReq) (*pb.ReserveProductsRes, error)
-	UnreserveProducts(ctx context.Context, req *pb.ReserveProductsReq) (*pb.ReserveProductsRes, error)
+	UnreserveProducts(ctx context.Context, req *pb.UnreserveProductsReq) (*pb.ReserveProductsRes, error)
+	CommitReservation(ctx context.Context, req *pb.CommitReservationReq) (*pb.ReserveProductsRes, error)
 }
 
+const (
+	DefaultReservationTTL = 30 * time.Minute
+)
+
 type ProductService struct {
-	repo repository.IProductRepository
+	repo           repository.IProductRepository
+	variantRepo    repository.IVariantRepository
+	stockRepo      repository.IStockRepository
+	reservationTTL time.Duration
 }
 
-func NewProductService(repo repository.IProductRepository) *ProductService {
+func NewProductService(repo repository.IProductRepository, variantRepo repository.IVariantRepository, stockRepo repository.IStockRepository, reservationTTL time.Duration) *ProductService {
 	return &ProductService{
-		repo: repo,
+		repo:           repo,
+		variantRepo:    variantRepo,
+		stockRepo:      stockRepo,
+		reservationTTL: reservationTTL,
 	}
 }
 
@@ -41,12 +60,42 @@ func (s *ProductService) CreateProduct(req *model.ProductReq) (*model.Product, e
 	return product, nil
 }
 
-func (s *ProductService) GetAllProducts() ([]model.Product, error) {
-	return s.repo.GetAllProducts()
+func (s *ProductService) GetProducts(req *model.ProductSearchReq) (*model.ProductListRes, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 {
+		req.Limit = model.DefaultPageLimit
+	}
+	if req.Limit > model.MaxPageLimit {
+		req.Limit = model.MaxPageLimit
+	}
+
+	products, total, err := s.repo.GetProducts(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ProductListRes{
+		Products: products,
+		Total:    total,
+		Page:     req.Page,
+		Limit:    req.Limit,
+	}, nil
 }
 
 func (s *ProductService) GetProductByID(id int) (*model.Product, error) {
-	return s.repo.GetProductByID(id)
+	product, err := s.repo.GetProductByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	product.Variants, err = s.variantRepo.GetVariantsByProductID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
 }
 
 func (s *ProductService) UpdateProduct(id int, input model.UpdateProduct) error {
@@ -57,49 +106,125 @@ func (s *ProductService) DeleteProduct(id int) error {
 	return s.repo.DeleteProduct(id)
 }
 
+func (s *ProductService) CreateVariant(productID int, req *model.VariantReq) (*model.Variant, error) {
+	_, err := s.repo.GetProductByID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.variantRepo.CreateVariant(productID, req)
+}
+
+func (s *ProductService) GetVariants(productID int) ([]model.Variant, error) {
+	return s.variantRepo.GetVariantsByProductID(productID)
+}
+
+func (s *ProductService) UpdateVariant(id int, input model.UpdateVariant) error {
+	return s.variantRepo.UpdateVariant(id, input)
+}
+
+func (s *ProductService) DeleteVariant(id int) error {
+	return s.variantRepo.DeleteVariant(id)
+}
+
 func (s *ProductService) ReserveProducts(ctx context.Context, req *pb.ReserveProductsReq) (*pb.ReserveProductsRes, error) {
-	for _, item := range req.Products {
-		product, err := s.GetProductByID(int(item.ProductID))
-		if err != nil {
-			return nil, err
-		}
-		if product.Amount < int(item.Quantity) {
-			return nil, status.Errorf(codes.FailedPrecondition, "not enough amount for product %s", product.Name)
-		}
-		if product.InStock == false {
-			return nil, status.Errorf(codes.FailedPrecondition, "product %s is not in stock", product.Name)
-		}
-
-		updatedAmount := product.Amount - int(item.Quantity)
-		inStock := updatedAmount > 0
-
-		err = s.UpdateProduct(product.ID, model.UpdateProduct{
-			Amount:  &updatedAmount,
-			InStock: &inStock,
-		})
-		if err != nil {
-			return nil, err
-		}
+	expiresAt := time.Now().Add(s.reservationTTL)
+
+	reservationID, shortages, err := s.stockRepo.ReserveStock(ctx, int(req.OrderID), stockItems(req.Products), expiresAt)
+	if errors.Is(err, repository.ErrInvalidQuantity) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
-	return &pb.ReserveProductsRes{Success: true}, nil
+	if err != nil {
+		logrus.Errorf("Reserve products error: %s", err)
+		return nil, status.Error(codes.Internal, "failed to reserve products")
+	}
+
+	if len(shortages) > 0 {
+		return nil, shortageError(shortages)
+	}
+
+	return &pb.ReserveProductsRes{Success: true, ReservationID: int32(reservationID)}, nil
+}
+
+// UnreserveProducts drops the whole reservation, or with req.Products puts just those items of a committed
+// reservation back in stock.
+func (s *ProductService) UnreserveProducts(ctx context.Context, req *pb.UnreserveProductsReq) (*pb.ReserveProductsRes, error) {
+	var err error
+	if len(req.Products) > 0 {
+		err = s.stockRepo.ReturnItems(ctx, int(req.ReservationID), stockItems(req.Products))
+	} else {
+		err = s.stockRepo.ReleaseReservation(ctx, int(req.ReservationID))
+	}
+	if errors.Is(err, repository.ErrReservationNotFound) {
+		return nil, status.Errorf(codes.NotFound, "reservation %d not found", req.ReservationID)
+	}
+	if errors.Is(err, repository.ErrReservationNotCommitted) || errors.Is(err, repository.ErrReturnExceedsReserved) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if errors.Is(err, repository.ErrInvalidQuantity) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		logrus.Errorf("Unreserve products error: %s", err)
+		return nil, status.Error(codes.Internal, "failed to unreserve products")
+	}
+
+	return &pb.ReserveProductsRes{Success: true, ReservationID: req.ReservationID}, nil
+}
+
+func (s *ProductService) CommitReservation(ctx context.Context, req *pb.CommitReservationReq) (*pb.ReserveProductsRes, error) {
+	shortages, err := s.stockRepo.CommitReservation(ctx, int(req.ReservationID), req.Backorder)
+	if errors.Is(err, repository.ErrReservationNotFound) {
+		return nil, status.Errorf(codes.NotFound, "reservation %d not found", req.ReservationID)
+	}
+	if errors.Is(err, repository.ErrReservationReleased) {
+		return nil, status.Errorf(codes.FailedPrecondition, "reservation %d has been released", req.ReservationID)
+	}
+	if err != nil {
+		logrus.Errorf("Commit reservation error: %s", err)
+		return nil, status.Error(codes.Internal, "failed to commit reservation")
+	}
+
+	if len(shortages) > 0 && !req.Backorder {
+		return nil, shortageError(shortages)
+	}
+	if len(shortages) > 0 {
+		logrus.Warnf("Reservation %d committed as backorder, not enough stock for: %s", req.ReservationID, shortageNames(shortages))
+	}
+
+	return &pb.ReserveProductsRes{Success: true, ReservationID: req.ReservationID}, nil
 }
 
-func (s *ProductService) UnreserveProducts(ctx context.Context, req *pb.ReserveProductsReq) (*pb.ReserveProductsRes, error) {
-	for _, item := range req.Products {
-		product, err := s.GetProductByID(int(item.ProductID))
-		if err != nil {
-			return nil, err
-		}
+func shortageError(shortages []model.StockShortage) error {
+	return status.Errorf(codes.FailedPrecondition, "not enough stock for: %s", shortageNames(shortages))
+}
+
+func shortageNames(shortages []model.StockShortage) string {
+	names := make([]string, 0, len(shortages))
+	for _, shortage := range shortages {
+		names = append(names, shortageName(shortage))
+	}
+	return strings.Join(names, ", ")
+}
 
-		updatedAmount := product.Amount + int(item.Quantity)
-		inStock := updatedAmount > 0
-		err = s.UpdateProduct(product.ID, model.UpdateProduct{
-			Amount:  &updatedAmount,
-			InStock: &inStock,
+func stockItems(products []*pb.ReservedProduct) []model.StockItem {
+	items := make([]model.StockItem, 0, len(products))
+	for _, product := range products {
+		items = append(items, model.StockItem{
+			ProductID: int(product.ProductID),
+			VariantID: int(product.VariantID),
+			Quantity:  int(product.Quantity),
 		})
-		if err != nil {
-			return nil, err
-		}
 	}
-	return &pb.ReserveProductsRes{Success: true}, nil
+	return items
+}
+
+func shortageName(shortage model.StockShortage) string {
+	if shortage.Name != "" {
+		return shortage.Name
+	}
+	if shortage.VariantID != 0 {
+		return fmt.Sprintf("variant #%d of product #%d", shortage.VariantID, shortage.ProductID)
+	}
+	return fmt.Sprintf("product #%d", shortage.ProductID)
 }
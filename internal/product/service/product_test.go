@@ -1,22 +1,33 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"github.com/aaanger/ecommerce/internal/product/model"
+	"github.com/aaanger/ecommerce/internal/product/repository"
 	"github.com/aaanger/ecommerce/internal/product/repository/mocks"
+	"github.com/aaanger/ecommerce/pkg/money"
+	pb "github.com/aaanger/ecommerce/pkg/proto/gen/product"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"testing"
 )
 
 type ProductServiceSuite struct {
 	suite.Suite
-	repo    *mocks.IProductRepository
-	service *ProductService
+	repo        *mocks.IProductRepository
+	variantRepo *mocks.IVariantRepository
+	stockRepo   *mocks.IStockRepository
+	service     *ProductService
 }
 
 func (suite *ProductServiceSuite) SetupTest() {
 	suite.repo = mocks.NewIProductRepository(suite.T())
-	suite.service = NewProductService(suite.repo)
+	suite.variantRepo = mocks.NewIVariantRepository(suite.T())
+	suite.stockRepo = mocks.NewIStockRepository(suite.T())
+	suite.service = NewProductService(suite.repo, suite.variantRepo, suite.stockRepo, DefaultReservationTTL)
 }
 
 func TestProductServiceSuite(t *testing.T) {
@@ -27,7 +38,7 @@ func (suite *ProductServiceSuite) TestService_CreateProductSuccess() {
 	req := &model.ProductReq{
 		Name:        "test",
 		Description: "test",
-		Price:       5,
+		Price:       money.FromMinor(500),
 		Amount:      5,
 		InStock:     true,
 	}
@@ -36,7 +47,7 @@ func (suite *ProductServiceSuite) TestService_CreateProductSuccess() {
 		ID:          1,
 		Name:        "test",
 		Description: "test",
-		Price:       5,
+		Price:       money.FromMinor(500),
 		Amount:      5,
 		InStock:     true,
 	}
@@ -55,7 +66,7 @@ func (suite *ProductServiceSuite) TestService_CreateProductRepoFailure() {
 	req := &model.ProductReq{
 		Name:        "test",
 		Description: "test",
-		Price:       5,
+		Price:       money.FromMinor(500),
 		Amount:      5,
 		InStock:     true,
 	}
@@ -69,26 +80,39 @@ func (suite *ProductServiceSuite) TestService_CreateProductRepoFailure() {
 
 // ====================================================================================================================
 
-func (suite *ProductServiceSuite) TestService_GetAllProductsSuccess() {
-	suite.repo.On("GetAllProducts").Return([]model.Product{
+func (suite *ProductServiceSuite) TestService_GetProductsSuccess() {
+	req := &model.ProductSearchReq{Search: "test"}
+
+	suite.repo.On("GetProducts", &model.ProductSearchReq{Search: "test", Page: 1, Limit: model.DefaultPageLimit}).Return([]model.Product{
 		{
 			ID:   1,
 			Name: "test",
 		},
-	}, nil)
+	}, 1, nil)
+
+	res, err := suite.service.GetProducts(req)
+	suite.NotNil(res)
+	suite.Equal(1, res.Products[0].ID)
+	suite.Equal("test", res.Products[0].Name)
+	suite.Equal(1, res.Total)
+	suite.Equal(1, res.Page)
+	suite.Equal(model.DefaultPageLimit, res.Limit)
+	suite.Nil(err)
+}
+
+func (suite *ProductServiceSuite) TestService_GetProductsLimitCapped() {
+	suite.repo.On("GetProducts", &model.ProductSearchReq{Page: 3, Limit: model.MaxPageLimit}).Return([]model.Product{}, 0, nil)
 
-	products, err := suite.service.GetAllProducts()
-	suite.NotNil(products)
-	suite.Equal(1, products[0].ID)
-	suite.Equal("test", products[0].Name)
+	res, err := suite.service.GetProducts(&model.ProductSearchReq{Page: 3, Limit: 1000})
 	suite.Nil(err)
+	suite.Equal(model.MaxPageLimit, res.Limit)
 }
 
-func (suite *ProductServiceSuite) TestService_GetAllProductsRepoFailure() {
-	suite.repo.On("GetAllProducts").Return(nil, errors.New("error"))
+func (suite *ProductServiceSuite) TestService_GetProductsRepoFailure() {
+	suite.repo.On("GetProducts", &model.ProductSearchReq{Page: 1, Limit: model.DefaultPageLimit}).Return(nil, 0, errors.New("error"))
 
-	products, err := suite.service.GetAllProducts()
-	suite.Nil(products)
+	res, err := suite.service.GetProducts(&model.ProductSearchReq{})
+	suite.Nil(res)
 	suite.NotNil(err)
 }
 
@@ -99,11 +123,19 @@ func (suite *ProductServiceSuite) TestService_GetProductByIDSuccess() {
 		ID:   1,
 		Name: "test",
 	}, nil)
+	suite.variantRepo.On("GetVariantsByProductID", 1).Return([]model.Variant{
+		{
+			ID:        2,
+			ProductID: 1,
+			SKU:       "test-xl",
+		},
+	}, nil)
 
 	product, err := suite.service.GetProductByID(1)
 	suite.NotNil(product)
 	suite.Equal(1, product.ID)
 	suite.Equal("test", product.Name)
+	suite.Equal("test-xl", product.Variants[0].SKU)
 	suite.Nil(err)
 }
 
@@ -160,3 +192,156 @@ func (suite *ProductServiceSuite) TestService_DeleteProductRepoFailure() {
 
 	suite.NotNil(err)
 }
+
+// ====================================================================================================================
+
+func (suite *ProductServiceSuite) TestService_ReserveProductsSuccess() {
+	suite.stockRepo.On("ReserveStock", context.Background(), 10, []model.StockItem{
+		{ProductID: 1, Quantity: 2},
+		{ProductID: 1, VariantID: 2, Quantity: 1},
+	}, mock.AnythingOfType("time.Time")).Return(7, nil, nil)
+
+	res, err := suite.service.ReserveProducts(context.Background(), &pb.ReserveProductsReq{
+		Products: []*pb.ReservedProduct{
+			{ProductID: 1, Quantity: 2},
+			{ProductID: 1, VariantID: 2, Quantity: 1},
+		},
+		OrderID: 10,
+	})
+
+	suite.Nil(err)
+	suite.True(res.Success)
+	suite.Equal(int32(7), res.ReservationID)
+}
+
+func (suite *ProductServiceSuite) TestService_ReserveProductsInvalidQuantity() {
+	suite.stockRepo.On("ReserveStock", context.Background(), 0, []model.StockItem{
+		{ProductID: 1, Quantity: 10},
+		{ProductID: 2, Quantity: -9},
+	}, mock.AnythingOfType("time.Time")).Return(0, nil, repository.ErrInvalidQuantity)
+
+	res, err := suite.service.ReserveProducts(context.Background(), &pb.ReserveProductsReq{
+		Products: []*pb.ReservedProduct{
+			{ProductID: 1, Quantity: 10},
+			{ProductID: 2, Quantity: -9},
+		},
+	})
+
+	suite.Nil(res)
+	suite.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (suite *ProductServiceSuite) TestService_ReserveProductsShortage() {
+	suite.stockRepo.On("ReserveStock", context.Background(), 0, []model.StockItem{
+		{ProductID: 1, Quantity: 5},
+		{ProductID: 2, VariantID: 3, Quantity: 5},
+		{ProductID: 4, Quantity: 1},
+	}, mock.AnythingOfType("time.Time")).Return(0, []model.StockShortage{
+		{ProductID: 1, Name: "Phone"},
+		{ProductID: 2, VariantID: 3, Name: "Shirt (shirt-red-xl)"},
+		{ProductID: 4},
+	}, nil)
+
+	res, err := suite.service.ReserveProducts(context.Background(), &pb.ReserveProductsReq{
+		Products: []*pb.ReservedProduct{
+			{ProductID: 1, Quantity: 5},
+			{ProductID: 2, VariantID: 3, Quantity: 5},
+			{ProductID: 4, Quantity: 1},
+		},
+	})
+
+	suite.Nil(res)
+	suite.Equal(codes.FailedPrecondition, status.Code(err))
+	suite.Equal("not enough stock for: Phone, Shirt (shirt-red-xl), product #4", status.Convert(err).Message())
+}
+
+func (suite *ProductServiceSuite) TestService_ReserveProductsRepoFailure() {
+	suite.stockRepo.On("ReserveStock", context.Background(), 0, []model.StockItem{{ProductID: 1, Quantity: 1}}, mock.AnythingOfType("time.Time")).Return(0, nil, errors.New("error"))
+
+	res, err := suite.service.ReserveProducts(context.Background(), &pb.ReserveProductsReq{
+		Products: []*pb.ReservedProduct{{ProductID: 1, Quantity: 1}},
+	})
+
+	suite.Nil(res)
+	suite.Equal(codes.Internal, status.Code(err))
+}
+
+func (suite *ProductServiceSuite) TestService_UnreserveProductsSuccess() {
+	suite.stockRepo.On("ReleaseReservation", context.Background(), 7).Return(nil)
+
+	res, err := suite.service.UnreserveProducts(context.Background(), &pb.UnreserveProductsReq{ReservationID: 7})
+
+	suite.Nil(err)
+	suite.True(res.Success)
+}
+
+func (suite *ProductServiceSuite) TestService_UnreserveProductsNotFound() {
+	suite.stockRepo.On("ReleaseReservation", context.Background(), 7).Return(repository.ErrReservationNotFound)
+
+	res, err := suite.service.UnreserveProducts(context.Background(), &pb.UnreserveProductsReq{ReservationID: 7})
+
+	suite.Nil(res)
+	suite.Equal(codes.NotFound, status.Code(err))
+}
+
+func (suite *ProductServiceSuite) TestService_UnreserveProductsReturnsItems() {
+	suite.stockRepo.On("ReturnItems", context.Background(), 7, []model.StockItem{{ProductID: 1, VariantID: 2, Quantity: 1}}).Return(nil)
+
+	res, err := suite.service.UnreserveProducts(context.Background(), &pb.UnreserveProductsReq{
+		ReservationID: 7,
+		Products:      []*pb.ReservedProduct{{ProductID: 1, VariantID: 2, Quantity: 1}},
+	})
+
+	suite.Nil(err)
+	suite.True(res.Success)
+	suite.stockRepo.AssertNotCalled(suite.T(), "ReleaseReservation", mock.Anything, mock.Anything)
+}
+
+func (suite *ProductServiceSuite) TestService_UnreserveProductsReturnExceedsReserved() {
+	suite.stockRepo.On("ReturnItems", context.Background(), 7, mock.Anything).Return(repository.ErrReturnExceedsReserved)
+
+	res, err := suite.service.UnreserveProducts(context.Background(), &pb.UnreserveProductsReq{
+		ReservationID: 7,
+		Products:      []*pb.ReservedProduct{{ProductID: 1, Quantity: 5}},
+	})
+
+	suite.Nil(res)
+	suite.Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (suite *ProductServiceSuite) TestService_CommitReservationSuccess() {
+	suite.stockRepo.On("CommitReservation", context.Background(), 7, false).Return(nil, nil)
+
+	res, err := suite.service.CommitReservation(context.Background(), &pb.CommitReservationReq{ReservationID: 7})
+
+	suite.Nil(err)
+	suite.True(res.Success)
+}
+
+func (suite *ProductServiceSuite) TestService_CommitReservationShortage() {
+	suite.stockRepo.On("CommitReservation", context.Background(), 7, false).Return([]model.StockShortage{{ProductID: 1, Name: "Phone"}}, nil)
+
+	res, err := suite.service.CommitReservation(context.Background(), &pb.CommitReservationReq{ReservationID: 7})
+
+	suite.Nil(res)
+	suite.Equal(codes.FailedPrecondition, status.Code(err))
+	suite.Equal("not enough stock for: Phone", status.Convert(err).Message())
+}
+
+func (suite *ProductServiceSuite) TestService_CommitReservationBackorder() {
+	suite.stockRepo.On("CommitReservation", context.Background(), 7, true).Return([]model.StockShortage{{ProductID: 1, Name: "Phone"}}, nil)
+
+	res, err := suite.service.CommitReservation(context.Background(), &pb.CommitReservationReq{ReservationID: 7, Backorder: true})
+
+	suite.Nil(err)
+	suite.True(res.Success)
+}
+
+func (suite *ProductServiceSuite) TestService_CommitReservationReleased() {
+	suite.stockRepo.On("CommitReservation", context.Background(), 7, false).Return(nil, repository.ErrReservationReleased)
+
+	res, err := suite.service.CommitReservation(context.Background(), &pb.CommitReservationReq{ReservationID: 7})
+
+	suite.Nil(res)
+	suite.Equal(codes.FailedPrecondition, status.Code(err))
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"github.com/aaanger/ecommerce/internal/product/repository"
+	"go.uber.org/zap"
+	"time"
+)
+
+// ReservationSweeper periodically expires stock holds whose TTL has passed.
+// Expiry is a single conditional UPDATE, so several instances may run it concurrently.
+type ReservationSweeper struct {
+	repo     repository.IStockRepository
+	interval time.Duration
+	log      *zap.Logger
+}
+
+func NewReservationSweeper(repo repository.IStockRepository, interval time.Duration, log *zap.Logger) *ReservationSweeper {
+	return &ReservationSweeper{
+		repo:     repo,
+		interval: interval,
+		log:      log,
+	}
+}
+
+func (s *ReservationSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Sweep(ctx)
+		}
+	}
+}
+
+func (s *ReservationSweeper) Sweep(ctx context.Context) {
+	expired, err := s.repo.ExpireReservations(ctx)
+	if err != nil {
+		s.log.Error("Reservation sweeper: failed to expire reservations", zap.Error(err))
+		return
+	}
+
+	if expired > 0 {
+		s.log.Info("Reservation sweeper: expired reservations released", zap.Int64("count", expired))
+	}
+}
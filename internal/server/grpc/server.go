@@ -13,6 +13,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"net"
+	"time"
 )
 
 type Server struct {
@@ -44,7 +45,7 @@ func InterceptorLogger(l *zap.Logger) logging.Logger {
 	})
 }
 
-func NewServer(log *zap.Logger, db *sql.DB, port int) *Server {
+func NewServer(log *zap.Logger, db *sql.DB, port int, reservationTTL time.Duration) *Server {
 	loggingOpts := []logging.Option{
 		logging.WithLogOnEvents(
 			logging.PayloadReceived, logging.PayloadSent),
@@ -59,7 +60,7 @@ func NewServer(log *zap.Logger, db *sql.DB, port int) *Server {
 	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(recovery.UnaryServerInterceptor(recoveryOpts...),
 		logging.UnaryServerInterceptor(InterceptorLogger(log), loggingOpts...)))
 
-	productgrpc.RegisterProductGRPCServer(grpcServer, db)
+	productgrpc.RegisterProductGRPCServer(grpcServer, db, reservationTTL)
 
 	return &Server{
 		engine: grpcServer,
@@ -2,15 +2,28 @@ package handler
 
 import (
 	"database/sql"
+	cartRepository "github.com/aaanger/ecommerce/internal/cart/repository"
+	cartService "github.com/aaanger/ecommerce/internal/cart/service"
+	productRepository "github.com/aaanger/ecommerce/internal/product/repository"
 	"github.com/aaanger/ecommerce/internal/user/repository"
 	"github.com/aaanger/ecommerce/internal/user/service"
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"go.uber.org/zap"
 )
 
-func UserRoutes(r *gin.Engine, db *sql.DB) {
+func UserRoutes(r *gin.Engine, db *sql.DB, log *zap.Logger, redisClient *redis.Client) {
 	repo := repository.NewUserRepository(db)
 	svc := service.NewUserService(repo)
-	h := NewUserHandler(svc)
+
+	cartSvc := cartService.NewCartService(
+		cartRepository.NewCartRepository(db),
+		cartRepository.NewRedisCartRepository(redisClient, cartRepository.TTL, log),
+		productRepository.NewProductRepository(db),
+		productRepository.NewVariantRepository(db),
+		log)
+
+	h := NewUserHandler(svc, cartSvc)
 
 	r.POST("/signup", h.SignUp)
 	r.POST("/signin", h.SignIn)
@@ -1,8 +1,10 @@
 package handler
 
 import (
+	cartService "github.com/aaanger/ecommerce/internal/cart/service"
 	"github.com/aaanger/ecommerce/internal/user/model"
 	"github.com/aaanger/ecommerce/internal/user/service"
+	"github.com/aaanger/ecommerce/pkg/cookie"
 	"github.com/aaanger/ecommerce/pkg/response"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -10,12 +12,14 @@ import (
 )
 
 type UserHandler struct {
-	service service.IUserService
+	service     service.IUserService
+	cartService cartService.ICartService
 }
 
-func NewUserHandler(service service.IUserService) *UserHandler {
+func NewUserHandler(service service.IUserService, cartService cartService.ICartService) *UserHandler {
 	return &UserHandler{
-		service: service,
+		service:     service,
+		cartService: cartService,
 	}
 }
 
@@ -58,6 +62,13 @@ func (h *UserHandler) SignIn(c *gin.Context) {
 		return
 	}
 
+	// A failed merge must not block the login: the guest cart stays in Redis until it expires.
+	if sessionID, err := c.Cookie(cookie.CookieSession); err == nil && sessionID != "" {
+		if _, err = h.cartService.MergeGuestCart(user.ID, sessionID); err != nil {
+			logrus.Errorf("Merge guest cart error: %s", err)
+		}
+	}
+
 	res := model.LoginRes{
 		ID:           user.ID,
 		Email:        user.Email,
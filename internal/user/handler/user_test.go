@@ -2,13 +2,14 @@ package handler
 
 import (
 	"bytes"
-	"github.com/aaanger/ecommerce/internal/user/model"
-
 	"errors"
+	cartMocks "github.com/aaanger/ecommerce/internal/cart/service/mocks"
+	"github.com/aaanger/ecommerce/internal/user/model"
 	mock_service "github.com/aaanger/ecommerce/internal/user/service/mocks"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 )
@@ -32,7 +33,7 @@ func TestHandler_SignUp(t *testing.T) {
 				s.EXPECT().Register(user).Return(&model.User{ID: 0, Email: user.Email, Password: user.Password}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: `{"Successfully registered":{"id":0,"email":"test@test.com"}}`,
+			expectedResponseBody: `{"id":0,"email":"test@test.com"}`,
 		},
 		{
 			name:      "Empty fields",
@@ -41,7 +42,7 @@ func TestHandler_SignUp(t *testing.T) {
 
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: `{"Error":"Invalid input parameters"}`,
+			expectedResponseBody: `"Invalid input parameters"`,
 		},
 		{
 			name:      "Service failure",
@@ -51,7 +52,7 @@ func TestHandler_SignUp(t *testing.T) {
 				s.EXPECT().Register(user).Return(nil, errors.New("Something went wrong"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"Error":"Something went wrong"}`,
+			expectedResponseBody: `"Something went wrong"`,
 		},
 	}
 
@@ -63,7 +64,7 @@ func TestHandler_SignUp(t *testing.T) {
 			auth := mock_service.NewMockIUserService(c)
 			testCase.mockBehavior(auth, testCase.inputUser)
 
-			handler := NewUserHandler(auth)
+			handler := NewUserHandler(auth, cartMocks.NewICartService(t))
 
 			r := gin.New()
 			r.POST("/signup", handler.SignUp)
@@ -98,7 +99,7 @@ func TestHandler_SignIn(t *testing.T) {
 				s.EXPECT().Login(user).Return(&model.User{ID: 0, Email: user.Email, Password: user.Password}, "access_token", "refresh_token", nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: `{"Logged in":{"id":0,"email":"test@test.com","access_token":"access_token","refresh_token":"refresh_token"}}`,
+			expectedResponseBody: `{"id":0,"email":"test@test.com","access_token":"access_token","refresh_token":"refresh_token"}`,
 		},
 		{
 			name:      "Empty fields",
@@ -106,7 +107,7 @@ func TestHandler_SignIn(t *testing.T) {
 			mockBehavior: func(s *mock_service.MockIUserService, user *model.UserReq) {
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: `{"Error":"Invalid input parameters"}`,
+			expectedResponseBody: `"Invalid input parameters"`,
 		},
 		{
 			name:      "Service failure",
@@ -116,7 +117,7 @@ func TestHandler_SignIn(t *testing.T) {
 				s.EXPECT().Login(user).Return(nil, "access_token", "refresh_token", errors.New("Something went wrong"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"Error":"Something went wrong"}`,
+			expectedResponseBody: `"Something went wrong"`,
 		},
 	}
 
@@ -128,7 +129,7 @@ func TestHandler_SignIn(t *testing.T) {
 			auth := mock_service.NewMockIUserService(c)
 			testCase.mockBehavior(auth, testCase.inputUser)
 
-			handler := NewUserHandler(auth)
+			handler := NewUserHandler(auth, cartMocks.NewICartService(t))
 
 			r := gin.New()
 			r.POST("/signin", handler.SignIn)
@@ -143,3 +144,41 @@ func TestHandler_SignIn(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_SignInMergesGuestCart(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mergeErr error
+	}{
+		{name: "OK"},
+		{name: "Merge failure", mergeErr: errors.New("error")},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			user := &model.UserReq{Email: "test@test.com", Password: "123"}
+
+			auth := mock_service.NewMockIUserService(c)
+			auth.EXPECT().Login(user).Return(&model.User{ID: 7, Email: user.Email}, "access_token", "refresh_token", nil)
+
+			carts := cartMocks.NewICartService(t)
+			carts.On("MergeGuestCart", 7, "guest-session").Return(nil, testCase.mergeErr)
+
+			handler := NewUserHandler(auth, carts)
+
+			r := gin.New()
+			r.POST("/signin", handler.SignIn)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/signin", bytes.NewBufferString(`{"email":"test@test.com","password":"123"}`))
+			req.AddCookie(&http.Cookie{Name: "session", Value: "guest-session"})
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+		})
+	}
+}
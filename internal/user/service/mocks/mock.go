@@ -5,9 +5,9 @@
 package mock_service
 
 import (
-	"github.com/aaanger/ecommerce/internal/user/model"
 	reflect "reflect"
 
+	model "github.com/aaanger/ecommerce/internal/user/model"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -34,6 +34,20 @@ func (m *MockIUserService) EXPECT() *MockIUserServiceMockRecorder {
 	return m.recorder
 }
 
+// GetEmail mocks base method.
+func (m *MockIUserService) GetEmail(userID int) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmail", userID)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetEmail indicates an expected call of GetEmail.
+func (mr *MockIUserServiceMockRecorder) GetEmail(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmail", reflect.TypeOf((*MockIUserService)(nil).GetEmail), userID)
+}
+
 // Login mocks base method.
 func (m *MockIUserService) Login(req *model.UserReq) (*model.User, string, string, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+)
+
+// Locker hands out locks shared by every instance of the service, so a periodic job runs on one
+// instance at a time.
+type Locker interface {
+	TryLock(ctx context.Context, key int64) (unlock func(), ok bool, err error)
+}
+
+// AdvisoryLocker implements Locker with PostgreSQL session advisory locks. Each lock holds on to a
+// connection from the pool until it is released.
+type AdvisoryLocker struct {
+	db *sql.DB
+}
+
+func NewAdvisoryLocker(db *sql.DB) *AdvisoryLocker {
+	return &AdvisoryLocker{db: db}
+}
+
+// TryLock takes the lock without waiting. ok is false when another session holds it.
+func (l *AdvisoryLocker) TryLock(ctx context.Context, key int64) (func(), bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var ok bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1);`, key).Scan(&ok)
+	if err != nil || !ok {
+		conn.Close()
+		return nil, false, err
+	}
+
+	unlock := func() {
+		_, err := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1);`, key)
+		if err != nil {
+			// The lock lives as long as the session, so a connection that could not release it must not go back to the pool.
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+
+	return unlock, true, nil
+}
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE categories (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    slug TEXT UNIQUE NOT NULL,
+    parent_id INT REFERENCES categories(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX categories_parent_id_idx ON categories (parent_id);
+
+CREATE TABLE product_categories (
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    category_id INT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    PRIMARY KEY (product_id, category_id)
+);
+
+CREATE INDEX product_categories_category_id_idx ON product_categories (category_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE product_categories;
+DROP TABLE categories;
+-- +goose StatementEnd
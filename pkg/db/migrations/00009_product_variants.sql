@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE product_variants (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    sku TEXT UNIQUE NOT NULL,
+    attributes JSONB NOT NULL DEFAULT '{}',
+    price FLOAT,
+    amount INT NOT NULL DEFAULT 0,
+    in_stock BOOLEAN NOT NULL DEFAULT false
+);
+
+CREATE INDEX product_variants_product_id_idx ON product_variants (product_id);
+
+ALTER TABLE cartline ADD COLUMN variant_id INT REFERENCES product_variants(id) ON DELETE CASCADE;
+ALTER TABLE orderline ADD COLUMN variant_id INT REFERENCES product_variants(id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orderline DROP COLUMN variant_id;
+ALTER TABLE cartline DROP COLUMN variant_id;
+DROP TABLE product_variants;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE reservations (
+    id SERIAL PRIMARY KEY,
+    order_id INT UNIQUE NOT NULL,
+    status TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX reservations_status_expires_at_idx ON reservations (status, expires_at);
+
+CREATE TABLE reservation_items (
+    id SERIAL PRIMARY KEY,
+    reservation_id INT NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id INT REFERENCES product_variants(id) ON DELETE CASCADE,
+    quantity INT NOT NULL
+);
+
+CREATE INDEX reservation_items_product_id_idx ON reservation_items (product_id, variant_id);
+
+ALTER TABLE orders ADD COLUMN reservation_id INT REFERENCES reservations(id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders DROP COLUMN reservation_id;
+DROP TABLE reservation_items;
+DROP TABLE reservations;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Prices are stored as integer minor units (kopecks) of money.DefaultCurrency.
+ALTER TABLE products ALTER COLUMN price TYPE BIGINT USING round(price * 100)::BIGINT;
+ALTER TABLE product_variants ALTER COLUMN price TYPE BIGINT USING round(price * 100)::BIGINT;
+ALTER TABLE orders ALTER COLUMN total_price TYPE BIGINT USING round(total_price * 100)::BIGINT;
+ALTER TABLE orderline ALTER COLUMN price TYPE BIGINT USING round(price * 100)::BIGINT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orderline ALTER COLUMN price TYPE FLOAT USING price / 100.0;
+ALTER TABLE orders ALTER COLUMN total_price TYPE FLOAT USING total_price / 100.0;
+ALTER TABLE product_variants ALTER COLUMN price TYPE FLOAT USING price / 100.0;
+ALTER TABLE products ALTER COLUMN price TYPE FLOAT USING price / 100.0;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+UPDATE cartline l SET quantity = d.total
+FROM (
+    SELECT MIN(id) AS id, SUM(COALESCE(quantity, 1)) AS total
+    FROM cartline
+    GROUP BY cart_id, product_id, COALESCE(variant_id, 0)
+    HAVING COUNT(*) > 1
+) d
+WHERE l.id = d.id;
+
+DELETE FROM cartline l USING cartline k
+WHERE l.cart_id = k.cart_id
+  AND l.product_id = k.product_id
+  AND COALESCE(l.variant_id, 0) = COALESCE(k.variant_id, 0)
+  AND l.id > k.id;
+
+UPDATE cartline SET quantity = 1 WHERE quantity IS NULL OR quantity < 1;
+ALTER TABLE cartline ALTER COLUMN quantity SET NOT NULL;
+ALTER TABLE cartline ADD CONSTRAINT cartline_quantity_positive CHECK (quantity > 0);
+
+CREATE UNIQUE INDEX cartline_cart_product_variant_idx ON cartline (cart_id, product_id, (COALESCE(variant_id, 0)));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX cartline_cart_product_variant_idx;
+ALTER TABLE cartline DROP CONSTRAINT cartline_quantity_positive;
+ALTER TABLE cartline ALTER COLUMN quantity DROP NOT NULL;
+-- +goose StatementEnd
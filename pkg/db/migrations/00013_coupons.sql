@@ -0,0 +1,59 @@
+-- +goose Up
+-- +goose StatementBegin
+-- percent_off is in basis points, amount_off and min_basket are minor units. A limit of 0 means unlimited.
+CREATE TABLE coupons (
+    id SERIAL PRIMARY KEY,
+    code TEXT UNIQUE NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('percent', 'fixed', 'free_shipping')),
+    percent_off BIGINT NOT NULL DEFAULT 0 CHECK (percent_off BETWEEN 0 AND 10000),
+    amount_off BIGINT NOT NULL DEFAULT 0 CHECK (amount_off >= 0),
+    min_basket BIGINT NOT NULL DEFAULT 0 CHECK (min_basket >= 0),
+    starts_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    usage_limit INT NOT NULL DEFAULT 0 CHECK (usage_limit >= 0),
+    per_user_limit INT NOT NULL DEFAULT 0 CHECK (per_user_limit >= 0),
+    used_count INT NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+
+-- A coupon with no rows in either table applies to every product.
+CREATE TABLE coupon_products (
+    coupon_id INT NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    PRIMARY KEY (coupon_id, product_id)
+);
+
+CREATE TABLE coupon_categories (
+    coupon_id INT NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+    category_id INT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    PRIMARY KEY (coupon_id, category_id)
+);
+
+CREATE TABLE coupon_redemptions (
+    id SERIAL PRIMARY KEY,
+    coupon_id INT NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    order_id INT NOT NULL UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX coupon_redemptions_coupon_user_idx ON coupon_redemptions (coupon_id, user_id);
+
+ALTER TABLE orders ADD COLUMN coupon_code TEXT;
+ALTER TABLE orders ADD COLUMN discount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN free_shipping BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE orderline ADD COLUMN discount BIGINT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orderline DROP COLUMN discount;
+ALTER TABLE orders DROP COLUMN free_shipping;
+ALTER TABLE orders DROP COLUMN discount;
+ALTER TABLE orders DROP COLUMN coupon_code;
+DROP TABLE coupon_redemptions;
+DROP TABLE coupon_categories;
+DROP TABLE coupon_products;
+DROP TABLE coupons;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE order_status_history (
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status TEXT,
+    to_status TEXT NOT NULL,
+    actor_id INT,
+    actor_role TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX order_status_history_order_id_idx ON order_status_history (order_id, created_at);
+
+INSERT INTO order_status_history (order_id, to_status, actor_role, reason, created_at)
+SELECT id, status, 'system', 'backfilled', COALESCE(updated_at, created_at, CURRENT_TIMESTAMP) FROM orders;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE order_status_history;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX orders_pending_created_at_idx ON orders (created_at) WHERE status = 'Pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX orders_pending_created_at_idx;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE addresses (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipient TEXT NOT NULL,
+    phone TEXT NOT NULL,
+    country TEXT NOT NULL,
+    city TEXT NOT NULL,
+    street TEXT NOT NULL,
+    postal_code TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX addresses_user_id_idx ON addresses (user_id);
+
+-- price is in minor units.
+CREATE TABLE delivery_methods (
+    code TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    price BIGINT NOT NULL CHECK (price >= 0),
+    active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+INSERT INTO delivery_methods (code, name, price) VALUES
+    ('courier', 'Курьер', 50000),
+    ('pickup', 'Пункт выдачи', 20000),
+    ('post', 'Почта России', 30000);
+
+-- The address is copied onto the order, so editing or deleting it in the address book leaves the order as placed.
+ALTER TABLE orders ADD COLUMN delivery_method TEXT REFERENCES delivery_methods(code);
+ALTER TABLE orders ADD COLUMN delivery_price BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN shipping_address JSONB;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders DROP COLUMN shipping_address;
+ALTER TABLE orders DROP COLUMN delivery_price;
+ALTER TABLE orders DROP COLUMN delivery_method;
+DROP TABLE delivery_methods;
+DROP TABLE addresses;
+-- +goose StatementEnd
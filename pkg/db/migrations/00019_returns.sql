@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+-- payment_id is the YooKassa payment that paid for the order, refunds are issued against it.
+ALTER TABLE orders ADD COLUMN payment_id TEXT;
+
+-- returned counts the items of a committed reservation put back in stock by returns.
+ALTER TABLE reservation_items ADD COLUMN returned INT NOT NULL DEFAULT 0 CHECK (returned >= 0 AND returned <= quantity);
+
+-- refund_amount is in minor units. refund_id is set once the refund has been issued.
+CREATE TABLE returns (
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    order_line_id INT NOT NULL REFERENCES orderline(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    reason TEXT NOT NULL,
+    status TEXT NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    refund_amount BIGINT NOT NULL DEFAULT 0,
+    refund_id TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX returns_order_id_idx ON returns (order_id);
+CREATE INDEX returns_status_idx ON returns (status, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE returns;
+ALTER TABLE reservation_items DROP COLUMN returned;
+ALTER TABLE orders DROP COLUMN payment_id;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Order lists are paged on (created_at, id) or (total_price, id), for one customer or across all of them.
+CREATE INDEX orders_user_created_at_idx ON orders (user_id, created_at, id);
+CREATE INDEX orders_user_total_price_idx ON orders (user_id, total_price, id);
+CREATE INDEX orders_created_at_idx ON orders (created_at, id);
+CREATE INDEX orders_total_price_idx ON orders (total_price, id);
+CREATE INDEX orders_status_created_at_idx ON orders (status, created_at, id);
+CREATE INDEX orders_user_email_created_at_idx ON orders (lower(user_email), created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX orders_user_email_created_at_idx;
+DROP INDEX orders_status_created_at_idx;
+DROP INDEX orders_total_price_idx;
+DROP INDEX orders_created_at_idx;
+DROP INDEX orders_user_total_price_idx;
+DROP INDEX orders_user_created_at_idx;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- payment_events records the YooKassa notifications already applied, so redelivered ones are skipped.
+CREATE TABLE payment_events (
+    payment_id TEXT NOT NULL,
+    event TEXT NOT NULL,
+    order_id INT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (payment_id, event)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE payment_events;
+-- +goose StatementEnd
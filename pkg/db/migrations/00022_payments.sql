@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- payments holds every YooKassa payment made for an order. amount is in minor units, payload is the
+-- provider's last response for the payment.
+CREATE TABLE payments (
+    id SERIAL PRIMARY KEY,
+    provider_payment_id TEXT NOT NULL UNIQUE,
+    order_id INT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    amount BIGINT NOT NULL,
+    currency TEXT NOT NULL,
+    status TEXT NOT NULL,
+    confirmation_url TEXT NOT NULL DEFAULT '',
+    payload JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX payments_order_id_idx ON payments (order_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE payments;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- expires_at is set for payments waiting for capture, YooKassa cancels the hold after it.
+ALTER TABLE payments ADD COLUMN expires_at TIMESTAMP;
+
+CREATE INDEX payments_holds_expires_at_idx ON payments (expires_at) WHERE status = 'waiting_for_capture';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX payments_holds_expires_at_idx;
+ALTER TABLE payments DROP COLUMN expires_at;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- reconciliation_reports keeps the outcome of every payment reconciliation run for finance.
+CREATE TABLE reconciliation_reports (
+    id SERIAL PRIMARY KEY,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    checked INT NOT NULL,
+    failed INT NOT NULL,
+    discrepancies JSONB NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE reconciliation_reports;
+-- +goose StatementEnd
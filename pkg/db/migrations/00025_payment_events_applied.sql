@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- applied_at is set once the notification has moved its order. A notification recorded without it failed
+-- half way and is applied again when redelivered.
+ALTER TABLE payment_events ADD COLUMN applied_at TIMESTAMP;
+
+UPDATE payment_events SET applied_at = received_at;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE payment_events DROP COLUMN applied_at;
+-- +goose StatementEnd
@@ -0,0 +1,8 @@
+package db
+
+import "database/sql"
+
+// NullInt maps the zero ID used across services to SQL NULL.
+func NullInt(v int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(v), Valid: v != 0}
+}
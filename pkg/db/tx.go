@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DBTX is the part of *sql.DB and *sql.Tx that repositories query through.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Transactor is the unit of work services use to make several repository calls atomic.
+// Repositories pick the transaction up from the context through Conn.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+type TxManager struct {
+	db *sql.DB
+}
+
+func NewTxManager(db *sql.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return RunInTx(ctx, m.db, fn)
+}
+
+// RunInTx runs fn in a transaction on db and commits it if fn returns nil. When ctx already
+// carries a transaction fn joins it, and the outermost caller decides whether to commit.
+func RunInTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Conn returns the transaction carried by ctx, or db when there is none.
+func Conn(ctx context.Context, db *sql.DB) DBTX {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
@@ -59,7 +59,10 @@ func (es *EmailService) CreateOrder(to string, order model.Order) error {
 		From:      es.DefaultSender,
 		To:        to,
 		Subject:   "Ваш заказ принят в обработку",
-		Plaintext: fmt.Sprintf("Детали заказа: %v, Итого: %f рублей", order.Lines, order.TotalPrice),
+		Plaintext: fmt.Sprintf("Детали заказа: %v, Итого: %s", order.Lines, order.TotalPrice),
+	}
+	if order.Delivery != nil {
+		email.Plaintext += fmt.Sprintf("\nДоставка: %s, %s\nАдрес: %s", order.Delivery.Method, order.Delivery.Price, order.Delivery.Address)
 	}
 
 	err := es.Send(email)
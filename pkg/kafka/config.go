@@ -17,12 +17,7 @@ func NewKafkaConnection(cfg KafkaConfig) (*kafka.Writer, *kafka.Reader) {
 		DualStack: true,
 	}
 
-	writer := &kafka.Writer{
-		Addr:         kafka.TCP(cfg.Brokers...),
-		Topic:        cfg.Topic,
-		Balancer:     &kafka.LeastBytes{},
-		BatchTimeout: 50 * time.Millisecond,
-	}
+	writer := NewWriter(cfg)
 
 	reader := kafka.NewReader(kafka.ReaderConfig{
 		Brokers:  cfg.Brokers,
@@ -34,3 +29,13 @@ func NewKafkaConnection(cfg KafkaConfig) (*kafka.Writer, *kafka.Reader) {
 
 	return writer, reader
 }
+
+// NewWriter returns a writer for cfg.Topic, for topics the service only produces to.
+func NewWriter(cfg KafkaConfig) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.Topic,
+		Balancer:     &kafka.LeastBytes{},
+		BatchTimeout: 50 * time.Millisecond,
+	}
+}
@@ -42,6 +42,16 @@ func UserIdentity(c *gin.Context) {
 	c.Set("role", role)
 }
 
+// OptionalUserIdentity authenticates the request when an Authorization header is present
+// and lets anonymous requests through, for routes shared by guests and users.
+func OptionalUserIdentity(c *gin.Context) {
+	if c.GetHeader("Authorization") == "" {
+		return
+	}
+
+	UserIdentity(c)
+}
+
 func GetUserID(c *gin.Context) (int, error) {
 	id, ok := c.Get("userID")
 	if !ok {
@@ -70,6 +80,20 @@ func GetUserEmail(c *gin.Context) (string, error) {
 	return emailString, nil
 }
 
+func GetUserRole(c *gin.Context) (string, error) {
+	role, ok := c.Get("role")
+	if !ok {
+		return "", fmt.Errorf("get user role: role not found")
+	}
+
+	roleString, ok := role.(string)
+	if !ok {
+		return "", fmt.Errorf("get user role: invalid type of role")
+	}
+
+	return roleString, nil
+}
+
 func ModeratorIdentity(c *gin.Context) {
 	role, ok := c.Get("role")
 	if !ok {
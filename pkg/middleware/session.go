@@ -14,13 +14,20 @@ const (
 
 func SessionMiddleware(c *gin.Context) {
 	sessionToken, err := c.Cookie(cookie.CookieSession)
-	if err != nil {
-		newToken, err := lib.String(bytesPerToken)
+	if err != nil || sessionToken == "" {
+		sessionToken, err = lib.String(bytesPerToken)
 		if err != nil {
 			response.Error(c, http.StatusBadGateway, "502 Bad Gateway")
+			c.Abort()
+			return
 		}
-		cookie.SetCookie(c.Writer, cookie.CookieSession, newToken)
+		cookie.SetCookie(c.Writer, cookie.CookieSession, sessionToken)
 	}
 
 	c.Set(cookie.CookieSession, sessionToken)
 }
+
+// GetSessionID returns the session token set by SessionMiddleware, including one issued on this request.
+func GetSessionID(c *gin.Context) string {
+	return c.GetString(cookie.CookieSession)
+}
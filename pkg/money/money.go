@@ -0,0 +1,335 @@
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency the shop trades in. Prices are stored in the database
+// as integer minor units of this currency.
+const DefaultCurrency = "RUB"
+
+// minorUnits is the number of minor units in one major unit (kopecks per ruble).
+const minorUnits = 100
+
+var (
+	ErrInvalidAmount    = errors.New("money: invalid amount")
+	ErrCurrencyMismatch = errors.New("money: currency mismatch")
+)
+
+// Money is an amount in integer minor units together with its ISO 4217 currency code.
+//
+// Rounding rules: parsing never rounds and rejects more than two fractional digits,
+// Mul is exact, and Percent rounds half away from zero to the nearest minor unit.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New returns an amount of minor units in the given currency, DefaultCurrency if empty.
+func New(amount int64, currency string) Money {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return Money{Amount: amount, Currency: currency}
+}
+
+// FromMinor returns an amount of minor units in DefaultCurrency.
+func FromMinor(amount int64) Money {
+	return New(amount, DefaultCurrency)
+}
+
+// Parse reads a decimal string such as "1299", "1299.9" or "1299.90".
+func Parse(value, currency string) (Money, error) {
+	value = strings.TrimSpace(value)
+
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" || len(fraction) > 2 || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	major, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || major > (1<<63-1)/minorUnits-1 {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	fraction += strings.Repeat("0", 2-len(fraction))
+	minor, _ := strconv.ParseInt(fraction, 10, 64)
+
+	amount := major*minorUnits + minor
+	if negative {
+		amount = -amount
+	}
+
+	return New(amount, currency), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns m + o. A zero-value Money takes the currency of the other operand, so a
+// `var total money.Money` accumulator works without initialisation.
+func (m Money) Add(o Money) Money {
+	return Money{Amount: m.Amount + o.Amount, Currency: m.currencyWith(o)}
+}
+
+// Sub returns m - o.
+func (m Money) Sub(o Money) Money {
+	return Money{Amount: m.Amount - o.Amount, Currency: m.currencyWith(o)}
+}
+
+// Mul multiplies the amount by an integer quantity.
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// Percent returns the given share of m in basis points (1/100 of a percent),
+// rounded half away from zero.
+func (m Money) Percent(basisPoints int64) Money {
+	return Money{Amount: roundDiv(m.Amount*basisPoints, 10000), Currency: m.Currency}
+}
+
+// Allocate splits m into parts proportional to weights. The parts always add up to m: the
+// minor units lost to rounding go to the parts with the largest remainders, earlier parts
+// first on ties. If all weights are zero every part is zero.
+func (m Money) Allocate(weights []Money) []Money {
+	parts := make([]Money, len(weights))
+	remainders := make([]int64, len(weights))
+
+	var total int64
+	for _, w := range weights {
+		total += w.Amount
+	}
+
+	var allocated int64
+	for i, w := range weights {
+		parts[i] = Money{Currency: m.Currency}
+		if total == 0 {
+			continue
+		}
+		parts[i].Amount = m.Amount * w.Amount / total
+		remainders[i] = m.Amount * w.Amount % total
+		allocated += parts[i].Amount
+	}
+	if total == 0 {
+		return parts
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+
+	for i := 0; allocated < m.Amount; i++ {
+		parts[order[i%len(order)]].Amount++
+		allocated++
+	}
+
+	return parts
+}
+
+// Min returns the smaller of m and o.
+func Min(m, o Money) Money {
+	if m.Cmp(o) <= 0 {
+		return m
+	}
+	return o
+}
+
+func (m Money) Cmp(o Money) int {
+	m.currencyWith(o)
+	switch {
+	case m.Amount < o.Amount:
+		return -1
+	case m.Amount > o.Amount:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// CheckCurrency returns ErrCurrencyMismatch unless m and o can be combined. Amounts that did
+// not come from the database should be checked before they meet one that did.
+func (m Money) CheckCurrency(o Money) error {
+	if m.Currency != "" && o.Currency != "" && m.Currency != o.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return nil
+}
+
+// currencyWith resolves the currency of a binary operation. Mixing currencies is a
+// programming error: everything loaded from the database or decoded from JSON is in
+// DefaultCurrency.
+func (m Money) currencyWith(o Money) string {
+	switch {
+	case m.Currency == "":
+		return o.Currency
+	case o.Currency == "" || m.Currency == o.Currency:
+		return m.Currency
+	default:
+		panic(m.CheckCurrency(o))
+	}
+}
+
+func roundDiv(a, b int64) int64 {
+	q, r := a/b, a%b
+	if r < 0 {
+		r = -r
+	}
+	if 2*r >= b {
+		if a < 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+	return q
+}
+
+// Decimal formats the amount in major units with two fractional digits, e.g. "1299.90".
+// This is the format YooKassa expects in amount.value.
+func (m Money) Decimal() string {
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/minorUnits, amount%minorUnits)
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+type jsonMoney struct {
+	Value    json.RawMessage `json:"value"`
+	Currency string          `json:"currency"`
+}
+
+// MarshalJSON encodes Money as {"value": "1299.90", "currency": "RUB"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	currency := m.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return json.Marshal(struct {
+		Value    string `json:"value"`
+		Currency string `json:"currency"`
+	}{m.Decimal(), currency})
+}
+
+// UnmarshalJSON accepts the object form produced by MarshalJSON as well as a bare
+// decimal string or number in DefaultCurrency. Numbers are read from their literal
+// text, never through float64. Any other currency gives ErrCurrencyMismatch.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(data, []byte("{")) {
+		var v jsonMoney
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		parsed, err := parseJSONValue(v.Value, v.Currency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	parsed, err := parseJSONValue(data, DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func parseJSONValue(data json.RawMessage, currency string) (Money, error) {
+	if currency != "" && currency != DefaultCurrency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, currency, DefaultCurrency)
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n json.Number
+		if err = json.Unmarshal(data, &n); err != nil {
+			return Money{}, fmt.Errorf("%w: %s", ErrInvalidAmount, data)
+		}
+		s = n.String()
+	}
+	return Parse(s, currency)
+}
+
+// UnmarshalParam lets gin bind query and form parameters such as ?min_price=100.50.
+func (m *Money) UnmarshalParam(param string) error {
+	parsed, err := Parse(param, DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value stores the amount as integer minor units of DefaultCurrency, any other currency
+// gives ErrCurrencyMismatch.
+func (m Money) Value() (driver.Value, error) {
+	if err := m.CheckCurrency(FromMinor(0)); err != nil {
+		return nil, err
+	}
+	return m.Amount, nil
+}
+
+// Scan reads integer minor units. The currency is DefaultCurrency unless already set.
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case int64:
+		m.Amount = v
+	case []byte:
+		amount, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAmount, v)
+		}
+		m.Amount = amount
+	case string:
+		amount, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAmount, v)
+		}
+		m.Amount = amount
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency
+	}
+	return nil
+}
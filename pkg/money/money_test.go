@@ -0,0 +1,220 @@
+package money
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{name: "whole", value: "1299", want: 129900},
+		{name: "one fractional digit", value: "1299.9", want: 129990},
+		{name: "two fractional digits", value: "1299.90", want: 129990},
+		{name: "trailing dot", value: "12.", want: 1200},
+		{name: "surrounding spaces", value: " 12.05 ", want: 1205},
+		{name: "zero", value: "0.00", want: 0},
+		{name: "negative", value: "-5.5", want: -550},
+		{name: "largest", value: "92233720368547757.99", want: 9223372036854775799},
+		{name: "three fractional digits", value: "1.234", wantErr: true},
+		{name: "no whole part", value: ".5", wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+		{name: "plus sign", value: "+1", wantErr: true},
+		{name: "double minus", value: "--1", wantErr: true},
+		{name: "letters", value: "12a", wantErr: true},
+		{name: "exponent", value: "1e3", wantErr: true},
+		{name: "overflow in minor units", value: "92233720368547758", wantErr: true},
+		{name: "overflow of int64", value: "99999999999999999999", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.value, "")
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidAmount)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, FromMinor(tt.want), got)
+		})
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      int64
+		basisPoints int64
+		want        int64
+	}{
+		{name: "exact", amount: 1000, basisPoints: 1000, want: 100},
+		{name: "below half rounds down", amount: 4, basisPoints: 1000, want: 0},
+		{name: "half rounds up", amount: 5, basisPoints: 1000, want: 1},
+		{name: "above half rounds up", amount: 15, basisPoints: 3333, want: 5},
+		{name: "negative half rounds away from zero", amount: -5, basisPoints: 1000, want: -1},
+		{name: "negative below half rounds toward zero", amount: -4, basisPoints: 1000, want: 0},
+		{name: "whole amount", amount: 1299, basisPoints: 10000, want: 1299},
+		{name: "nothing", amount: 1299, basisPoints: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, FromMinor(tt.want), FromMinor(tt.amount).Percent(tt.basisPoints))
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  int64
+		weights []int64
+		want    []int64
+	}{
+		{name: "even", amount: 90, weights: []int64{1, 1, 1}, want: []int64{30, 30, 30}},
+		{name: "remainder goes to earlier parts on ties", amount: 100, weights: []int64{1, 1, 1}, want: []int64{34, 33, 33}},
+		{name: "remainder goes to the largest remainder", amount: 100, weights: []int64{1, 2}, want: []int64{33, 67}},
+		{name: "proportional", amount: 1000, weights: []int64{300, 700}, want: []int64{300, 700}},
+		{name: "zero weight gets nothing", amount: 7, weights: []int64{0, 5, 5}, want: []int64{0, 4, 3}},
+		{name: "all weights zero", amount: 10, weights: []int64{0, 0}, want: []int64{0, 0}},
+		{name: "no weights", amount: 10, weights: nil, want: []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weights := make([]Money, 0, len(tt.weights))
+			for _, w := range tt.weights {
+				weights = append(weights, FromMinor(w))
+			}
+
+			parts := FromMinor(tt.amount).Allocate(weights)
+
+			got := make([]int64, 0, len(parts))
+			for _, part := range parts {
+				assert.Equal(t, DefaultCurrency, part.Currency)
+				got = append(got, part.Amount)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCurrencyMismatch(t *testing.T) {
+	rub, usd := FromMinor(100), New(100, "USD")
+
+	assert.ErrorIs(t, rub.CheckCurrency(usd), ErrCurrencyMismatch)
+	assert.NoError(t, rub.CheckCurrency(FromMinor(5)))
+	assert.NoError(t, Money{}.CheckCurrency(usd))
+	assert.Panics(t, func() { rub.Add(usd) })
+	assert.Equal(t, usd, Money{}.Add(usd))
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Money
+		wantErr error
+	}{
+		{name: "object", data: `{"value": "1299.90", "currency": "RUB"}`, want: FromMinor(129990)},
+		{name: "object without currency", data: `{"value": "12"}`, want: FromMinor(1200)},
+		{name: "object with number value", data: `{"value": 12.5, "currency": "RUB"}`, want: FromMinor(1250)},
+		{name: "string", data: `"1299.90"`, want: FromMinor(129990)},
+		{name: "number", data: `1299.9`, want: FromMinor(129990)},
+		{name: "negative number", data: `-0.01`, want: FromMinor(-1)},
+		{name: "object in another currency", data: `{"value": "1", "currency": "USD"}`, wantErr: ErrCurrencyMismatch},
+		{name: "too precise number", data: `0.001`, wantErr: ErrInvalidAmount},
+		{name: "exponent number", data: `1e2`, wantErr: ErrInvalidAmount},
+		{name: "boolean", data: `true`, wantErr: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			err := json.Unmarshal([]byte(tt.data), &got)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, m := range []Money{FromMinor(129990), FromMinor(5), FromMinor(-1250), FromMinor(0), {Amount: 100}} {
+		data, err := json.Marshal(m)
+		require.NoError(t, err)
+
+		var got Money
+		require.NoError(t, json.Unmarshal(data, &got))
+		assert.Equal(t, FromMinor(m.Amount), got, string(data))
+	}
+
+	data, err := json.Marshal(FromMinor(129990))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"value": "1299.90", "currency": "RUB"}`, string(data))
+}
+
+func TestUnmarshalParam(t *testing.T) {
+	var m Money
+
+	require.NoError(t, m.UnmarshalParam("100.50"))
+	assert.Equal(t, FromMinor(10050), m)
+
+	assert.ErrorIs(t, m.UnmarshalParam("100,50"), ErrInvalidAmount)
+	assert.Equal(t, FromMinor(10050), m)
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     any
+		want    Money
+		wantErr bool
+	}{
+		{name: "int64", src: int64(129990), want: FromMinor(129990)},
+		{name: "bytes", src: []byte("-42"), want: FromMinor(-42)},
+		{name: "string", src: "42", want: FromMinor(42)},
+		{name: "decimal string", src: "4.20", wantErr: true},
+		{name: "bad bytes", src: []byte("x"), wantErr: true},
+		{name: "float", src: 4.2, wantErr: true},
+		{name: "null", src: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			err := got.Scan(tt.src)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValue(t *testing.T) {
+	v, err := FromMinor(129990).Value()
+	require.NoError(t, err)
+	assert.Equal(t, int64(129990), v)
+
+	v, err = Money{Amount: 5}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), v)
+
+	_, err = New(100, "USD").Value()
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
@@ -21,6 +21,7 @@ const _ = grpc.SupportPackageIsVersion9
 const (
 	ProductService_ReserveProducts_FullMethodName   = "/product.ProductService/ReserveProducts"
 	ProductService_UnreserveProducts_FullMethodName = "/product.ProductService/UnreserveProducts"
+	ProductService_CommitReservation_FullMethodName = "/product.ProductService/CommitReservation"
 )
 
 // ProductServiceClient is the client API for ProductService service.
@@ -28,7 +29,8 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ProductServiceClient interface {
 	ReserveProducts(ctx context.Context, in *ReserveProductsReq, opts ...grpc.CallOption) (*ReserveProductsRes, error)
-	UnreserveProducts(ctx context.Context, in *ReserveProductsReq, opts ...grpc.CallOption) (*ReserveProductsRes, error)
+	UnreserveProducts(ctx context.Context, in *UnreserveProductsReq, opts ...grpc.CallOption) (*ReserveProductsRes, error)
+	CommitReservation(ctx context.Context, in *CommitReservationReq, opts ...grpc.CallOption) (*ReserveProductsRes, error)
 }
 
 type productServiceClient struct {
@@ -49,7 +51,7 @@ func (c *productServiceClient) ReserveProducts(ctx context.Context, in *ReserveP
 	return out, nil
 }
 
-func (c *productServiceClient) UnreserveProducts(ctx context.Context, in *ReserveProductsReq, opts ...grpc.CallOption) (*ReserveProductsRes, error) {
+func (c *productServiceClient) UnreserveProducts(ctx context.Context, in *UnreserveProductsReq, opts ...grpc.CallOption) (*ReserveProductsRes, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ReserveProductsRes)
 	err := c.cc.Invoke(ctx, ProductService_UnreserveProducts_FullMethodName, in, out, cOpts...)
@@ -59,12 +61,23 @@ func (c *productServiceClient) UnreserveProducts(ctx context.Context, in *Reserv
 	return out, nil
 }
 
+func (c *productServiceClient) CommitReservation(ctx context.Context, in *CommitReservationReq, opts ...grpc.CallOption) (*ReserveProductsRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReserveProductsRes)
+	err := c.cc.Invoke(ctx, ProductService_CommitReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ProductServiceServer is the server API for ProductService service.
 // All implementations must embed UnimplementedProductServiceServer
 // for forward compatibility.
 type ProductServiceServer interface {
 	ReserveProducts(context.Context, *ReserveProductsReq) (*ReserveProductsRes, error)
-	UnreserveProducts(context.Context, *ReserveProductsReq) (*ReserveProductsRes, error)
+	UnreserveProducts(context.Context, *UnreserveProductsReq) (*ReserveProductsRes, error)
+	CommitReservation(context.Context, *CommitReservationReq) (*ReserveProductsRes, error)
 	mustEmbedUnimplementedProductServiceServer()
 }
 
@@ -78,9 +91,12 @@ type UnimplementedProductServiceServer struct{}
 func (UnimplementedProductServiceServer) ReserveProducts(context.Context, *ReserveProductsReq) (*ReserveProductsRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ReserveProducts not implemented")
 }
-func (UnimplementedProductServiceServer) UnreserveProducts(context.Context, *ReserveProductsReq) (*ReserveProductsRes, error) {
+func (UnimplementedProductServiceServer) UnreserveProducts(context.Context, *UnreserveProductsReq) (*ReserveProductsRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UnreserveProducts not implemented")
 }
+func (UnimplementedProductServiceServer) CommitReservation(context.Context, *CommitReservationReq) (*ReserveProductsRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CommitReservation not implemented")
+}
 func (UnimplementedProductServiceServer) mustEmbedUnimplementedProductServiceServer() {}
 func (UnimplementedProductServiceServer) testEmbeddedByValue()                        {}
 
@@ -121,7 +137,7 @@ func _ProductService_ReserveProducts_Handler(srv interface{}, ctx context.Contex
 }
 
 func _ProductService_UnreserveProducts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ReserveProductsReq)
+	in := new(UnreserveProductsReq)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -133,7 +149,25 @@ func _ProductService_UnreserveProducts_Handler(srv interface{}, ctx context.Cont
 		FullMethod: ProductService_UnreserveProducts_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ProductServiceServer).UnreserveProducts(ctx, req.(*ReserveProductsReq))
+		return srv.(ProductServiceServer).UnreserveProducts(ctx, req.(*UnreserveProductsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductService_CommitReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommitReservationReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductServiceServer).CommitReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductService_CommitReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductServiceServer).CommitReservation(ctx, req.(*CommitReservationReq))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -153,6 +187,10 @@ var ProductService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UnreserveProducts",
 			Handler:    _ProductService_UnreserveProducts_Handler,
 		},
+		{
+			MethodName: "CommitReservation",
+			Handler:    _ProductService_CommitReservation_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/product/product.proto",